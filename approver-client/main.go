@@ -212,7 +212,15 @@ func main() {
 			}
 			log.Debugf("Got a token: %s", token)
 
-			displayErrors(cli.PushSig(int64(*pushSig), data, token))
+			if displayErrors(cli.PushSig(int64(*pushSig), data, token)) {
+				return
+			}
+
+			app, appErrs := cli.GetApprovalObject(int64(*pushSig))
+			if displayErrors(appErrs) {
+				return
+			}
+			fmt.Printf("Approval %d is %s with %s signatures collected\n", app.ID, app.State, app.Progress())
 		} else {
 			log.Fatal("-in required for -push_sig but not set")
 		}
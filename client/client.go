@@ -17,8 +17,6 @@ import (
 	"strings"
 	"time"
 
-	"github.com/ProtonMail/go-crypto/openpgp/clearsign"
-
 	"github.com/timapril/go-registrar/epp"
 	"github.com/timapril/go-registrar/keychain"
 	"github.com/timapril/go-registrar/lib"
@@ -1298,17 +1296,24 @@ func (a *Client) VerifyApproverSet(as *lib.ApproverSetExportFull) (verified bool
 					errs = append(errs, errList...)
 					return
 				}
-				asVerified, _, asErrs := a.VerifyApproverSet(asNew)
+				asVerified, asE, asErrs := a.VerifyApproverSet(asNew)
 
 				a.log.Infof("Approver Set Verified: %t", asVerified)
 				errs = append(errs, asErrs...)
+				if !asVerified {
+					errMsg := fmt.Sprintf("Approver Set %d was not verified", app.ApproverSetID)
+					errs = append(errs, errors.New(errMsg))
+					return false, obj, errs
+				}
 
-				// now we need to extract the signed data
-				block, _ := clearsign.Decode(app.Signature)
-				if block == nil {
-					errs = append(errs, errors.New("no signature found"))
-				} else {
-					trustedSignedData = block.Bytes
+				// now we need to extract the data signed by enough of the
+				// verified approvers
+				var signedBySet bool
+				signedBySet, trustedSignedData = asE.IsSignedBy(app.Signature)
+				if !signedBySet {
+					errMsg := fmt.Sprintf("Approver Set %d at Change Request %d was not signed by enough approvers of Approver Set %d", as.ID, crid, app.ApproverSetID)
+					errs = append(errs, errors.New(errMsg))
+					return false, obj, errs
 				}
 			}
 			// Unpack the approval assertion
@@ -1460,7 +1465,8 @@ func (a *Client) VerifyChangeRequest(id int64, revision lib.RegistrarObjectExpor
 			}
 			signedByAnchor, data = ase.IsSignedBy(app.Signature)
 			if !signedByAnchor {
-				errs = append(errs, errors.New("Not signed by anchor"))
+				errMsg := fmt.Sprintf("Change request %d was not signed by enough approvers of Approver Set %d", id, app.ApproverSetID)
+				errs = append(errs, errors.New(errMsg))
 				return false, errs, data
 			}
 
@@ -2165,6 +2171,55 @@ func (a *Client) PushInfoEPP(objectType string, objectID int64, info *epp.Respon
 	return nil
 }
 
+// PushDNSStatus will try to push the result of comparing a domain's
+// delegation in its parent zone with the registrar and registry
+func (a *Client) PushDNSStatus(domainID int64, status string) (errs []error) {
+	dataBuffer, marshalErr := json.Marshal(lib.DNSStatusUpdate{DNSStatus: status})
+	if marshalErr != nil {
+		errs = append(errs, marshalErr)
+		return
+	}
+
+	token, tokenErrs := a.GetToken()
+	if len(tokenErrs) != 0 {
+		errs = append(errs, tokenErrs...)
+		return
+	}
+
+	reader := bytes.NewReader(dataBuffer)
+
+	url := fmt.Sprintf("/api/%s/%d/%s?csrf_token=%s", lib.DomainType, domainID, lib.ActionUpdateDNSStatus, token)
+	resp, postErr := a.Post(url, "application/json", reader)
+	if postErr != nil {
+		errs = append(errs, postErr)
+		return
+	}
+	defer resp.Body.Close()
+
+	data, readErr := io.ReadAll(resp.Body)
+	if readErr != nil {
+		errs = append(errs, readErr)
+		return
+	}
+	if len(strings.TrimSpace(string(data))) != 0 {
+		respObj := lib.APIResponse{}
+		unmarshalErr := json.Unmarshal(data, &respObj)
+		if unmarshalErr != nil {
+			errs = append(errs, unmarshalErr)
+			return
+		}
+
+		if respObj.MessageType == lib.ErrorResponseType {
+			for _, err := range respObj.Errors {
+				errs = append(errs, errors.New(err))
+			}
+			return
+		}
+	}
+
+	return nil
+}
+
 // UnsetEPPCheck will try to unset the check_required field for the registry
 // object
 func (a *Client) UnsetEPPCheck(objectType string, objectID int64) (errs []error) {
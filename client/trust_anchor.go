@@ -2,11 +2,14 @@ package client
 
 import (
 	"bytes"
+	"encoding/json"
 
 	"golang.org/x/crypto/openpgp"
 	"golang.org/x/crypto/openpgp/armor"
 	"golang.org/x/crypto/openpgp/clearsign"
 	"golang.org/x/crypto/openpgp/packet"
+
+	"github.com/timapril/go-registrar/lib"
 )
 
 // TrustAnchors are used to pin GPG keys that are trusted, often the
@@ -109,16 +112,39 @@ func (t TrustAnchors) DecryptionKeys() (keys []openpgp.Key) {
 }
 
 // IsSignedBy will return true if the object is signed by one of the
-// members of the TrustAnchors list
+// members of the TrustAnchors list. The signature may hold several
+// clearsigned approval attestations, all of which must attest to the
+// same approval, and the body of the one signed by a trust anchor is
+// returned.
 func (t TrustAnchors) IsSignedBy(sig []byte) (valid bool, signedBody []byte) {
-	block, _ := clearsign.Decode(sig)
-	if block == nil {
-		return false, signedBody
-	}
+	var first lib.ApprovalAttestationUnmarshal
+
+	for idx, single := range lib.SplitSignatures(sig) {
+		block, _ := clearsign.Decode(single)
+		if block == nil {
+			return false, nil
+		}
 
-	_, sigErr := openpgp.CheckDetachedSignature(t, bytes.NewBuffer(block.Bytes), block.ArmoredSignature.Body)
-	if sigErr == nil {
-		return true, block.Bytes
+		var att lib.ApprovalAttestationUnmarshal
+		if err := json.Unmarshal(block.Bytes, &att); err != nil {
+			return false, nil
+		}
+
+		if idx == 0 {
+			first = att
+		} else if !first.SameApproval(att) {
+			return false, nil
+		}
+
+		if valid {
+			continue
+		}
+
+		_, sigErr := openpgp.CheckDetachedSignature(t, bytes.NewBuffer(block.Bytes), block.ArmoredSignature.Body)
+		if sigErr == nil {
+			valid, signedBody = true, block.Bytes
+		}
 	}
-	return false, signedBody
+
+	return valid, signedBody
 }
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/op/go-logging"
+	"github.com/timapril/go-registrar/client"
+	"github.com/timapril/go-registrar/dnscheck"
+	"github.com/timapril/go-registrar/keychain"
+	"github.com/timapril/go-registrar/lib"
+	"gopkg.in/gcfg.v1"
+)
+
+var (
+	configPath = flag.String("conf", "~/.registrar", "A configuration file to provide default values for the Registrar client application")
+	verbose    = flag.Bool("v", false, "Verbose logging")
+	dryRun     = flag.Bool("n", false, "Report drift without updating the DNS status of the domains")
+	domainName = flag.String("domain", "", "Only check the domain with the name passed")
+)
+
+// Config is an object that holds the configuration for the client
+// application. gcfg parses the configuarion file into this format of
+// object
+type Config struct {
+	Registrar struct {
+		Server      string
+		Port        int64
+		UseHTTPS    bool
+		TrustAnchor []string
+	}
+
+	Certs struct {
+		CACertPath string
+		CertPath   string
+		KeyPath    string
+	}
+
+	Mac keychain.Conf
+
+	Testing struct {
+		SpoofCert  string
+		CertHeader string
+	}
+
+	DNS struct {
+		Resolver string
+		Timeout  int
+	}
+
+	Parent map[string]*struct {
+		Server []string
+	}
+
+	CacheConfig client.DiskCacheConfig
+}
+
+var conf Config
+
+// GetConnectionURL will return the URL that can be used to connect to the
+// Registrar server as defined by the parameters in the configuartion
+func (c Config) GetConnectionURL() string {
+	if c.Registrar.UseHTTPS {
+		return fmt.Sprintf("https://%s:%d", c.Registrar.Server, c.Registrar.Port)
+	}
+	return fmt.Sprintf("http://%s:%d", c.Registrar.Server, c.Registrar.Port)
+}
+
+// GetTrustAnchor will use the information in the config object to create the
+// trust anchor set for the application and return the trustanchors or an error
+// if an error occurs.
+func (c Config) GetTrustAnchor() (client.TrustAnchors, error) {
+	ta := client.TrustAnchors{}
+	for _, anchor := range c.Registrar.TrustAnchor {
+		pubkey, readErr := os.ReadFile(anchor)
+		if readErr != nil {
+			return ta, readErr
+		}
+		err := ta.AddKey(string(pubkey))
+		if err != nil {
+			return ta, err
+		}
+	}
+	return ta, nil
+}
+
+// GetRegistrarClient will use the configuration object and generate and
+// return an Registrar client object. If an error occurs when generating
+// the client, the error is returned
+func (c Config) GetRegistrarClient() (cli client.Client, err error) {
+	cli.TrustAnchor, err = c.GetTrustAnchor()
+	if err != nil {
+		return
+	}
+
+	cli.Prepare(c.GetConnectionURL(), log, c.CacheConfig)
+	if c.Testing.SpoofCert != "" {
+		spoofCert, readErr := os.ReadFile(c.Testing.SpoofCert)
+		if readErr != nil {
+			err = readErr
+			return
+		}
+		cli.SpoofCertificateForTesting(string(spoofCert), conf.Testing.CertHeader)
+	} else {
+		cli.PrepareSSL(c.GetConnectionURL(), c.Certs.CertPath, c.Certs.KeyPath, c.Certs.CACertPath, c.Mac, log, c.CacheConfig)
+	}
+
+	return cli, nil
+}
+
+// GetChecker will return a delegation checker using the configured resolver,
+// query timeout and parent zone servers
+func (c Config) GetChecker() *dnscheck.Checker {
+	parents := make(map[string][]string)
+	for zone, parent := range c.Parent {
+		if parent != nil {
+			parents[zone] = parent.Server
+		}
+	}
+
+	return dnscheck.NewChecker(c.DNS.Resolver, parents, time.Duration(c.DNS.Timeout)*time.Second)
+}
+
+var log = logging.MustGetLogger("registrar")
+var format = logging.MustStringFormatter(
+	"%{color}%{level:.4s} %{time:15:04:05.000000} %{shortfile} %{callpath} %{longfunc} %{id:03x}%{color:reset} %{message}",
+)
+
+// prepareLogging sets up logging so the application can have
+// configurable logging
+func prepareLogging(level logging.Level) {
+	backend := logging.NewLogBackend(os.Stdout, "", 0)
+	backendFormatter := logging.NewBackendFormatter(backend, format)
+	backendLevel := logging.AddModuleLevel(backendFormatter)
+	backendLevel.SetLevel(level, "")
+	logging.SetBackend(backendLevel)
+}
+
+func main() {
+	flag.Parse()
+
+	ll := logging.ERROR
+	if *verbose {
+		ll = logging.DEBUG
+	}
+
+	prepareLogging(ll)
+
+	confErr := gcfg.ReadFileInto(&conf, *configPath)
+	if confErr != nil {
+		log.Fatal(confErr.Error())
+	}
+
+	cli, err := conf.GetRegistrarClient()
+	if err != nil {
+		log.Error(err)
+		return
+	}
+	errs := cli.PrepareObjectDirectory()
+	if len(errs) != 0 {
+		for _, err := range errs {
+			log.Error(err)
+		}
+		return
+	}
+
+	checker := conf.GetChecker()
+
+	domainIDs := cli.ObjectDir.DomainIDs
+	if *domainName != "" {
+		domainID, idErrs := cli.GetDomainIDFromName(*domainName)
+		if len(idErrs) != 0 {
+			for _, err := range idErrs {
+				log.Error(err)
+			}
+			return
+		}
+		domainIDs = []int64{domainID}
+	}
+
+	drift := false
+
+	for _, domainID := range domainIDs {
+		if !checkDomain(&cli, checker, domainID) {
+			drift = true
+		}
+	}
+
+	if drift {
+		os.Exit(1)
+	}
+}
+
+// checkDomain will compare the delegation of an active domain served by its
+// parent zone with the registrar and the registry and record the result as
+// the DNS status of the domain. Domains whose current revision is not
+// effective yet are skipped. False is returned if the domain could not be
+// checked or its delegation has drifted.
+func checkDomain(cli *client.Client, checker *dnscheck.Checker, domainID int64) bool {
+	verified, domErrs, dom := cli.GetVerifiedDomain(domainID, time.Now().Unix())
+	for _, err := range domErrs {
+		log.Error(err.Error())
+	}
+	if !verified || dom == nil {
+		log.Errorf("Unable to verifiy domain %d", domainID)
+		return false
+	}
+
+	if dom.CurrentRevision.DesiredState != lib.StateActive {
+		return true
+	}
+
+	// A scheduled revision holds the delegation the domain will have once
+	// it is effective, so it cannot be compared with the live delegation yet
+	if !dom.CurrentRevision.IsEffective(lib.TimeNow()) {
+		fmt.Printf("SKIP  %s: not effective until %s\n", dom.DomainName, dom.CurrentRevision.EffectiveAt.UTC().Format(time.RFC3339))
+		return true
+	}
+
+	deleg, lookupErr := checker.Delegation(dom.DomainName)
+
+	var mismatches []string
+	if lookupErr == nil {
+		mismatches = dnscheck.Compare(dom, deleg)
+	}
+
+	status := dnscheck.Status(mismatches, lookupErr)
+
+	switch {
+	case lookupErr != nil:
+		fmt.Printf("FAIL  %s: %s\n", dom.DomainName, lookupErr)
+	case len(mismatches) != 0:
+		for _, mismatch := range mismatches {
+			fmt.Printf("DRIFT %s: %s\n", dom.DomainName, mismatch)
+		}
+	default:
+		fmt.Printf("OK    %s\n", dom.DomainName)
+	}
+
+	if !*dryRun {
+		for _, err := range cli.PushDNSStatus(domainID, status) {
+			log.Error(err.Error())
+		}
+	}
+
+	return lookupErr == nil && len(mismatches) == 0
+}
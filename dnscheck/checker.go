@@ -0,0 +1,299 @@
+package dnscheck
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultTimeout is the time allowed for each DNS query if no timeout is
+// configured.
+const DefaultTimeout = 5 * time.Second
+
+// Delegation is the delegation of a domain served by its parent zone.
+type Delegation struct {
+	Domain      string
+	Server      string
+	Exists      bool
+	Nameservers []string
+	DS          []DS
+}
+
+// Checker looks up the delegation of domains by querying the servers of
+// their parent zones directly. The parent zone servers are taken from
+// ParentServers if they are listed there, otherwise they are found using
+// the recursive resolver.
+type Checker struct {
+	// Resolver is the host:port of the recursive resolver used to find the
+	// servers of parent zones.
+	Resolver string
+
+	// ParentServers maps a parent zone to the host:port of its servers.
+	ParentServers map[string][]string
+
+	// Timeout is the time allowed for each query.
+	Timeout time.Duration
+
+	servers map[string][]string
+}
+
+// NewChecker creates a checker using the resolver and parent zone servers
+// passed.
+func NewChecker(resolver string, parentServers map[string][]string, timeout time.Duration) *Checker {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	servers := make(map[string][]string)
+	for zone, addrs := range parentServers {
+		servers[CanonicalName(zone)] = addrs
+	}
+
+	return &Checker{
+		Resolver:      resolver,
+		ParentServers: servers,
+		Timeout:       timeout,
+		servers:       make(map[string][]string),
+	}
+}
+
+// Exchange sends a single question to the server passed and returns the
+// response. The query is sent over UDP and retried over TCP if the
+// response is truncated.
+func (c *Checker) Exchange(server string, q Question, recursionDesired bool) (*Message, error) {
+	query := &Message{
+		ID:               uint16(rand.Intn(1 << 16)),
+		RecursionDesired: recursionDesired,
+		Questions:        []Question{q},
+	}
+
+	packed, err := query.Pack()
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.exchangeUDP(server, packed, query.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Truncated {
+		resp, err = c.exchangeTCP(server, packed, query.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return resp, nil
+}
+
+// exchangeUDP sends the packed query over UDP and waits for the matching
+// response.
+func (c *Checker) exchangeUDP(server string, packed []byte, id uint16) (*Message, error) {
+	conn, err := net.DialTimeout("udp", server, c.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if err := conn.SetDeadline(time.Now().Add(c.Timeout)); err != nil {
+		return nil, err
+	}
+
+	if _, err := conn.Write(packed); err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, 65535)
+
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := Unpack(buf[:n])
+		if err != nil || resp.ID != id || !resp.Response {
+			continue
+		}
+
+		return resp, nil
+	}
+}
+
+// exchangeTCP sends the packed query over TCP and reads the response.
+func (c *Checker) exchangeTCP(server string, packed []byte, id uint16) (*Message, error) {
+	conn, err := net.DialTimeout("tcp", server, c.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if err := conn.SetDeadline(time.Now().Add(c.Timeout)); err != nil {
+		return nil, err
+	}
+
+	if _, err := conn.Write(append(binary.BigEndian.AppendUint16(nil, uint16(len(packed))), packed...)); err != nil {
+		return nil, err
+	}
+
+	length := make([]byte, 2)
+	if _, err := io.ReadFull(conn, length); err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, binary.BigEndian.Uint16(length))
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		return nil, err
+	}
+
+	resp, err := Unpack(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.ID != id {
+		return nil, errors.New("response ID does not match the query")
+	}
+
+	return resp, nil
+}
+
+// ParentZone returns the parent zone of the domain passed.
+func ParentZone(domain string) string {
+	domain = CanonicalName(domain)
+
+	if idx := strings.Index(domain, "."); idx >= 0 {
+		return domain[idx+1:]
+	}
+
+	return ""
+}
+
+// parentServers returns the host:port of the servers of the zone passed.
+func (c *Checker) parentServers(zone string) ([]string, error) {
+	if servers, ok := c.ParentServers[zone]; ok {
+		return servers, nil
+	}
+
+	if servers, ok := c.servers[zone]; ok {
+		return servers, nil
+	}
+
+	if c.Resolver == "" {
+		return nil, fmt.Errorf("no servers configured for %s and no resolver to find them", zone)
+	}
+
+	resp, err := c.Exchange(c.Resolver, Question{Name: zone + ".", Type: TypeNS, Class: ClassINET}, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var servers []string
+
+	for _, rr := range resp.Answers {
+		if rr.Type != TypeNS {
+			continue
+		}
+
+		for _, qtype := range []uint16{TypeA, TypeAAAA} {
+			addrResp, addrErr := c.Exchange(c.Resolver, Question{Name: rr.Target, Type: qtype, Class: ClassINET}, true)
+			if addrErr != nil {
+				continue
+			}
+			for _, addr := range addrResp.Answers {
+				if addr.Type == qtype {
+					servers = append(servers, net.JoinHostPort(addr.Address.String(), "53"))
+				}
+			}
+		}
+	}
+
+	if len(servers) == 0 {
+		return nil, fmt.Errorf("no servers found for %s", zone)
+	}
+
+	c.servers[zone] = servers
+
+	return servers, nil
+}
+
+// Delegation looks up the NS and DS records of the domain passed from the
+// servers of its parent zone. Each server is tried in turn until one of
+// them answers both queries.
+func (c *Checker) Delegation(domain string) (Delegation, error) {
+	name := CanonicalName(domain)
+	deleg := Delegation{Domain: name}
+
+	zone := ParentZone(name)
+	if zone == "" {
+		return deleg, fmt.Errorf("%s has no parent zone", name)
+	}
+
+	servers, err := c.parentServers(zone)
+	if err != nil {
+		return deleg, err
+	}
+
+	var lastErr error
+
+	for _, server := range servers {
+		deleg, lastErr = c.delegationFrom(server, name)
+		if lastErr == nil {
+			return deleg, nil
+		}
+	}
+
+	return deleg, lastErr
+}
+
+// delegationFrom looks up the NS and DS records of the domain from a
+// single parent zone server.
+func (c *Checker) delegationFrom(server string, name string) (Delegation, error) {
+	deleg := Delegation{Domain: name, Server: server}
+
+	nsResp, err := c.Exchange(server, Question{Name: name + ".", Type: TypeNS, Class: ClassINET}, false)
+	if err != nil {
+		return deleg, err
+	}
+
+	switch nsResp.Rcode {
+	case RcodeSuccess:
+	case RcodeNameError:
+		return deleg, nil
+	default:
+		return deleg, fmt.Errorf("%s returned response code %d for %s", server, nsResp.Rcode, name)
+	}
+
+	for _, rr := range append(nsResp.Answers, nsResp.Authority...) {
+		if rr.Type == TypeNS && CanonicalName(rr.Name) == name {
+			deleg.Nameservers = append(deleg.Nameservers, CanonicalName(rr.Target))
+		}
+	}
+
+	deleg.Exists = len(deleg.Nameservers) != 0
+	sort.Strings(deleg.Nameservers)
+
+	dsResp, err := c.Exchange(server, Question{Name: name + ".", Type: TypeDS, Class: ClassINET}, false)
+	if err != nil {
+		return deleg, err
+	}
+
+	if dsResp.Rcode != RcodeSuccess && dsResp.Rcode != RcodeNameError {
+		return deleg, fmt.Errorf("%s returned response code %d for DS %s", server, dsResp.Rcode, name)
+	}
+
+	for _, rr := range dsResp.Answers {
+		if rr.Type == TypeDS && CanonicalName(rr.Name) == name {
+			deleg.DS = append(deleg.DS, rr.DS)
+		}
+	}
+
+	return deleg, nil
+}
@@ -0,0 +1,163 @@
+package dnscheck
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// stubServer is a parent zone server that answers NS queries with a
+// referral and DS queries with an authoritative answer.
+type stubServer struct {
+	addr      string
+	referrals map[string][]string
+	ds        map[string][]DS
+	truncate  map[string]bool
+	queries   int
+}
+
+func (s *stubServer) answer(query *Message, udp bool) *Message {
+	s.queries++
+	resp := &Message{ID: query.ID, Response: true, Questions: query.Questions}
+	q := query.Questions[0]
+	name := CanonicalName(q.Name)
+
+	nameservers, ok := s.referrals[name]
+	if !ok {
+		resp.Rcode = RcodeNameError
+		return resp
+	}
+
+	if udp && s.truncate[name] {
+		resp.Truncated = true
+		return resp
+	}
+
+	switch q.Type {
+	case TypeNS:
+		for _, ns := range nameservers {
+			resp.Authority = append(resp.Authority, Record{Name: q.Name, Type: TypeNS, Class: ClassINET, TTL: 60, Target: ns + "."})
+		}
+	case TypeDS:
+		resp.Authoritative = true
+		for _, ds := range s.ds[name] {
+			resp.Answers = append(resp.Answers, Record{Name: q.Name, Type: TypeDS, Class: ClassINET, TTL: 60, DS: ds})
+		}
+	}
+
+	return resp
+}
+
+func newStubServer(t *testing.T) *stubServer {
+	s := &stubServer{
+		referrals: make(map[string][]string),
+		ds:        make(map[string][]DS),
+		truncate:  make(map[string]bool),
+	}
+
+	udp, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.addr = udp.LocalAddr().String()
+
+	tcp, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		udp.Close()
+		tcp.Close()
+	})
+
+	go func() {
+		buf := make([]byte, 65535)
+		for {
+			n, addr, err := udp.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			query, err := Unpack(buf[:n])
+			if err != nil {
+				continue
+			}
+			packed, _ := s.answer(query, true).Pack()
+			udp.WriteTo(packed, addr)
+		}
+	}()
+
+	go func() {
+		for {
+			conn, err := tcp.Accept()
+			if err != nil {
+				return
+			}
+			length := make([]byte, 2)
+			if _, err := io.ReadFull(conn, length); err == nil {
+				buf := make([]byte, binary.BigEndian.Uint16(length))
+				if _, err := io.ReadFull(conn, buf); err == nil {
+					if query, err := Unpack(buf); err == nil {
+						packed, _ := s.answer(query, false).Pack()
+						conn.Write(append(binary.BigEndian.AppendUint16(nil, uint16(len(packed))), packed...))
+					}
+				}
+			}
+			conn.Close()
+		}
+	}()
+
+	return s
+}
+
+func TestChecker(t *testing.T) {
+	t.Parallel()
+	Convey("Given a stub parent zone server", t, func() {
+		stub := newStubServer(t)
+		stub.referrals["example.com"] = []string{"NS2.EXAMPLE.NET", "ns1.example.net"}
+		stub.ds["example.com"] = []DS{{KeyTag: 12345, Algorithm: 13, DigestType: 2, Digest: "ABCDEF"}}
+		stub.referrals["big.com"] = []string{"ns1.example.net"}
+		stub.truncate["big.com"] = true
+
+		checker := NewChecker("", map[string][]string{"COM.": {stub.addr}}, time.Second)
+
+		Convey("The delegation should be read from the referral", func() {
+			deleg, err := checker.Delegation("Example.COM.")
+			So(err, ShouldBeNil)
+			So(deleg.Exists, ShouldBeTrue)
+			So(deleg.Server, ShouldEqual, stub.addr)
+			So(deleg.Nameservers, ShouldResemble, []string{"ns1.example.net", "ns2.example.net"})
+			So(deleg.DS, ShouldResemble, []DS{{KeyTag: 12345, Algorithm: 13, DigestType: 2, Digest: "ABCDEF"}})
+		})
+
+		Convey("Domains that are not delegated should not exist", func() {
+			deleg, err := checker.Delegation("missing.com")
+			So(err, ShouldBeNil)
+			So(deleg.Exists, ShouldBeFalse)
+		})
+
+		Convey("Truncated responses should be retried over TCP", func() {
+			deleg, err := checker.Delegation("big.com")
+			So(err, ShouldBeNil)
+			So(deleg.Nameservers, ShouldResemble, []string{"ns1.example.net"})
+		})
+
+		Convey("Zones without servers should fail", func() {
+			_, err := checker.Delegation("example.org")
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "no servers configured for org")
+		})
+	})
+
+	Convey("Parent zones should drop the first label", t, func() {
+		So(ParentZone("www.example.co.uk."), ShouldEqual, "example.co.uk")
+		So(ParentZone("example.com"), ShouldEqual, "com")
+		So(ParentZone("com"), ShouldEqual, "")
+		So(strings.Count(ParentZone("a.b.c"), "."), ShouldEqual, 1)
+	})
+}
@@ -0,0 +1,149 @@
+package dnscheck
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/timapril/go-registrar/lib"
+)
+
+// MaxStatusLength is the longest DNS status that is recorded for a domain.
+const MaxStatusLength = 255
+
+// registrarNameservers returns the nameservers of the current revision of
+// the domain.
+func registrarNameservers(dom *lib.DomainExport) (names []string) {
+	for _, host := range dom.CurrentRevision.Hostnames {
+		names = append(names, CanonicalName(host.HostName))
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// registryNameservers returns the nameservers of the domain from the last
+// registry EPP info.
+func registryNameservers(dom *lib.DomainExport) (names []string) {
+	for _, name := range strings.Fields(dom.DomainNSList) {
+		names = append(names, CanonicalName(name))
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// formatDS formats a DS entry so it can be compared.
+func formatDS(keyTag int64, algorithm int64, digestType int64, digest string) string {
+	return fmt.Sprintf("%d %d %d %s", keyTag, algorithm, digestType, strings.ToUpper(digest))
+}
+
+// registrarDS returns the DS entries of the current revision of the domain.
+func registrarDS(dom *lib.DomainExport) (entries []string) {
+	for _, ds := range dom.CurrentRevision.DSDataEntries {
+		entries = append(entries, formatDS(ds.KeyTag, ds.Algorithm, ds.DigestType, ds.Digest))
+	}
+
+	sort.Strings(entries)
+
+	return entries
+}
+
+// registryDS returns the DS entries of the domain from the last registry
+// EPP info.
+func registryDS(dom *lib.DomainExport) (entries []string) {
+	for _, ds := range dom.EPPDSDataEntries {
+		entries = append(entries, formatDS(ds.KeyTag, ds.Algorithm, ds.DigestType, ds.Digest))
+	}
+
+	sort.Strings(entries)
+
+	return entries
+}
+
+// delegationDS returns the DS records served by the parent zone.
+func delegationDS(deleg Delegation) (entries []string) {
+	for _, ds := range deleg.DS {
+		entries = append(entries, ds.String())
+	}
+
+	sort.Strings(entries)
+
+	return entries
+}
+
+// sameList checks if two sorted lists hold the same values.
+func sameList(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Compare compares the delegation the registrar holds for the domain with
+// the last registry EPP info for it and the delegation served by the
+// parent zone. A description of each difference found is returned.
+func Compare(dom *lib.DomainExport, deleg Delegation) (mismatches []string) {
+	regNS := registrarNameservers(dom)
+	regDS := registrarDS(dom)
+
+	if dom.EPPStatus != lib.EPPStatusProvisioned {
+		mismatches = append(mismatches, fmt.Sprintf("registry EPP status is %q", dom.EPPStatus))
+	}
+
+	if registryNS := registryNameservers(dom); !sameList(regNS, registryNS) {
+		mismatches = append(mismatches, fmt.Sprintf("registry NS [%s] differ from registrar NS [%s]", strings.Join(registryNS, " "), strings.Join(regNS, " ")))
+	}
+
+	if registryDS := registryDS(dom); !sameList(regDS, registryDS) {
+		mismatches = append(mismatches, fmt.Sprintf("registry DS [%s] differ from registrar DS [%s]", strings.Join(registryDS, ", "), strings.Join(regDS, ", ")))
+	}
+
+	if !deleg.Exists {
+		return append(mismatches, "not delegated by the parent zone")
+	}
+
+	if !sameList(regNS, deleg.Nameservers) {
+		mismatches = append(mismatches, fmt.Sprintf("parent zone NS [%s] differ from registrar NS [%s]", strings.Join(deleg.Nameservers, " "), strings.Join(regNS, " ")))
+	}
+
+	if dnsDS := delegationDS(deleg); !sameList(regDS, dnsDS) {
+		mismatches = append(mismatches, fmt.Sprintf("parent zone DS [%s] differ from registrar DS [%s]", strings.Join(dnsDS, ", "), strings.Join(regDS, ", ")))
+	}
+
+	return mismatches
+}
+
+// Status returns the DNS status to record for a domain given the result of
+// looking up and comparing its delegation. Long statuses are truncated on a
+// character boundary.
+func Status(mismatches []string, lookupErr error) string {
+	status := lib.DNSStatusMatches
+
+	switch {
+	case lookupErr != nil:
+		status = fmt.Sprintf("%s: %s", lib.DNSStatusLookupFailed, lookupErr)
+	case len(mismatches) != 0:
+		status = fmt.Sprintf("%s: %s", lib.DNSStatusDrift, strings.Join(mismatches, "; "))
+	}
+
+	if len(status) > MaxStatusLength {
+		cut := MaxStatusLength - 3
+		for cut > 0 && !utf8.RuneStart(status[cut]) {
+			cut--
+		}
+		status = status[:cut] + "..."
+	}
+
+	return status
+}
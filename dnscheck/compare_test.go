@@ -0,0 +1,77 @@
+package dnscheck
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/timapril/go-registrar/lib"
+)
+
+func TestCompare(t *testing.T) {
+	t.Parallel()
+	Convey("Given a domain that is provisioned at the registry", t, func() {
+		dom := &lib.DomainExport{
+			DomainName:   "EXAMPLE.COM",
+			EPPStatus:    lib.EPPStatusProvisioned,
+			DomainNSList: "NS1.EXAMPLE.NET\nNS2.EXAMPLE.NET",
+		}
+		dom.CurrentRevision.Hostnames = []lib.HostExportShort{{HostName: "NS2.EXAMPLE.NET"}, {HostName: "NS1.EXAMPLE.NET"}}
+		dom.CurrentRevision.DSDataEntries = []lib.DSDataEntry{{KeyTag: 12345, Algorithm: 13, DigestType: 2, Digest: "abcdef"}}
+		dom.EPPDSDataEntries = []lib.DSDataEntryEpp{{KeyTag: 12345, Algorithm: 13, DigestType: 2, Digest: "ABCDEF"}}
+
+		deleg := Delegation{
+			Domain:      "example.com",
+			Exists:      true,
+			Nameservers: []string{"ns1.example.net", "ns2.example.net"},
+			DS:          []DS{{KeyTag: 12345, Algorithm: 13, DigestType: 2, Digest: "ABCDEF"}},
+		}
+
+		Convey("A matching delegation should have no mismatches", func() {
+			mismatches := Compare(dom, deleg)
+			So(mismatches, ShouldBeEmpty)
+			So(Status(mismatches, nil), ShouldEqual, lib.DNSStatusMatches)
+		})
+
+		Convey("Differences in the parent zone should be reported", func() {
+			deleg.Nameservers = []string{"ns1.example.net", "ns3.example.net"}
+			deleg.DS = nil
+
+			mismatches := Compare(dom, deleg)
+			So(mismatches, ShouldHaveLength, 2)
+			So(mismatches[0], ShouldContainSubstring, "parent zone NS [ns1.example.net ns3.example.net]")
+			So(mismatches[1], ShouldContainSubstring, "parent zone DS []")
+			So(Status(mismatches, nil), ShouldStartWith, lib.DNSStatusDrift+": parent zone NS")
+		})
+
+		Convey("Differences at the registry should be reported", func() {
+			dom.EPPStatus = lib.EPPStatusHostMismatch
+			dom.DomainNSList = "NS1.EXAMPLE.NET"
+			dom.EPPDSDataEntries = nil
+
+			mismatches := Compare(dom, deleg)
+			So(mismatches, ShouldHaveLength, 3)
+			So(mismatches[0], ShouldContainSubstring, "registry EPP status")
+			So(mismatches[1], ShouldContainSubstring, "registry NS")
+			So(mismatches[2], ShouldContainSubstring, "registry DS")
+		})
+
+		Convey("Domains missing from the parent zone should be reported", func() {
+			mismatches := Compare(dom, Delegation{Domain: "example.com"})
+			So(mismatches, ShouldResemble, []string{"not delegated by the parent zone"})
+		})
+
+		Convey("Statuses should be limited in length", func() {
+			So(Status(nil, errors.New("timeout")), ShouldEqual, lib.DNSStatusLookupFailed+": timeout")
+			So(len(Status([]string{strings.Repeat("x", 300)}, nil)), ShouldEqual, MaxStatusLength)
+
+			status := Status([]string{"x" + strings.Repeat("é", 150)}, nil)
+			So(utf8.ValidString(status), ShouldBeTrue)
+			So(len(status), ShouldBeLessThanOrEqualTo, MaxStatusLength)
+			So(status, ShouldEndWith, "é...")
+		})
+	})
+}
@@ -0,0 +1,356 @@
+// Package dnscheck compares the delegation of a domain served by its parent
+// zone with the delegation the registrar and registry hold for it.
+package dnscheck
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// The DNS record types used when checking a delegation.
+const (
+	TypeA    uint16 = 1
+	TypeNS   uint16 = 2
+	TypeSOA  uint16 = 6
+	TypeAAAA uint16 = 28
+	TypeDS   uint16 = 43
+)
+
+// ClassINET is the Internet class of DNS records.
+const ClassINET uint16 = 1
+
+// The DNS response codes used when checking a delegation.
+const (
+	RcodeSuccess   = 0
+	RcodeNameError = 3
+)
+
+const (
+	headerLength   = 12
+	maxPointerHops = 32
+
+	flagResponse           = 1 << 15
+	flagAuthoritative      = 1 << 10
+	flagTruncated          = 1 << 9
+	flagRecursionDesired   = 1 << 8
+	flagRecursionAvailable = 1 << 7
+)
+
+var errShortMessage = errors.New("dns message is too short")
+
+// Question is a single question of a DNS message.
+type Question struct {
+	Name  string
+	Type  uint16
+	Class uint16
+}
+
+// DS is the data of a DS record.
+type DS struct {
+	KeyTag     uint16
+	Algorithm  uint8
+	DigestType uint8
+	Digest     string
+}
+
+// String formats the DS record data in presentation format.
+func (ds DS) String() string {
+	return fmt.Sprintf("%d %d %d %s", ds.KeyTag, ds.Algorithm, ds.DigestType, strings.ToUpper(ds.Digest))
+}
+
+// Record is a single resource record of a DNS message. The data of NS, A,
+// AAAA and DS records is decoded into the matching field, the data of other
+// records is left in Data.
+type Record struct {
+	Name  string
+	Type  uint16
+	Class uint16
+	TTL   uint32
+
+	Target  string
+	Address net.IP
+	DS      DS
+	Data    []byte
+}
+
+// Message is a DNS query or response.
+type Message struct {
+	ID                 uint16
+	Response           bool
+	Authoritative      bool
+	Truncated          bool
+	RecursionDesired   bool
+	RecursionAvailable bool
+	Rcode              int
+
+	Questions  []Question
+	Answers    []Record
+	Authority  []Record
+	Additional []Record
+}
+
+// CanonicalName returns the name in lower case without the trailing dot so
+// names can be compared.
+func CanonicalName(name string) string {
+	return strings.ToLower(strings.TrimSuffix(name, "."))
+}
+
+// packName appends the name in wire format to the buffer passed.
+func packName(buf []byte, name string) ([]byte, error) {
+	name = strings.TrimSuffix(name, ".")
+	if name != "" {
+		for _, label := range strings.Split(name, ".") {
+			if len(label) == 0 || len(label) > 63 {
+				return nil, fmt.Errorf("invalid label in name %q", name)
+			}
+			buf = append(buf, byte(len(label)))
+			buf = append(buf, label...)
+		}
+	}
+
+	return append(buf, 0), nil
+}
+
+// packRecord appends the record in wire format to the buffer passed.
+func packRecord(buf []byte, rr Record) ([]byte, error) {
+	buf, err := packName(buf, rr.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	buf = binary.BigEndian.AppendUint16(buf, rr.Type)
+	buf = binary.BigEndian.AppendUint16(buf, rr.Class)
+	buf = binary.BigEndian.AppendUint32(buf, rr.TTL)
+
+	var data []byte
+
+	switch rr.Type {
+	case TypeNS:
+		if data, err = packName(nil, rr.Target); err != nil {
+			return nil, err
+		}
+	case TypeA:
+		data = rr.Address.To4()
+		if data == nil {
+			return nil, fmt.Errorf("invalid IPv4 address %s", rr.Address)
+		}
+	case TypeAAAA:
+		data = rr.Address.To16()
+		if data == nil {
+			return nil, fmt.Errorf("invalid IPv6 address %s", rr.Address)
+		}
+	case TypeDS:
+		digest, hexErr := hex.DecodeString(rr.DS.Digest)
+		if hexErr != nil {
+			return nil, hexErr
+		}
+		data = binary.BigEndian.AppendUint16(data, rr.DS.KeyTag)
+		data = append(data, rr.DS.Algorithm, rr.DS.DigestType)
+		data = append(data, digest...)
+	default:
+		data = rr.Data
+	}
+
+	buf = binary.BigEndian.AppendUint16(buf, uint16(len(data)))
+
+	return append(buf, data...), nil
+}
+
+// Pack returns the message in wire format. Names are not compressed.
+func (m *Message) Pack() ([]byte, error) {
+	var flags uint16
+	for _, flag := range []struct {
+		set   bool
+		value uint16
+	}{
+		{m.Response, flagResponse},
+		{m.Authoritative, flagAuthoritative},
+		{m.Truncated, flagTruncated},
+		{m.RecursionDesired, flagRecursionDesired},
+		{m.RecursionAvailable, flagRecursionAvailable},
+	} {
+		if flag.set {
+			flags |= flag.value
+		}
+	}
+	flags |= uint16(m.Rcode & 0xf)
+
+	buf := make([]byte, 0, 512)
+	buf = binary.BigEndian.AppendUint16(buf, m.ID)
+	buf = binary.BigEndian.AppendUint16(buf, flags)
+	for _, count := range []int{len(m.Questions), len(m.Answers), len(m.Authority), len(m.Additional)} {
+		buf = binary.BigEndian.AppendUint16(buf, uint16(count))
+	}
+
+	var err error
+
+	for _, q := range m.Questions {
+		if buf, err = packName(buf, q.Name); err != nil {
+			return nil, err
+		}
+		buf = binary.BigEndian.AppendUint16(buf, q.Type)
+		buf = binary.BigEndian.AppendUint16(buf, q.Class)
+	}
+
+	for _, section := range [][]Record{m.Answers, m.Authority, m.Additional} {
+		for _, rr := range section {
+			if buf, err = packRecord(buf, rr); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return buf, nil
+}
+
+// unpackName reads the possibly compressed name at the offset passed and
+// returns it along with the offset following the name.
+func unpackName(msg []byte, off int) (string, int, error) {
+	var labels []string
+
+	end := -1
+
+	for hops := 0; ; {
+		if off >= len(msg) {
+			return "", 0, errShortMessage
+		}
+
+		length := int(msg[off])
+
+		switch {
+		case length == 0:
+			if end < 0 {
+				end = off + 1
+			}
+			return strings.Join(labels, ".") + ".", end, nil
+		case length&0xc0 == 0xc0:
+			if off+1 >= len(msg) {
+				return "", 0, errShortMessage
+			}
+			if end < 0 {
+				end = off + 2
+			}
+			hops++
+			if hops > maxPointerHops {
+				return "", 0, errors.New("too many compression pointers in name")
+			}
+			off = int(binary.BigEndian.Uint16(msg[off:]) & 0x3fff)
+		case length&0xc0 != 0:
+			return "", 0, fmt.Errorf("unsupported label type %#x", length&0xc0)
+		default:
+			if off+1+length > len(msg) {
+				return "", 0, errShortMessage
+			}
+			labels = append(labels, string(msg[off+1:off+1+length]))
+			off += 1 + length
+		}
+	}
+}
+
+// unpackRecord reads the record at the offset passed and returns it along
+// with the offset following the record.
+func unpackRecord(msg []byte, off int) (rr Record, next int, err error) {
+	if rr.Name, off, err = unpackName(msg, off); err != nil {
+		return rr, 0, err
+	}
+
+	if off+10 > len(msg) {
+		return rr, 0, errShortMessage
+	}
+
+	rr.Type = binary.BigEndian.Uint16(msg[off:])
+	rr.Class = binary.BigEndian.Uint16(msg[off+2:])
+	rr.TTL = binary.BigEndian.Uint32(msg[off+4:])
+	length := int(binary.BigEndian.Uint16(msg[off+8:]))
+	off += 10
+
+	if off+length > len(msg) {
+		return rr, 0, errShortMessage
+	}
+
+	rr.Data = msg[off : off+length]
+
+	switch rr.Type {
+	case TypeNS:
+		if rr.Target, _, err = unpackName(msg, off); err != nil {
+			return rr, 0, err
+		}
+	case TypeA, TypeAAAA:
+		if length != net.IPv4len && length != net.IPv6len {
+			return rr, 0, fmt.Errorf("invalid address length %d", length)
+		}
+		rr.Address = net.IP(append([]byte(nil), rr.Data...))
+	case TypeDS:
+		if length < 4 {
+			return rr, 0, errShortMessage
+		}
+		rr.DS = DS{
+			KeyTag:     binary.BigEndian.Uint16(rr.Data),
+			Algorithm:  rr.Data[2],
+			DigestType: rr.Data[3],
+			Digest:     strings.ToUpper(hex.EncodeToString(rr.Data[4:])),
+		}
+	}
+
+	return rr, off + length, nil
+}
+
+// Unpack parses a message in wire format.
+func Unpack(msg []byte) (*Message, error) {
+	if len(msg) < headerLength {
+		return nil, errShortMessage
+	}
+
+	flags := binary.BigEndian.Uint16(msg[2:])
+	m := &Message{
+		ID:                 binary.BigEndian.Uint16(msg),
+		Response:           flags&flagResponse != 0,
+		Authoritative:      flags&flagAuthoritative != 0,
+		Truncated:          flags&flagTruncated != 0,
+		RecursionDesired:   flags&flagRecursionDesired != 0,
+		RecursionAvailable: flags&flagRecursionAvailable != 0,
+		Rcode:              int(flags & 0xf),
+	}
+
+	counts := []int{
+		int(binary.BigEndian.Uint16(msg[4:])),
+		int(binary.BigEndian.Uint16(msg[6:])),
+		int(binary.BigEndian.Uint16(msg[8:])),
+		int(binary.BigEndian.Uint16(msg[10:])),
+	}
+
+	off := headerLength
+
+	for i := 0; i < counts[0]; i++ {
+		name, next, err := unpackName(msg, off)
+		if err != nil {
+			return nil, err
+		}
+		if next+4 > len(msg) {
+			return nil, errShortMessage
+		}
+		m.Questions = append(m.Questions, Question{
+			Name:  name,
+			Type:  binary.BigEndian.Uint16(msg[next:]),
+			Class: binary.BigEndian.Uint16(msg[next+2:]),
+		})
+		off = next + 4
+	}
+
+	for section, records := range []*[]Record{&m.Answers, &m.Authority, &m.Additional} {
+		for i := 0; i < counts[section+1]; i++ {
+			rr, next, err := unpackRecord(msg, off)
+			if err != nil {
+				return nil, err
+			}
+			*records = append(*records, rr)
+			off = next
+		}
+	}
+
+	return m, nil
+}
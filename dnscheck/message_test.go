@@ -0,0 +1,69 @@
+package dnscheck
+
+import (
+	"net"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestMessage(t *testing.T) {
+	t.Parallel()
+	Convey("Messages should round trip through the wire format", t, func() {
+		msg := &Message{
+			ID:            1234,
+			Response:      true,
+			Authoritative: true,
+			Rcode:         RcodeNameError,
+			Questions:     []Question{{Name: "example.com.", Type: TypeNS, Class: ClassINET}},
+			Answers: []Record{
+				{Name: "example.com.", Type: TypeDS, Class: ClassINET, TTL: 60, DS: DS{KeyTag: 12345, Algorithm: 13, DigestType: 2, Digest: "ABCDEF01"}},
+			},
+			Authority: []Record{
+				{Name: "example.com.", Type: TypeNS, Class: ClassINET, TTL: 60, Target: "ns1.example.net."},
+			},
+			Additional: []Record{
+				{Name: "ns1.example.net.", Type: TypeA, Class: ClassINET, TTL: 60, Address: net.ParseIP("192.0.2.1")},
+				{Name: "ns1.example.net.", Type: TypeAAAA, Class: ClassINET, TTL: 60, Address: net.ParseIP("2001:db8::1")},
+			},
+		}
+
+		packed, err := msg.Pack()
+		So(err, ShouldBeNil)
+
+		parsed, err := Unpack(packed)
+		So(err, ShouldBeNil)
+		So(parsed.ID, ShouldEqual, 1234)
+		So(parsed.Response, ShouldBeTrue)
+		So(parsed.Authoritative, ShouldBeTrue)
+		So(parsed.RecursionDesired, ShouldBeFalse)
+		So(parsed.Rcode, ShouldEqual, RcodeNameError)
+		So(parsed.Questions, ShouldResemble, msg.Questions)
+		So(parsed.Answers[0].DS, ShouldResemble, msg.Answers[0].DS)
+		So(parsed.Authority[0].Target, ShouldEqual, "ns1.example.net.")
+		So(parsed.Additional[0].Address.String(), ShouldEqual, "192.0.2.1")
+		So(parsed.Additional[1].Address.String(), ShouldEqual, "2001:db8::1")
+	})
+
+	Convey("Compressed names should be expanded", t, func() {
+		msg := []byte{
+			0, 1, 0x80, 0, 0, 1, 0, 1, 0, 0, 0, 0,
+			7, 'e', 'x', 'a', 'm', 'p', 'l', 'e', 3, 'c', 'o', 'm', 0, 0, 2, 0, 1,
+			0xc0, 12, 0, 2, 0, 1, 0, 0, 0, 60, 0, 6, 3, 'n', 's', '1', 0xc0, 12,
+		}
+
+		parsed, err := Unpack(msg)
+		So(err, ShouldBeNil)
+		So(parsed.Answers[0].Name, ShouldEqual, "example.com.")
+		So(parsed.Answers[0].Target, ShouldEqual, "ns1.example.com.")
+
+		_, err = Unpack(msg[:len(msg)-3])
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Compression loops should be rejected", t, func() {
+		msg := []byte{0, 1, 0x80, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0xc0, 12, 0, 2, 0, 1}
+		_, err := Unpack(msg)
+		So(err, ShouldNotBeNil)
+	})
+}
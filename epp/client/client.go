@@ -2,6 +2,7 @@ package client
 
 import (
 	"bufio"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"net"
@@ -37,6 +38,14 @@ var ErrUnknownState = errors.New("unknown finite state machine state")
 // received.
 var ErrUnexpectedMessageType = errors.New("unexpected EPP message")
 
+// ErrConnectionLost indicates that the connection to the server was
+// closed and the client is not configured to reconnect.
+var ErrConnectionLost = errors.New("the connection to the server was lost")
+
+// ErrReconnectFailed indicates that the client was unable to reconnect
+// to the server within the number of attempts configured.
+var ErrReconnectFailed = errors.New("unable to reconnect to the server")
+
 // EPPClient is used to communicate with the EPP Server.
 type EPPClient struct {
 	EPPConn net.Conn
@@ -71,6 +80,19 @@ type EPPClient struct {
 
 	senderStop  chan bool
 	shouldlogin bool
+
+	// connectionLost is signaled by the listener when the current
+	// connection to the server is closed.
+	connectionLost chan bool
+
+	// inFlight is the command that has been sent to the server and has
+	// not been answered yet. replay is a command that was in flight when
+	// the connection was lost and is to be sent again after logging in.
+	inFlight *epp.Epp
+	replay   *epp.Epp
+
+	reconnectAttempts int
+	lastActivity      time.Time
 }
 
 // StatusMessage is used to report the status of the client to consumers.
@@ -170,6 +192,10 @@ const (
 	// the server to return a response after attempting to logout.
 	WaitLogout EPPClientState = "waitLogout"
 
+	// Reconnecting represents the state where the connection to the
+	// server was lost and the client is waiting to open a new one.
+	Reconnecting EPPClientState = "reconnecting"
+
 	// ShutdownRequested is a state that is used to indicate that a
 	// shutdown has been requested outside the normal FSM process.
 	ShutdownRequested EPPClientState = "shutdownRequested"
@@ -195,6 +221,7 @@ var fsmStateMap = map[EPPClientState]eppClientFSMStateHandler{
 	WaitForCommandResponse: (*EPPClient).waitForCommandResponseState,
 	PrepareLogout:          (*EPPClient).prepareLogoutState,
 	WaitLogout:             (*EPPClient).waitLogoutState,
+	Reconnecting:           (*EPPClient).reconnectingState,
 	ShutdownRequested:      (*EPPClient).shutdownState,
 }
 
@@ -252,12 +279,22 @@ func (c *EPPClient) waitForCommandResponseState() (nextState EPPClientState, err
 		return ShutdownRequested, nil
 	case msg := <-c.RecvChannel:
 		c.Log.Debug("waitForCommandResponse: got message")
+		c.inFlight = nil
+		c.lastActivity = time.Now()
 		c.WorkResponse <- msg
 
 		return WaitForWork, nil
+	case <-c.connectionLost:
+		c.Log.Debug("waitForCommandResponse: connection lost")
+
+		return c.connectionLostState()
 	case <-timeout:
 		c.Log.Debug("waitForCommandResponse: timeout")
 
+		if c.ClientConfig.Reconnect {
+			return Reconnecting, nil
+		}
+
 		return PrepareLogout, ErrTimeout
 	}
 }
@@ -272,11 +309,18 @@ func (c *EPPClient) waitForWorkHelloRespState() (nextState EPPClientState, err e
 		if msg.MessageType() == epp.GreetingType {
 			c.RecentGreeting = *msg.GreetingObject
 			c.LastGreetingTime = time.Now()
+			c.lastActivity = c.LastGreetingTime
 
 			return WaitForWork, nil
 		}
 
+	case <-c.connectionLost:
+		return c.connectionLostState()
 	case <-timeout:
+		if c.ClientConfig.Reconnect {
+			return Reconnecting, nil
+		}
+
 		return ClosedConnection, ErrTimeout
 	}
 
@@ -291,13 +335,17 @@ func (c *EPPClient) waitForWorkHelloState() (nextState EPPClientState, err error
 	return WaitForWorkHelloResp, nil
 }
 
-const (
-	eppKeepalivePeriodNomenator   = 80
-	eppKeepalivePeriodDenomenator = 100
-)
-
 func (c *EPPClient) waitForWorkState() (nextState EPPClientState, err error) {
-	timeoutTime := (EPPConnTimeout * eppKeepalivePeriodNomenator / eppKeepalivePeriodDenomenator) - time.Since(c.LastGreetingTime)
+	if c.replay != nil {
+		msg := *c.replay
+		c.replay = nil
+
+		c.Log.Debug("WaitForWorkState: Replaying command")
+
+		return c.sendCommand(msg)
+	}
+
+	timeoutTime := c.ClientConfig.GetKeepalivePeriod() - time.Since(c.lastActivity)
 
 	if timeoutTime.Seconds() < 0 {
 		return WaitForWorkHello, nil
@@ -311,13 +359,12 @@ func (c *EPPClient) waitForWorkState() (nextState EPPClientState, err error) {
 		return ShutdownRequested, nil
 	case msg := <-c.RecvChannel:
 		c.WorkResponse <- msg
+	case <-c.connectionLost:
+		return c.connectionLostState()
 	case <-timeout:
-		return WaitForWorkHello, ErrTimeout
+		return WaitForWorkHello, nil
 	case msg := <-c.NewWork:
-		c.SendChannel <- msg
-		c.Log.Debug("WaitForWorkState: Command message sent")
-
-		return WaitForCommandResponse, nil
+		return c.sendCommand(msg)
 	case <-c.LogoutChannel:
 		c.shouldlogin = false
 
@@ -345,9 +392,14 @@ func (c *EPPClient) waitLoginState() (nextState EPPClientState, err error) {
 				return PrepareLogin, fmt.Errorf("epp response error: %w", msg.ResponseObject.GetError())
 			}
 
+			c.reconnectAttempts = 0
+			c.lastActivity = time.Now()
+
 			return WaitForWork, nil
 		}
 
+	case <-c.connectionLost:
+		return c.connectionLostState()
 	case <-timeout:
 		return ClosedConnection, ErrTimeout
 	}
@@ -383,11 +435,116 @@ func (c *EPPClient) waitLogoutState() (nextState EPPClientState, err error) {
 }
 
 func (c *EPPClient) shutdownState() (nextState EPPClientState, err error) {
-	c.senderStop <- true
+	c.closeConnection()
 
 	return ClosedConnection, nil
 }
 
+// sendCommand sends the command passed to the server and records it as
+// in flight until a response is received.
+func (c *EPPClient) sendCommand(msg epp.Epp) (nextState EPPClientState, err error) {
+	c.inFlight = &msg
+	c.SendChannel <- msg
+	c.Log.Debug("WaitForWorkState: Command message sent")
+
+	return WaitForCommandResponse, nil
+}
+
+// connectionLostState determines the next state once the connection to
+// the server has been lost. If the client is not configured to
+// reconnect, any command in flight is failed and the client stops.
+func (c *EPPClient) connectionLostState() (nextState EPPClientState, err error) {
+	c.Log.Error(ErrConnectionLost.Error())
+
+	if c.ClientConfig.Reconnect {
+		return Reconnecting, nil
+	}
+
+	c.failPendingCommands()
+
+	return ClosedConnection, ErrConnectionLost
+}
+
+// reconnectingState closes the current connection and waits for the
+// backoff delay before opening a new connection. A command that was in
+// flight is either queued to be replayed or failed.
+func (c *EPPClient) reconnectingState() (nextState EPPClientState, err error) {
+	c.closeConnection()
+
+	if c.inFlight != nil && c.ClientConfig.ReplayInFlight {
+		c.replay = c.inFlight
+		c.inFlight = nil
+	}
+
+	c.failPendingCommands()
+
+	c.reconnectAttempts = c.reconnectAttempts + 1
+
+	maxAttempts := c.ClientConfig.ReconnectMaxAttempts
+	if maxAttempts > 0 && c.reconnectAttempts > maxAttempts {
+		c.inFlight = c.replay
+		c.replay = nil
+		c.failPendingCommands()
+
+		return ClosedConnection, ErrReconnectFailed
+	}
+
+	delay := c.ClientConfig.GetReconnectDelay(c.reconnectAttempts)
+	c.Log.Noticef("Reconnecting in %s (attempt %d)", delay, c.reconnectAttempts)
+
+	select {
+	case <-c.StopChannel:
+		return ShutdownRequested, nil
+	case <-makeTimeout(delay):
+	}
+
+	return NewConnection, nil
+}
+
+// failPendingCommands returns a 2500 response for the command in flight,
+// if there is one, so that the caller waiting on it is not left blocked.
+func (c *EPPClient) failPendingCommands() {
+	if c.inFlight == nil {
+		return
+	}
+
+	cltxid, _ := c.inFlight.GetTransactionID()
+	c.inFlight = nil
+
+	c.WorkResponse <- epp.GetEPPResponseResult(cltxid, "", epp.ResponseCodeCommandFailedClosing, epp.ResponseCode2500)
+}
+
+// closeConnection stops the sender, closes the connection to the server
+// and discards any messages that were queued for the old connection.
+func (c *EPPClient) closeConnection() {
+	c.stopSender()
+
+	if c.EPPConn != nil {
+		err := c.EPPConn.Close()
+		if err != nil {
+			c.Log.Debugf("error closing connection: %s", err)
+		}
+	}
+
+	for {
+		select {
+		case <-c.RecvChannel:
+		case <-c.SendChannel:
+		default:
+			return
+		}
+	}
+}
+
+// stopSender signals the sender for the current connection to stop if
+// it is still running.
+func (c *EPPClient) stopSender() {
+	select {
+	case c.senderStop <- true:
+	default:
+	}
+}
+
 func (c *EPPClient) prepareLoginState() (nextState EPPClientState, err error) {
 	LoginObj := epp.GetEPPLogin(c.ClientConfig.Username,
 		c.ClientConfig.Password,
@@ -415,6 +572,7 @@ func (c *EPPClient) openedConnectionState() (nextState EPPClientState, err error
 		if msg.MessageType() == epp.GreetingType {
 			c.RecentGreeting = *msg.GreetingObject
 			c.LastGreetingTime = time.Now()
+			c.lastActivity = c.LastGreetingTime
 
 			return PrepareLogin, nil
 		}
@@ -427,6 +585,8 @@ func (c *EPPClient) openedConnectionState() (nextState EPPClientState, err error
 
 		return "", ErrUnexpectedMessageType
 
+	case <-c.connectionLost:
+		return c.connectionLostState()
 	case <-timeout:
 		c.Log.Info("A timeout has occurred when waiting for a greeting")
 
@@ -437,24 +597,44 @@ func (c *EPPClient) openedConnectionState() (nextState EPPClientState, err error
 func (c *EPPClient) newConnectionState() (nextState EPPClientState, err error) {
 	c.Log.Debug(fmt.Sprintf("Opening connection to %s", c.ClientConfig.GetConnectionString()))
 
-	conn, connectionErr := net.Dial("tcp", c.ClientConfig.GetConnectionString())
+	conn, connectionErr := c.dial()
 	if connectionErr != nil {
 		c.Log.Critical(fmt.Sprintf("An error occurred opening the connection: %s", connectionErr.Error()))
 
+		if c.ClientConfig.Reconnect {
+			return Reconnecting, nil
+		}
+
 		return ClosedConnection, fmt.Errorf("error opening epp connection: %w", connectionErr)
 	}
 
 	c.EPPConn = conn
 	c.Writer = bufio.NewWriter(conn)
 
-	c.senderStop = make(chan bool)
+	c.senderStop = make(chan bool, 1)
+	c.connectionLost = make(chan bool, 1)
 
-	go c.EPPListener()
+	go c.EPPListener(conn, c.connectionLost)
 	go c.EPPSender(c.senderStop)
 
 	return OpenedConnection, nil
 }
 
+// dial opens the connection to the EPP server, using TLS if the client
+// is configured to.
+func (c *EPPClient) dial() (net.Conn, error) {
+	if !c.ClientConfig.UseTLS {
+		return net.Dial("tcp", c.ClientConfig.GetConnectionString())
+	}
+
+	tlsConf, err := c.ClientConfig.TLSConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	return tls.Dial("tcp", c.ClientConfig.GetConnectionString(), tlsConf)
+}
+
 func (c *EPPClient) closedConnectionState() (EPPClientState, error) {
 	if c.shouldlogin {
 		return NewConnection, nil
@@ -511,9 +691,14 @@ func (c *EPPClient) EPPSender(stopChan chan bool) {
 }
 
 // EPPListener starts to listen on the connection from the server and
-// processes the messages that it gets back.
-func (c *EPPClient) EPPListener() {
-	scanner := bufio.NewScanner(c.EPPConn)
+// processes the messages that it gets back. When the connection is
+// closed, a message is sent to the lost channel passed.
+func (c *EPPClient) EPPListener(conn net.Conn, lostChan chan bool) {
+	defer func() {
+		lostChan <- true
+	}()
+
+	scanner := bufio.NewScanner(conn)
 	scanner.Split(epp.WireSplit)
 
 	c.Log.Info("Starting to listen for incoming messages")
@@ -1,8 +1,17 @@
 package client
 
 import (
+	"bytes"
+	"io"
+	"net"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/timapril/go-registrar/epp"
+	"github.com/timapril/go-registrar/epp/server"
+
+	logging "github.com/op/go-logging"
 	. "github.com/smartystreets/goconvey/convey"
 )
 
@@ -28,3 +37,324 @@ func TestGetConnectionString(t *testing.T) {
 		So(conf.GetConnectionString(), ShouldEqual, "example.com:1800")
 	})
 }
+
+func TestGetKeepalivePeriod(t *testing.T) {
+	t.Parallel()
+	Convey("Given no keepalive period, 80% of the connection timeout should be used", t, func() {
+		conf := Config{}
+		So(conf.GetKeepalivePeriod(), ShouldEqual, 72*time.Second)
+	})
+
+	Convey("Given a keepalive period, it should be used", t, func() {
+		conf := Config{KeepalivePeriod: 30 * time.Second}
+		So(conf.GetKeepalivePeriod(), ShouldEqual, 30*time.Second)
+	})
+}
+
+func TestGetReconnectDelay(t *testing.T) {
+	t.Parallel()
+	Convey("Given no reconnect delays, the defaults should be used", t, func() {
+		conf := Config{}
+		So(conf.GetReconnectDelay(1), ShouldEqual, time.Second)
+		So(conf.GetReconnectDelay(2), ShouldEqual, 2*time.Second)
+		So(conf.GetReconnectDelay(100), ShouldEqual, 2*time.Minute)
+	})
+
+	Convey("Given reconnect delays, the delay should double until the maximum", t, func() {
+		conf := Config{ReconnectMinDelay: 100 * time.Millisecond, ReconnectMaxDelay: time.Second}
+		So(conf.GetReconnectDelay(1), ShouldEqual, 100*time.Millisecond)
+		So(conf.GetReconnectDelay(2), ShouldEqual, 200*time.Millisecond)
+		So(conf.GetReconnectDelay(4), ShouldEqual, 800*time.Millisecond)
+		So(conf.GetReconnectDelay(5), ShouldEqual, time.Second)
+	})
+}
+
+// startTestingServer starts an EPP server on a random port that closes
+// connections after the idle timeout passed, returning a client config
+// that can be used to log in to it.
+func startTestingServer(t *testing.T, timeout time.Duration) Config {
+	t.Helper()
+
+	srv := server.NewEPPServer("127.0.0.1", 0, timeout)
+	srv.Logins["username"] = server.LoginObject{LoginID: "username", Password: "password", RegistrarID: "1"}
+
+	go func() {
+		_ = srv.Start()
+	}()
+
+	<-srv.Running
+
+	t.Cleanup(func() {
+		srv.KillChan <- true
+	})
+
+	return Config{
+		Host:               "127.0.0.1",
+		Port:               int64(srv.Addr().(*net.TCPAddr).Port),
+		Username:           "username",
+		Password:           "password",
+		TransactionPrefix:  "TEST-",
+		TransactionStartID: 1,
+		ReconnectMinDelay:  10 * time.Millisecond,
+		ReconnectMaxDelay:  50 * time.Millisecond,
+	}
+}
+
+// startTestingClient starts a client using the config passed.
+func startTestingClient(t *testing.T, conf Config) *EPPClient {
+	t.Helper()
+
+	cli := NewEPPClient()
+	cli.Prepare(conf, logging.MustGetLogger("eppclient"), nil, nil)
+
+	if err := cli.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(cli.Stop)
+
+	return &cli
+}
+
+// waitForState reads states from the client until the state passed is
+// reached, returning the states seen along the way. If the state is not
+// reached before the timeout, false is returned.
+func waitForState(cli *EPPClient, state EPPClientState, timeout time.Duration) ([]EPPClientState, bool) {
+	var seen []EPPClientState
+
+	deadline := time.After(timeout)
+
+	for {
+		select {
+		case msg := <-cli.StatusChannel:
+			seen = append(seen, msg.Status)
+			if msg.Status == state {
+				return seen, true
+			}
+		case <-deadline:
+			return seen, false
+		}
+	}
+}
+
+// sendCheck sends a domain check through the client and returns the
+// response.
+func sendCheck(cli *EPPClient, txid string) (epp.Epp, bool) {
+	cli.NewWork <- epp.GetEPPDomainCheck("example.com", txid)
+
+	select {
+	case resp := <-cli.WorkResponse:
+		return resp, true
+	case <-time.After(5 * time.Second):
+		return epp.Epp{}, false
+	}
+}
+
+// dropProxy forwards connections to an EPP server and closes the
+// connection the first time a message containing the marker is sent by
+// the client, simulating a connection lost with a command in flight.
+type dropProxy struct {
+	listener net.Listener
+	target   string
+	marker   []byte
+	dropped  atomic.Bool
+}
+
+// startDropProxy starts a proxy in front of the server in the config
+// passed and returns a copy of the config that connects to the proxy.
+func startDropProxy(t *testing.T, conf Config, marker string) Config {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		listener.Close()
+	})
+
+	proxy := &dropProxy{listener: listener, target: conf.GetConnectionString(), marker: []byte(marker)}
+
+	go proxy.serve()
+
+	conf.Port = int64(listener.Addr().(*net.TCPAddr).Port)
+
+	return conf
+}
+
+func (p *dropProxy) serve() {
+	for {
+		cliConn, err := p.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		srvConn, err := net.Dial("tcp", p.target)
+		if err != nil {
+			cliConn.Close()
+
+			continue
+		}
+
+		go func() {
+			_, _ = io.Copy(cliConn, srvConn)
+			cliConn.Close()
+		}()
+
+		go p.forward(cliConn, srvConn)
+	}
+}
+
+func (p *dropProxy) forward(cliConn net.Conn, srvConn net.Conn) {
+	defer cliConn.Close()
+	defer srvConn.Close()
+
+	buf := make([]byte, 64*1024)
+
+	for {
+		count, err := cliConn.Read(buf)
+		if err != nil {
+			return
+		}
+
+		if bytes.Contains(buf[:count], p.marker) && p.dropped.CompareAndSwap(false, true) {
+			return
+		}
+
+		if _, err := srvConn.Write(buf[:count]); err != nil {
+			return
+		}
+	}
+}
+
+func TestClientKeepalive(t *testing.T) {
+	t.Parallel()
+	Convey("Given a server that drops idle sessions and a client with a shorter keepalive", t, func() {
+		conf := startTestingServer(t, 400*time.Millisecond)
+		conf.KeepalivePeriod = 100 * time.Millisecond
+		cli := startTestingClient(t, conf)
+
+		_, ok := waitForState(cli, WaitForWork, 5*time.Second)
+		So(ok, ShouldBeTrue)
+
+		Convey("The client should send hellos while idle and keep the session open", func() {
+			time.Sleep(time.Second)
+
+			resp, ok := sendCheck(cli, "TEST-CHECK")
+			So(ok, ShouldBeTrue)
+			So(resp.ResponseObject.Result.Code, ShouldEqual, epp.ResponseCodeCommandSuccessful)
+
+			seen, _ := waitForState(cli, WaitForWork, time.Second)
+			So(seen, ShouldNotContain, Reconnecting)
+			So(cli.LastGreetingTime, ShouldHappenWithin, time.Second, time.Now())
+		})
+	})
+}
+
+func TestClientReconnect(t *testing.T) {
+	t.Parallel()
+	Convey("Given a server that drops idle sessions and a client configured to reconnect", t, func() {
+		conf := startTestingServer(t, 200*time.Millisecond)
+		conf.KeepalivePeriod = time.Hour
+		conf.Reconnect = true
+		cli := startTestingClient(t, conf)
+
+		_, ok := waitForState(cli, WaitForWork, 5*time.Second)
+		So(ok, ShouldBeTrue)
+
+		Convey("The client should reconnect, log in again and accept work", func() {
+			seen, ok := waitForState(cli, WaitForWork, 5*time.Second)
+			So(ok, ShouldBeTrue)
+			So(seen, ShouldResemble, []EPPClientState{Reconnecting, NewConnection, OpenedConnection, PrepareLogin, WaitLogin, WaitForWork})
+
+			resp, ok := sendCheck(cli, "TEST-CHECK")
+			So(ok, ShouldBeTrue)
+			So(resp.ResponseObject.Result.Code, ShouldEqual, epp.ResponseCodeCommandSuccessful)
+		})
+	})
+}
+
+func TestClientInFlightCommands(t *testing.T) {
+	t.Parallel()
+	Convey("Given a connection that is lost while a command is in flight", t, func() {
+		conf := startDropProxy(t, startTestingServer(t, 5*time.Second), "<check>")
+		conf.Reconnect = true
+
+		Convey("If replay is enabled, the command should be sent again after reconnecting", func() {
+			conf.ReplayInFlight = true
+			cli := startTestingClient(t, conf)
+
+			_, ok := waitForState(cli, WaitForWork, 5*time.Second)
+			So(ok, ShouldBeTrue)
+
+			resp, ok := sendCheck(cli, "TEST-REPLAY")
+			So(ok, ShouldBeTrue)
+			So(resp.ResponseObject.Result.Code, ShouldEqual, epp.ResponseCodeCommandSuccessful)
+			So(resp.ResponseObject.TransactionID.ClientTransactionID, ShouldEqual, "TEST-REPLAY")
+
+			seen, _ := waitForState(cli, WaitForWork, time.Second)
+			So(seen, ShouldContain, Reconnecting)
+		})
+
+		Convey("If replay is disabled, the command should fail and later commands succeed", func() {
+			cli := startTestingClient(t, conf)
+
+			_, ok := waitForState(cli, WaitForWork, 5*time.Second)
+			So(ok, ShouldBeTrue)
+
+			resp, ok := sendCheck(cli, "TEST-FAIL")
+			So(ok, ShouldBeTrue)
+			So(resp.ResponseObject.Result.Code, ShouldEqual, epp.ResponseCodeCommandFailedClosing)
+			So(resp.ResponseObject.TransactionID.ClientTransactionID, ShouldEqual, "TEST-FAIL")
+
+			_, ok = waitForState(cli, Reconnecting, 5*time.Second)
+			So(ok, ShouldBeTrue)
+			_, ok = waitForState(cli, WaitForWork, 5*time.Second)
+			So(ok, ShouldBeTrue)
+
+			resp, ok = sendCheck(cli, "TEST-AFTER")
+			So(ok, ShouldBeTrue)
+			So(resp.ResponseObject.Result.Code, ShouldEqual, epp.ResponseCodeCommandSuccessful)
+		})
+
+		Convey("If reconnect is disabled, the command should fail", func() {
+			conf.Reconnect = false
+			cli := startTestingClient(t, conf)
+
+			_, ok := waitForState(cli, WaitForWork, 5*time.Second)
+			So(ok, ShouldBeTrue)
+
+			resp, ok := sendCheck(cli, "TEST-CLOSED")
+			So(ok, ShouldBeTrue)
+			So(resp.ResponseObject.Result.Code, ShouldEqual, epp.ResponseCodeCommandFailedClosing)
+		})
+	})
+}
+
+func TestShareTransactionIDs(t *testing.T) {
+	t.Parallel()
+	Convey("Given copies of a config that share transaction IDs", t, func() {
+		conf := Config{TransactionPrefix: "TEST-", TransactionStartID: 10}
+		conf.ShareTransactionIDs()
+
+		first := conf
+		second := conf
+
+		Convey("Each copy should draw from the same counter", func() {
+			So(first.GetNewTransactionID(), ShouldEqual, "TEST-10")
+			So(second.GetNewTransactionID(), ShouldEqual, "TEST-11")
+			So(first.GetNewTransactionID(), ShouldEqual, "TEST-12")
+		})
+	})
+
+	Convey("Given copies of a config that do not share transaction IDs, each copy should count separately", t, func() {
+		conf := Config{TransactionPrefix: "TEST-", TransactionStartID: 10}
+
+		first := conf
+		second := conf
+
+		So(first.GetNewTransactionID(), ShouldEqual, "TEST-10")
+		So(second.GetNewTransactionID(), ShouldEqual, "TEST-10")
+	})
+}
@@ -2,6 +2,8 @@ package client
 
 import (
 	"fmt"
+	"sync"
+	"time"
 
 	logging "github.com/op/go-logging"
 )
@@ -22,14 +24,110 @@ type Config struct {
 
 	RegistrarID string
 
+	// UseTLS causes the client to connect to the server using TLS as
+	// described in RFC 5734 rather than relying on an auth proxy to
+	// provide the TLS session. CACertPath is used to verify the server,
+	// falling back to the system roots if empty, and ServerName overrides
+	// the name used for SNI and verification. TLSMinVersion may be "1.2"
+	// or "1.3" and defaults to "1.2".
+	UseTLS         bool
+	CACertPath     string
+	ClientCertPath string
+	ClientKeyPath  string
+	ServerName     string
+	TLSMinVersion  string
+
+	// KeychainEnabled, KeychainName and KeychainAccount identify the
+	// keychain entry holding the passphrase for an encrypted client key.
+	KeychainEnabled bool
+	KeychainName    string
+	KeychainAccount string
+
+	// KeepalivePeriod is how long the session may be idle before a
+	// <hello> is sent to keep it open. If it is not set, 80% of
+	// EPPConnTimeout is used.
+	KeepalivePeriod time.Duration
+
+	// Reconnect causes the client to open a new connection and log in
+	// again if the connection to the server is lost. Attempts are spaced
+	// using an exponential backoff that starts at ReconnectMinDelay and
+	// is capped at ReconnectMaxDelay. If ReconnectMaxAttempts is greater
+	// than zero the client gives up after that many consecutive attempts.
+	Reconnect            bool
+	ReconnectMinDelay    time.Duration
+	ReconnectMaxDelay    time.Duration
+	ReconnectMaxAttempts int
+
+	// ReplayInFlight causes a command that was sent but not answered
+	// before the connection was lost to be sent again once the client
+	// has logged back in. If it is not set, a 2500 response is returned
+	// for the command instead. Only enable this if replaying a command
+	// that may have been processed by the server is safe.
+	ReplayInFlight bool
+
+	// MaxSessions is the number of sessions that may be opened to the
+	// server at the same time by a pool of clients. If it is not set, a
+	// single session is used.
+	MaxSessions int
+
 	currentTransactionID int64
+
+	sharedTransactionIDs *transactionCounter
+}
+
+// transactionCounter holds the next transaction ID for a group of
+// configs that share transaction IDs.
+type transactionCounter struct {
+	lock sync.Mutex
+	next int64
 }
 
 const (
 	defaultEPPPort = 1700
 	defaultEPPIP   = "127.0.0.1"
+
+	defaultReconnectMinDelay = 1 * time.Second
+	defaultReconnectMaxDelay = 2 * time.Minute
+
+	eppKeepalivePeriodNomenator   = 80
+	eppKeepalivePeriodDenomenator = 100
 )
 
+// GetKeepalivePeriod returns the length of time the session may be idle
+// before a <hello> is sent to the server.
+func (c Config) GetKeepalivePeriod() time.Duration {
+	if c.KeepalivePeriod > 0 {
+		return c.KeepalivePeriod
+	}
+
+	return EPPConnTimeout * eppKeepalivePeriodNomenator / eppKeepalivePeriodDenomenator
+}
+
+// GetReconnectDelay returns the length of time to wait before making
+// the reconnect attempt passed, starting at 1. The delay doubles with
+// each attempt until it reaches the maximum delay.
+func (c Config) GetReconnectDelay(attempt int) time.Duration {
+	delay := c.ReconnectMinDelay
+	if delay <= 0 {
+		delay = defaultReconnectMinDelay
+	}
+
+	maxDelay := c.ReconnectMaxDelay
+	if maxDelay <= 0 {
+		maxDelay = defaultReconnectMaxDelay
+	}
+
+	for idx := 1; idx < attempt && delay < maxDelay; idx++ {
+		delay = delay * 2
+	}
+
+	if delay > maxDelay {
+		return maxDelay
+	}
+
+	return delay
+}
+
 // GetConnectionString returns the connection string for the EPP server
 // or auth proxy. If the host or port are not set correctly the
 // respecitive default values are "127.0.0.1" and 1700.
@@ -48,9 +146,42 @@ func (c Config) GetConnectionString() string {
 	return fmt.Sprintf("%s:%d", host, port)
 }
 
+// GetSessionCount returns the number of sessions that a pool of clients
+// should open to the server.
+func (c Config) GetSessionCount() int {
+	if c.MaxSessions > 0 {
+		return c.MaxSessions
+	}
+
+	return 1
+}
+
+// ShareTransactionIDs causes the config, and any copies made of it after
+// this call, to draw transaction IDs from a single counter so that the
+// IDs are unique across all of the sessions using those configs.
+func (c *Config) ShareTransactionIDs() {
+	next := c.currentTransactionID
+	if next == 0 {
+		next = c.TransactionStartID
+	}
+
+	c.sharedTransactionIDs = &transactionCounter{next: next}
+}
+
 // GetNewTransactionID is used to generate a new transaction ID for the
-// session (unique within session, may not be unique between session).
+// session (unique within session, may not be unique between session
+// unless ShareTransactionIDs has been called).
 func (c *Config) GetNewTransactionID() string {
+	if c.sharedTransactionIDs != nil {
+		c.sharedTransactionIDs.lock.Lock()
+		defer c.sharedTransactionIDs.lock.Unlock()
+
+		txid := fmt.Sprintf("%s%d", c.TransactionPrefix, c.sharedTransactionIDs.next)
+		c.sharedTransactionIDs.next = c.sharedTransactionIDs.next + 1
+
+		return txid
+	}
+
 	if c.currentTransactionID == 0 {
 		c.currentTransactionID = c.TransactionStartID
 	}
@@ -0,0 +1,147 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+
+	"github.com/timapril/go-registrar/keychain"
+)
+
+// ErrNoCACertificates indicates that the CA bundle configured for the
+// client did not contain any certificates.
+var ErrNoCACertificates = errors.New("no certificates found in CA bundle")
+
+// ErrUnknownTLSVersion indicates that the minimum TLS version configured
+// for the client is not supported.
+var ErrUnknownTLSVersion = errors.New("unknown TLS version")
+
+// ErrInvalidClientKey indicates that the client key file did not contain
+// a PEM encoded key.
+var ErrInvalidClientKey = errors.New("no PEM encoded key found in client key file")
+
+// ErrEncryptedKeyNoPassphrase indicates that the client key is encrypted
+// and no passphrase could be found to decrypt it.
+var ErrEncryptedKeyNoPassphrase = errors.New("client key is encrypted and no keychain passphrase is available")
+
+// tlsVersions maps the TLS versions that may be configured to the values
+// used by crypto/tls.
+var tlsVersions = map[string]uint16{
+	"":    tls.VersionTLS12,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// TLSConfig generates the TLS configuration used to connect to the EPP
+// server from the values in the config.
+func (c Config) TLSConfig() (*tls.Config, error) {
+	minVersion, ok := tlsVersions[c.TLSMinVersion]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownTLSVersion, c.TLSMinVersion)
+	}
+
+	tlsConf := &tls.Config{
+		MinVersion: minVersion,
+		ServerName: c.ServerName,
+	}
+
+	if tlsConf.ServerName == "" {
+		host, _, err := net.SplitHostPort(c.GetConnectionString())
+		if err != nil {
+			return nil, fmt.Errorf("error parsing server address: %w", err)
+		}
+
+		tlsConf.ServerName = host
+	}
+
+	if c.CACertPath != "" {
+		caBytes, err := os.ReadFile(c.CACertPath)
+		if err != nil {
+			return nil, fmt.Errorf("error reading CA bundle: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caBytes) {
+			return nil, ErrNoCACertificates
+		}
+
+		tlsConf.RootCAs = pool
+	}
+
+	if c.ClientCertPath != "" {
+		cert, err := c.loadClientCertificate()
+		if err != nil {
+			return nil, err
+		}
+
+		tlsConf.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConf, nil
+}
+
+// loadClientCertificate loads the client certificate and key from the
+// paths in the config. If the key is encrypted, the passphrase is taken
+// from the keychain.
+func (c Config) loadClientCertificate() (tls.Certificate, error) {
+	certBytes, err := os.ReadFile(c.ClientCertPath)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("error reading client certificate: %w", err)
+	}
+
+	keyBytes, err := os.ReadFile(c.ClientKeyPath)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("error reading client key: %w", err)
+	}
+
+	keyBlock, _ := pem.Decode(keyBytes)
+	if keyBlock == nil {
+		return tls.Certificate{}, ErrInvalidClientKey
+	}
+
+	if x509.IsEncryptedPEMBlock(keyBlock) {
+		keyBytes, err = c.decryptClientKey(keyBlock)
+		if err != nil {
+			return tls.Certificate{}, err
+		}
+	}
+
+	cert, err := tls.X509KeyPair(certBytes, keyBytes)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("error loading client certificate: %w", err)
+	}
+
+	return cert, nil
+}
+
+// decryptClientKey decrypts the PEM block passed using the passphrase
+// from the keychain and returns the PEM encoded key.
+func (c Config) decryptClientKey(keyBlock *pem.Block) ([]byte, error) {
+	if !c.KeychainEnabled || c.KeychainAccount == "" {
+		return nil, ErrEncryptedKeyNoPassphrase
+	}
+
+	pass, err := keychain.GetKeyChainPassphrase(keychain.Conf{
+		MacKeychainEnabled: c.KeychainEnabled,
+		MacKeychainName:    c.KeychainName,
+		MacKeychainAccount: c.KeychainAccount,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error reading client key passphrase: %w", err)
+	}
+
+	if len(pass) == 0 {
+		return nil, ErrEncryptedKeyNoPassphrase
+	}
+
+	der, err := x509.DecryptPEMBlock(keyBlock, pass)
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting client key: %w", err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: keyBlock.Type, Bytes: der}), nil
+}
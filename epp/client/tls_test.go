@@ -0,0 +1,120 @@
+package client
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// writeTestingKeyPair writes a self signed certificate and its key into
+// the directory passed. If a password is provided the key is encrypted.
+func writeTestingKeyPair(t *testing.T, dir string, password string) (string, string) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "epp client"},
+		NotBefore:    time.Now().Add(-1 * time.Hour),
+		NotAfter:     time.Now().Add(1 * time.Hour),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyBlock := &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}
+	if password != "" {
+		keyBlock, err = x509.EncryptPEMBlock(rand.Reader, keyBlock.Type, keyBlock.Bytes, []byte(password), x509.PEMCipherAES256)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	certPath := filepath.Join(dir, "client.pem")
+	keyPath := filepath.Join(dir, "client.key")
+
+	if err := os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(keyBlock), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return certPath, keyPath
+}
+
+func TestTLSConfig(t *testing.T) {
+	t.Parallel()
+	Convey("Given a config with no TLS settings", t, func() {
+		conf := Config{Host: "epp.example.com"}
+
+		Convey("TLSConfig should default to TLS 1.2 and the server host name", func() {
+			tlsConf, err := conf.TLSConfig()
+			So(err, ShouldBeNil)
+			So(tlsConf.MinVersion, ShouldEqual, tls.VersionTLS12)
+			So(tlsConf.ServerName, ShouldEqual, "epp.example.com")
+			So(tlsConf.RootCAs, ShouldBeNil)
+			So(tlsConf.Certificates, ShouldBeEmpty)
+		})
+
+		Convey("TLSConfig should use the server name and minimum version set", func() {
+			conf.ServerName = "sni.example.com"
+			conf.TLSMinVersion = "1.3"
+			tlsConf, err := conf.TLSConfig()
+			So(err, ShouldBeNil)
+			So(tlsConf.MinVersion, ShouldEqual, tls.VersionTLS13)
+			So(tlsConf.ServerName, ShouldEqual, "sni.example.com")
+		})
+
+		Convey("TLSConfig should reject an unknown minimum version", func() {
+			conf.TLSMinVersion = "1.0"
+			_, err := conf.TLSConfig()
+			So(err, ShouldWrap, ErrUnknownTLSVersion)
+		})
+	})
+
+	Convey("Given a config with client certificate settings", t, func() {
+		dir := t.TempDir()
+
+		Convey("An unencrypted key should be loaded", func() {
+			certPath, keyPath := writeTestingKeyPair(t, dir, "")
+			conf := Config{ClientCertPath: certPath, ClientKeyPath: keyPath, CACertPath: certPath}
+			tlsConf, err := conf.TLSConfig()
+			So(err, ShouldBeNil)
+			So(tlsConf.Certificates, ShouldHaveLength, 1)
+			So(tlsConf.RootCAs, ShouldNotBeNil)
+		})
+
+		Convey("An encrypted key without a keychain entry should fail", func() {
+			certPath, keyPath := writeTestingKeyPair(t, dir, "secret")
+			conf := Config{ClientCertPath: certPath, ClientKeyPath: keyPath}
+			_, err := conf.TLSConfig()
+			So(err, ShouldEqual, ErrEncryptedKeyNoPassphrase)
+		})
+
+		Convey("A CA bundle without certificates should fail", func() {
+			caPath := filepath.Join(dir, "ca.pem")
+			So(os.WriteFile(caPath, []byte("not a certificate"), 0o600), ShouldBeNil)
+			conf := Config{CACertPath: caPath}
+			_, err := conf.TLSConfig()
+			So(err, ShouldEqual, ErrNoCACertificates)
+		})
+	})
+}
@@ -165,6 +165,11 @@ func (c Command) TypedMessage() Command {
 		out.RenewObject = &renew
 	}
 
+	if c.PollObject != nil {
+		poll := *c.PollObject
+		out.PollObject = &poll
+	}
+
 	// TODO
 	if c.ExtensionObject != nil {
 		extension := c.ExtensionObject.TypedMessage()
@@ -89,3 +89,17 @@ func TestCommandMessageType(t *testing.T) {
 		})
 	})
 }
+
+func TestCommandTypedMessagePoll(t *testing.T) {
+	t.Parallel()
+	Convey("Given a poll acknowledge command object", t, func() {
+		obj := GetEPPPollAcknowledge("12345", "txid")
+		Convey("The typed message should keep the poll object", func() {
+			typed := obj.CommandObject.TypedMessage()
+			So(typed.PollObject, ShouldNotBeNil)
+			So(typed.PollObject.Operation, ShouldEqual, PollOperationAcknowledge)
+			So(typed.PollObject.MessageID, ShouldEqual, "12345")
+			So(typed.MessageType(), ShouldEqual, PollType)
+		})
+	})
+}
@@ -12,6 +12,10 @@ import (
 var (
 	eppPort = flag.Int("port", epp.DefaultEPPPort, "The port that the server should listen on")
 	eppHost = flag.String("host", "", "The host that the server should listen on")
+
+	tlsCert = flag.String("tls.cert", "", "The certificate to use for TLS, TLS is disabled if not set")
+	tlsKey  = flag.String("tls.key", "", "The key for the TLS certificate")
+	tlsCA   = flag.String("tls.ca", "", "The CA bundle used to verify client certificates, if set clients must present a certificate")
 )
 
 const (
@@ -20,10 +24,21 @@ const (
 )
 
 func main() {
+	flag.Parse()
+
 	log.Default().Print("Starting EPP Testing Server")
 
 	srv := server.NewEPPServer(*eppHost, *eppPort, eppTimeout)
 
+	if *tlsCert != "" {
+		tlsConf, err := server.LoadTLSConfig(*tlsCert, *tlsKey, *tlsCA)
+		if err != nil {
+			log.Default().Fatal(err.Error())
+		}
+
+		srv.TLSConfig = tlsConf
+	}
+
 	err := srv.Start()
 	if err != nil {
 		log.Default().Fatal(err.Error())
@@ -0,0 +1,335 @@
+package server
+
+import (
+	"net"
+	"strings"
+	"time"
+
+	"github.com/timapril/go-registrar/epp"
+	"github.com/timapril/go-registrar/lib"
+)
+
+// handleDomainCreate adds a new domain to the registry sponsored by the
+// logged in registrar if the domain does not already exist and all of
+// the referenced hosts and contacts exist.
+func (conn *EPPServerConnection) handleDomainCreate(msg epp.Epp) error {
+	obj := msg.CommandObject.CreateObject.DomainCreateObj
+
+	dom, code, message := conn.Conf.createDomain(obj, conn.registrarID())
+	if code != epp.ResponseCodeCommandSuccessful {
+		return conn.writeResult(msg, code, message)
+	}
+
+	cltxid, _ := msg.GetTransactionID()
+	srvtxid := conn.GetNextTransactionID()
+	res := epp.GetEPPResponseResult(cltxid, srvtxid, code, message)
+
+	res.ResponseObject.ResultData = &epp.ResultData{}
+	res.ResponseObject.ResultData.DomainCreDataResp = &epp.DomainCreDataResp{}
+	res.ResponseObject.ResultData.DomainCreDataResp.XMLNSDomain = epp.DomainXMLNS
+	res.ResponseObject.ResultData.DomainCreDataResp.XMLNsSchemaLocation = epp.DomainSchema
+	res.ResponseObject.ResultData.DomainCreDataResp.Name = dom.DomainName
+	res.ResponseObject.ResultData.DomainCreDataResp.CreateDate = dom.CreateDate.Format(epp.EPPTimeFormat)
+	res.ResponseObject.ResultData.DomainCreDataResp.ExpireDate = dom.ExpireDate.Format(epp.EPPTimeFormat)
+
+	return conn.WriteEPP(res)
+}
+
+// createDomain validates a domain create request and adds the domain to
+// the registry. The response code and message for the request are
+// returned along with the created domain if the request succeeded.
+func (srv *EPPServer) createDomain(obj *epp.DomainCreate, clientID string) (*lib.Domain, int, string) {
+	domainName := strings.ToUpper(obj.DomainName)
+
+	if _, err := srv.DomainByName(domainName); err == nil {
+		return nil, epp.ResponseCodeObjectExists, epp.ResponseCode2302
+	}
+
+	period := obj.Period
+	if period.Value == 0 {
+		period = epp.GetEPPDomainPeriod(epp.DomainPeriodYear, 1)
+	}
+
+	if !validPeriod(period) {
+		return nil, epp.ResponseCodeParameterValueRangeError, epp.ResponseCode2004
+	}
+
+	now := time.Now().UTC()
+
+	dom := lib.Domain{}
+	dom.ID = srv.nextID()
+	dom.DomainName = domainName
+	dom.DomainROID = srv.nextROID("DOMAIN")
+	dom.RegistryDomainID = dom.DomainROID
+	dom.SponsoringClientID = clientID
+	dom.CreateClientID = clientID
+	dom.CreateDate = now
+	dom.ExpireDate = addPeriod(now, period)
+
+	if obj.Registrant != "" {
+		cont, err := srv.ContactByID(obj.Registrant)
+		if err != nil {
+			return nil, epp.ResponseCodeObjectDoesNotExist, epp.ResponseCode2303
+		}
+
+		dom.CurrentRevision.DomainRegistrant = *cont
+	}
+
+	for _, domainContact := range obj.Contacts {
+		slot := domainContactSlot(&dom.CurrentRevision, domainContact.Type)
+		if slot == nil {
+			return nil, epp.ResponseCodeParameterValuePolicyError, epp.ResponseCode2306
+		}
+
+		cont, err := srv.ContactByID(domainContact.Value)
+		if err != nil {
+			return nil, epp.ResponseCodeObjectDoesNotExist, epp.ResponseCode2303
+		}
+
+		*slot = *cont
+	}
+
+	if obj.Hosts != nil {
+		for _, domainHost := range obj.Hosts.Hosts {
+			hos, err := srv.HostByName(domainHost.Value)
+			if err != nil {
+				return nil, epp.ResponseCodeObjectDoesNotExist, epp.ResponseCode2303
+			}
+
+			dom.CurrentRevision.Hostnames = append(dom.CurrentRevision.Hostnames, *hos)
+		}
+	}
+
+	srv.Domains[dom.DomainName] = dom
+
+	if obj.DomainAuthObj != nil && obj.DomainAuthObj.Password != "" {
+		srv.DomainAuthInfo[dom.DomainName] = obj.DomainAuthObj.Password
+	}
+
+	for _, hos := range dom.CurrentRevision.Hostnames {
+		srv.refreshHostLinked(hos.HostName)
+	}
+
+	srv.refreshDomainContactsLinked(dom.CurrentRevision)
+
+	return &dom, epp.ResponseCodeCommandSuccessful, epp.ResponseCode1000
+}
+
+// handleHostCreate adds a new host to the registry sponsored by the
+// logged in registrar if the host does not already exist.
+func (conn *EPPServerConnection) handleHostCreate(msg epp.Epp) error {
+	obj := msg.CommandObject.CreateObject.HostCreateObj
+
+	hos, code, message := conn.Conf.createHost(obj, conn.registrarID())
+	if code != epp.ResponseCodeCommandSuccessful {
+		return conn.writeResult(msg, code, message)
+	}
+
+	cltxid, _ := msg.GetTransactionID()
+	srvtxid := conn.GetNextTransactionID()
+	res := epp.GetEPPResponseResult(cltxid, srvtxid, code, message)
+
+	res.ResponseObject.ResultData = &epp.ResultData{}
+	res.ResponseObject.ResultData.HostCreDataResp = &epp.HostCreDataResp{}
+	res.ResponseObject.ResultData.HostCreDataResp.XMLNSHost = epp.HostXMLNS
+	res.ResponseObject.ResultData.HostCreDataResp.XMLNsSchemaLocation = epp.HostSchema
+	res.ResponseObject.ResultData.HostCreDataResp.Name = hos.HostName
+	res.ResponseObject.ResultData.HostCreDataResp.CreateDate = hos.CreateDate.Format(epp.EPPTimeFormat)
+
+	return conn.WriteEPP(res)
+}
+
+// createHost validates a host create request and adds the host to the
+// registry. The response code and message for the request are returned
+// along with the created host if the request succeeded.
+func (srv *EPPServer) createHost(obj *epp.HostCreate, clientID string) (*lib.Host, int, string) {
+	hostName := strings.ToUpper(obj.HostName)
+
+	if _, err := srv.HostByName(hostName); err == nil {
+		return nil, epp.ResponseCodeObjectExists, epp.ResponseCode2302
+	}
+
+	parent, err := srv.superordinateDomain(hostName)
+	if err == nil && parent.SponsoringClientID != clientID {
+		return nil, epp.ResponseCodeAuthorizationError, epp.ResponseCode2201
+	}
+
+	addresses, ok := hostAddresses(nil, obj.Addresses, nil)
+	if !ok {
+		return nil, epp.ResponseCodeParameterValueSyntaxError, epp.ResponseCode2005
+	}
+
+	now := time.Now().UTC()
+
+	hos := lib.Host{}
+	hos.ID = srv.nextID()
+	hos.HostName = hostName
+	hos.HostROID = srv.nextROID("HOST")
+	hos.SponsoringClientID = clientID
+	hos.CreateClientID = clientID
+	hos.CreateDate = now
+	hos.CurrentRevision.HostAddresses = addresses
+
+	srv.Hosts[hos.HostName] = hos
+
+	return &hos, epp.ResponseCodeCommandSuccessful, epp.ResponseCode1000
+}
+
+// handleContactCreate adds a new contact to the registry sponsored by the
+// logged in registrar if the contact ID is not already in use.
+func (conn *EPPServerConnection) handleContactCreate(msg epp.Epp) error {
+	obj := msg.CommandObject.CreateObject.ContactCreateObj
+
+	cont, code, message := conn.Conf.createContact(obj, conn.registrarID())
+	if code != epp.ResponseCodeCommandSuccessful {
+		return conn.writeResult(msg, code, message)
+	}
+
+	cltxid, _ := msg.GetTransactionID()
+	srvtxid := conn.GetNextTransactionID()
+	res := epp.GetEPPResponseResult(cltxid, srvtxid, code, message)
+
+	res.ResponseObject.ResultData = &epp.ResultData{}
+	res.ResponseObject.ResultData.ContactCreDataResp = &epp.ContactCreDataResp{}
+	res.ResponseObject.ResultData.ContactCreDataResp.XMLNSContact = epp.ContactXMLNS
+	res.ResponseObject.ResultData.ContactCreDataResp.XMLNsSchemaLocation = epp.ContactSchema
+	res.ResponseObject.ResultData.ContactCreDataResp.ID = cont.ContactRegistryID
+	res.ResponseObject.ResultData.ContactCreDataResp.CreateDate = cont.CreateDate.Format(epp.EPPTimeFormat)
+
+	return conn.WriteEPP(res)
+}
+
+// createContact validates a contact create request and adds the contact
+// to the registry. The response code and message for the request are
+// returned along with the created contact if the request succeeded.
+func (srv *EPPServer) createContact(obj *epp.ContactCreate, clientID string) (*lib.Contact, int, string) {
+	if obj.ID == "" || len(obj.PostalInfoList) == 0 || obj.Email == "" {
+		return nil, epp.ResponseCodeRequireParameterMissing, epp.ResponseCode2003
+	}
+
+	if _, err := srv.ContactByID(obj.ID); err == nil {
+		return nil, epp.ResponseCodeObjectExists, epp.ResponseCode2302
+	}
+
+	now := time.Now().UTC()
+
+	cont := lib.Contact{}
+	cont.ID = srv.nextID()
+	cont.ContactRegistryID = obj.ID
+	cont.ContactROID = srv.nextROID("CONTACT")
+	cont.SponsoringClientID = clientID
+	cont.CreateClientID = clientID
+	cont.CreateDate = now
+
+	setContactPostalInfo(&cont.CurrentRevision, obj.PostalInfoList[0])
+	cont.CurrentRevision.VoicePhoneNumber = obj.VoiceNumber.Number
+	cont.CurrentRevision.VoicePhoneExtension = obj.VoiceNumber.Extension
+	cont.CurrentRevision.FaxPhoneNumber = obj.FaxNumber.Number
+	cont.CurrentRevision.FaxPhoneExtension = obj.FaxNumber.Extension
+	cont.CurrentRevision.EmailAddress = obj.Email
+
+	srv.Contacts[cont.ContactRegistryID] = cont
+
+	if obj.ContactAuthObj != nil && obj.ContactAuthObj.Password != "" {
+		srv.ContactAuthInfo[cont.ContactRegistryID] = obj.ContactAuthObj.Password
+	}
+
+	return &cont, epp.ResponseCodeCommandSuccessful, epp.ResponseCode1000
+}
+
+// domainContactSlot returns a pointer to the contact in the revision
+// that holds the contact type provided or nil if the type is unknown.
+func domainContactSlot(rev *lib.DomainRevision, contactType epp.ContactType) *lib.Contact {
+	switch contactType {
+	case epp.Admin:
+		return &rev.DomainAdminContact
+	case epp.Tech:
+		return &rev.DomainTechContact
+	case epp.Billing:
+		return &rev.DomainBillingContact
+	}
+
+	return nil
+}
+
+// refreshDomainContactsLinked updates the linked status of each of the
+// contacts referenced by the domain revision provided.
+func (srv *EPPServer) refreshDomainContactsLinked(rev lib.DomainRevision) {
+	for _, cont := range []lib.Contact{rev.DomainRegistrant, rev.DomainAdminContact, rev.DomainTechContact, rev.DomainBillingContact} {
+		if cont.ID != 0 {
+			srv.refreshContactLinked(cont.ContactRegistryID)
+		}
+	}
+}
+
+// setContactPostalInfo copies the values from the postal info object
+// into the contact revision provided.
+func setContactPostalInfo(rev *lib.ContactRevision, postalInfo epp.PostalInfo) {
+	rev.Name = postalInfo.Name
+	rev.Org = postalInfo.Org
+
+	streets := make([]string, 3)
+	copy(streets, postalInfo.Address.Street)
+	rev.AddressStreet1 = streets[0]
+	rev.AddressStreet2 = streets[1]
+	rev.AddressStreet3 = streets[2]
+
+	rev.AddressCity = postalInfo.Address.City
+	rev.AddressState = postalInfo.Address.Sp
+	rev.AddressPostalCode = postalInfo.Address.Pc
+	rev.AddressCountry = postalInfo.Address.Cc
+}
+
+// hostAddresses returns the list of host addresses that results from
+// removing and then adding the addresses provided to the existing list.
+// If an address is not valid for its IP version, is added while already
+// present or is removed while not present, false is returned.
+func hostAddresses(existing []lib.HostAddress, add []epp.HostAddress, rem []epp.HostAddress) ([]lib.HostAddress, bool) {
+	out := []lib.HostAddress{}
+
+	for _, addr := range existing {
+		removed := false
+
+		for _, remAddr := range rem {
+			remIP := net.ParseIP(remAddr.Address)
+			if remIP != nil && remIP.Equal(net.ParseIP(addr.IPAddress)) {
+				removed = true
+			}
+		}
+
+		if !removed {
+			out = append(out, addr)
+		}
+	}
+
+	if len(existing)-len(out) != len(rem) {
+		return nil, false
+	}
+
+	for _, addAddr := range add {
+		ip := net.ParseIP(addAddr.Address)
+		if ip == nil {
+			return nil, false
+		}
+
+		protocol := int64(ipv6EPPProtocolIdentifier)
+
+		if ip.To4() != nil {
+			protocol = ipv4EPPProtocolIdentifier
+		}
+
+		if (addAddr.IPVersion == epp.IPv6) != (protocol == ipv6EPPProtocolIdentifier) {
+			return nil, false
+		}
+
+		for _, addr := range out {
+			if ip.Equal(net.ParseIP(addr.IPAddress)) {
+				return nil, false
+			}
+		}
+
+		out = append(out, lib.HostAddress{IPAddress: addAddr.Address, Protocol: protocol})
+	}
+
+	return out, true
+}
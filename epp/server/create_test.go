@@ -0,0 +1,212 @@
+package server
+
+import (
+	"bufio"
+	"net"
+	"testing"
+
+	"github.com/timapril/go-registrar/epp"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// getTestingContactCreate returns a contact create object for a new
+// contact with the ID provided.
+func getTestingContactCreate(id string) *epp.ContactCreate {
+	postal := epp.GetEPPPostalInfo("int", "Test User", "Test Org", "123 Main St", "", "", "Testville", "CA", "12345", "US")
+
+	return &epp.ContactCreate{
+		ID:             id,
+		PostalInfoList: []epp.PostalInfo{postal},
+		VoiceNumber:    epp.GetEPPPhoneNumber("+1.5555555555", ""),
+		Email:          "test@example.com",
+	}
+}
+
+// getTestingDomainCreate returns a domain create object for the domain
+// provided that uses the owned testing contact and host.
+func getTestingDomainCreate(domainName string) *epp.DomainCreate {
+	return &epp.DomainCreate{
+		DomainName: domainName,
+		Period:     epp.GetEPPDomainPeriod(epp.DomainPeriodYear, 2),
+		Hosts:      &epp.DomainHostList{Hosts: []epp.DomainHost{{Value: "NS1.EXAMPLE.NET"}}},
+		Registrant: "1234",
+		Contacts:   []epp.DomainContact{{Type: epp.Admin, Value: "1234"}},
+	}
+}
+
+func TestCreateDomain(t *testing.T) {
+	t.Parallel()
+	Convey("Given a default server", t, func() {
+		srv := GetDefaultServer()
+
+		Convey("Creating a new domain should add it to the registry", func() {
+			dom, code, _ := srv.createDomain(getTestingDomainCreate("new.com"), "1")
+			So(code, ShouldEqual, epp.ResponseCodeCommandSuccessful)
+			So(dom.DomainName, ShouldEqual, "NEW.COM")
+			So(dom.SponsoringClientID, ShouldEqual, "1")
+			So(dom.ExpireDate.Year(), ShouldEqual, dom.CreateDate.Year()+2)
+
+			stored, err := srv.DomainByName("NEW.COM")
+			So(err, ShouldBeNil)
+			So(stored.CurrentRevision.DomainRegistrant.ContactRegistryID, ShouldEqual, "1234")
+			So(stored.CurrentRevision.DomainAdminContact.ContactRegistryID, ShouldEqual, "1234")
+			So(stored.CurrentRevision.Hostnames, ShouldHaveLength, 1)
+		})
+
+		Convey("Creating an existing domain should fail", func() {
+			_, code, _ := srv.createDomain(getTestingDomainCreate(TestingDomainName1), "1")
+			So(code, ShouldEqual, epp.ResponseCodeObjectExists)
+		})
+
+		Convey("Creating a domain with an unknown host should fail", func() {
+			obj := getTestingDomainCreate("new.com")
+			obj.Hosts.Hosts = append(obj.Hosts.Hosts, epp.DomainHost{Value: "NS9.UNKNOWN.COM"})
+			_, code, _ := srv.createDomain(obj, "1")
+			So(code, ShouldEqual, epp.ResponseCodeObjectDoesNotExist)
+
+			_, err := srv.DomainByName("NEW.COM")
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Creating a domain with a period that is too long should fail", func() {
+			obj := getTestingDomainCreate("new.com")
+			obj.Period = epp.GetEPPDomainPeriod(epp.DomainPeriodYear, 11)
+			_, code, _ := srv.createDomain(obj, "1")
+			So(code, ShouldEqual, epp.ResponseCodeParameterValueRangeError)
+		})
+	})
+}
+
+func TestCreateHost(t *testing.T) {
+	t.Parallel()
+	Convey("Given a default server", t, func() {
+		srv := GetDefaultServer()
+
+		Convey("Creating a subordinate host of an owned domain should succeed", func() {
+			obj := &epp.HostCreate{
+				HostName:  "ns2.example.com",
+				Addresses: []epp.HostAddress{{IPVersion: epp.IPv4, Address: "192.0.2.1"}},
+			}
+			hos, code, _ := srv.createHost(obj, "1")
+			So(code, ShouldEqual, epp.ResponseCodeCommandSuccessful)
+			So(hos.HostName, ShouldEqual, "NS2.EXAMPLE.COM")
+			So(hos.CurrentRevision.HostAddresses, ShouldHaveLength, 1)
+			So(hos.CurrentRevision.HostAddresses[0].Protocol, ShouldEqual, 4)
+		})
+
+		Convey("Creating a subordinate host of another registrar's domain should fail", func() {
+			obj := &epp.HostCreate{HostName: "ns2.example2.com"}
+			_, code, _ := srv.createHost(obj, "1")
+			So(code, ShouldEqual, epp.ResponseCodeAuthorizationError)
+		})
+
+		Convey("Creating a host with a mismatched address version should fail", func() {
+			obj := &epp.HostCreate{
+				HostName:  "ns2.example.com",
+				Addresses: []epp.HostAddress{{IPVersion: epp.IPv6, Address: "192.0.2.1"}},
+			}
+			_, code, _ := srv.createHost(obj, "1")
+			So(code, ShouldEqual, epp.ResponseCodeParameterValueSyntaxError)
+		})
+	})
+}
+
+func TestCreateContact(t *testing.T) {
+	t.Parallel()
+	Convey("Given a default server", t, func() {
+		srv := GetDefaultServer()
+
+		Convey("Creating a new contact should add it to the registry", func() {
+			obj := getTestingContactCreate("NEW-1")
+			obj.ContactAuthObj = &epp.ContactAuth{Password: "secret"}
+			cont, code, _ := srv.createContact(obj, "1")
+			So(code, ShouldEqual, epp.ResponseCodeCommandSuccessful)
+			So(cont.CurrentRevision.Name, ShouldEqual, "Test User")
+			So(cont.CurrentRevision.AddressStreet1, ShouldEqual, "123 Main St")
+			So(srv.contactAuthInfo("NEW-1"), ShouldEqual, "secret")
+		})
+
+		Convey("Creating an existing contact should fail", func() {
+			_, code, _ := srv.createContact(getTestingContactCreate("1234"), "1")
+			So(code, ShouldEqual, epp.ResponseCodeObjectExists)
+		})
+
+		Convey("Creating a contact without an email address should fail", func() {
+			obj := getTestingContactCreate("NEW-1")
+			obj.Email = ""
+			_, code, _ := srv.createContact(obj, "1")
+			So(code, ShouldEqual, epp.ResponseCodeRequireParameterMissing)
+		})
+	})
+}
+
+// infoOverPipe passes the info command provided to the info handler of a
+// connection logged in as registrar "1" and returns the parsed response.
+func infoOverPipe(srv *EPPServer, msg epp.Epp, handler func(*EPPServerConnection, epp.Epp) error) (epp.Epp, error) {
+	serverSide, clientSide := net.Pipe()
+	defer clientSide.Close()
+
+	conn := EPPServerConnection{Conn: serverSide, Conf: srv, LoggedIn: &LoginObject{RegistrarID: "1"}, Log: log}
+
+	go func() {
+		_ = handler(&conn, msg)
+		serverSide.Close()
+	}()
+
+	scanner := bufio.NewScanner(clientSide)
+	scanner.Split(epp.WireSplit)
+	scanner.Scan()
+
+	out, err := epp.UnmarshalMessage(scanner.Bytes())
+
+	return out.TypedMessage(), err
+}
+
+func TestCreateLowerCaseNames(t *testing.T) {
+	t.Parallel()
+	Convey("Given a default server with a domain and host created in lower case", t, func() {
+		srv := GetDefaultServer()
+		_, code, _ := srv.createDomain(getTestingDomainCreate("lower.com"), "1")
+		So(code, ShouldEqual, epp.ResponseCodeCommandSuccessful)
+		_, code, _ = srv.createHost(&epp.HostCreate{HostName: "ns1.lower.com"}, "1")
+		So(code, ShouldEqual, epp.ResponseCodeCommandSuccessful)
+
+		Convey("Info on the lower case names should find the objects", func() {
+			res, err := infoOverPipe(&srv, epp.GetEPPDomainInfo("lower.com", ClientTXID1, "", epp.DomainInfoHostsAll), (*EPPServerConnection).handleDomainInfo)
+			So(err, ShouldBeNil)
+			So(res.ResponseObject.Result.Code, ShouldEqual, epp.ResponseCodeCommandSuccessful)
+			So(res.ResponseObject.ResultData.DomainInfDataResp.Name, ShouldEqual, "LOWER.COM")
+
+			res, err = infoOverPipe(&srv, epp.GetEPPHostInfo("ns1.lower.com", ClientTXID2), (*EPPServerConnection).handleHostInfo)
+			So(err, ShouldBeNil)
+			So(res.ResponseObject.Result.Code, ShouldEqual, epp.ResponseCodeCommandSuccessful)
+			So(res.ResponseObject.ResultData.HostInfDataResp.Name, ShouldEqual, "NS1.LOWER.COM")
+		})
+
+		Convey("Updating the domain by its lower case name should be applied", func() {
+			obj := &epp.DomainUpdate{DomainName: "lower.com"}
+			obj.AddObject = epp.GetEPPDomainUpdateAddRemove([]string{"ns1.lower.com"}, nil, []string{epp.StatusClientHold})
+
+			code, _ := srv.updateDomain(obj, "1")
+			So(code, ShouldEqual, epp.ResponseCodeCommandSuccessful)
+
+			dom, err := srv.DomainByName("LOWER.COM")
+			So(err, ShouldBeNil)
+			So(dom.CurrentRevision.ClientHoldStatus, ShouldBeTrue)
+			So(dom.CurrentRevision.Hostnames, ShouldHaveLength, 2)
+		})
+
+		Convey("Deleting the objects by their lower case names should remove them", func() {
+			code, _ := srv.deleteHost("ns1.lower.com", "1")
+			So(code, ShouldEqual, epp.ResponseCodeCommandSuccessful)
+			_, err := srv.HostByName("NS1.LOWER.COM")
+			So(err, ShouldNotBeNil)
+
+			code, _ = srv.deleteDomain("lower.com", "1")
+			So(code, ShouldEqual, epp.ResponseCodeCommandSuccessful)
+			_, err = srv.DomainByName("LOWER.COM")
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
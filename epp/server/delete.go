@@ -0,0 +1,146 @@
+package server
+
+import (
+	"strings"
+
+	"github.com/timapril/go-registrar/epp"
+)
+
+// handleDomainDelete removes a domain and its subordinate hosts from the
+// registry if the domain is sponsored by the logged in registrar and no
+// status or association prevents the delete.
+func (conn *EPPServerConnection) handleDomainDelete(msg epp.Epp) error {
+	obj := msg.CommandObject.DeleteObject.DomainDeleteObj
+
+	code, message := conn.Conf.deleteDomain(obj.DomainName, conn.registrarID())
+
+	return conn.writeResult(msg, code, message)
+}
+
+// deleteDomain validates a domain delete request and removes the domain
+// from the registry. Hosts that are subordinate to the domain are removed
+// with it, so the delete is refused if any of those hosts are still used
+// by another domain. The response code and message for the request are
+// returned.
+func (srv *EPPServer) deleteDomain(domainName string, clientID string) (int, string) {
+	dom, err := srv.DomainByName(domainName)
+	if err != nil {
+		return epp.ResponseCodeObjectDoesNotExist, epp.ResponseCode2303
+	}
+
+	if dom.SponsoringClientID != clientID {
+		return epp.ResponseCodeAuthorizationError, epp.ResponseCode2201
+	}
+
+	rev := dom.CurrentRevision
+
+	if rev.ClientDeleteProhibitedStatus || rev.ServerDeleteProhibitedStatus || dom.PendingTransferStatus {
+		return epp.ResponseCodeObjectStatusProhibited, epp.ResponseCode2304
+	}
+
+	subordinates := srv.subordinateHosts(dom.DomainName)
+
+	for name, other := range srv.Domains {
+		if name == dom.DomainName {
+			continue
+		}
+
+		for _, hos := range other.CurrentRevision.Hostnames {
+			for _, sub := range subordinates {
+				if strings.EqualFold(hos.HostName, sub) {
+					return epp.ResponseCodeObjectAssociationProhibitsOperation, epp.ResponseCode2305
+				}
+			}
+		}
+	}
+
+	delete(srv.Domains, dom.DomainName)
+	delete(srv.DomainAuthInfo, dom.DomainName)
+	delete(srv.DomainTransfers, dom.DomainName)
+
+	for _, sub := range subordinates {
+		delete(srv.Hosts, sub)
+	}
+
+	for _, hos := range rev.Hostnames {
+		srv.refreshHostLinked(hos.HostName)
+	}
+
+	srv.refreshDomainContactsLinked(rev)
+
+	return epp.ResponseCodeCommandSuccessful, epp.ResponseCode1000
+}
+
+// handleHostDelete removes a host from the registry if the host is
+// sponsored by the logged in registrar and is not used by any domain.
+func (conn *EPPServerConnection) handleHostDelete(msg epp.Epp) error {
+	obj := msg.CommandObject.DeleteObject.HostDeleteObj
+
+	code, message := conn.Conf.deleteHost(obj.HostName, conn.registrarID())
+
+	return conn.writeResult(msg, code, message)
+}
+
+// deleteHost validates a host delete request and removes the host from
+// the registry. The response code and message for the request are
+// returned.
+func (srv *EPPServer) deleteHost(hostName string, clientID string) (int, string) {
+	hos, err := srv.HostByName(hostName)
+	if err != nil {
+		return epp.ResponseCodeObjectDoesNotExist, epp.ResponseCode2303
+	}
+
+	if hos.SponsoringClientID != clientID {
+		return epp.ResponseCodeAuthorizationError, epp.ResponseCode2201
+	}
+
+	if hos.CurrentRevision.ClientDeleteProhibitedStatus || hos.CurrentRevision.ServerDeleteProhibitedStatus {
+		return epp.ResponseCodeObjectStatusProhibited, epp.ResponseCode2304
+	}
+
+	if srv.hostIsLinked(hos.HostName) {
+		return epp.ResponseCodeObjectAssociationProhibitsOperation, epp.ResponseCode2305
+	}
+
+	delete(srv.Hosts, hos.HostName)
+
+	return epp.ResponseCodeCommandSuccessful, epp.ResponseCode1000
+}
+
+// handleContactDelete removes a contact from the registry if the contact
+// is sponsored by the logged in registrar and is not used by any domain.
+func (conn *EPPServerConnection) handleContactDelete(msg epp.Epp) error {
+	obj := msg.CommandObject.DeleteObject.ContactDeleteObj
+
+	code, message := conn.Conf.deleteContact(obj.ContactID, conn.registrarID())
+
+	return conn.writeResult(msg, code, message)
+}
+
+// deleteContact validates a contact delete request and removes the
+// contact from the registry. The response code and message for the
+// request are returned.
+func (srv *EPPServer) deleteContact(contactID string, clientID string) (int, string) {
+	cont, err := srv.ContactByID(contactID)
+	if err != nil {
+		return epp.ResponseCodeObjectDoesNotExist, epp.ResponseCode2303
+	}
+
+	if cont.SponsoringClientID != clientID {
+		return epp.ResponseCodeAuthorizationError, epp.ResponseCode2201
+	}
+
+	if cont.CurrentRevision.ClientDeleteProhibitedStatus || cont.CurrentRevision.ServerDeleteProhibitedStatus || cont.PendingTransferStatus {
+		return epp.ResponseCodeObjectStatusProhibited, epp.ResponseCode2304
+	}
+
+	if srv.contactIsLinked(cont.ContactRegistryID) {
+		return epp.ResponseCodeObjectAssociationProhibitsOperation, epp.ResponseCode2305
+	}
+
+	delete(srv.Contacts, cont.ContactRegistryID)
+	delete(srv.ContactAuthInfo, cont.ContactRegistryID)
+	delete(srv.ContactTransfers, cont.ContactRegistryID)
+
+	return epp.ResponseCodeCommandSuccessful, epp.ResponseCode1000
+}
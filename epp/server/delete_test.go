@@ -0,0 +1,120 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/timapril/go-registrar/epp"
+	"github.com/timapril/go-registrar/lib"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDeleteDomain(t *testing.T) {
+	t.Parallel()
+	Convey("Given a default server with a newly created domain", t, func() {
+		srv := GetDefaultServer()
+		_, code, _ := srv.createDomain(getTestingDomainCreate("new.com"), "1")
+		So(code, ShouldEqual, epp.ResponseCodeCommandSuccessful)
+		_, code, _ = srv.createHost(&epp.HostCreate{HostName: "ns1.new.com"}, "1")
+		So(code, ShouldEqual, epp.ResponseCodeCommandSuccessful)
+
+		Convey("Deleting the domain should remove it and its subordinate hosts", func() {
+			code, _ := srv.deleteDomain("NEW.COM", "1")
+			So(code, ShouldEqual, epp.ResponseCodeCommandSuccessful)
+
+			_, err := srv.DomainByName("NEW.COM")
+			So(err, ShouldNotBeNil)
+			_, err = srv.HostByName("NS1.NEW.COM")
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Deleting the domain while a subordinate host is used elsewhere should fail", func() {
+			_, code, _ := srv.createDomain(getTestingDomainCreate("other.com"), "1")
+			So(code, ShouldEqual, epp.ResponseCodeCommandSuccessful)
+
+			upd := &epp.DomainUpdate{DomainName: "OTHER.COM"}
+			upd.AddObject = epp.GetEPPDomainUpdateAddRemove([]string{"NS1.NEW.COM"}, nil, nil)
+			code, _ = srv.updateDomain(upd, "1")
+			So(code, ShouldEqual, epp.ResponseCodeCommandSuccessful)
+
+			code, _ = srv.deleteDomain("NEW.COM", "1")
+			So(code, ShouldEqual, epp.ResponseCodeObjectAssociationProhibitsOperation)
+		})
+
+		Convey("Deleting the domain while a subordinate host is used elsewhere in another case should fail", func() {
+			_, code, _ := srv.createDomain(getTestingDomainCreate("other.com"), "1")
+			So(code, ShouldEqual, epp.ResponseCodeCommandSuccessful)
+
+			other, err := srv.DomainByName("OTHER.COM")
+			So(err, ShouldBeNil)
+			other.CurrentRevision.Hostnames = append(other.CurrentRevision.Hostnames, lib.Host{HostName: "Ns1.New.Com"})
+			srv.Domains[other.DomainName] = *other
+
+			code, _ = srv.deleteDomain("NEW.COM", "1")
+			So(code, ShouldEqual, epp.ResponseCodeObjectAssociationProhibitsOperation)
+
+			_, err = srv.HostByName("NS1.NEW.COM")
+			So(err, ShouldBeNil)
+		})
+
+		Convey("Deleting a domain with clientDeleteProhibited should fail", func() {
+			code, _ := srv.deleteDomain(TestingDomainName1, "1")
+			So(code, ShouldEqual, epp.ResponseCodeObjectStatusProhibited)
+		})
+
+		Convey("Deleting a domain sponsored by another registrar should fail", func() {
+			code, _ := srv.deleteDomain(TestingDomainName2, "1")
+			So(code, ShouldEqual, epp.ResponseCodeAuthorizationError)
+		})
+	})
+}
+
+func TestDeleteHost(t *testing.T) {
+	t.Parallel()
+	Convey("Given a default server with a newly created host", t, func() {
+		srv := GetDefaultServer()
+		_, code, _ := srv.createHost(&epp.HostCreate{HostName: "ns2.example.com"}, "1")
+		So(code, ShouldEqual, epp.ResponseCodeCommandSuccessful)
+
+		Convey("Deleting an unlinked host should remove it", func() {
+			code, _ := srv.deleteHost("NS2.EXAMPLE.COM", "1")
+			So(code, ShouldEqual, epp.ResponseCodeCommandSuccessful)
+
+			_, err := srv.HostByName("NS2.EXAMPLE.COM")
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Deleting a linked host should fail", func() {
+			upd := &epp.DomainUpdate{DomainName: TestingDomainName1}
+			upd.AddObject = epp.GetEPPDomainUpdateAddRemove([]string{"NS2.EXAMPLE.COM"}, nil, nil)
+			upd.RemoveObject = epp.GetEPPDomainUpdateAddRemove(nil, nil, []string{epp.StatusClientUpdateProhibited})
+			code, _ := srv.updateDomain(upd, "1")
+			So(code, ShouldEqual, epp.ResponseCodeCommandSuccessful)
+
+			code, _ = srv.deleteHost("NS2.EXAMPLE.COM", "1")
+			So(code, ShouldEqual, epp.ResponseCodeObjectAssociationProhibitsOperation)
+		})
+	})
+}
+
+func TestDeleteContact(t *testing.T) {
+	t.Parallel()
+	Convey("Given a default server with a newly created contact", t, func() {
+		srv := GetDefaultServer()
+		_, code, _ := srv.createContact(getTestingContactCreate("NEW-1"), "1")
+		So(code, ShouldEqual, epp.ResponseCodeCommandSuccessful)
+
+		Convey("Deleting an unlinked contact should remove it", func() {
+			code, _ := srv.deleteContact("NEW-1", "1")
+			So(code, ShouldEqual, epp.ResponseCodeCommandSuccessful)
+
+			_, err := srv.ContactByID("NEW-1")
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Deleting a contact with clientDeleteProhibited should fail", func() {
+			code, _ := srv.deleteContact("1234", "1")
+			So(code, ShouldEqual, epp.ResponseCodeObjectStatusProhibited)
+		})
+	})
+}
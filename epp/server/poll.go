@@ -0,0 +1,187 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/timapril/go-registrar/epp"
+	"github.com/timapril/go-registrar/lib"
+)
+
+// unusedObjectsPolicyMessage is the poll message sent for each host that
+// is not used by any domain when the unused objects policy is applied.
+const unusedObjectsPolicyMessage = "Unused objects policy"
+
+// ErrUnknownServerStatus indicates that a status passed to the server
+// is not one that may be set by the server operator.
+var ErrUnknownServerStatus = errors.New("unknown server status")
+
+// PollMessage is a message that is waiting in the poll queue of a
+// registrar. ResultData holds the object information that is sent to
+// the registrar along with the message, if any.
+type PollMessage struct {
+	ID         string
+	QueueDate  time.Time
+	Message    string
+	ResultData *epp.ResultData
+}
+
+// queueMessage adds a message to the end of the poll queue for the
+// registrar provided and returns the ID assigned to the message.
+func (srv *EPPServer) queueMessage(registrarID string, message string, resData *epp.ResultData) string {
+	msg := PollMessage{
+		ID:         strconv.FormatInt(srv.nextID(), 10),
+		QueueDate:  time.Now().UTC(),
+		Message:    message,
+		ResultData: resData,
+	}
+
+	srv.MessageQueues[registrarID] = append(srv.MessageQueues[registrarID], msg)
+
+	return msg.ID
+}
+
+// InjectMessage adds a message to the poll queue of the registrar
+// provided as if it had been generated by the registry. The ID of the
+// queued message is returned.
+func (srv *EPPServer) InjectMessage(registrarID string, message string, resData *epp.ResultData) string {
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+
+	return srv.queueMessage(registrarID, message, resData)
+}
+
+// ApplyUnusedObjectsPolicy queues a message for the sponsor of each host
+// that is not used by any domain, as a registry does before it removes
+// unused hosts. The number of messages queued is returned.
+func (srv *EPPServer) ApplyUnusedObjectsPolicy() int {
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+
+	count := 0
+
+	for _, hos := range srv.Hosts {
+		if !srv.hostIsLinked(hos.HostName) {
+			srv.queueMessage(hos.SponsoringClientID, unusedObjectsPolicyMessage, hostInfoData(hos))
+			count++
+		}
+	}
+
+	return count
+}
+
+// SetDomainServerStatus sets or clears one of the server statuses on a
+// domain and notifies the sponsoring registrar of the change.
+func (srv *EPPServer) SetDomainServerStatus(domainName string, status string, value bool) error {
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+
+	dom, err := srv.DomainByName(domainName)
+	if err != nil {
+		return err
+	}
+
+	flag := domainServerStatus(&dom.CurrentRevision, status)
+	if flag == nil {
+		return ErrUnknownServerStatus
+	}
+
+	*flag = value
+	srv.Domains[dom.DomainName] = *dom
+
+	action := "removed from"
+	if value {
+		action = "added to"
+	}
+
+	srv.queueMessage(dom.SponsoringClientID, fmt.Sprintf("Status %s %s %s.", status, action, dom.DomainName), nil)
+
+	return nil
+}
+
+// domainServerStatus returns a pointer to the revision flag that
+// represents the server settable domain status provided or nil if the
+// status is not one that the server may set.
+func domainServerStatus(rev *lib.DomainRevision, status string) *bool {
+	switch status {
+	case epp.StatusServerDeleteProhibited:
+		return &rev.ServerDeleteProhibitedStatus
+	case epp.StatusServerHold:
+		return &rev.ServerHoldStatus
+	case epp.StatusServerRenewProhibited:
+		return &rev.ServerRenewProhibitedStatus
+	case epp.StatusServerTransferProhibited:
+		return &rev.ServerTransferProhibitedStatus
+	case epp.StatusServerUpdateProhibited:
+		return &rev.ServerUpdateProhibitedStatus
+	}
+
+	return nil
+}
+
+// handlePoll handles poll requests and acknowledgements for the poll
+// queue of the logged in registrar.
+func (conn *EPPServerConnection) handlePoll(msg epp.Epp) error {
+	poll := msg.CommandObject.PollObject
+	clientID := conn.registrarID()
+
+	cltxid, _ := msg.GetTransactionID()
+
+	switch poll.Operation {
+	case epp.PollOperationRequest:
+		queue := conn.Conf.MessageQueues[clientID]
+		if len(queue) == 0 {
+			return conn.writeResult(msg, epp.ResponseCodeCompletedNoMessages, epp.ResponseCode1300)
+		}
+
+		first := queue[0]
+
+		srvtxid := conn.GetNextTransactionID()
+		res := epp.GetEPPResponseResult(cltxid, srvtxid, epp.ResponseCodeCompletedAckToDequeue, epp.ResponseCode1301)
+		res.ResponseObject.MessageQueue = &epp.MessageQueue{
+			Count:   int64(len(queue)),
+			ID:      first.ID,
+			QDate:   first.QueueDate.Format(epp.EPPTimeFormat),
+			Message: first.Message,
+		}
+		res.ResponseObject.ResultData = first.ResultData
+
+		return conn.WriteEPP(res)
+	case epp.PollOperationAcknowledge:
+		remaining, ok := conn.Conf.ackMessage(clientID, poll.MessageID)
+		if !ok {
+			return conn.writeResult(msg, epp.ResponseCodeObjectDoesNotExist, epp.ResponseCode2303)
+		}
+
+		srvtxid := conn.GetNextTransactionID()
+		res := epp.GetEPPResponseResult(cltxid, srvtxid, epp.ResponseCodeCommandSuccessful, epp.ResponseCode1000)
+		res.ResponseObject.MessageQueue = &epp.MessageQueue{
+			Count: int64(remaining),
+			ID:    poll.MessageID,
+		}
+
+		return conn.WriteEPP(res)
+	}
+
+	return conn.writeResult(msg, epp.ResponseCodeParameterValueSyntaxError, epp.ResponseCode2005)
+}
+
+// ackMessage removes the message with the ID provided from the poll queue
+// of the registrar passed. The number of messages left in the queue is
+// returned along with false if no message with the ID was found.
+func (srv *EPPServer) ackMessage(registrarID string, messageID string) (int, bool) {
+	queue := srv.MessageQueues[registrarID]
+
+	for idx, msg := range queue {
+		if msg.ID == messageID {
+			queue = append(queue[:idx:idx], queue[idx+1:]...)
+			srv.MessageQueues[registrarID] = queue
+
+			return len(queue), true
+		}
+	}
+
+	return len(queue), false
+}
@@ -0,0 +1,138 @@
+package server
+
+import (
+	"bufio"
+	"net"
+	"testing"
+
+	"github.com/timapril/go-registrar/epp"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestMessageQueue(t *testing.T) {
+	t.Parallel()
+	Convey("Given a default server", t, func() {
+		srv := GetDefaultServer()
+
+		Convey("Injected messages should be queued in order and removed when acknowledged", func() {
+			firstID := srv.InjectMessage("1", "first", nil)
+			secondID := srv.InjectMessage("1", "second", nil)
+			So(firstID, ShouldNotEqual, secondID)
+			So(srv.MessageQueues["1"], ShouldHaveLength, 2)
+			So(srv.MessageQueues["1"][0].Message, ShouldEqual, "first")
+
+			remaining, ok := srv.ackMessage("1", secondID)
+			So(ok, ShouldBeTrue)
+			So(remaining, ShouldEqual, 1)
+			So(srv.MessageQueues["1"][0].ID, ShouldEqual, firstID)
+
+			_, ok = srv.ackMessage("1", secondID)
+			So(ok, ShouldBeFalse)
+
+			_, ok = srv.ackMessage("2", firstID)
+			So(ok, ShouldBeFalse)
+		})
+
+		Convey("Applying the unused objects policy should notify the sponsor of each unused host", func() {
+			_, code, _ := srv.createHost(&epp.HostCreate{HostName: "ns2.example.com"}, "1")
+			So(code, ShouldEqual, epp.ResponseCodeCommandSuccessful)
+
+			So(srv.ApplyUnusedObjectsPolicy(), ShouldEqual, 1)
+			So(srv.MessageQueues["1"], ShouldHaveLength, 1)
+			So(srv.MessageQueues["1"][0].Message, ShouldEqual, unusedObjectsPolicyMessage)
+			So(srv.MessageQueues["1"][0].ResultData.HostInfDataResp.Name, ShouldEqual, "NS2.EXAMPLE.COM")
+		})
+
+		Convey("Changing a server status should notify the sponsor", func() {
+			err := srv.SetDomainServerStatus(TestingDomainName1, epp.StatusServerHold, true)
+			So(err, ShouldBeNil)
+
+			dom, err := srv.DomainByName(TestingDomainName1)
+			So(err, ShouldBeNil)
+			So(dom.CurrentRevision.ServerHoldStatus, ShouldBeTrue)
+
+			So(srv.MessageQueues["1"], ShouldHaveLength, 1)
+			So(srv.MessageQueues["1"][0].Message, ShouldEqual, "Status serverHold added to EXAMPLE.COM.")
+
+			err = srv.SetDomainServerStatus(TestingDomainName1, epp.StatusClientHold, true)
+			So(err, ShouldEqual, ErrUnknownServerStatus)
+		})
+	})
+}
+
+// pollOverPipe passes the poll command provided to the poll handler of a
+// connection logged in as registrar "1" and returns the parsed response.
+func pollOverPipe(srv *EPPServer, msg epp.Epp) (epp.Epp, error) {
+	serverSide, clientSide := net.Pipe()
+	defer clientSide.Close()
+
+	conn := EPPServerConnection{Conn: serverSide, Conf: srv, LoggedIn: &LoginObject{RegistrarID: "1"}, Log: log}
+
+	go func() {
+		_ = conn.handlePoll(msg)
+		serverSide.Close()
+	}()
+
+	scanner := bufio.NewScanner(clientSide)
+	scanner.Split(epp.WireSplit)
+	scanner.Scan()
+
+	out, err := epp.UnmarshalMessage(scanner.Bytes())
+
+	return out.TypedMessage(), err
+}
+
+func TestHandlePoll(t *testing.T) {
+	t.Parallel()
+	Convey("Given a default server", t, func() {
+		srv := GetDefaultServer()
+
+		Convey("A poll request with an empty queue should report no messages", func() {
+			res, err := pollOverPipe(&srv, epp.GetEPPPollRequest(ClientTXID1))
+			So(err, ShouldBeNil)
+			So(res.ResponseObject.Result.Code, ShouldEqual, epp.ResponseCodeCompletedNoMessages)
+			So(res.ResponseObject.HasMessageQueue(), ShouldBeFalse)
+		})
+
+		Convey("A poll request should return the oldest message and the queue size", func() {
+			firstID := srv.InjectMessage("1", "first", nil)
+			srv.InjectMessage("1", "second", nil)
+
+			res, err := pollOverPipe(&srv, epp.GetEPPPollRequest(ClientTXID1))
+			So(err, ShouldBeNil)
+			So(res.ResponseObject.Result.Code, ShouldEqual, epp.ResponseCodeCompletedAckToDequeue)
+			So(res.ResponseObject.MessageQueue.Count, ShouldEqual, 2)
+			So(res.ResponseObject.MessageQueue.ID, ShouldEqual, firstID)
+			So(res.ResponseObject.MessageQueue.Message, ShouldEqual, "first")
+
+			Convey("Acknowledging the message should remove it from the queue", func() {
+				res, err := pollOverPipe(&srv, epp.GetEPPPollAcknowledge(firstID, ClientTXID2))
+				So(err, ShouldBeNil)
+				So(res.ResponseObject.Result.Code, ShouldEqual, epp.ResponseCodeCommandSuccessful)
+				So(res.ResponseObject.MessageQueue.Count, ShouldEqual, 1)
+				So(res.ResponseObject.MessageQueue.ID, ShouldEqual, firstID)
+
+				res, err = pollOverPipe(&srv, epp.GetEPPPollAcknowledge(firstID, ClientTXID3))
+				So(err, ShouldBeNil)
+				So(res.ResponseObject.Result.Code, ShouldEqual, epp.ResponseCodeObjectDoesNotExist)
+			})
+		})
+
+		Convey("A transfer message should be returned with its transfer data", func() {
+			_, code, _ := srv.createDomain(getTestingDomainCreate("gaining.com"), "2")
+			So(code, ShouldEqual, epp.ResponseCodeCommandSuccessful)
+			code, _ = srv.requestDomainTransfer(getTestingDomainTransfer("GAINING.COM", defaultAuthInfo), "1")
+			So(code, ShouldEqual, epp.ResponseCodeCommandSuccessfulPending)
+			code, _ = srv.approveDomainTransfer("GAINING.COM", "2")
+			So(code, ShouldEqual, epp.ResponseCodeCommandSuccessful)
+
+			res, err := pollOverPipe(&srv, epp.GetEPPPollRequest(ClientTXID1))
+			So(err, ShouldBeNil)
+			So(res.MessageType(), ShouldEqual, epp.ResponseDomainTransferType)
+			So(res.ResponseObject.MessageQueue.Message, ShouldEqual, transferApprovedMessage)
+			So(res.ResponseObject.ResultData.DomainTrnDataResp.Name, ShouldEqual, "GAINING.COM")
+			So(res.ResponseObject.ResultData.DomainTrnDataResp.TrStatus, ShouldEqual, TransferStatusClientApproved)
+		})
+	})
+}
@@ -0,0 +1,268 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/timapril/go-registrar/epp"
+	"github.com/timapril/go-registrar/lib"
+)
+
+const (
+	// maximumRegistrationYears is the longest period that a domain may be
+	// registered for, as measured from the current time.
+	maximumRegistrationYears = 10
+
+	// monthsPerYear is used to convert a registration period in months
+	// into years when validating the period.
+	monthsPerYear = 12
+)
+
+// writeResult sends a response to the client that only contains a
+// result code and the message associated with it.
+func (conn *EPPServerConnection) writeResult(msg epp.Epp, code int, message string) error {
+	cltxid, _ := msg.GetTransactionID()
+	srvtxid := conn.GetNextTransactionID()
+	res := epp.GetEPPResponseResult(cltxid, srvtxid, code, message)
+
+	return conn.WriteEPP(res)
+}
+
+// registrarID returns the registrar ID of the logged in client or an
+// empty string if the connection has not logged in.
+func (conn *EPPServerConnection) registrarID() string {
+	if conn.LoggedIn == nil {
+		return ""
+	}
+
+	return conn.LoggedIn.RegistrarID
+}
+
+// nextID returns the next object ID to be assigned to an object that is
+// created through the server.
+func (srv *EPPServer) nextID() int64 {
+	id := srv.nextObjectID
+	srv.nextObjectID = srv.nextObjectID + 1
+
+	return id
+}
+
+// nextROID generates a new repository object ID with the suffix passed.
+func (srv *EPPServer) nextROID(suffix string) string {
+	return fmt.Sprintf("%d_%s-SIM", srv.nextID(), suffix)
+}
+
+// domainAuthInfo returns the authInfo password for the domain provided.
+func (srv EPPServer) domainAuthInfo(domainName string) string {
+	if pw, ok := srv.DomainAuthInfo[domainName]; ok {
+		return pw
+	}
+
+	return defaultAuthInfo
+}
+
+// contactAuthInfo returns the authInfo password for the contact ID
+// provided.
+func (srv EPPServer) contactAuthInfo(contactID string) string {
+	if pw, ok := srv.ContactAuthInfo[contactID]; ok {
+		return pw
+	}
+
+	return defaultAuthInfo
+}
+
+// hostIsLinked returns true if any domain in the registry references the
+// host name provided.
+func (srv EPPServer) hostIsLinked(hostName string) bool {
+	for _, dom := range srv.Domains {
+		for _, hos := range dom.CurrentRevision.Hostnames {
+			if strings.EqualFold(hos.HostName, hostName) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// contactIsLinked returns true if any domain in the registry references
+// the contact ID provided.
+func (srv EPPServer) contactIsLinked(contactID string) bool {
+	for _, dom := range srv.Domains {
+		rev := dom.CurrentRevision
+		for _, cont := range []lib.Contact{rev.DomainRegistrant, rev.DomainAdminContact, rev.DomainTechContact, rev.DomainBillingContact} {
+			if cont.ID != 0 && cont.ContactRegistryID == contactID {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// refreshHostLinked updates the linked status of the host provided to
+// match the domains in the registry.
+func (srv *EPPServer) refreshHostLinked(hostName string) {
+	hos, err := srv.HostByName(hostName)
+	if err != nil {
+		return
+	}
+
+	hos.LinkedStatus = srv.hostIsLinked(hos.HostName)
+	srv.Hosts[hos.HostName] = *hos
+}
+
+// refreshContactLinked updates the linked status of the contact provided
+// to match the domains in the registry.
+func (srv *EPPServer) refreshContactLinked(contactID string) {
+	cont, err := srv.ContactByID(contactID)
+	if err != nil {
+		return
+	}
+
+	cont.LinkedStatus = srv.contactIsLinked(cont.ContactRegistryID)
+	srv.Contacts[cont.ContactRegistryID] = *cont
+}
+
+// subordinateHosts returns the names of the hosts in the registry that
+// are subordinate to the domain provided.
+func (srv EPPServer) subordinateHosts(domainName string) (hosts []string) {
+	suffix := "." + strings.ToUpper(domainName)
+
+	for name := range srv.Hosts {
+		if strings.HasSuffix(strings.ToUpper(name), suffix) {
+			hosts = append(hosts, name)
+		}
+	}
+
+	return hosts
+}
+
+// superordinateDomain returns the domain in the registry that the host
+// provided is subordinate to, if there is one.
+func (srv EPPServer) superordinateDomain(hostName string) (*lib.Domain, error) {
+	labels := strings.Split(hostName, ".")
+
+	for idx := 1; idx < len(labels)-1; idx++ {
+		dom, err := srv.DomainByName(strings.Join(labels[idx:], "."))
+		if err == nil {
+			return dom, nil
+		}
+	}
+
+	return nil, ErrNoDomainFound
+}
+
+// addPeriod adds the domain period provided to the time passed.
+func addPeriod(start time.Time, period epp.DomainPeriod) time.Time {
+	if period.Unit == epp.DomainPeriodMonth {
+		return start.AddDate(0, period.Value, 0)
+	}
+
+	return start.AddDate(period.Value, 0, 0)
+}
+
+// validPeriod checks that the period provided is a positive number of
+// years or months that is not longer than the maximum registration
+// period.
+func validPeriod(period epp.DomainPeriod) bool {
+	switch period.Unit {
+	case epp.DomainPeriodYear:
+		return period.Value > 0 && period.Value <= maximumRegistrationYears
+	case epp.DomainPeriodMonth:
+		return period.Value > 0 && period.Value <= maximumRegistrationYears*monthsPerYear
+	}
+
+	return false
+}
+
+// domainClientStatus returns a pointer to the revision flag that
+// represents the client settable domain status provided or nil if the
+// status is not one that a client may set.
+func domainClientStatus(rev *lib.DomainRevision, status string) *bool {
+	switch status {
+	case epp.StatusClientDeleteProhibited:
+		return &rev.ClientDeleteProhibitedStatus
+	case epp.StatusClientHold:
+		return &rev.ClientHoldStatus
+	case epp.StatusClientRenewProhibited:
+		return &rev.ClientRenewProhibitedStatus
+	case epp.StatusClientTransferProhibited:
+		return &rev.ClientTransferProhibitedStatus
+	case epp.StatusClientUpdateProhibited:
+		return &rev.ClientUpdateProhibitedStatus
+	}
+
+	return nil
+}
+
+// hostClientStatus returns a pointer to the revision flag that
+// represents the client settable host status provided or nil if the
+// status is not one that a client may set.
+func hostClientStatus(rev *lib.HostRevision, status string) *bool {
+	switch status {
+	case epp.StatusClientDeleteProhibited:
+		return &rev.ClientDeleteProhibitedStatus
+	case epp.StatusClientTransferProhibited:
+		return &rev.ClientTransferProhibitedStatus
+	case epp.StatusClientUpdateProhibited:
+		return &rev.ClientUpdateProhibitedStatus
+	}
+
+	return nil
+}
+
+// contactClientStatus returns a pointer to the revision flag that
+// represents the client settable contact status provided or nil if the
+// status is not one that a client may set.
+func contactClientStatus(rev *lib.ContactRevision, status string) *bool {
+	switch status {
+	case epp.StatusClientDeleteProhibited:
+		return &rev.ClientDeleteProhibitedStatus
+	case epp.StatusClientTransferProhibited:
+		return &rev.ClientTransferProhibitedStatus
+	case epp.StatusClientUpdateProhibited:
+		return &rev.ClientUpdateProhibitedStatus
+	}
+
+	return nil
+}
+
+// applyStatusChanges removes and then adds the statuses provided using
+// the lookup function to find the flag for each status. If a status is
+// not client settable, is added while already present or is removed
+// while not present, false is returned.
+func applyStatusChanges(add []string, rem []string, lookup func(string) *bool) bool {
+	for _, status := range rem {
+		flag := lookup(status)
+		if flag == nil || !*flag {
+			return false
+		}
+
+		*flag = false
+	}
+
+	for _, status := range add {
+		flag := lookup(status)
+		if flag == nil || *flag {
+			return false
+		}
+
+		*flag = true
+	}
+
+	return true
+}
+
+// removesStatus returns true if the status provided is in the list of
+// statuses passed.
+func removesStatus(statuses []string, status string) bool {
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+
+	return false
+}
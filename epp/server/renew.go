@@ -0,0 +1,79 @@
+package server
+
+import (
+	"time"
+
+	"github.com/timapril/go-registrar/epp"
+	"github.com/timapril/go-registrar/lib"
+)
+
+// handleDomainRenew extends the registration period of a domain that is
+// sponsored by the logged in registrar.
+func (conn *EPPServerConnection) handleDomainRenew(msg epp.Epp) error {
+	obj := msg.CommandObject.RenewObject.DomainRenewObj
+
+	dom, code, message := conn.Conf.renewDomain(obj, conn.registrarID())
+	if code != epp.ResponseCodeCommandSuccessful {
+		return conn.writeResult(msg, code, message)
+	}
+
+	cltxid, _ := msg.GetTransactionID()
+	srvtxid := conn.GetNextTransactionID()
+	res := epp.GetEPPResponseResult(cltxid, srvtxid, code, message)
+
+	res.ResponseObject.ResultData = &epp.ResultData{}
+	res.ResponseObject.ResultData.DomainRenDataResp = &epp.DomainRenDataResp{}
+	res.ResponseObject.ResultData.DomainRenDataResp.XMLNSDomain = epp.DomainXMLNS
+	res.ResponseObject.ResultData.DomainRenDataResp.XMLNsSchemaLocation = epp.DomainSchema
+	res.ResponseObject.ResultData.DomainRenDataResp.Name = dom.DomainName
+	res.ResponseObject.ResultData.DomainRenDataResp.ExpireDate = dom.ExpireDate.Format(epp.EPPTimeFormat)
+
+	return conn.WriteEPP(res)
+}
+
+// renewDomain validates a domain renew request and extends the expiration
+// date of the domain. The current expiration date in the request must
+// match the domain and the new expiration date may not be further out
+// than the maximum registration period. The response code and message for
+// the request are returned along with the renewed domain if the request
+// succeeded.
+func (srv *EPPServer) renewDomain(obj *epp.DomainRenew, clientID string) (*lib.Domain, int, string) {
+	dom, err := srv.DomainByName(obj.DomainName)
+	if err != nil {
+		return nil, epp.ResponseCodeObjectDoesNotExist, epp.ResponseCode2303
+	}
+
+	if dom.SponsoringClientID != clientID {
+		return nil, epp.ResponseCodeAuthorizationError, epp.ResponseCode2201
+	}
+
+	rev := dom.CurrentRevision
+
+	if rev.ClientRenewProhibitedStatus || rev.ServerRenewProhibitedStatus || dom.PendingTransferStatus {
+		return nil, epp.ResponseCodeObjectStatusProhibited, epp.ResponseCode2304
+	}
+
+	if obj.CurrentExpDate != dom.ExpireDate.Format(epp.EPPDateForamt) {
+		return nil, epp.ResponseCodeParameterValuePolicyError, epp.ResponseCode2306
+	}
+
+	period := obj.RenewPeriod
+	if period.Value == 0 {
+		period = epp.GetEPPDomainPeriod(epp.DomainPeriodYear, 1)
+	}
+
+	if !validPeriod(period) {
+		return nil, epp.ResponseCodeParameterValueRangeError, epp.ResponseCode2004
+	}
+
+	expireDate := addPeriod(dom.ExpireDate, period)
+	if expireDate.After(time.Now().UTC().AddDate(maximumRegistrationYears, 0, 0)) {
+		return nil, epp.ResponseCodeParameterValuePolicyError, epp.ResponseCode2306
+	}
+
+	dom.ExpireDate = expireDate
+
+	srv.Domains[dom.DomainName] = *dom
+
+	return dom, epp.ResponseCodeCommandSuccessful, epp.ResponseCode1000
+}
@@ -0,0 +1,62 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/timapril/go-registrar/epp"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRenewDomain(t *testing.T) {
+	t.Parallel()
+	Convey("Given a default server with a newly created domain", t, func() {
+		srv := GetDefaultServer()
+		dom, code, _ := srv.createDomain(getTestingDomainCreate("new.com"), "1")
+		So(code, ShouldEqual, epp.ResponseCodeCommandSuccessful)
+
+		Convey("Renewing the domain should extend the expiration date", func() {
+			obj := &epp.DomainRenew{
+				DomainName:     "NEW.COM",
+				CurrentExpDate: dom.ExpireDate.Format(epp.EPPDateForamt),
+				RenewPeriod:    epp.GetEPPDomainPeriod(epp.DomainPeriodYear, 1),
+			}
+			renewed, code, _ := srv.renewDomain(obj, "1")
+			So(code, ShouldEqual, epp.ResponseCodeCommandSuccessful)
+			So(renewed.ExpireDate, ShouldEqual, dom.ExpireDate.AddDate(1, 0, 0))
+		})
+
+		Convey("Renewing with the wrong current expiration date should fail", func() {
+			obj := &epp.DomainRenew{
+				DomainName:     "NEW.COM",
+				CurrentExpDate: dom.ExpireDate.AddDate(-1, 0, 0).Format(epp.EPPDateForamt),
+			}
+			_, code, _ := srv.renewDomain(obj, "1")
+			So(code, ShouldEqual, epp.ResponseCodeParameterValuePolicyError)
+		})
+
+		Convey("Renewing past the maximum registration period should fail", func() {
+			obj := &epp.DomainRenew{
+				DomainName:     "NEW.COM",
+				CurrentExpDate: dom.ExpireDate.Format(epp.EPPDateForamt),
+				RenewPeriod:    epp.GetEPPDomainPeriod(epp.DomainPeriodYear, 9),
+			}
+			_, code, _ := srv.renewDomain(obj, "1")
+			So(code, ShouldEqual, epp.ResponseCodeParameterValuePolicyError)
+		})
+
+		Convey("Renewing a domain with clientRenewProhibited should fail", func() {
+			upd := &epp.DomainUpdate{DomainName: "NEW.COM"}
+			upd.AddObject = epp.GetEPPDomainUpdateAddRemove(nil, nil, []string{epp.StatusClientRenewProhibited})
+			code, _ := srv.updateDomain(upd, "1")
+			So(code, ShouldEqual, epp.ResponseCodeCommandSuccessful)
+
+			obj := &epp.DomainRenew{
+				DomainName:     "NEW.COM",
+				CurrentExpDate: dom.ExpireDate.Format(epp.EPPDateForamt),
+			}
+			_, code, _ = srv.renewDomain(obj, "1")
+			So(code, ShouldEqual, epp.ResponseCodeObjectStatusProhibited)
+		})
+	})
+}
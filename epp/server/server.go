@@ -2,9 +2,14 @@ package server
 
 import (
 	"bufio"
+	"bytes"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"net"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/timapril/go-registrar/epp"
@@ -32,6 +37,10 @@ type EPPServer struct {
 
 	socket net.Listener
 
+	// TLSConfig, if set, causes the server to only accept TLS
+	// connections using the configuration provided.
+	TLSConfig *tls.Config
+
 	Running chan bool
 
 	Logins                 map[string]LoginObject
@@ -43,6 +52,29 @@ type EPPServer struct {
 	Hosts    map[string]lib.Host
 	Domains  map[string]lib.Domain
 
+	// DomainAuthInfo and ContactAuthInfo hold the authInfo passwords for
+	// the objects in the registry, keyed by domain name and contact ID.
+	DomainAuthInfo  map[string]string
+	ContactAuthInfo map[string]string
+
+	// DomainTransfers and ContactTransfers hold the most recent transfer
+	// of each object, keyed by domain name and contact ID. Pending
+	// transfers are approved by the server once TransferApprovalPeriod
+	// has passed.
+	DomainTransfers        map[string]TransferRecord
+	ContactTransfers       map[string]TransferRecord
+	TransferApprovalPeriod time.Duration
+
+	// MessageQueues holds the poll messages waiting for each registrar,
+	// keyed by registrar ID.
+	MessageQueues map[string][]PollMessage
+
+	// lock guards the registry objects which are shared between all of
+	// the connections to the server.
+	lock *sync.Mutex
+
+	nextObjectID int64
+
 	Log *logging.Logger
 }
 
@@ -55,6 +87,10 @@ var (
 	ErrUnhandledTransfer = errors.New("unhandled transfer")
 )
 
+// defaultAuthInfo is the authInfo password reported for objects that
+// have not had a password set explicitly.
+const defaultAuthInfo = "testpassword"
+
 // LoginObject is used to hold the information about a specific Login
 // in to the epp server.
 type LoginObject struct {
@@ -74,10 +110,11 @@ func (srv EPPServer) ContactByID(id string) (*lib.Contact, error) {
 	return nil, ErrNoContactFound
 }
 
-// HostByName searches for hosts by the HostName.
+// HostByName searches for hosts by the HostName. Host names are
+// matched without regard to case.
 func (srv EPPServer) HostByName(name string) (*lib.Host, error) {
 	for _, hos := range srv.Hosts {
-		if hos.HostName == name {
+		if strings.EqualFold(hos.HostName, name) {
 			return &hos, nil
 		}
 	}
@@ -85,10 +122,11 @@ func (srv EPPServer) HostByName(name string) (*lib.Host, error) {
 	return nil, ErrNoHostFound
 }
 
-// DomainByName searches for domain by the DomainName.
+// DomainByName searches for domain by the DomainName. Domain names are
+// matched without regard to case.
 func (srv EPPServer) DomainByName(name string) (*lib.Domain, error) {
 	for _, dom := range srv.Domains {
-		if dom.DomainName == name {
+		if strings.EqualFold(dom.DomainName, name) {
 			return &dom, nil
 		}
 	}
@@ -111,6 +149,11 @@ type EPPServerConnection struct {
 	LoggedIn *LoginObject
 
 	Log *logging.Logger
+
+	// pendingWrites holds the messages written while a command is
+	// handled under the server lock. They are sent once the lock has
+	// been released so a slow client cannot hold up other connections.
+	pendingWrites *bytes.Buffer
 }
 
 // maximumFailedLoginAttempts is the number of failed logins before the server
@@ -121,6 +164,10 @@ const maximumFailedLoginAttempts = 2
 // first connection attempt.
 const firstConnectionID = 1
 
+// firstObjectID is the first ID that will be assigned to objects created
+// through the server, chosen to stay clear of any preloaded objects.
+const firstObjectID = 1000
+
 // NewEPPServer will take the vital information related to the server
 // like the host address, port and connection timeout duration that it
 // should use for communicating with clients.
@@ -141,6 +188,18 @@ func NewEPPServer(host string, port int, timeout time.Duration) EPPServer {
 		Hosts:    make(map[string]lib.Host),
 		Domains:  make(map[string]lib.Domain),
 
+		DomainAuthInfo:  make(map[string]string),
+		ContactAuthInfo: make(map[string]string),
+
+		DomainTransfers:        make(map[string]TransferRecord),
+		ContactTransfers:       make(map[string]TransferRecord),
+		TransferApprovalPeriod: DefaultTransferApprovalPeriod,
+
+		MessageQueues: make(map[string][]PollMessage),
+
+		lock:         &sync.Mutex{},
+		nextObjectID: firstObjectID,
+
 		Log: logging.MustGetLogger("eppserver"),
 	}
 
@@ -160,12 +219,25 @@ func (srv *EPPServer) watchForKill() {
 	srv.socket.Close()
 }
 
+// Addr returns the address that the server is listening on once it is
+// running.
+func (srv *EPPServer) Addr() net.Addr {
+	return srv.socket.Addr()
+}
+
 // Start will initiate the server and block until the server is killed
 // or an error occurs while stariting up or running.
 func (srv *EPPServer) Start() error {
 	var err error
 
-	srv.socket, err = net.Listen("tcp", fmt.Sprintf("%s:%d", srv.Host, srv.Port))
+	address := net.JoinHostPort(srv.Host, strconv.Itoa(srv.Port))
+
+	if srv.TLSConfig != nil {
+		srv.socket, err = tls.Listen("tcp", address, srv.TLSConfig)
+	} else {
+		srv.socket, err = net.Listen("tcp", address)
+	}
+
 	if err != nil {
 		srv.Log.Error(err)
 
@@ -280,6 +352,12 @@ func (conn *EPPServerConnection) HandleWaitingForClient(timeout chan bool) (stat
 	case msg := <-conn.RecvChannel:
 		msgType := msg.MessageType()
 		switch msgType {
+		case epp.HelloType:
+			err = conn.WriteEPP(epp.GetEPPGreeting(epp.GetDefaultServiceMenu()))
+
+			if err != nil {
+				return state, err
+			}
 		case epp.CommandLoginType:
 			if conn.Conf.Logins[msg.CommandObject.LoginObject.ClientID].Password == msg.CommandObject.LoginObject.Password {
 				client := conn.Conf.Logins[msg.CommandObject.LoginObject.ClientID]
@@ -606,13 +684,17 @@ func (conn *EPPServerConnection) handleContactInfo(msg epp.Epp) error {
 				resData.ContactInfDataResp.Status = append(resData.ContactInfDataResp.Status, epp.ContactStatus{StatusFlag: "serverUpdateProhibited"})
 			}
 
+			if cont.PendingTransferStatus {
+				resData.ContactInfDataResp.Status = append(resData.ContactInfDataResp.Status, epp.ContactStatus{StatusFlag: "pendingTransfer"})
+			}
+
 			resData.ContactInfDataResp.Status = append(resData.ContactInfDataResp.Status, epp.ContactStatus{StatusFlag: "OK"})
 
 			resData.ContactInfDataResp.PostalInfos = append(resData.ContactInfDataResp.PostalInfos, postalInfo)
 
 			if cont.SponsoringClientID == conn.LoggedIn.RegistrarID {
 				resData.ContactInfDataResp.AuthPW = &epp.ContactAuth{}
-				resData.ContactInfDataResp.AuthPW.Password = "testpassword"
+				resData.ContactInfDataResp.AuthPW.Password = conn.Conf.contactAuthInfo(cont.ContactRegistryID)
 			}
 
 			resData.ContactInfDataResp.CreateID = cont.CreateClientID
@@ -654,89 +736,98 @@ func (conn *EPPServerConnection) handleHostInfo(msg epp.Epp) error {
 	responseError := epp.ResponseCode1000
 
 	resData := &(epp.ResultData{})
-	resData.HostInfDataResp = &epp.HostInfDataResp{}
-
-	resData.HostInfDataResp.XMLNSHost = epp.HostXMLNS
-	resData.HostInfDataResp.XMLNsSchemaLocation = epp.HostSchema
 
 	if msg.CommandObject.InfoObject.HostInfoObj != nil {
 		obj := msg.CommandObject.InfoObject.HostInfoObj
 		host, err := conn.Conf.HostByName(obj.Name)
 
 		if err == nil {
-			resData.HostInfDataResp.ROID = host.HostROID
+			resData = hostInfoData(*host)
+		} else {
+			responseCode = epp.ResponseCodeObjectDoesNotExist
+			responseError = epp.ResponseCode2303
+		}
+	}
 
-			resData.HostInfDataResp.Name = host.HostName
+	res := epp.GetEPPResponseResult(cltxid, srvtxid, responseCode, responseError)
 
-			for _, hostAddress := range host.CurrentRevision.HostAddresses {
-				addr := epp.HostAddress{}
-				addr.Address = hostAddress.IPAddress
+	if responseCode == epp.ResponseCodeCommandSuccessful {
+		res.ResponseObject.ResultData = resData
+	}
 
-				if hostAddress.Protocol == ipv4EPPProtocolIdentifier {
-					addr.IPVersion = "v4"
-				}
+	return conn.WriteEPP(res)
+}
 
-				if hostAddress.Protocol == ipv6EPPProtocolIdentifier {
-					addr.IPVersion = "v6"
-				}
+// hostInfoData generates the infData response for the host provided.
+func hostInfoData(host lib.Host) *epp.ResultData {
+	resData := &(epp.ResultData{})
+	resData.HostInfDataResp = &epp.HostInfDataResp{}
 
-				resData.HostInfDataResp.Addresses = append(resData.HostInfDataResp.Addresses, addr)
-			}
+	resData.HostInfDataResp.XMLNSHost = epp.HostXMLNS
+	resData.HostInfDataResp.XMLNsSchemaLocation = epp.HostSchema
 
-			if host.LinkedStatus {
-				resData.HostInfDataResp.Status = append(resData.HostInfDataResp.Status, epp.HostStatus{StatusFlag: "linked"})
-			}
+	resData.HostInfDataResp.ROID = host.HostROID
 
-			if host.CurrentRevision.ClientDeleteProhibitedStatus {
-				resData.HostInfDataResp.Status = append(resData.HostInfDataResp.Status, epp.HostStatus{StatusFlag: "clientDeleteProhibited"})
-			}
+	resData.HostInfDataResp.Name = host.HostName
 
-			if host.CurrentRevision.ClientTransferProhibitedStatus {
-				resData.HostInfDataResp.Status = append(resData.HostInfDataResp.Status, epp.HostStatus{StatusFlag: "clientTransferProhibited"})
-			}
+	for _, hostAddress := range host.CurrentRevision.HostAddresses {
+		addr := epp.HostAddress{}
+		addr.Address = hostAddress.IPAddress
 
-			if host.CurrentRevision.ClientUpdateProhibitedStatus {
-				resData.HostInfDataResp.Status = append(resData.HostInfDataResp.Status, epp.HostStatus{StatusFlag: "clientUpdateProhibited"})
-			}
+		if hostAddress.Protocol == ipv4EPPProtocolIdentifier {
+			addr.IPVersion = "v4"
+		}
 
-			if host.CurrentRevision.ServerDeleteProhibitedStatus {
-				resData.HostInfDataResp.Status = append(resData.HostInfDataResp.Status, epp.HostStatus{StatusFlag: "serverDeleteProhibited"})
-			}
+		if hostAddress.Protocol == ipv6EPPProtocolIdentifier {
+			addr.IPVersion = "v6"
+		}
 
-			if host.CurrentRevision.ServerTransferProhibitedStatus {
-				resData.HostInfDataResp.Status = append(resData.HostInfDataResp.Status, epp.HostStatus{StatusFlag: "serverTransferProhibited"})
-			}
+		resData.HostInfDataResp.Addresses = append(resData.HostInfDataResp.Addresses, addr)
+	}
 
-			if host.CurrentRevision.ServerUpdateProhibitedStatus {
-				resData.HostInfDataResp.Status = append(resData.HostInfDataResp.Status, epp.HostStatus{StatusFlag: "serverUpdateProhibited"})
-			}
+	if host.LinkedStatus {
+		resData.HostInfDataResp.Status = append(resData.HostInfDataResp.Status, epp.HostStatus{StatusFlag: "linked"})
+	}
 
-			resData.HostInfDataResp.Status = append(resData.HostInfDataResp.Status, epp.HostStatus{StatusFlag: "OK"})
+	if host.CurrentRevision.ClientDeleteProhibitedStatus {
+		resData.HostInfDataResp.Status = append(resData.HostInfDataResp.Status, epp.HostStatus{StatusFlag: "clientDeleteProhibited"})
+	}
 
-			resData.HostInfDataResp.CreateID = host.CreateClientID
-			resData.HostInfDataResp.CreateDate = host.CreateDate.Format(epp.EPPTimeFormat)
+	if host.CurrentRevision.ClientTransferProhibitedStatus {
+		resData.HostInfDataResp.Status = append(resData.HostInfDataResp.Status, epp.HostStatus{StatusFlag: "clientTransferProhibited"})
+	}
 
-			if host.UpdateClientID != "" {
-				resData.HostInfDataResp.UpdateID = host.UpdateClientID
-				resData.HostInfDataResp.UpdateDate = host.UpdateDate.Format(epp.EPPTimeFormat)
-			}
+	if host.CurrentRevision.ClientUpdateProhibitedStatus {
+		resData.HostInfDataResp.Status = append(resData.HostInfDataResp.Status, epp.HostStatus{StatusFlag: "clientUpdateProhibited"})
+	}
 
-			if host.TransferDate.Unix() > 0 {
-				resData.HostInfDataResp.TransferDate = host.TransferDate.Format(epp.EPPTimeFormat)
-			}
-		} else {
-			responseCode = epp.ResponseCodeObjectDoesNotExist
-			responseError = epp.ResponseCode2303
-		}
+	if host.CurrentRevision.ServerDeleteProhibitedStatus {
+		resData.HostInfDataResp.Status = append(resData.HostInfDataResp.Status, epp.HostStatus{StatusFlag: "serverDeleteProhibited"})
 	}
 
-	res := epp.GetEPPResponseResult(cltxid, srvtxid, responseCode, responseError)
+	if host.CurrentRevision.ServerTransferProhibitedStatus {
+		resData.HostInfDataResp.Status = append(resData.HostInfDataResp.Status, epp.HostStatus{StatusFlag: "serverTransferProhibited"})
+	}
 
-	if responseCode == epp.ResponseCodeCommandSuccessful {
-		res.ResponseObject.ResultData = resData
+	if host.CurrentRevision.ServerUpdateProhibitedStatus {
+		resData.HostInfDataResp.Status = append(resData.HostInfDataResp.Status, epp.HostStatus{StatusFlag: "serverUpdateProhibited"})
 	}
 
-	return conn.WriteEPP(res)
+	resData.HostInfDataResp.Status = append(resData.HostInfDataResp.Status, epp.HostStatus{StatusFlag: "OK"})
+
+	resData.HostInfDataResp.CreateID = host.CreateClientID
+	resData.HostInfDataResp.CreateDate = host.CreateDate.Format(epp.EPPTimeFormat)
+
+	if host.UpdateClientID != "" {
+		resData.HostInfDataResp.UpdateID = host.UpdateClientID
+		resData.HostInfDataResp.UpdateDate = host.UpdateDate.Format(epp.EPPTimeFormat)
+	}
+
+	if host.TransferDate.Unix() > 0 {
+		resData.HostInfDataResp.TransferDate = host.TransferDate.Format(epp.EPPTimeFormat)
+	}
+
+	return resData
 }
 
 func (conn *EPPServerConnection) handleDomainInfo(msg epp.Epp) error {
@@ -845,11 +936,15 @@ func (conn *EPPServerConnection) handleDomainInfo(msg epp.Epp) error {
 				resData.DomainInfDataResp.Status = append(resData.DomainInfDataResp.Status, epp.DomainStatus{StatusFlag: "serverUpdateProhibited"})
 			}
 
+			if dom.PendingTransferStatus {
+				resData.DomainInfDataResp.Status = append(resData.DomainInfDataResp.Status, epp.DomainStatus{StatusFlag: "pendingTransfer"})
+			}
+
 			resData.DomainInfDataResp.Status = append(resData.DomainInfDataResp.Status, epp.DomainStatus{StatusFlag: "OK"})
 
 			if dom.SponsoringClientID == conn.LoggedIn.RegistrarID {
 				resData.DomainInfDataResp.AuthPW = &epp.DomainAuth{}
-				resData.DomainInfDataResp.AuthPW.Password = "testpassword"
+				resData.DomainInfDataResp.AuthPW.Password = conn.Conf.domainAuthInfo(dom.DomainName)
 			}
 		} else {
 			responseCode = epp.ResponseCodeObjectDoesNotExist
@@ -866,79 +961,6 @@ func (conn *EPPServerConnection) handleDomainInfo(msg epp.Epp) error {
 	return conn.WriteEPP(res)
 }
 
-func (conn *EPPServerConnection) handleContactUpdate(msg epp.Epp) error {
-	conn.Log.Info("Need to handle Contact Update")
-
-	updateObj := msg.CommandObject.UpdateObject.ContactUpdateObj
-
-	conn.Log.Debug(updateObj)
-
-	return ErrNotImplemented
-}
-
-func (conn *EPPServerConnection) handleDomainUpdate(msg epp.Epp) error {
-	conn.Log.Info("Need to handle Domain Update")
-
-	updateObj := msg.CommandObject.UpdateObject.DomainUpdateObj
-
-	conn.Log.Debug(updateObj)
-
-	cltxid, _ := msg.GetTransactionID()
-	srvtxid := conn.GetNextTransactionID()
-	// res := epp.GetEPPResponseResult(cltxid, srvtxid, Code int, Message string)
-
-	if msg.MessageType() == epp.CommandUpdateDomainType && msg.CommandObject.UpdateObject.DomainUpdateObj != nil {
-		obj := msg.CommandObject.UpdateObject.DomainUpdateObj
-
-		dom, err := conn.Conf.DomainByName(obj.DomainName)
-		if err != nil {
-			res := epp.GetEPPResponseResult(cltxid, srvtxid, epp.ResponseCodeObjectDoesNotExist, epp.ResponseCode2303)
-
-			err = conn.WriteEPP(res)
-			if err != nil {
-				return err
-			}
-		}
-
-		if dom.SponsoringClientID == conn.LoggedIn.RegistrarID {
-			// Add
-			for _, contact := range obj.AddObject.Contacts {
-				conn.Log.Debug(contact)
-			}
-
-			for _, status := range obj.AddObject.Statuses {
-				conn.Log.Debug(status)
-			}
-
-			if obj.AddObject.Hosts != nil {
-				for _, host := range obj.AddObject.Hosts.Hosts {
-					conn.Log.Debug(host)
-				}
-			}
-
-			// Remove
-
-			// Change
-
-			res := epp.GetEPPResponseResult(cltxid, srvtxid, epp.ResponseCodeCommandSuccessful, epp.ResponseCode1000)
-
-			return conn.WriteEPP(res)
-		}
-	}
-
-	return nil
-}
-
-func (conn *EPPServerConnection) handleHostUpdate(msg epp.Epp) error {
-	conn.Log.Info("Need to handle Host Update")
-
-	updateObj := msg.CommandObject.UpdateObject.HostUpdateObj
-
-	conn.Log.Debug(updateObj)
-
-	return ErrNotImplemented
-}
-
 // HandleWaitingForCommand handles the waiting for command sate of the
 // EPP server connection.
 func (conn *EPPServerConnection) HandleWaitingForCommand(timeout chan bool) (state EPPServerState, err error) {
@@ -951,7 +973,30 @@ func (conn *EPPServerConnection) HandleWaitingForCommand(timeout chan bool) (sta
 
 		conn.Log.Debug(msgType)
 
+		conn.Conf.lock.Lock()
+		conn.pendingWrites = new(bytes.Buffer)
+
+		defer func() {
+			conn.Conf.lock.Unlock()
+
+			if flushErr := conn.flushWrites(); flushErr != nil && err == nil {
+				err = flushErr
+			}
+		}()
+
+		// Pending transfers are settled before each command so that the
+		// command sees the registry as it would be at this point in time.
+		conn.Conf.AutoApproveTransfers(time.Now().UTC())
+
 		switch msgType {
+		case epp.HelloType:
+			err = conn.WriteEPP(epp.GetEPPGreeting(epp.GetDefaultServiceMenu()))
+
+			if err != nil {
+				return state, err
+			}
+
+			state = EPPServerWaitingForCommand
 		case epp.CommandLogoutType:
 			cltxid, _ := msg.GetTransactionID()
 			srvtxid := conn.GetNextTransactionID()
@@ -1016,6 +1061,32 @@ func (conn *EPPServerConnection) HandleWaitingForCommand(timeout chan bool) (sta
 
 			state = EPPServerWaitingForCommand
 
+		// Create Commands
+		case epp.CommandCreateContactType:
+			err = conn.handleContactCreate(msg)
+
+			if err != nil {
+				return state, err
+			}
+
+			state = EPPServerWaitingForCommand
+		case epp.CommandCreateDomainType:
+			err = conn.handleDomainCreate(msg)
+
+			if err != nil {
+				return state, err
+			}
+
+			state = EPPServerWaitingForCommand
+		case epp.CommandCreateHostType:
+			err = conn.handleHostCreate(msg)
+
+			if err != nil {
+				return state, err
+			}
+
+			state = EPPServerWaitingForCommand
+
 			// Update Commands
 		case epp.CommandUpdateContactType:
 			err = conn.handleContactUpdate(msg)
@@ -1042,21 +1113,75 @@ func (conn *EPPServerConnection) HandleWaitingForCommand(timeout chan bool) (sta
 
 			state = EPPServerWaitingForCommand
 
-		case epp.CommandTransferType:
-			if msg.CommandObject.TransferObject.ContactTransferObj != nil {
-				if msg.CommandObject.TransferObject.Operation == "query" {
-					// TODO: ContactQuery
-					return state, ErrUnhandledTransfer
-				}
+		// Delete Commands
+		case epp.CommandDeleteContactType:
+			err = conn.handleContactDelete(msg)
+
+			if err != nil {
+				return state, err
 			}
 
-			if msg.CommandObject.TransferObject.DomainTransferObj != nil {
-				switch msg.CommandObject.TransferObject.Operation {
-				case "query":
-					// TODO: DomainTransfer
-					return state, ErrUnhandledTransfer
-				}
+			state = EPPServerWaitingForCommand
+		case epp.CommandDeleteDomainType:
+			err = conn.handleDomainDelete(msg)
+
+			if err != nil {
+				return state, err
+			}
+
+			state = EPPServerWaitingForCommand
+		case epp.CommandDeleteHostType:
+			err = conn.handleHostDelete(msg)
+
+			if err != nil {
+				return state, err
+			}
+
+			state = EPPServerWaitingForCommand
+
+		// Renew Commands
+		case epp.CommandRenewDomainType:
+			err = conn.handleDomainRenew(msg)
+
+			if err != nil {
+				return state, err
+			}
+
+			state = EPPServerWaitingForCommand
+
+		// Transfer Commands
+		case epp.CommandTransferContactRequestType, epp.CommandTransferContactQueryType,
+			epp.CommandTransferContactApproveType, epp.CommandTransferContactRejectType,
+			epp.CommandTransferContactCancelType:
+			err = conn.handleContactTransfer(msg)
+
+			if err != nil {
+				return state, err
+			}
+
+			state = EPPServerWaitingForCommand
+		case epp.CommandTransferDomainRequestType, epp.CommandTransferDomainQueryType,
+			epp.CommandTransferDomainApproveType, epp.CommandTransferDomainRejectType,
+			epp.CommandTransferDomainCancelType:
+			err = conn.handleDomainTransfer(msg)
+
+			if err != nil {
+				return state, err
+			}
+
+			state = EPPServerWaitingForCommand
+		case epp.CommandTransferType:
+			return state, ErrUnhandledTransfer
+
+		// Poll Commands
+		case epp.PollType:
+			err = conn.handlePoll(msg)
+
+			if err != nil {
+				return state, err
 			}
+
+			state = EPPServerWaitingForCommand
 		}
 	case <-timeout:
 		err := conn.CloseConnection(nil)
@@ -1113,14 +1238,37 @@ func (conn *EPPServerConnection) Handle() error {
 }
 
 // WriteEPP takes an epp object and serializes it onto the socket for
-// the connection.
+// the connection. Messages written while a command is being handled are
+// held until the server lock has been released.
 func (conn *EPPServerConnection) WriteEPP(msg epp.Epp) error {
 	data, _ := msg.EncodeEPP()
+
+	if conn.pendingWrites != nil {
+		conn.pendingWrites.Write(data)
+
+		return nil
+	}
+
 	_, err := conn.Write(data)
 
 	return err
 }
 
+// flushWrites sends the messages held while handling a command and
+// stops holding writes.
+func (conn *EPPServerConnection) flushWrites() error {
+	pending := conn.pendingWrites
+	conn.pendingWrites = nil
+
+	if pending == nil || pending.Len() == 0 {
+		return nil
+	}
+
+	_, err := conn.Write(pending.Bytes())
+
+	return err
+}
+
 // CloseConnection closes the socket associated with the connection.
 func (conn *EPPServerConnection) CloseConnection(err error) error {
 	conn.Close()
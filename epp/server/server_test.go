@@ -1234,7 +1234,13 @@ func TestWaitingForCommandDomainUpdate(t *testing.T) {
 		domainUpdate1 := epp.GetEPPDomainUpdate("FOO.COM", &domainAdd, &domainRem, &domainChg, cltxid)
 		simpleClient.SendChannel <- domainUpdate1
 
-		time.Sleep(time.Second * 2)
+		msg = <-simpleClient.RecvChannel
+		So(msg.MessageType(), ShouldEqual, epp.ResponseType)
+		So(msg.ResponseObject.Result, ShouldNotBeNil)
+		So(msg.ResponseObject.Result.Code, ShouldEqual, epp.ResponseCodeObjectDoesNotExist)
+		So(msg.ResponseObject.Result.Msg, ShouldEqual, epp.ResponseCode2303)
+		So(msg.ResponseObject.TransactionID.ClientTransactionID, ShouldEqual, cltxid)
+
 		srv.KillChan <- true
 	})
 }
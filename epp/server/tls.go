@@ -0,0 +1,46 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ErrNoCACertificates indicates that the CA bundle used to verify client
+// certificates did not contain any certificates.
+var ErrNoCACertificates = errors.New("no certificates found in CA bundle")
+
+// LoadTLSConfig generates a TLS configuration for the server using the
+// certificate and key paths provided. If a CA bundle path is passed,
+// clients are required to present a certificate signed by one of the
+// CAs in the bundle.
+func LoadTLSConfig(certPath string, keyPath string, caPath string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certPath, keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("error loading server certificate: %w", err)
+	}
+
+	tlsConf := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if caPath != "" {
+		caBytes, err := os.ReadFile(caPath)
+		if err != nil {
+			return nil, fmt.Errorf("error reading CA bundle: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caBytes) {
+			return nil, ErrNoCACertificates
+		}
+
+		tlsConf.ClientCAs = pool
+		tlsConf.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return tlsConf, nil
+}
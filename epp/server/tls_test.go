@@ -0,0 +1,160 @@
+package server
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/timapril/go-registrar/epp"
+	"github.com/timapril/go-registrar/epp/client"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// testingCertificate generates a certificate and key signed by the parent
+// provided, or self signed if parent is nil, and writes them as PEM files
+// with the name passed into the directory.
+func testingCertificate(t *testing.T, dir string, name string, isCA bool, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-1 * time.Hour),
+		NotAfter:     time.Now().Add(1 * time.Hour),
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP(testingHost)},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+
+	if isCA {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage |= x509.KeyUsageCertSign
+	}
+
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+
+	if err := os.WriteFile(filepath.Join(dir, name+".pem"), certPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, name+".key"), keyPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return cert, key
+}
+
+func TestTLSServer(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	ca, caKey := testingCertificate(t, dir, "ca", true, nil, nil)
+	testingCertificate(t, dir, "server", false, ca, caKey)
+	testingCertificate(t, dir, "client", false, ca, caKey)
+
+	Convey("Given a server listening with TLS that requires client certificates", t, func() {
+		tlsConf, err := LoadTLSConfig(filepath.Join(dir, "server.pem"), filepath.Join(dir, "server.key"), filepath.Join(dir, "ca.pem"))
+		So(err, ShouldBeNil)
+
+		srv := NewEPPServer(testingHost, 0, 2*time.Second)
+		srv.TLSConfig = tlsConf
+
+		go func() {
+			_ = srv.Start()
+		}()
+
+		So(<-srv.Running, ShouldBeTrue)
+
+		_, portStr, err := net.SplitHostPort(srv.socket.Addr().String())
+		So(err, ShouldBeNil)
+		port, err := strconv.ParseInt(portStr, 10, 64)
+		So(err, ShouldBeNil)
+
+		conf := client.Config{
+			Host:       testingHost,
+			Port:       port,
+			UseTLS:     true,
+			CACertPath: filepath.Join(dir, "ca.pem"),
+			ServerName: "localhost",
+		}
+
+		Convey("A client with a certificate should receive a greeting", func() {
+			conf.ClientCertPath = filepath.Join(dir, "client.pem")
+			conf.ClientKeyPath = filepath.Join(dir, "client.key")
+
+			clientConf, err := conf.TLSConfig()
+			So(err, ShouldBeNil)
+
+			conn, err := tls.Dial("tcp", conf.GetConnectionString(), clientConf)
+			So(err, ShouldBeNil)
+
+			defer conn.Close()
+
+			scanner := bufio.NewScanner(conn)
+			scanner.Split(epp.WireSplit)
+			So(scanner.Scan(), ShouldBeTrue)
+
+			msg, err := epp.UnmarshalMessage(scanner.Bytes())
+			So(err, ShouldBeNil)
+			So(msg.MessageType(), ShouldEqual, epp.GreetingType)
+		})
+
+		Convey("A client without a certificate should not receive a greeting", func() {
+			clientConf, err := conf.TLSConfig()
+			So(err, ShouldBeNil)
+
+			conn, err := tls.Dial("tcp", conf.GetConnectionString(), clientConf)
+			if err == nil {
+				defer conn.Close()
+
+				scanner := bufio.NewScanner(conn)
+				scanner.Split(epp.WireSplit)
+				So(scanner.Scan(), ShouldBeFalse)
+			}
+		})
+
+		Reset(func() {
+			srv.KillChan <- true
+		})
+	})
+}
@@ -0,0 +1,524 @@
+package server
+
+import (
+	"time"
+
+	"github.com/timapril/go-registrar/epp"
+)
+
+const (
+	// TransferStatusPending indicates that a transfer has been requested
+	// and is waiting for the losing registrar to act on it.
+	TransferStatusPending = "pending"
+
+	// TransferStatusClientApproved indicates that the losing registrar
+	// approved the transfer.
+	TransferStatusClientApproved = "clientApproved"
+
+	// TransferStatusClientCancelled indicates that the gaining registrar
+	// cancelled the transfer.
+	TransferStatusClientCancelled = "clientCancelled"
+
+	// TransferStatusClientRejected indicates that the losing registrar
+	// rejected the transfer.
+	TransferStatusClientRejected = "clientRejected"
+
+	// TransferStatusServerApproved indicates that the server approved the
+	// transfer after the losing registrar did not act on it in time.
+	TransferStatusServerApproved = "serverApproved"
+
+	// DefaultTransferApprovalPeriod is the time a losing registrar has to
+	// act on a transfer before the server approves it.
+	DefaultTransferApprovalPeriod = 5 * 24 * time.Hour
+)
+
+// Poll messages sent to registrars when the state of a transfer changes.
+const (
+	transferRequestedMessage    = "Transfer Requested."
+	transferApprovedMessage     = "Transfer Approved."
+	transferRejectedMessage     = "Transfer Rejected."
+	transferCancelledMessage    = "Transfer Cancelled."
+	transferAutoApprovedMessage = "Transfer Auto Approved."
+)
+
+// TransferRecord holds the state of the most recent transfer request for
+// a domain or contact in the registry. For domains, ExpireDate is the
+// expiration date the domain will have once the transfer completes.
+type TransferRecord struct {
+	Status string
+
+	RequestingID string
+	RequestDate  time.Time
+	ActingID     string
+	ActionDate   time.Time
+
+	ExpireDate time.Time
+}
+
+// handleDomainTransfer handles all of the transfer operations for a
+// domain object.
+func (conn *EPPServerConnection) handleDomainTransfer(msg epp.Epp) error {
+	op := msg.CommandObject.TransferObject.Operation
+	obj := msg.CommandObject.TransferObject.DomainTransferObj
+	clientID := conn.registrarID()
+
+	var code int
+
+	var message string
+
+	switch op {
+	case epp.TransferRequest:
+		code, message = conn.Conf.requestDomainTransfer(obj, clientID)
+	case epp.TransferQuery:
+		code, message = conn.Conf.queryDomainTransfer(obj, clientID)
+	case epp.TransferApprove:
+		code, message = conn.Conf.approveDomainTransfer(obj.DomainName, clientID)
+	case epp.TransferReject:
+		code, message = conn.Conf.finishDomainTransfer(obj.DomainName, clientID, TransferStatusClientRejected)
+	case epp.TransferCancel:
+		code, message = conn.Conf.finishDomainTransfer(obj.DomainName, clientID, TransferStatusClientCancelled)
+	default:
+		code, message = epp.ResponseCodeParameterValueSyntaxError, epp.ResponseCode2005
+	}
+
+	if code != epp.ResponseCodeCommandSuccessful && code != epp.ResponseCodeCommandSuccessfulPending {
+		return conn.writeResult(msg, code, message)
+	}
+
+	cltxid, _ := msg.GetTransactionID()
+	srvtxid := conn.GetNextTransactionID()
+	res := epp.GetEPPResponseResult(cltxid, srvtxid, code, message)
+	res.ResponseObject.ResultData = conn.Conf.domainTransferData(obj.DomainName)
+
+	return conn.WriteEPP(res)
+}
+
+// requestDomainTransfer starts a transfer of a domain to the registrar
+// provided and notifies the losing registrar. The response code and
+// message for the request are returned.
+func (srv *EPPServer) requestDomainTransfer(obj *epp.DomainTransfer, clientID string) (int, string) {
+	dom, err := srv.DomainByName(obj.DomainName)
+	if err != nil {
+		return epp.ResponseCodeObjectDoesNotExist, epp.ResponseCode2303
+	}
+
+	if dom.SponsoringClientID == clientID {
+		return epp.ResponseCodeObjectNotEligibleForTransfer, epp.ResponseCode2106
+	}
+
+	if dom.PendingTransferStatus {
+		return epp.ResponseCodeObjectPendingTransfer, epp.ResponseCode2300
+	}
+
+	rev := dom.CurrentRevision
+	if rev.ClientTransferProhibitedStatus || rev.ServerTransferProhibitedStatus {
+		return epp.ResponseCodeObjectStatusProhibited, epp.ResponseCode2304
+	}
+
+	if obj.AuthInfo == nil || obj.AuthInfo.Password != srv.domainAuthInfo(dom.DomainName) {
+		return epp.ResponseCodeInvalidAuthorizationInformation, epp.ResponseCode2202
+	}
+
+	period := epp.GetEPPDomainPeriod(epp.DomainPeriodYear, 1)
+	if obj.Period != nil && obj.Period.Value != 0 {
+		period = *obj.Period
+	}
+
+	if !validPeriod(period) {
+		return epp.ResponseCodeParameterValueRangeError, epp.ResponseCode2004
+	}
+
+	now := time.Now().UTC()
+
+	expireDate := addPeriod(dom.ExpireDate, period)
+	if expireDate.After(now.AddDate(maximumRegistrationYears, 0, 0)) {
+		return epp.ResponseCodeParameterValuePolicyError, epp.ResponseCode2306
+	}
+
+	srv.DomainTransfers[dom.DomainName] = TransferRecord{
+		Status:       TransferStatusPending,
+		RequestingID: clientID,
+		RequestDate:  now,
+		ActingID:     dom.SponsoringClientID,
+		ActionDate:   now.Add(srv.TransferApprovalPeriod),
+		ExpireDate:   expireDate,
+	}
+
+	dom.PendingTransferStatus = true
+	srv.Domains[dom.DomainName] = *dom
+
+	srv.queueMessage(dom.SponsoringClientID, transferRequestedMessage, srv.domainTransferData(dom.DomainName))
+
+	return epp.ResponseCodeCommandSuccessfulPending, epp.ResponseCode1001
+}
+
+// queryDomainTransfer checks that the registrar provided may see the
+// state of the most recent transfer of a domain. Registrars that are not
+// party to the transfer must provide the authInfo for the domain. The
+// response code and message for the request are returned.
+func (srv *EPPServer) queryDomainTransfer(obj *epp.DomainTransfer, clientID string) (int, string) {
+	dom, err := srv.DomainByName(obj.DomainName)
+	if err != nil {
+		return epp.ResponseCodeObjectDoesNotExist, epp.ResponseCode2303
+	}
+
+	record, ok := srv.DomainTransfers[dom.DomainName]
+	if !ok {
+		return epp.ResponseCodeObjectNotPendingTransfer, epp.ResponseCode2301
+	}
+
+	if clientID != record.RequestingID && clientID != record.ActingID {
+		if obj.AuthInfo == nil || obj.AuthInfo.Password != srv.domainAuthInfo(dom.DomainName) {
+			return epp.ResponseCodeAuthorizationError, epp.ResponseCode2201
+		}
+	}
+
+	return epp.ResponseCodeCommandSuccessful, epp.ResponseCode1000
+}
+
+// approveDomainTransfer approves a pending transfer of a domain on
+// behalf of the losing registrar and notifies the gaining registrar.
+// The response code and message for the request are returned.
+func (srv *EPPServer) approveDomainTransfer(domainName string, clientID string) (int, string) {
+	dom, err := srv.DomainByName(domainName)
+	if err != nil {
+		return epp.ResponseCodeObjectDoesNotExist, epp.ResponseCode2303
+	}
+
+	record, ok := srv.DomainTransfers[dom.DomainName]
+	if !ok || record.Status != TransferStatusPending {
+		return epp.ResponseCodeObjectNotPendingTransfer, epp.ResponseCode2301
+	}
+
+	if clientID != dom.SponsoringClientID {
+		return epp.ResponseCodeAuthorizationError, epp.ResponseCode2201
+	}
+
+	srv.completeDomainTransfer(dom.DomainName, TransferStatusClientApproved, time.Now().UTC())
+
+	srv.queueMessage(record.RequestingID, transferApprovedMessage, srv.domainTransferData(dom.DomainName))
+
+	return epp.ResponseCodeCommandSuccessful, epp.ResponseCode1000
+}
+
+// finishDomainTransfer ends a pending transfer of a domain without moving
+// the domain. Rejecting a transfer is only allowed for the losing
+// registrar and notifies the gaining registrar, cancelling is only
+// allowed for the gaining registrar and notifies the losing registrar.
+// The response code and message for the request are returned.
+func (srv *EPPServer) finishDomainTransfer(domainName string, clientID string, status string) (int, string) {
+	dom, err := srv.DomainByName(domainName)
+	if err != nil {
+		return epp.ResponseCodeObjectDoesNotExist, epp.ResponseCode2303
+	}
+
+	record, ok := srv.DomainTransfers[dom.DomainName]
+	if !ok || record.Status != TransferStatusPending {
+		return epp.ResponseCodeObjectNotPendingTransfer, epp.ResponseCode2301
+	}
+
+	notify, message := record.RequestingID, transferRejectedMessage
+	allowed := record.ActingID
+
+	if status == TransferStatusClientCancelled {
+		notify, message = record.ActingID, transferCancelledMessage
+		allowed = record.RequestingID
+	}
+
+	if clientID != allowed {
+		return epp.ResponseCodeAuthorizationError, epp.ResponseCode2201
+	}
+
+	record.Status = status
+	record.ActionDate = time.Now().UTC()
+	srv.DomainTransfers[dom.DomainName] = record
+
+	dom.PendingTransferStatus = false
+	srv.Domains[dom.DomainName] = *dom
+
+	srv.queueMessage(notify, message, srv.domainTransferData(dom.DomainName))
+
+	return epp.ResponseCodeCommandSuccessful, epp.ResponseCode1000
+}
+
+// completeDomainTransfer moves a domain, and the hosts subordinate to it,
+// to the gaining registrar of the pending transfer and records the final
+// status of the transfer.
+func (srv *EPPServer) completeDomainTransfer(domainName string, status string, now time.Time) {
+	dom, err := srv.DomainByName(domainName)
+	if err != nil {
+		return
+	}
+
+	record := srv.DomainTransfers[dom.DomainName]
+	record.Status = status
+	record.ActionDate = now
+	srv.DomainTransfers[dom.DomainName] = record
+
+	dom.SponsoringClientID = record.RequestingID
+	dom.TransferDate = now
+	dom.ExpireDate = record.ExpireDate
+	dom.PendingTransferStatus = false
+	srv.Domains[dom.DomainName] = *dom
+
+	for _, hostName := range srv.subordinateHosts(dom.DomainName) {
+		hos := srv.Hosts[hostName]
+		hos.SponsoringClientID = record.RequestingID
+		hos.TransferDate = now
+		srv.Hosts[hostName] = hos
+	}
+}
+
+// domainTransferData generates the trnData response for the most recent
+// transfer of the domain provided.
+func (srv EPPServer) domainTransferData(domainName string) *epp.ResultData {
+	record := srv.DomainTransfers[domainName]
+
+	resData := &epp.ResultData{}
+	resData.DomainTrnDataResp = &epp.DomainTrnDataResp{}
+	resData.DomainTrnDataResp.XMLNSDomain = epp.DomainXMLNS
+	resData.DomainTrnDataResp.XMLNsSchemaLocation = epp.DomainSchema
+	resData.DomainTrnDataResp.Name = domainName
+	resData.DomainTrnDataResp.TrStatus = record.Status
+	resData.DomainTrnDataResp.ReID = record.RequestingID
+	resData.DomainTrnDataResp.ReDate = record.RequestDate.Format(epp.EPPTimeFormat)
+	resData.DomainTrnDataResp.AcID = record.ActingID
+	resData.DomainTrnDataResp.AcDate = record.ActionDate.Format(epp.EPPTimeFormat)
+	resData.DomainTrnDataResp.ExpireDate = record.ExpireDate.Format(epp.EPPTimeFormat)
+
+	return resData
+}
+
+// handleContactTransfer handles all of the transfer operations for a
+// contact object.
+func (conn *EPPServerConnection) handleContactTransfer(msg epp.Epp) error {
+	op := msg.CommandObject.TransferObject.Operation
+	obj := msg.CommandObject.TransferObject.ContactTransferObj
+	clientID := conn.registrarID()
+
+	var code int
+
+	var message string
+
+	switch op {
+	case epp.TransferRequest:
+		code, message = conn.Conf.requestContactTransfer(obj, clientID)
+	case epp.TransferQuery:
+		code, message = conn.Conf.queryContactTransfer(obj, clientID)
+	case epp.TransferApprove:
+		code, message = conn.Conf.approveContactTransfer(obj.ContactID, clientID)
+	case epp.TransferReject:
+		code, message = conn.Conf.finishContactTransfer(obj.ContactID, clientID, TransferStatusClientRejected)
+	case epp.TransferCancel:
+		code, message = conn.Conf.finishContactTransfer(obj.ContactID, clientID, TransferStatusClientCancelled)
+	default:
+		code, message = epp.ResponseCodeParameterValueSyntaxError, epp.ResponseCode2005
+	}
+
+	if code != epp.ResponseCodeCommandSuccessful && code != epp.ResponseCodeCommandSuccessfulPending {
+		return conn.writeResult(msg, code, message)
+	}
+
+	cltxid, _ := msg.GetTransactionID()
+	srvtxid := conn.GetNextTransactionID()
+	res := epp.GetEPPResponseResult(cltxid, srvtxid, code, message)
+	res.ResponseObject.ResultData = conn.Conf.contactTransferData(obj.ContactID)
+
+	return conn.WriteEPP(res)
+}
+
+// requestContactTransfer starts a transfer of a contact to the registrar
+// provided and notifies the losing registrar. The response code and
+// message for the request are returned.
+func (srv *EPPServer) requestContactTransfer(obj *epp.ContactTransfer, clientID string) (int, string) {
+	cont, err := srv.ContactByID(obj.ContactID)
+	if err != nil {
+		return epp.ResponseCodeObjectDoesNotExist, epp.ResponseCode2303
+	}
+
+	if cont.SponsoringClientID == clientID {
+		return epp.ResponseCodeObjectNotEligibleForTransfer, epp.ResponseCode2106
+	}
+
+	if cont.PendingTransferStatus {
+		return epp.ResponseCodeObjectPendingTransfer, epp.ResponseCode2300
+	}
+
+	rev := cont.CurrentRevision
+	if rev.ClientTransferProhibitedStatus || rev.ServerTransferProhibitedStatus {
+		return epp.ResponseCodeObjectStatusProhibited, epp.ResponseCode2304
+	}
+
+	if obj.AuthInfo == nil || obj.AuthInfo.Password != srv.contactAuthInfo(cont.ContactRegistryID) {
+		return epp.ResponseCodeInvalidAuthorizationInformation, epp.ResponseCode2202
+	}
+
+	now := time.Now().UTC()
+
+	srv.ContactTransfers[cont.ContactRegistryID] = TransferRecord{
+		Status:       TransferStatusPending,
+		RequestingID: clientID,
+		RequestDate:  now,
+		ActingID:     cont.SponsoringClientID,
+		ActionDate:   now.Add(srv.TransferApprovalPeriod),
+	}
+
+	cont.PendingTransferStatus = true
+	srv.Contacts[cont.ContactRegistryID] = *cont
+
+	srv.queueMessage(cont.SponsoringClientID, transferRequestedMessage, srv.contactTransferData(cont.ContactRegistryID))
+
+	return epp.ResponseCodeCommandSuccessfulPending, epp.ResponseCode1001
+}
+
+// queryContactTransfer checks that the registrar provided may see the
+// state of the most recent transfer of a contact. Registrars that are
+// not party to the transfer must provide the authInfo for the contact.
+// The response code and message for the request are returned.
+func (srv *EPPServer) queryContactTransfer(obj *epp.ContactTransfer, clientID string) (int, string) {
+	cont, err := srv.ContactByID(obj.ContactID)
+	if err != nil {
+		return epp.ResponseCodeObjectDoesNotExist, epp.ResponseCode2303
+	}
+
+	record, ok := srv.ContactTransfers[cont.ContactRegistryID]
+	if !ok {
+		return epp.ResponseCodeObjectNotPendingTransfer, epp.ResponseCode2301
+	}
+
+	if clientID != record.RequestingID && clientID != record.ActingID {
+		if obj.AuthInfo == nil || obj.AuthInfo.Password != srv.contactAuthInfo(cont.ContactRegistryID) {
+			return epp.ResponseCodeAuthorizationError, epp.ResponseCode2201
+		}
+	}
+
+	return epp.ResponseCodeCommandSuccessful, epp.ResponseCode1000
+}
+
+// approveContactTransfer approves a pending transfer of a contact on
+// behalf of the losing registrar and notifies the gaining registrar.
+// The response code and message for the request are returned.
+func (srv *EPPServer) approveContactTransfer(contactID string, clientID string) (int, string) {
+	cont, err := srv.ContactByID(contactID)
+	if err != nil {
+		return epp.ResponseCodeObjectDoesNotExist, epp.ResponseCode2303
+	}
+
+	record, ok := srv.ContactTransfers[cont.ContactRegistryID]
+	if !ok || record.Status != TransferStatusPending {
+		return epp.ResponseCodeObjectNotPendingTransfer, epp.ResponseCode2301
+	}
+
+	if clientID != cont.SponsoringClientID {
+		return epp.ResponseCodeAuthorizationError, epp.ResponseCode2201
+	}
+
+	srv.completeContactTransfer(cont.ContactRegistryID, TransferStatusClientApproved, time.Now().UTC())
+
+	srv.queueMessage(record.RequestingID, transferApprovedMessage, srv.contactTransferData(cont.ContactRegistryID))
+
+	return epp.ResponseCodeCommandSuccessful, epp.ResponseCode1000
+}
+
+// finishContactTransfer ends a pending transfer of a contact without
+// moving the contact. Rejecting a transfer is only allowed for the losing
+// registrar and notifies the gaining registrar, cancelling is only
+// allowed for the gaining registrar and notifies the losing registrar.
+// The response code and message for the request are returned.
+func (srv *EPPServer) finishContactTransfer(contactID string, clientID string, status string) (int, string) {
+	cont, err := srv.ContactByID(contactID)
+	if err != nil {
+		return epp.ResponseCodeObjectDoesNotExist, epp.ResponseCode2303
+	}
+
+	record, ok := srv.ContactTransfers[cont.ContactRegistryID]
+	if !ok || record.Status != TransferStatusPending {
+		return epp.ResponseCodeObjectNotPendingTransfer, epp.ResponseCode2301
+	}
+
+	notify, message := record.RequestingID, transferRejectedMessage
+	allowed := record.ActingID
+
+	if status == TransferStatusClientCancelled {
+		notify, message = record.ActingID, transferCancelledMessage
+		allowed = record.RequestingID
+	}
+
+	if clientID != allowed {
+		return epp.ResponseCodeAuthorizationError, epp.ResponseCode2201
+	}
+
+	record.Status = status
+	record.ActionDate = time.Now().UTC()
+	srv.ContactTransfers[cont.ContactRegistryID] = record
+
+	cont.PendingTransferStatus = false
+	srv.Contacts[cont.ContactRegistryID] = *cont
+
+	srv.queueMessage(notify, message, srv.contactTransferData(cont.ContactRegistryID))
+
+	return epp.ResponseCodeCommandSuccessful, epp.ResponseCode1000
+}
+
+// completeContactTransfer moves a contact to the gaining registrar of the
+// pending transfer and records the final status of the transfer.
+func (srv *EPPServer) completeContactTransfer(contactID string, status string, now time.Time) {
+	cont, err := srv.ContactByID(contactID)
+	if err != nil {
+		return
+	}
+
+	record := srv.ContactTransfers[cont.ContactRegistryID]
+	record.Status = status
+	record.ActionDate = now
+	srv.ContactTransfers[cont.ContactRegistryID] = record
+
+	cont.SponsoringClientID = record.RequestingID
+	cont.TransferDate = now
+	cont.PendingTransferStatus = false
+	srv.Contacts[cont.ContactRegistryID] = *cont
+}
+
+// contactTransferData generates the trnData response for the most recent
+// transfer of the contact provided.
+func (srv EPPServer) contactTransferData(contactID string) *epp.ResultData {
+	record := srv.ContactTransfers[contactID]
+
+	resData := &epp.ResultData{}
+	resData.ContactTrnDataResp = &epp.ContactTrnDataResp{}
+	resData.ContactTrnDataResp.XMLNSContact = epp.ContactXMLNS
+	resData.ContactTrnDataResp.XMLNsSchemaLocation = epp.ContactSchema
+	resData.ContactTrnDataResp.ID = contactID
+	resData.ContactTrnDataResp.TrStatus = record.Status
+	resData.ContactTrnDataResp.ReID = record.RequestingID
+	resData.ContactTrnDataResp.ReDate = record.RequestDate.Format(epp.EPPTimeFormat)
+	resData.ContactTrnDataResp.AcID = record.ActingID
+	resData.ContactTrnDataResp.AcDate = record.ActionDate.Format(epp.EPPTimeFormat)
+
+	return resData
+}
+
+// AutoApproveTransfers approves every pending transfer whose approval
+// period has passed at the time provided. Both the gaining and losing
+// registrars are notified of each transfer that is approved.
+func (srv *EPPServer) AutoApproveTransfers(now time.Time) {
+	for domainName, record := range srv.DomainTransfers {
+		if record.Status == TransferStatusPending && !now.Before(record.ActionDate) {
+			srv.completeDomainTransfer(domainName, TransferStatusServerApproved, now)
+
+			resData := srv.domainTransferData(domainName)
+			srv.queueMessage(record.RequestingID, transferAutoApprovedMessage, resData)
+			srv.queueMessage(record.ActingID, transferAutoApprovedMessage, resData)
+		}
+	}
+
+	for contactID, record := range srv.ContactTransfers {
+		if record.Status == TransferStatusPending && !now.Before(record.ActionDate) {
+			srv.completeContactTransfer(contactID, TransferStatusServerApproved, now)
+
+			resData := srv.contactTransferData(contactID)
+			srv.queueMessage(record.RequestingID, transferAutoApprovedMessage, resData)
+			srv.queueMessage(record.ActingID, transferAutoApprovedMessage, resData)
+		}
+	}
+}
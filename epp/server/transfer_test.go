@@ -0,0 +1,190 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/timapril/go-registrar/epp"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// getTestingDomainTransfer returns a domain transfer object for the
+// domain provided using the password passed.
+func getTestingDomainTransfer(domainName string, password string) *epp.DomainTransfer {
+	return &epp.DomainTransfer{
+		DomainName: domainName,
+		AuthInfo:   &epp.DomainAuth{Password: password},
+	}
+}
+
+func TestDomainTransfer(t *testing.T) {
+	t.Parallel()
+	Convey("Given a default server with a domain sponsored by another registrar", t, func() {
+		srv := GetDefaultServer()
+		obj := getTestingDomainCreate("losing.com")
+		obj.DomainAuthObj = &epp.DomainAuth{Password: "transferme"}
+		_, code, _ := srv.createContact(getTestingContactCreate("LOSING-1"), "2")
+		So(code, ShouldEqual, epp.ResponseCodeCommandSuccessful)
+		obj.Registrant = "LOSING-1"
+		dom, code, _ := srv.createDomain(obj, "2")
+		So(code, ShouldEqual, epp.ResponseCodeCommandSuccessful)
+		_, code, _ = srv.createHost(&epp.HostCreate{HostName: "ns1.losing.com"}, "2")
+		So(code, ShouldEqual, epp.ResponseCodeCommandSuccessful)
+
+		Convey("Requesting a transfer with the wrong authInfo should fail", func() {
+			code, _ := srv.requestDomainTransfer(getTestingDomainTransfer("LOSING.COM", "wrong"), "1")
+			So(code, ShouldEqual, epp.ResponseCodeInvalidAuthorizationInformation)
+		})
+
+		Convey("Requesting a transfer of an owned domain should fail", func() {
+			code, _ := srv.requestDomainTransfer(getTestingDomainTransfer("LOSING.COM", "transferme"), "2")
+			So(code, ShouldEqual, epp.ResponseCodeObjectNotEligibleForTransfer)
+		})
+
+		Convey("Querying a domain that has never been transferred should fail", func() {
+			code, _ := srv.queryDomainTransfer(getTestingDomainTransfer("LOSING.COM", ""), "1")
+			So(code, ShouldEqual, epp.ResponseCodeObjectNotPendingTransfer)
+		})
+
+		Convey("Requesting a transfer with the right authInfo should leave it pending", func() {
+			code, _ := srv.requestDomainTransfer(getTestingDomainTransfer("LOSING.COM", "transferme"), "1")
+			So(code, ShouldEqual, epp.ResponseCodeCommandSuccessfulPending)
+
+			pending, err := srv.DomainByName("LOSING.COM")
+			So(err, ShouldBeNil)
+			So(pending.PendingTransferStatus, ShouldBeTrue)
+
+			resData := srv.domainTransferData("LOSING.COM")
+			So(resData.DomainTrnDataResp.TrStatus, ShouldEqual, TransferStatusPending)
+			So(resData.DomainTrnDataResp.ReID, ShouldEqual, "1")
+			So(resData.DomainTrnDataResp.AcID, ShouldEqual, "2")
+			So(resData.DomainTrnDataResp.ExpireDate, ShouldEqual, dom.ExpireDate.AddDate(1, 0, 0).Format(epp.EPPTimeFormat))
+
+			So(srv.MessageQueues["2"], ShouldHaveLength, 1)
+			So(srv.MessageQueues["2"][0].Message, ShouldEqual, transferRequestedMessage)
+
+			Convey("A second request should fail", func() {
+				code, _ := srv.requestDomainTransfer(getTestingDomainTransfer("LOSING.COM", "transferme"), "3")
+				So(code, ShouldEqual, epp.ResponseCodeObjectPendingTransfer)
+			})
+
+			Convey("Both registrars should be able to query the transfer", func() {
+				code, _ := srv.queryDomainTransfer(getTestingDomainTransfer("LOSING.COM", ""), "1")
+				So(code, ShouldEqual, epp.ResponseCodeCommandSuccessful)
+				code, _ = srv.queryDomainTransfer(getTestingDomainTransfer("LOSING.COM", ""), "2")
+				So(code, ShouldEqual, epp.ResponseCodeCommandSuccessful)
+				code, _ = srv.queryDomainTransfer(getTestingDomainTransfer("LOSING.COM", ""), "3")
+				So(code, ShouldEqual, epp.ResponseCodeAuthorizationError)
+			})
+
+			Convey("Updating the domain while the transfer is pending should fail", func() {
+				code, _ := srv.updateDomain(&epp.DomainUpdate{DomainName: "LOSING.COM"}, "2")
+				So(code, ShouldEqual, epp.ResponseCodeObjectStatusProhibited)
+			})
+
+			Convey("Only the losing registrar should be able to approve the transfer", func() {
+				code, _ := srv.approveDomainTransfer("LOSING.COM", "1")
+				So(code, ShouldEqual, epp.ResponseCodeAuthorizationError)
+
+				code, _ = srv.approveDomainTransfer("LOSING.COM", "2")
+				So(code, ShouldEqual, epp.ResponseCodeCommandSuccessful)
+
+				moved, err := srv.DomainByName("LOSING.COM")
+				So(err, ShouldBeNil)
+				So(moved.SponsoringClientID, ShouldEqual, "1")
+				So(moved.PendingTransferStatus, ShouldBeFalse)
+				So(moved.ExpireDate, ShouldEqual, dom.ExpireDate.AddDate(1, 0, 0))
+
+				hos, err := srv.HostByName("NS1.LOSING.COM")
+				So(err, ShouldBeNil)
+				So(hos.SponsoringClientID, ShouldEqual, "1")
+
+				So(srv.MessageQueues["1"], ShouldHaveLength, 1)
+				So(srv.MessageQueues["1"][0].Message, ShouldEqual, transferApprovedMessage)
+				So(srv.MessageQueues["1"][0].ResultData.DomainTrnDataResp.TrStatus, ShouldEqual, TransferStatusClientApproved)
+			})
+
+			Convey("Rejecting the transfer should leave the domain with the losing registrar", func() {
+				code, _ := srv.finishDomainTransfer("LOSING.COM", "1", TransferStatusClientRejected)
+				So(code, ShouldEqual, epp.ResponseCodeAuthorizationError)
+
+				code, _ = srv.finishDomainTransfer("LOSING.COM", "2", TransferStatusClientRejected)
+				So(code, ShouldEqual, epp.ResponseCodeCommandSuccessful)
+
+				kept, err := srv.DomainByName("LOSING.COM")
+				So(err, ShouldBeNil)
+				So(kept.SponsoringClientID, ShouldEqual, "2")
+				So(kept.PendingTransferStatus, ShouldBeFalse)
+
+				So(srv.MessageQueues["1"], ShouldHaveLength, 1)
+				So(srv.MessageQueues["1"][0].Message, ShouldEqual, transferRejectedMessage)
+			})
+
+			Convey("Cancelling the transfer should notify the losing registrar", func() {
+				code, _ := srv.finishDomainTransfer("LOSING.COM", "2", TransferStatusClientCancelled)
+				So(code, ShouldEqual, epp.ResponseCodeAuthorizationError)
+
+				code, _ = srv.finishDomainTransfer("LOSING.COM", "1", TransferStatusClientCancelled)
+				So(code, ShouldEqual, epp.ResponseCodeCommandSuccessful)
+
+				So(srv.MessageQueues["2"], ShouldHaveLength, 2)
+				So(srv.MessageQueues["2"][1].Message, ShouldEqual, transferCancelledMessage)
+			})
+
+			Convey("The transfer should be approved by the server after the approval period", func() {
+				srv.AutoApproveTransfers(time.Now().UTC())
+
+				stillPending, err := srv.DomainByName("LOSING.COM")
+				So(err, ShouldBeNil)
+				So(stillPending.PendingTransferStatus, ShouldBeTrue)
+
+				srv.AutoApproveTransfers(time.Now().UTC().Add(srv.TransferApprovalPeriod))
+
+				moved, err := srv.DomainByName("LOSING.COM")
+				So(err, ShouldBeNil)
+				So(moved.SponsoringClientID, ShouldEqual, "1")
+				So(srv.DomainTransfers["LOSING.COM"].Status, ShouldEqual, TransferStatusServerApproved)
+
+				So(srv.MessageQueues["1"], ShouldHaveLength, 1)
+				So(srv.MessageQueues["1"][0].Message, ShouldEqual, transferAutoApprovedMessage)
+				So(srv.MessageQueues["2"], ShouldHaveLength, 2)
+				So(srv.MessageQueues["2"][1].Message, ShouldEqual, transferAutoApprovedMessage)
+			})
+		})
+	})
+}
+
+func TestContactTransfer(t *testing.T) {
+	t.Parallel()
+	Convey("Given a default server with a contact sponsored by another registrar", t, func() {
+		srv := GetDefaultServer()
+		obj := getTestingContactCreate("LOSING-1")
+		obj.ContactAuthObj = &epp.ContactAuth{Password: "transferme"}
+		_, code, _ := srv.createContact(obj, "2")
+		So(code, ShouldEqual, epp.ResponseCodeCommandSuccessful)
+
+		transfer := &epp.ContactTransfer{ContactID: "LOSING-1", AuthInfo: &epp.ContactAuth{Password: "transferme"}}
+
+		Convey("Requesting and approving a transfer should move the contact", func() {
+			code, _ := srv.requestContactTransfer(transfer, "1")
+			So(code, ShouldEqual, epp.ResponseCodeCommandSuccessfulPending)
+			So(srv.MessageQueues["2"], ShouldHaveLength, 1)
+
+			code, _ = srv.approveContactTransfer("LOSING-1", "2")
+			So(code, ShouldEqual, epp.ResponseCodeCommandSuccessful)
+
+			cont, err := srv.ContactByID("LOSING-1")
+			So(err, ShouldBeNil)
+			So(cont.SponsoringClientID, ShouldEqual, "1")
+			So(srv.contactTransferData("LOSING-1").ContactTrnDataResp.TrStatus, ShouldEqual, TransferStatusClientApproved)
+			So(srv.MessageQueues["1"], ShouldHaveLength, 1)
+		})
+
+		Convey("Requesting a transfer with the wrong authInfo should fail", func() {
+			transfer.AuthInfo.Password = "wrong"
+			code, _ := srv.requestContactTransfer(transfer, "1")
+			So(code, ShouldEqual, epp.ResponseCodeInvalidAuthorizationInformation)
+		})
+	})
+}
@@ -0,0 +1,359 @@
+package server
+
+import (
+	"strings"
+	"time"
+
+	"github.com/timapril/go-registrar/epp"
+	"github.com/timapril/go-registrar/lib"
+)
+
+// handleDomainUpdate applies the add, remove and change sets of a domain
+// update to the domain in the registry.
+func (conn *EPPServerConnection) handleDomainUpdate(msg epp.Epp) error {
+	obj := msg.CommandObject.UpdateObject.DomainUpdateObj
+
+	code, message := conn.Conf.updateDomain(obj, conn.registrarID())
+
+	return conn.writeResult(msg, code, message)
+}
+
+// updateDomain validates a domain update request and applies it to the
+// domain in the registry. The update is only stored if every part of the
+// request is valid. The response code and message for the request are
+// returned.
+func (srv *EPPServer) updateDomain(obj *epp.DomainUpdate, clientID string) (int, string) {
+	dom, err := srv.DomainByName(obj.DomainName)
+	if err != nil {
+		return epp.ResponseCodeObjectDoesNotExist, epp.ResponseCode2303
+	}
+
+	if dom.SponsoringClientID != clientID {
+		return epp.ResponseCodeAuthorizationError, epp.ResponseCode2201
+	}
+
+	rev := &dom.CurrentRevision
+
+	addStatuses := domainStatusList(obj.AddObject.Statuses)
+	remStatuses := domainStatusList(obj.RemoveObject.Statuses)
+
+	if rev.ServerUpdateProhibitedStatus || dom.PendingTransferStatus ||
+		(rev.ClientUpdateProhibitedStatus && !removesStatus(remStatuses, epp.StatusClientUpdateProhibited)) {
+		return epp.ResponseCodeObjectStatusProhibited, epp.ResponseCode2304
+	}
+
+	statusLookup := func(status string) *bool { return domainClientStatus(rev, status) }
+	if !applyStatusChanges(addStatuses, remStatuses, statusLookup) {
+		return epp.ResponseCodeParameterValuePolicyError, epp.ResponseCode2306
+	}
+
+	touchedHosts := []string{}
+	hostnames := []lib.Host{}
+
+	for _, hos := range rev.Hostnames {
+		if obj.RemoveObject.Hosts == nil || !containsDomainHost(obj.RemoveObject.Hosts.Hosts, hos.HostName) {
+			hostnames = append(hostnames, hos)
+		} else {
+			touchedHosts = append(touchedHosts, hos.HostName)
+		}
+	}
+
+	if obj.RemoveObject.Hosts != nil && len(touchedHosts) != len(obj.RemoveObject.Hosts.Hosts) {
+		return epp.ResponseCodeParameterValuePolicyError, epp.ResponseCode2306
+	}
+
+	if obj.AddObject.Hosts != nil {
+		for _, domainHost := range obj.AddObject.Hosts.Hosts {
+			hos, hostErr := srv.HostByName(domainHost.Value)
+			if hostErr != nil {
+				return epp.ResponseCodeObjectDoesNotExist, epp.ResponseCode2303
+			}
+
+			for _, existing := range hostnames {
+				if strings.EqualFold(existing.HostName, hos.HostName) {
+					return epp.ResponseCodeParameterValuePolicyError, epp.ResponseCode2306
+				}
+			}
+
+			hostnames = append(hostnames, *hos)
+			touchedHosts = append(touchedHosts, hos.HostName)
+		}
+	}
+
+	rev.Hostnames = hostnames
+
+	touchedContacts := []string{}
+
+	for _, domainContact := range obj.RemoveObject.Contacts {
+		slot := domainContactSlot(rev, domainContact.Type)
+		if slot == nil || slot.ID == 0 || slot.ContactRegistryID != domainContact.Value {
+			return epp.ResponseCodeParameterValuePolicyError, epp.ResponseCode2306
+		}
+
+		*slot = lib.Contact{}
+
+		touchedContacts = append(touchedContacts, domainContact.Value)
+	}
+
+	for _, domainContact := range obj.AddObject.Contacts {
+		slot := domainContactSlot(rev, domainContact.Type)
+		if slot == nil || slot.ID != 0 {
+			return epp.ResponseCodeParameterValuePolicyError, epp.ResponseCode2306
+		}
+
+		cont, contErr := srv.ContactByID(domainContact.Value)
+		if contErr != nil {
+			return epp.ResponseCodeObjectDoesNotExist, epp.ResponseCode2303
+		}
+
+		*slot = *cont
+
+		touchedContacts = append(touchedContacts, domainContact.Value)
+	}
+
+	if obj.ChangeObject.Registrant != "" {
+		cont, contErr := srv.ContactByID(obj.ChangeObject.Registrant)
+		if contErr != nil {
+			return epp.ResponseCodeObjectDoesNotExist, epp.ResponseCode2303
+		}
+
+		if rev.DomainRegistrant.ID != 0 {
+			touchedContacts = append(touchedContacts, rev.DomainRegistrant.ContactRegistryID)
+		}
+
+		rev.DomainRegistrant = *cont
+
+		touchedContacts = append(touchedContacts, cont.ContactRegistryID)
+	}
+
+	dom.UpdateClientID = clientID
+	dom.UpdateDate = time.Now().UTC()
+
+	srv.Domains[dom.DomainName] = *dom
+
+	if obj.ChangeObject.AuthInfo != nil && obj.ChangeObject.AuthInfo.Password != "" {
+		srv.DomainAuthInfo[dom.DomainName] = obj.ChangeObject.AuthInfo.Password
+	}
+
+	for _, hostName := range touchedHosts {
+		srv.refreshHostLinked(hostName)
+	}
+
+	for _, contactID := range touchedContacts {
+		srv.refreshContactLinked(contactID)
+	}
+
+	return epp.ResponseCodeCommandSuccessful, epp.ResponseCode1000
+}
+
+// handleHostUpdate applies the add, remove and change sets of a host
+// update to the host in the registry.
+func (conn *EPPServerConnection) handleHostUpdate(msg epp.Epp) error {
+	obj := msg.CommandObject.UpdateObject.HostUpdateObj
+
+	code, message := conn.Conf.updateHost(obj, conn.registrarID())
+
+	return conn.writeResult(msg, code, message)
+}
+
+// updateHost validates a host update request and applies it to the host
+// in the registry. If the host is renamed, the domains that reference the
+// host are updated to use the new name. The response code and message
+// for the request are returned.
+func (srv *EPPServer) updateHost(obj *epp.HostUpdate, clientID string) (int, string) {
+	hos, err := srv.HostByName(obj.HostName)
+	if err != nil {
+		return epp.ResponseCodeObjectDoesNotExist, epp.ResponseCode2303
+	}
+
+	if hos.SponsoringClientID != clientID {
+		return epp.ResponseCodeAuthorizationError, epp.ResponseCode2201
+	}
+
+	rev := &hos.CurrentRevision
+
+	addStatuses := hostStatusList(obj.AddObject.Statuses)
+	remStatuses := hostStatusList(obj.RemoveObject.Statuses)
+
+	if rev.ServerUpdateProhibitedStatus ||
+		(rev.ClientUpdateProhibitedStatus && !removesStatus(remStatuses, epp.StatusClientUpdateProhibited)) {
+		return epp.ResponseCodeObjectStatusProhibited, epp.ResponseCode2304
+	}
+
+	statusLookup := func(status string) *bool { return hostClientStatus(rev, status) }
+	if !applyStatusChanges(addStatuses, remStatuses, statusLookup) {
+		return epp.ResponseCodeParameterValuePolicyError, epp.ResponseCode2306
+	}
+
+	addresses, ok := hostAddresses(rev.HostAddresses, obj.AddObject.Addresses, obj.RemoveObject.Addresses)
+	if !ok {
+		return epp.ResponseCodeParameterValuePolicyError, epp.ResponseCode2306
+	}
+
+	rev.HostAddresses = addresses
+
+	oldName := hos.HostName
+
+	if obj.ChangeObject != nil && obj.ChangeObject.HostName != "" &&
+		!strings.EqualFold(obj.ChangeObject.HostName, oldName) {
+		newName := strings.ToUpper(obj.ChangeObject.HostName)
+
+		if _, existsErr := srv.HostByName(newName); existsErr == nil {
+			return epp.ResponseCodeObjectExists, epp.ResponseCode2302
+		}
+
+		parent, parentErr := srv.superordinateDomain(newName)
+		if parentErr == nil && parent.SponsoringClientID != clientID {
+			return epp.ResponseCodeAuthorizationError, epp.ResponseCode2201
+		}
+
+		hos.HostName = newName
+	}
+
+	hos.UpdateClientID = clientID
+	hos.UpdateDate = time.Now().UTC()
+
+	if oldName != hos.HostName {
+		delete(srv.Hosts, oldName)
+		srv.renameDomainHosts(oldName, hos.HostName)
+	}
+
+	srv.Hosts[hos.HostName] = *hos
+
+	return epp.ResponseCodeCommandSuccessful, epp.ResponseCode1000
+}
+
+// renameDomainHosts updates every domain in the registry that references
+// the old host name to reference the new host name instead.
+func (srv *EPPServer) renameDomainHosts(oldName string, newName string) {
+	for key, dom := range srv.Domains {
+		changed := false
+		hostnames := []lib.Host{}
+
+		for _, hos := range dom.CurrentRevision.Hostnames {
+			if strings.EqualFold(hos.HostName, oldName) {
+				hos.HostName = newName
+				changed = true
+			}
+
+			hostnames = append(hostnames, hos)
+		}
+
+		if changed {
+			dom.CurrentRevision.Hostnames = hostnames
+			srv.Domains[key] = dom
+		}
+	}
+}
+
+// handleContactUpdate applies the add, remove and change sets of a
+// contact update to the contact in the registry.
+func (conn *EPPServerConnection) handleContactUpdate(msg epp.Epp) error {
+	obj := msg.CommandObject.UpdateObject.ContactUpdateObj
+
+	code, message := conn.Conf.updateContact(obj, conn.registrarID())
+
+	return conn.writeResult(msg, code, message)
+}
+
+// updateContact validates a contact update request and applies it to the
+// contact in the registry. The response code and message for the request
+// are returned.
+func (srv *EPPServer) updateContact(obj *epp.ContactUpdate, clientID string) (int, string) {
+	cont, err := srv.ContactByID(obj.ContactID)
+	if err != nil {
+		return epp.ResponseCodeObjectDoesNotExist, epp.ResponseCode2303
+	}
+
+	if cont.SponsoringClientID != clientID {
+		return epp.ResponseCodeAuthorizationError, epp.ResponseCode2201
+	}
+
+	rev := &cont.CurrentRevision
+
+	addStatuses := contactStatusList(obj.AddObject.Statuses)
+	remStatuses := contactStatusList(obj.RemoveObject.Statuses)
+
+	if rev.ServerUpdateProhibitedStatus || cont.PendingTransferStatus ||
+		(rev.ClientUpdateProhibitedStatus && !removesStatus(remStatuses, epp.StatusClientUpdateProhibited)) {
+		return epp.ResponseCodeObjectStatusProhibited, epp.ResponseCode2304
+	}
+
+	statusLookup := func(status string) *bool { return contactClientStatus(rev, status) }
+	if !applyStatusChanges(addStatuses, remStatuses, statusLookup) {
+		return epp.ResponseCodeParameterValuePolicyError, epp.ResponseCode2306
+	}
+
+	chg := obj.ChangeObject
+
+	if chg.Postal != nil {
+		setContactPostalInfo(rev, *chg.Postal)
+	}
+
+	if chg.VoiceNumber != nil {
+		rev.VoicePhoneNumber = chg.VoiceNumber.Number
+		rev.VoicePhoneExtension = chg.VoiceNumber.Extension
+	}
+
+	if chg.FaxNumber != nil {
+		rev.FaxPhoneNumber = chg.FaxNumber.Number
+		rev.FaxPhoneExtension = chg.FaxNumber.Extension
+	}
+
+	if chg.Email != "" {
+		rev.EmailAddress = chg.Email
+	}
+
+	cont.UpdateClientID = clientID
+	cont.UpdateDate = time.Now().UTC()
+
+	srv.Contacts[cont.ContactRegistryID] = *cont
+
+	if chg.AuthInfo != nil && chg.AuthInfo.Password != "" {
+		srv.ContactAuthInfo[cont.ContactRegistryID] = chg.AuthInfo.Password
+	}
+
+	return epp.ResponseCodeCommandSuccessful, epp.ResponseCode1000
+}
+
+// containsDomainHost returns true if the host name provided is in the
+// list of domain hosts passed.
+func containsDomainHost(hosts []epp.DomainHost, hostName string) bool {
+	for _, hos := range hosts {
+		if strings.EqualFold(hos.Value, hostName) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// domainStatusList converts a list of domain statuses into a list of
+// status strings.
+func domainStatusList(statuses []epp.DomainStatus) (out []string) {
+	for _, status := range statuses {
+		out = append(out, status.StatusFlag)
+	}
+
+	return out
+}
+
+// hostStatusList converts a list of host statuses into a list of status
+// strings.
+func hostStatusList(statuses []epp.HostStatus) (out []string) {
+	for _, status := range statuses {
+		out = append(out, status.StatusFlag)
+	}
+
+	return out
+}
+
+// contactStatusList converts a list of contact statuses into a list of
+// status strings.
+func contactStatusList(statuses []epp.ContactStatus) (out []string) {
+	for _, status := range statuses {
+		out = append(out, status.StatusFlag)
+	}
+
+	return out
+}
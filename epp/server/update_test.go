@@ -0,0 +1,171 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/timapril/go-registrar/epp"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestUpdateDomain(t *testing.T) {
+	t.Parallel()
+	Convey("Given a default server with a newly created domain and contact", t, func() {
+		srv := GetDefaultServer()
+		_, code, _ := srv.createContact(getTestingContactCreate("NEW-1"), "1")
+		So(code, ShouldEqual, epp.ResponseCodeCommandSuccessful)
+		_, code, _ = srv.createDomain(getTestingDomainCreate("new.com"), "1")
+		So(code, ShouldEqual, epp.ResponseCodeCommandSuccessful)
+
+		Convey("Adding statuses, hosts and contacts should be applied", func() {
+			obj := &epp.DomainUpdate{DomainName: "NEW.COM"}
+			obj.AddObject = epp.GetEPPDomainUpdateAddRemove([]string{"NS1.EXAMPLE1.COM"}, []epp.DomainContact{{Type: epp.Tech, Value: "NEW-1"}}, []string{epp.StatusClientHold})
+			obj.RemoveObject = epp.GetEPPDomainUpdateAddRemove([]string{"NS1.EXAMPLE.NET"}, []epp.DomainContact{{Type: epp.Admin, Value: "1234"}}, nil)
+			password := "newpassword"
+			obj.ChangeObject = epp.GetEPPDomainUpdateChange(nil, &password)
+
+			code, _ := srv.updateDomain(obj, "1")
+			So(code, ShouldEqual, epp.ResponseCodeCommandSuccessful)
+
+			dom, err := srv.DomainByName("NEW.COM")
+			So(err, ShouldBeNil)
+			So(dom.CurrentRevision.ClientHoldStatus, ShouldBeTrue)
+			So(dom.CurrentRevision.Hostnames, ShouldHaveLength, 1)
+			So(dom.CurrentRevision.Hostnames[0].HostName, ShouldEqual, "NS1.EXAMPLE1.COM")
+			So(dom.CurrentRevision.DomainAdminContact.ID, ShouldEqual, 0)
+			So(dom.CurrentRevision.DomainTechContact.ContactRegistryID, ShouldEqual, "NEW-1")
+			So(dom.UpdateClientID, ShouldEqual, "1")
+			So(srv.domainAuthInfo("NEW.COM"), ShouldEqual, password)
+
+			cont, err := srv.ContactByID("NEW-1")
+			So(err, ShouldBeNil)
+			So(cont.LinkedStatus, ShouldBeTrue)
+		})
+
+		Convey("Adding a status that is already set should fail without changes", func() {
+			obj := &epp.DomainUpdate{DomainName: "NEW.COM"}
+			obj.AddObject = epp.GetEPPDomainUpdateAddRemove(nil, nil, []string{epp.StatusClientHold})
+			code, _ := srv.updateDomain(obj, "1")
+			So(code, ShouldEqual, epp.ResponseCodeCommandSuccessful)
+
+			obj.AddObject = epp.GetEPPDomainUpdateAddRemove([]string{"NS1.EXAMPLE1.COM"}, nil, []string{epp.StatusClientHold})
+			code, _ = srv.updateDomain(obj, "1")
+			So(code, ShouldEqual, epp.ResponseCodeParameterValuePolicyError)
+
+			dom, err := srv.DomainByName("NEW.COM")
+			So(err, ShouldBeNil)
+			So(dom.CurrentRevision.Hostnames, ShouldHaveLength, 1)
+		})
+
+		Convey("Updating a domain sponsored by another registrar should fail", func() {
+			obj := &epp.DomainUpdate{DomainName: TestingDomainName2}
+			code, _ := srv.updateDomain(obj, "1")
+			So(code, ShouldEqual, epp.ResponseCodeAuthorizationError)
+		})
+
+		Convey("Updating a domain with clientUpdateProhibited should fail unless it is removed", func() {
+			obj := &epp.DomainUpdate{DomainName: TestingDomainName1}
+			obj.AddObject = epp.GetEPPDomainUpdateAddRemove(nil, nil, []string{epp.StatusClientHold})
+			code, _ := srv.updateDomain(obj, "1")
+			So(code, ShouldEqual, epp.ResponseCodeObjectStatusProhibited)
+
+			obj.RemoveObject = epp.GetEPPDomainUpdateAddRemove(nil, nil, []string{epp.StatusClientUpdateProhibited})
+			code, _ = srv.updateDomain(obj, "1")
+			So(code, ShouldEqual, epp.ResponseCodeCommandSuccessful)
+
+			dom, err := srv.DomainByName(TestingDomainName1)
+			So(err, ShouldBeNil)
+			So(dom.CurrentRevision.ClientUpdateProhibitedStatus, ShouldBeFalse)
+			So(dom.CurrentRevision.ClientHoldStatus, ShouldBeTrue)
+		})
+	})
+}
+
+func TestUpdateHost(t *testing.T) {
+	t.Parallel()
+	Convey("Given a default server with a newly created host", t, func() {
+		srv := GetDefaultServer()
+		obj := &epp.HostCreate{
+			HostName:  "ns2.example.com",
+			Addresses: []epp.HostAddress{{IPVersion: epp.IPv4, Address: "192.0.2.1"}},
+		}
+		_, code, _ := srv.createHost(obj, "1")
+		So(code, ShouldEqual, epp.ResponseCodeCommandSuccessful)
+
+		Convey("Changing the addresses should be applied", func() {
+			upd := &epp.HostUpdate{HostName: "NS2.EXAMPLE.COM"}
+			upd.AddObject = epp.GetEPPHostUpdateAddRemove([]epp.HostAddress{{IPVersion: epp.IPv6, Address: "2001:db8::1"}}, nil)
+			upd.RemoveObject = epp.GetEPPHostUpdateAddRemove([]epp.HostAddress{{IPVersion: epp.IPv4, Address: "192.0.2.1"}}, nil)
+
+			code, _ := srv.updateHost(upd, "1")
+			So(code, ShouldEqual, epp.ResponseCodeCommandSuccessful)
+
+			hos, err := srv.HostByName("NS2.EXAMPLE.COM")
+			So(err, ShouldBeNil)
+			So(hos.CurrentRevision.HostAddresses, ShouldHaveLength, 1)
+			So(hos.CurrentRevision.HostAddresses[0].Protocol, ShouldEqual, 6)
+		})
+
+		Convey("Renaming the host should update the domains using it", func() {
+			dom := &epp.DomainUpdate{DomainName: "NEW.COM"}
+			_, code, _ := srv.createDomain(getTestingDomainCreate("new.com"), "1")
+			So(code, ShouldEqual, epp.ResponseCodeCommandSuccessful)
+			dom.AddObject = epp.GetEPPDomainUpdateAddRemove([]string{"NS2.EXAMPLE.COM"}, nil, nil)
+			code, _ = srv.updateDomain(dom, "1")
+			So(code, ShouldEqual, epp.ResponseCodeCommandSuccessful)
+
+			chg := epp.GetEPPHostUpdateChange("ns3.example.com")
+			upd := &epp.HostUpdate{HostName: "NS2.EXAMPLE.COM", ChangeObject: &chg}
+			code, _ = srv.updateHost(upd, "1")
+			So(code, ShouldEqual, epp.ResponseCodeCommandSuccessful)
+
+			_, err := srv.HostByName("NS2.EXAMPLE.COM")
+			So(err, ShouldNotBeNil)
+			_, err = srv.HostByName("NS3.EXAMPLE.COM")
+			So(err, ShouldBeNil)
+
+			updated, err := srv.DomainByName("NEW.COM")
+			So(err, ShouldBeNil)
+			So(updated.CurrentRevision.Hostnames[1].HostName, ShouldEqual, "NS3.EXAMPLE.COM")
+		})
+
+		Convey("Updating a host with serverUpdateProhibited should fail", func() {
+			upd := &epp.HostUpdate{HostName: "NS1.EXAMPLE.NET"}
+			code, _ := srv.updateHost(upd, "1")
+			So(code, ShouldEqual, epp.ResponseCodeObjectStatusProhibited)
+		})
+	})
+}
+
+func TestUpdateContact(t *testing.T) {
+	t.Parallel()
+	Convey("Given a default server with a newly created contact", t, func() {
+		srv := GetDefaultServer()
+		_, code, _ := srv.createContact(getTestingContactCreate("NEW-1"), "1")
+		So(code, ShouldEqual, epp.ResponseCodeCommandSuccessful)
+
+		Convey("Changing the contact information and statuses should be applied", func() {
+			voice := epp.GetEPPPhoneNumber("+1.5555550000", "12")
+			upd := &epp.ContactUpdate{ContactID: "NEW-1"}
+			upd.AddObject = epp.GetEPPContactUpdateAddRemove([]string{epp.StatusClientDeleteProhibited})
+			upd.ChangeObject = epp.GetEPPContactUpdateChange(nil, &voice, nil, "new@example.com", "")
+
+			code, _ := srv.updateContact(upd, "1")
+			So(code, ShouldEqual, epp.ResponseCodeCommandSuccessful)
+
+			cont, err := srv.ContactByID("NEW-1")
+			So(err, ShouldBeNil)
+			So(cont.CurrentRevision.ClientDeleteProhibitedStatus, ShouldBeTrue)
+			So(cont.CurrentRevision.VoicePhoneNumber, ShouldEqual, "+1.5555550000")
+			So(cont.CurrentRevision.VoicePhoneExtension, ShouldEqual, "12")
+			So(cont.CurrentRevision.EmailAddress, ShouldEqual, "new@example.com")
+			So(cont.CurrentRevision.Name, ShouldEqual, "Test User")
+		})
+
+		Convey("Updating a contact sponsored by another registrar should fail", func() {
+			upd := &epp.ContactUpdate{ContactID: "8013"}
+			code, _ := srv.updateContact(upd, "1")
+			So(code, ShouldEqual, epp.ResponseCodeAuthorizationError)
+		})
+	})
+}
@@ -0,0 +1,139 @@
+package superclient
+
+import (
+	"sync"
+
+	"github.com/timapril/go-registrar/epp"
+	"github.com/timapril/go-registrar/lib"
+)
+
+// DomainAvailability holds the result of checking the availability of
+// a single domain as part of a batch.
+type DomainAvailability struct {
+	DomainName string
+	Available  bool
+	Reason     string
+	Action     lib.EPPAction
+	Err        error
+}
+
+// HostAvailability holds the result of checking the availability of a
+// single host as part of a batch.
+type HostAvailability struct {
+	HostName  string
+	Available bool
+	Action    lib.EPPAction
+	Err       error
+}
+
+// DomainInfoResult holds the result of requesting the information for
+// a single domain as part of a batch.
+type DomainInfoResult struct {
+	DomainName string
+	Info       *epp.DomainInfDataResp
+	Response   *epp.Response
+	Action     lib.EPPAction
+	Err        error
+}
+
+// HostInfoResult holds the result of requesting the information for a
+// single host as part of a batch.
+type HostInfoResult struct {
+	HostName string
+	Info     *epp.HostInfDataResp
+	Response *epp.Response
+	Action   lib.EPPAction
+	Err      error
+}
+
+// DomainsAvailable checks the availability of each of the domains
+// passed, running as many checks at the same time as there are clients
+// in the pool. The results are returned in the same order as the
+// domains were passed.
+func (sc *SuperClient) DomainsAvailable(domainNames []string) []DomainAvailability {
+	results := make([]DomainAvailability, len(domainNames))
+
+	sc.runBatch(len(domainNames), func(idx int) {
+		res := &results[idx]
+		res.DomainName = domainNames[idx]
+		res.Available, res.Reason, res.Action, res.Err = sc.DomainAvailable(domainNames[idx])
+	})
+
+	return results
+}
+
+// HostsAvailable checks the availability of each of the hosts passed,
+// running as many checks at the same time as there are clients in the
+// pool. The results are returned in the same order as the hosts were
+// passed.
+func (sc *SuperClient) HostsAvailable(hostNames []string) []HostAvailability {
+	results := make([]HostAvailability, len(hostNames))
+
+	sc.runBatch(len(hostNames), func(idx int) {
+		res := &results[idx]
+		res.HostName = hostNames[idx]
+		res.Available, res.Action, res.Err = sc.HostAvailable(hostNames[idx])
+	})
+
+	return results
+}
+
+// DomainInfos requests the information for each of the domains passed,
+// running as many requests at the same time as there are clients in the
+// pool. The results are returned in the same order as the domains were
+// passed.
+func (sc *SuperClient) DomainInfos(domainNames []string, hosts epp.DomainInfoHosts) []DomainInfoResult {
+	results := make([]DomainInfoResult, len(domainNames))
+
+	sc.runBatch(len(domainNames), func(idx int) {
+		res := &results[idx]
+		res.DomainName = domainNames[idx]
+		res.Info, res.Response, res.Action, res.Err = sc.DomainInfo(domainNames[idx], hosts, nil)
+	})
+
+	return results
+}
+
+// HostInfos requests the information for each of the hosts passed,
+// running as many requests at the same time as there are clients in the
+// pool. The results are returned in the same order as the hosts were
+// passed.
+func (sc *SuperClient) HostInfos(hostNames []string) []HostInfoResult {
+	results := make([]HostInfoResult, len(hostNames))
+
+	sc.runBatch(len(hostNames), func(idx int) {
+		res := &results[idx]
+		res.HostName = hostNames[idx]
+		res.Info, res.Response, res.Action, res.Err = sc.HostInfo(hostNames[idx])
+	})
+
+	return results
+}
+
+// runBatch calls the work function once for each index from zero up to
+// the count passed, using one goroutine per client in the pool, and
+// blocks until all of the work has completed.
+func (sc *SuperClient) runBatch(count int, work func(idx int)) {
+	var wg sync.WaitGroup
+
+	indexes := make(chan int)
+
+	for range min(len(sc.clients), count) {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for idx := range indexes {
+				work(idx)
+			}
+		}()
+	}
+
+	for idx := range count {
+		indexes <- idx
+	}
+
+	close(indexes)
+	wg.Wait()
+}
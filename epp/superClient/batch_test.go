@@ -0,0 +1,147 @@
+package superclient
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	logging "github.com/op/go-logging"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/timapril/go-registrar/epp"
+	"github.com/timapril/go-registrar/epp/client"
+	"github.com/timapril/go-registrar/epp/server"
+	"github.com/timapril/go-registrar/lib"
+)
+
+// startTestingServer starts an EPP server on a random port and returns a
+// client config that can be used to log in to it as registrar "1". The
+// login "other" may be used to log in as registrar "2".
+func startTestingServer(t *testing.T) client.Config {
+	t.Helper()
+
+	srv := server.NewEPPServer("127.0.0.1", 0, time.Minute)
+	srv.Logins["username"] = server.LoginObject{LoginID: "username", Password: "password", RegistrarID: "1"}
+	srv.Logins["other"] = server.LoginObject{LoginID: "other", Password: "password", RegistrarID: "2"}
+
+	go func() {
+		_ = srv.Start()
+	}()
+
+	<-srv.Running
+
+	t.Cleanup(func() {
+		srv.KillChan <- true
+	})
+
+	return client.Config{
+		Host:               "127.0.0.1",
+		Port:               int64(srv.Addr().(*net.TCPAddr).Port),
+		Username:           "username",
+		Password:           "password",
+		TransactionPrefix:  "TEST-",
+		TransactionStartID: 1,
+	}
+}
+
+func TestSuperClientPool(t *testing.T) {
+	t.Parallel()
+	Convey("Given a super client with a pool of three sessions", t, func() {
+		conf := startTestingServer(t)
+		conf.MaxSessions = 3
+
+		var lock sync.Mutex
+
+		var logins []lib.EPPAction
+
+		sc, err := NewSuperClient(conf, logging.MustGetLogger("superclient"), func(action lib.EPPAction) {
+			lock.Lock()
+			defer lock.Unlock()
+
+			logins = append(logins, action)
+		}, func(lib.EPPAction) {})
+		So(err, ShouldBeNil)
+
+		Convey("Each session should have logged in with its own transaction ID", func() {
+			So(logins, ShouldHaveLength, 3)
+			So(logins[0].ClientTransactionID, ShouldNotEqual, logins[1].ClientTransactionID)
+			So(logins[1].ClientTransactionID, ShouldNotEqual, logins[2].ClientTransactionID)
+			So(logins[0].ClientTransactionID, ShouldNotEqual, logins[2].ClientTransactionID)
+		})
+
+		Convey("Checking a batch of domains should return results in order with unique transaction IDs", func() {
+			var names []string
+			for idx := range 10 {
+				names = append(names, fmt.Sprintf("DOMAIN%d.EXAMPLE", idx))
+			}
+
+			results := sc.DomainsAvailable(names)
+			So(results, ShouldHaveLength, len(names))
+
+			txids := make(map[string]bool)
+
+			for idx, res := range results {
+				So(res.Err, ShouldBeNil)
+				So(res.DomainName, ShouldEqual, names[idx])
+				So(res.Available, ShouldBeTrue)
+				So(res.Action.ClientTransactionID, ShouldNotBeEmpty)
+
+				txids[res.Action.ClientTransactionID] = true
+			}
+
+			So(txids, ShouldHaveLength, len(names))
+		})
+
+		Convey("Getting the information for a batch of domains should report each result", func() {
+			for _, name := range []string{"ONE.EXAMPLE", "TWO.EXAMPLE"} {
+				_, _, err := sc.DomainCreate(name, 1)
+				So(err, ShouldBeNil)
+			}
+
+			results := sc.DomainInfos([]string{"ONE.EXAMPLE", "MISSING.EXAMPLE", "TWO.EXAMPLE"}, epp.DomainInfoHostsAll)
+			So(results, ShouldHaveLength, 3)
+
+			So(results[0].Err, ShouldBeNil)
+			So(results[0].Info.Name, ShouldEqual, "ONE.EXAMPLE")
+			So(results[1].Err, ShouldNotBeNil)
+			So(results[1].Action.ResponseCode, ShouldEqual, epp.ResponseCodeObjectDoesNotExist)
+			So(results[2].Err, ShouldBeNil)
+			So(results[2].Info.Name, ShouldEqual, "TWO.EXAMPLE")
+		})
+	})
+}
+
+func TestSuperClientDiscardSession(t *testing.T) {
+	t.Parallel()
+	Convey("Given a super client with a single session", t, func() {
+		conf := startTestingServer(t)
+		conf.MaxSessions = 1
+
+		sc, err := NewSuperClient(conf, logging.MustGetLogger("superclient"), func(lib.EPPAction) {}, func(lib.EPPAction) {})
+		So(err, ShouldBeNil)
+
+		original := sc.clients[0]
+
+		Convey("A command that times out should replace the session", func() {
+			sc.Timeout = time.Nanosecond
+
+			for attempt := 0; attempt < 10 && err != ErrResponseTimeout; attempt++ {
+				_, _, _, err = sc.DomainAvailable("LATE.EXAMPLE")
+			}
+			So(err, ShouldEqual, ErrResponseTimeout)
+
+			sc.Timeout = superclientTimeout
+
+			So(sc.clients, ShouldHaveLength, 1)
+			So(sc.clients[0] == original, ShouldBeFalse)
+
+			Convey("The late response should not be read as the response to the next command", func() {
+				avail, _, _, err := sc.DomainAvailable("NEXT.EXAMPLE")
+				So(err, ShouldBeNil)
+				So(avail, ShouldBeTrue)
+			})
+		})
+	})
+}
@@ -0,0 +1,436 @@
+package superclient
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/timapril/go-registrar/epp"
+	"github.com/timapril/go-registrar/lib"
+)
+
+// ContactAvailable takes a contact ID and will run a check command with
+// the epp server and return true iff the contact ID is available. If an
+// error occures during the process, an error is returned and the
+// availablility will be false. This function times out after the
+// timeout defined by the server without a response.
+func (sc *SuperClient) ContactAvailable(contactID string) (bool, lib.EPPAction, error) {
+	action := lib.NewEPPAction(sc.getTransactionID())
+	action.SetAction(lib.EPPLogActionContactAvailable, contactID)
+
+	msg := epp.GetEPPContactCheck(contactID, action.ClientTransactionID)
+
+	session := sc.acquireSession()
+	defer sc.releaseSession(session)
+
+	session.NewWork <- msg
+	timeout := sc.getTimeout()
+
+	select {
+	case respMsg := <-session.WorkResponse:
+		err := action.HandleResponse(respMsg)
+		if err != nil {
+			return false, action, fmt.Errorf("unexpected epp error: %w", err)
+		}
+
+		if respMsg.MessageType() == epp.ResponseContactCheckType {
+			contacts := respMsg.ResponseObject.ResultData.ContactChkDataResp.ContactIDs
+			for _, contact := range contacts {
+				if contact.ID.Value == contactID {
+					if contact.ID.Available == 1 {
+						action.AddNote("Available")
+
+						return true, action, nil
+					} else if contact.ID.Available == 0 {
+						action.AddNote("Not Available")
+
+						return false, action, nil
+					}
+
+					action.SetError(ErrUnknownCheckAvailableReturn)
+
+					return false, action, ErrUnknownCheckAvailableReturn
+				}
+			}
+		} else {
+			if respMsg.ResponseObject != nil {
+				if respMsg.ResponseObject.IsError() {
+					return false, action, fmt.Errorf("EPP error: %w", respMsg.ResponseObject.GetError())
+				}
+			}
+		}
+
+	case <-timeout:
+		sc.discardSession(session)
+		action.SetError(ErrResponseTimeout)
+
+		return false, action, ErrResponseTimeout
+	}
+
+	action.SetError(ErrInvalidContactReturned)
+
+	return false, action, ErrInvalidContactReturned
+}
+
+// ContactInfo takes a contact ID and will attempt to send a contact info
+// request to the epp server. If the contact is not sponsored by the
+// registrar, the authInfo value may be provided to see the full object.
+// If no error occurs, the contact info response object will be
+// returned, otherwise an error will be returned.
+func (sc *SuperClient) ContactInfo(contactID string, authInfo *string) (*epp.ContactInfDataResp, *epp.Response, lib.EPPAction, error) {
+	action := lib.NewEPPAction(sc.getTransactionID())
+	action.SetAction(lib.EPPLogActionContactInfo, contactID)
+
+	var msg epp.Epp
+
+	if authInfo != nil {
+		action.AddNote("AuthInfo value is set")
+		msg = epp.GetEPPContactInfo(contactID, action.ClientTransactionID, *authInfo)
+	} else {
+		action.AddNote("AuthInfo value not is set")
+		msg = epp.GetEPPContactInfo(contactID, action.ClientTransactionID, "")
+	}
+
+	session := sc.acquireSession()
+	defer sc.releaseSession(session)
+
+	session.NewWork <- msg
+	timeout := sc.getTimeout()
+
+	select {
+	case respMsg := <-session.WorkResponse:
+		err := action.HandleResponse(respMsg)
+		if err != nil {
+			return nil, nil, action, fmt.Errorf("unexpected epp error: %w", err)
+		}
+
+		if respMsg.MessageType() == epp.ResponseContactInfoType {
+			contactInfo := respMsg.ResponseObject.ResultData.ContactInfDataResp
+			if contactInfo.ID == contactID {
+				return contactInfo, respMsg.ResponseObject, action, nil
+			}
+		} else {
+			if respMsg.ResponseObject != nil {
+				if respMsg.ResponseObject.IsError() {
+					return nil, nil, action, fmt.Errorf("EPP error: %w", respMsg.ResponseObject.GetError())
+				}
+			}
+		}
+
+	case <-timeout:
+		sc.discardSession(session)
+		action.SetError(ErrResponseTimeout)
+
+		return nil, nil, action, ErrResponseTimeout
+	}
+
+	action.SetError(ErrInvalidContactReturned)
+
+	return nil, nil, action, ErrInvalidContactReturned
+}
+
+// ContactCreate takes a contact ID along with the postal information,
+// email address and phone numbers for the contact and will attempt to
+// create the contact object with a new random authInfo value. If the
+// contact creation fails an error and response code will be returned.
+func (sc *SuperClient) ContactCreate(contactID string, postalInfo epp.PostalInfo, email string, voice epp.PhoneNumber, fax epp.PhoneNumber) (responseCode int, action lib.EPPAction, err error) {
+	authInfo, aiErr := GetAuthInfo()
+	if aiErr != nil {
+		return 0, action, fmt.Errorf("unexpected authinfo error: %w", aiErr)
+	}
+
+	action = lib.NewEPPAction(sc.getTransactionID())
+	action.SetAction(lib.EPPLogActionContactCreate, contactID)
+	action.AddNote(fmt.Sprintf("Name: %s", postalInfo.Name))
+	action.AddNote(fmt.Sprintf("Email: %s", email))
+
+	msg := epp.GetEPPContactCreate(contactID, postalInfo, email, voice, fax, authInfo, action.ClientTransactionID)
+
+	return sc.expect1000Response(msg, &action)
+}
+
+// ContactChanges holds the changes to be made to a contact object by
+// ContactUpdate. Fields that are nil are left unchanged.
+type ContactChanges struct {
+	PostalInfo *epp.PostalInfo
+	Voice      *epp.PhoneNumber
+	Fax        *epp.PhoneNumber
+	Email      *string
+	AuthInfo   *string
+
+	AddStatuses    []string
+	RemoveStatuses []string
+}
+
+// IsEmpty returns true if there are no changes to be made.
+func (c ContactChanges) IsEmpty() bool {
+	return c.PostalInfo == nil && c.Voice == nil && c.Fax == nil &&
+		c.Email == nil && c.AuthInfo == nil &&
+		len(c.AddStatuses) == 0 && len(c.RemoveStatuses) == 0
+}
+
+// GetContactChanges compares the contact information returned by the
+// registry with the desired values provided and returns the changes
+// required to make the registry match. Only client settable statuses
+// are considered when comparing the statuses, desiredStatuses should
+// contain all of the client statuses that the contact should have. If
+// authInfo is empty the authInfo of the contact is left unchanged.
+func GetContactChanges(current *epp.ContactInfDataResp, postalInfo epp.PostalInfo, email string, voice epp.PhoneNumber, fax epp.PhoneNumber, authInfo string, desiredStatuses []string) ContactChanges {
+	changes := ContactChanges{}
+
+	if len(current.PostalInfos) == 0 || !postalInfoEqual(current.PostalInfos[0], postalInfo) {
+		changes.PostalInfo = &postalInfo
+	}
+
+	if current.Voice != voice {
+		changes.Voice = &voice
+	}
+
+	if current.Fax != fax {
+		changes.Fax = &fax
+	}
+
+	if current.Email != email {
+		changes.Email = &email
+	}
+
+	if authInfo != "" && (current.AuthPW == nil || current.AuthPW.Password != authInfo) {
+		changes.AuthInfo = &authInfo
+	}
+
+	var currentStatuses []string
+
+	for _, status := range current.Status {
+		currentStatuses = append(currentStatuses, status.StatusFlag)
+
+		if strings.HasPrefix(status.StatusFlag, "client") && !slices.Contains(desiredStatuses, status.StatusFlag) {
+			changes.RemoveStatuses = append(changes.RemoveStatuses, status.StatusFlag)
+		}
+	}
+
+	for _, status := range desiredStatuses {
+		if !slices.Contains(currentStatuses, status) {
+			changes.AddStatuses = append(changes.AddStatuses, status)
+		}
+	}
+
+	return changes
+}
+
+// GetContactAuthInfo returns the authInfo that the contact should have
+// at the registry. The current authInfo is kept if it is valid,
+// otherwise a new random authInfo value is generated.
+func GetContactAuthInfo(current *epp.ContactInfDataResp) (string, error) {
+	if current.AuthPW != nil && IsValidAuthInfo(current.AuthPW.Password) {
+		return current.AuthPW.Password, nil
+	}
+
+	authInfo, err := GetAuthInfo()
+	if err != nil {
+		return "", fmt.Errorf("unexpected authinfo error: %w", err)
+	}
+
+	return authInfo, nil
+}
+
+// postalInfoEqual returns true if the two postal info objects passed
+// hold the same postal information.
+func postalInfoEqual(left epp.PostalInfo, right epp.PostalInfo) bool {
+	return left.PostalInfoType == right.PostalInfoType &&
+		left.Name == right.Name &&
+		left.Org == right.Org &&
+		slices.Equal(left.Address.Street, right.Address.Street) &&
+		left.Address.City == right.Address.City &&
+		left.Address.Sp == right.Address.Sp &&
+		left.Address.Pc == right.Address.Pc &&
+		left.Address.Cc == right.Address.Cc
+}
+
+// ContactUpdate will take a contact ID and the changes to make to the
+// contact and attempt to send the update to the registry. If there are
+// no changes, no request is sent. If an error occurs, the response code
+// and error will be returned.
+func (sc *SuperClient) ContactUpdate(contactID string, changes ContactChanges) (responseCode int, action lib.EPPAction, err error) {
+	action = lib.NewEPPAction(sc.getTransactionID())
+	action.SetAction(lib.EPPLogActionContactUpdate, contactID)
+
+	if changes.IsEmpty() {
+		return 0, action, nil
+	}
+
+	if changes.PostalInfo != nil {
+		action.AddNote(fmt.Sprintf("Change Postal Info: %s", changes.PostalInfo.Name))
+	}
+
+	if changes.Voice != nil {
+		action.AddNote(fmt.Sprintf("Change Voice: %s", changes.Voice.Number))
+	}
+
+	if changes.Fax != nil {
+		action.AddNote(fmt.Sprintf("Change Fax: %s", changes.Fax.Number))
+	}
+
+	email := ""
+	if changes.Email != nil {
+		email = *changes.Email
+		action.AddNote(fmt.Sprintf("Change Email: %s", email))
+	}
+
+	authInfo := ""
+	if changes.AuthInfo != nil {
+		authInfo = *changes.AuthInfo
+		action.AddNote("Change AuthInfo")
+	}
+
+	for _, status := range changes.AddStatuses {
+		action.AddNote(fmt.Sprintf("Add Status: %s", status))
+	}
+
+	for _, status := range changes.RemoveStatuses {
+		action.AddNote(fmt.Sprintf("Remove Status: %s", status))
+	}
+
+	add := epp.GetEPPContactUpdateAddRemove(changes.AddStatuses)
+	rem := epp.GetEPPContactUpdateAddRemove(changes.RemoveStatuses)
+	chg := epp.GetEPPContactUpdateChange(changes.PostalInfo, changes.Voice, changes.Fax, email, authInfo)
+
+	msg := epp.GetEPPContactUpdate(contactID, add, rem, chg, action.ClientTransactionID)
+
+	return sc.expect1000Response(msg, &action)
+}
+
+// ContactDelete takes a contact ID and will attempt to delete the
+// contact object from the registry. In the event of an error processing
+// the delete request, the error code and the error body will be
+// returned.
+func (sc *SuperClient) ContactDelete(contactID string) (responseCode int, action lib.EPPAction, err error) {
+	action = lib.NewEPPAction(sc.getTransactionID())
+	action.SetAction(lib.EPPLogActionContactDelete, contactID)
+
+	msg := epp.GetEPPContactDelete(contactID, action.ClientTransactionID)
+
+	return sc.expect1000Response(msg, &action)
+}
+
+// RequestContactTransfer will take a contact ID and the authorization
+// info password and attempt to request a transfer of the contact. If
+// the transfer request does not succeed, a response code and an error
+// will be returned otherwise the contact transfer object will returned.
+func (sc *SuperClient) RequestContactTransfer(contactID string, authInfo string) (trResp *epp.ContactTrnDataResp, responseCode int, action lib.EPPAction, err error) {
+	action = lib.NewEPPAction(sc.getTransactionID())
+	action.SetAction(lib.EPPLogActionContactTransferRequest, contactID)
+
+	msg := epp.GetEPPContactTransferRequest(contactID, authInfo, action.ClientTransactionID)
+	if sc.recordDryRun(msg, &action) {
+		return nil, action.ResponseCode, action, nil
+	}
+
+	session := sc.acquireSession()
+	defer sc.releaseSession(session)
+
+	session.NewWork <- msg
+	timeout := sc.getTimeout()
+
+	select {
+	case respMsg := <-session.WorkResponse:
+		err := action.HandleResponse(respMsg)
+		if err != nil {
+			return nil, 0, action, fmt.Errorf("unexpected epp error: %w", err)
+		}
+
+		switch respMsg.MessageType() {
+		case epp.ResponseContactTransferType:
+			return respMsg.ResponseObject.ResultData.ContactTrnDataResp, respMsg.ResponseObject.Result.Code, action, nil
+		case epp.ResponseType:
+			return nil, respMsg.ResponseObject.Result.Code, action, fmt.Errorf("EPP error: %w", respMsg.ResponseObject.GetError())
+		}
+
+		err = ErrUnexpectedReponse
+
+		action.SetError(err)
+
+		return nil, 0, action, err
+	case <-timeout:
+		sc.discardSession(session)
+		action.SetError(ErrResponseTimeout)
+
+		return nil, 0, action, ErrResponseTimeout
+	}
+}
+
+// QueryContactTransfer takes a contact ID and will query its transfer
+// status and return the contact transfer object if it exists, otherwise
+// an error is returned.
+func (sc *SuperClient) QueryContactTransfer(contactID string) (trResp *epp.ContactTrnDataResp, action lib.EPPAction, err error) {
+	action = lib.NewEPPAction(sc.getTransactionID())
+	action.SetAction(lib.EPPLogActionContactTransferQuery, contactID)
+
+	msg := epp.GetEPPContactTransferQuery(contactID, action.ClientTransactionID)
+
+	session := sc.acquireSession()
+	defer sc.releaseSession(session)
+
+	session.NewWork <- msg
+	timeout := sc.getTimeout()
+
+	select {
+	case respMsg := <-session.WorkResponse:
+		err := action.HandleResponse(respMsg)
+		if err != nil {
+			return nil, action, fmt.Errorf("unexpected epp error: %w", err)
+		}
+
+		if respMsg.MessageType() == epp.ResponseContactTransferType {
+			return respMsg.ResponseObject.ResultData.ContactTrnDataResp, action, nil
+		}
+
+		err = ErrUnexpectedReponse
+
+		action.SetError(err)
+
+		return nil, action, err
+	case <-timeout:
+		sc.discardSession(session)
+		action.SetError(ErrResponseTimeout)
+
+		return nil, action, ErrResponseTimeout
+	}
+}
+
+// ApproveContactTransfer takes a contact ID and will attempt to approve
+// the pending transfer request for that contact. If the transfer
+// approval does not succeed, an error and response code will be
+// returned.
+func (sc *SuperClient) ApproveContactTransfer(contactID string) (responseCode int, action lib.EPPAction, err error) {
+	action = lib.NewEPPAction(sc.getTransactionID())
+	action.SetAction(lib.EPPLogActionContactTransferApprove, contactID)
+
+	msg := epp.GetEPPContactTransferApprove(contactID, action.ClientTransactionID)
+
+	return sc.expect1000Response(msg, &action)
+}
+
+// RejectContactTransfer takes a contact ID and will attempt to reject
+// the pending transfer request for that contact. If the transfer reject
+// does not succeed an error and response code will be returned.
+func (sc *SuperClient) RejectContactTransfer(contactID string) (responseCode int, action lib.EPPAction, err error) {
+	action = lib.NewEPPAction(sc.getTransactionID())
+	action.SetAction(lib.EPPLogActionContactTransferReject, contactID)
+
+	msg := epp.GetEPPContactTransferReject(contactID, action.ClientTransactionID)
+
+	return sc.expect1000Response(msg, &action)
+}
+
+// CancelContactTransfer takes a contact ID and will attempt to cancel a
+// transfer request for the contact that was made by this registrar. If
+// the cancel does not succeed an error and response code will be
+// returned.
+func (sc *SuperClient) CancelContactTransfer(contactID string) (responseCode int, action lib.EPPAction, err error) {
+	action = lib.NewEPPAction(sc.getTransactionID())
+	action.SetAction(lib.EPPLogActionContactTransferCancel, contactID)
+
+	msg := epp.GetEPPContactTransferCancel(contactID, action.ClientTransactionID)
+
+	return sc.expect1000Response(msg, &action)
+}
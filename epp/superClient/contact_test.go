@@ -0,0 +1,164 @@
+package superclient
+
+import (
+	"testing"
+
+	logging "github.com/op/go-logging"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/timapril/go-registrar/epp"
+	"github.com/timapril/go-registrar/lib"
+)
+
+// getTestingPostalInfo returns a postal info object for use in tests.
+func getTestingPostalInfo(name string) epp.PostalInfo {
+	return epp.GetEPPPostalInfo("int", name, "Example Org", "123 Main St", "", "", "Springfield", "VA", "22150", "US")
+}
+
+func TestGetContactChanges(t *testing.T) {
+	t.Parallel()
+	Convey("Given the registry information for a contact", t, func() {
+		current := &epp.ContactInfDataResp{
+			ID:          "C1",
+			PostalInfos: []epp.PostalInfo{getTestingPostalInfo("Jane Doe")},
+			Voice:       epp.GetEPPPhoneNumber("+1.5555551234", ""),
+			Email:       "jane@example.com",
+			Status: []epp.ContactStatus{
+				{StatusFlag: epp.StatusClientDeleteProhibited},
+				{StatusFlag: epp.StatusServerUpdateProhibited},
+			},
+		}
+
+		Convey("Matching values should produce no changes", func() {
+			changes := GetContactChanges(current, getTestingPostalInfo("Jane Doe"), "jane@example.com", epp.GetEPPPhoneNumber("+1.5555551234", ""), epp.PhoneNumber{}, "", []string{epp.StatusClientDeleteProhibited})
+			So(changes.IsEmpty(), ShouldBeTrue)
+		})
+
+		Convey("Differing values should only include the fields that changed", func() {
+			changes := GetContactChanges(current, getTestingPostalInfo("Jane Smith"), "jane@example.com", epp.GetEPPPhoneNumber("+1.5555550000", ""), epp.PhoneNumber{}, "", []string{epp.StatusClientUpdateProhibited})
+			So(changes.IsEmpty(), ShouldBeFalse)
+			So(changes.PostalInfo.Name, ShouldEqual, "Jane Smith")
+			So(changes.Voice.Number, ShouldEqual, "+1.5555550000")
+			So(changes.Fax, ShouldBeNil)
+			So(changes.Email, ShouldBeNil)
+			So(changes.AddStatuses, ShouldResemble, []string{epp.StatusClientUpdateProhibited})
+			So(changes.RemoveStatuses, ShouldResemble, []string{epp.StatusClientDeleteProhibited})
+		})
+
+		Convey("A differing authInfo should be changed", func() {
+			current.AuthPW = &epp.ContactAuth{Password: "Old-Pass1"}
+			changes := GetContactChanges(current, getTestingPostalInfo("Jane Doe"), "jane@example.com", epp.GetEPPPhoneNumber("+1.5555551234", ""), epp.PhoneNumber{}, "New-Pass2", []string{epp.StatusClientDeleteProhibited})
+			So(changes.IsEmpty(), ShouldBeFalse)
+			So(*changes.AuthInfo, ShouldEqual, "New-Pass2")
+
+			changes = GetContactChanges(current, getTestingPostalInfo("Jane Doe"), "jane@example.com", epp.GetEPPPhoneNumber("+1.5555551234", ""), epp.PhoneNumber{}, "Old-Pass1", []string{epp.StatusClientDeleteProhibited})
+			So(changes.IsEmpty(), ShouldBeTrue)
+		})
+
+		Convey("A missing authInfo should be replaced with a valid one", func() {
+			current.AuthPW = nil
+			authInfo, err := GetContactAuthInfo(current)
+			So(err, ShouldBeNil)
+			So(IsValidAuthInfo(authInfo), ShouldBeTrue)
+
+			changes := GetContactChanges(current, getTestingPostalInfo("Jane Doe"), "jane@example.com", epp.GetEPPPhoneNumber("+1.5555551234", ""), epp.PhoneNumber{}, authInfo, []string{epp.StatusClientDeleteProhibited})
+			So(*changes.AuthInfo, ShouldEqual, authInfo)
+
+			current.AuthPW = &epp.ContactAuth{Password: authInfo}
+			kept, err := GetContactAuthInfo(current)
+			So(err, ShouldBeNil)
+			So(kept, ShouldEqual, authInfo)
+		})
+	})
+}
+
+func TestSuperClientContacts(t *testing.T) {
+	t.Parallel()
+	Convey("Given a super client connected to a server", t, func() {
+		conf := startTestingServer(t)
+		log := logging.MustGetLogger("superclient")
+
+		sc, err := NewSuperClient(conf, log, func(lib.EPPAction) {}, func(lib.EPPAction) {})
+		So(err, ShouldBeNil)
+
+		voice := epp.GetEPPPhoneNumber("+1.5555551234", "")
+
+		Convey("A contact should be able to be created, updated and deleted", func() {
+			avail, action, err := sc.ContactAvailable("C1")
+			So(err, ShouldBeNil)
+			So(avail, ShouldBeTrue)
+			So(action.Action, ShouldEqual, lib.EPPLogActionContactAvailable)
+
+			_, action, err = sc.ContactCreate("C1", getTestingPostalInfo("Jane Doe"), "jane@example.com", voice, epp.PhoneNumber{})
+			So(err, ShouldBeNil)
+			So(action.Successful, ShouldBeTrue)
+
+			avail, _, err = sc.ContactAvailable("C1")
+			So(err, ShouldBeNil)
+			So(avail, ShouldBeFalse)
+
+			info, _, _, err := sc.ContactInfo("C1", nil)
+			So(err, ShouldBeNil)
+			So(info.Email, ShouldEqual, "jane@example.com")
+
+			changes := GetContactChanges(info, getTestingPostalInfo("Jane Smith"), "jane.smith@example.com", voice, epp.PhoneNumber{}, "", []string{epp.StatusClientDeleteProhibited})
+			code, action, err := sc.ContactUpdate("C1", changes)
+			So(err, ShouldBeNil)
+			So(code, ShouldEqual, epp.ResponseCodeCommandSuccessful)
+			So(action.Notes, ShouldContainSubstring, "Change Email: jane.smith@example.com")
+
+			info, _, _, err = sc.ContactInfo("C1", nil)
+			So(err, ShouldBeNil)
+			So(info.Email, ShouldEqual, "jane.smith@example.com")
+			So(info.PostalInfos[0].Name, ShouldEqual, "Jane Smith")
+
+			changes = GetContactChanges(info, getTestingPostalInfo("Jane Smith"), "jane.smith@example.com", voice, epp.PhoneNumber{}, "", []string{epp.StatusClientDeleteProhibited})
+			So(changes.IsEmpty(), ShouldBeTrue)
+
+			code, _, err = sc.ContactUpdate("C1", changes)
+			So(err, ShouldBeNil)
+			So(code, ShouldEqual, 0)
+
+			_, _, err = sc.ContactDelete("C1")
+			So(err, ShouldNotBeNil)
+
+			_, _, err = sc.ContactUpdate("C1", ContactChanges{RemoveStatuses: []string{epp.StatusClientDeleteProhibited}})
+			So(err, ShouldBeNil)
+
+			_, action, err = sc.ContactDelete("C1")
+			So(err, ShouldBeNil)
+			So(action.Action, ShouldEqual, lib.EPPLogActionContactDelete)
+		})
+
+		Convey("A contact should be able to be transferred to another registrar", func() {
+			_, _, err := sc.ContactCreate("C2", getTestingPostalInfo("John Doe"), "john@example.com", voice, epp.PhoneNumber{})
+			So(err, ShouldBeNil)
+
+			info, _, _, err := sc.ContactInfo("C2", nil)
+			So(err, ShouldBeNil)
+			So(info.AuthPW, ShouldNotBeNil)
+
+			otherConf := conf
+			otherConf.Username = "other"
+			other, err := NewSuperClient(otherConf, log, func(lib.EPPAction) {}, func(lib.EPPAction) {})
+			So(err, ShouldBeNil)
+
+			trn, code, _, err := other.RequestContactTransfer("C2", info.AuthPW.Password)
+			So(err, ShouldBeNil)
+			So(code, ShouldEqual, epp.ResponseCodeCommandSuccessfulPending)
+			So(trn.TrStatus, ShouldEqual, "pending")
+
+			trn, _, err = sc.QueryContactTransfer("C2")
+			So(err, ShouldBeNil)
+			So(trn.ReID, ShouldEqual, "2")
+
+			_, action, err := sc.ApproveContactTransfer("C2")
+			So(err, ShouldBeNil)
+			So(action.Action, ShouldEqual, lib.EPPLogActionContactTransferApprove)
+
+			trn, _, err = other.QueryContactTransfer("C2")
+			So(err, ShouldBeNil)
+			So(trn.TrStatus, ShouldEqual, "clientApproved")
+		})
+	})
+}
@@ -0,0 +1,99 @@
+package superclient
+
+import (
+	"fmt"
+
+	"github.com/timapril/go-registrar/epp"
+	"github.com/timapril/go-registrar/lib"
+)
+
+// DryRunNote is added to the action of every command that was recorded
+// during a dry run rather than being sent to the registry.
+const DryRunNote = "Dry run: command was planned but not sent to the registry"
+
+// SetDryRun enables or disables dry run mode. While a recorder is set,
+// commands that would change the state of the registry are passed to
+// the recorder and reported as successful instead of being sent.
+// Commands that only read from the registry are still sent. Passing nil
+// disables dry run mode.
+func (sc *SuperClient) SetDryRun(recorder func(msg epp.Epp, action lib.EPPAction)) {
+	sc.dryRunRecorder = recorder
+}
+
+// IsDryRun returns true if the client is currently in dry run mode.
+func (sc *SuperClient) IsDryRun() bool {
+	return sc.dryRunRecorder != nil
+}
+
+// recordDryRun will pass the message and action to the dry run recorder
+// if dry run mode is enabled and return true, otherwise false is
+// returned and the message should be sent.
+func (sc *SuperClient) recordDryRun(msg epp.Epp, action *lib.EPPAction) bool {
+	if sc.dryRunRecorder == nil {
+		return false
+	}
+
+	action.ResponseCode = epp.ResponseCodeCommandSuccessful
+	action.AddNote(DryRunNote)
+
+	sc.dryRunRecorder(msg, *action)
+
+	return true
+}
+
+// SendPlanned takes the XML of a command that was recorded during a dry
+// run and sends it to the registry with a new transaction ID. The
+// action name and arguments are used for the action log. If the
+// registry does not report success, the response code and an error are
+// returned.
+func (sc *SuperClient) SendPlanned(message []byte, actionName string, args string) (responseCode int, action lib.EPPAction, err error) {
+	action = lib.NewEPPAction(sc.getTransactionID())
+	action.SetAction(actionName, args)
+
+	parsed, err := epp.UnmarshalMessage(message)
+	if err != nil {
+		action.SetError(err)
+
+		return 0, action, fmt.Errorf("error parsing planned command: %w", err)
+	}
+
+	msg := parsed.TypedMessage()
+	if msg.CommandObject == nil {
+		action.SetError(ErrUnexpectedReponse)
+
+		return 0, action, fmt.Errorf("planned command is not an EPP command: %w", ErrUnexpectedReponse)
+	}
+
+	msg.CommandObject.TransactionID = action.ClientTransactionID
+
+	session := sc.acquireSession()
+	defer sc.releaseSession(session)
+
+	session.NewWork <- msg
+	timeout := sc.getTimeout()
+
+	select {
+	case respMsg := <-session.WorkResponse:
+		err := action.HandleResponse(respMsg)
+		if err != nil {
+			return 0, action, fmt.Errorf("unexpected epp error: %w", err)
+		}
+
+		if respMsg.ResponseObject != nil && respMsg.ResponseObject.Result != nil {
+			if respMsg.ResponseObject.IsError() {
+				return respMsg.ResponseObject.Result.Code, action, fmt.Errorf("EPP error: %w", respMsg.ResponseObject.GetError())
+			}
+
+			return respMsg.ResponseObject.Result.Code, action, nil
+		}
+	case <-timeout:
+		sc.discardSession(session)
+		action.SetError(ErrResponseTimeout)
+
+		return 0, action, ErrResponseTimeout
+	}
+
+	action.SetError(ErrUnhandledResponse)
+
+	return 0, action, ErrUnhandledResponse
+}
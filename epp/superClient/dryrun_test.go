@@ -0,0 +1,87 @@
+package superclient
+
+import (
+	"testing"
+	"time"
+
+	logging "github.com/op/go-logging"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/timapril/go-registrar/epp"
+	"github.com/timapril/go-registrar/lib"
+)
+
+func TestSuperClientDryRun(t *testing.T) {
+	t.Parallel()
+	Convey("Given a super client in dry run mode", t, func() {
+		conf := startTestingServer(t)
+
+		sc, err := NewSuperClient(conf, logging.MustGetLogger("superclient"), func(lib.EPPAction) {}, func(lib.EPPAction) {})
+		So(err, ShouldBeNil)
+
+		var recorded []epp.Epp
+
+		var actions []lib.EPPAction
+
+		sc.SetDryRun(func(msg epp.Epp, action lib.EPPAction) {
+			recorded = append(recorded, msg)
+			actions = append(actions, action)
+		})
+		So(sc.IsDryRun(), ShouldBeTrue)
+
+		Convey("Creating a domain should be recorded and not sent", func() {
+			rc, action, err := sc.DomainCreate("PLANNED.EXAMPLE", 1)
+			So(err, ShouldBeNil)
+			So(rc, ShouldEqual, epp.ResponseCodeCommandSuccessful)
+			So(action.Notes, ShouldContainSubstring, DryRunNote)
+			So(recorded, ShouldHaveLength, 1)
+			So(actions[0].Action, ShouldEqual, lib.EPPLogActionDomainCreate)
+
+			avail, _, _, err := sc.DomainAvailable("PLANNED.EXAMPLE")
+			So(err, ShouldBeNil)
+			So(avail, ShouldBeTrue)
+
+			Convey("Sending the planned command should create the domain", func() {
+				sc.SetDryRun(nil)
+				So(sc.IsDryRun(), ShouldBeFalse)
+
+				xml, err := recorded[0].ToString()
+				So(err, ShouldBeNil)
+
+				rc, action, err := sc.SendPlanned([]byte(xml), actions[0].Action, actions[0].Args)
+				So(err, ShouldBeNil)
+				So(rc, ShouldEqual, epp.ResponseCodeCommandSuccessful)
+				So(action.ClientTransactionID, ShouldNotEqual, actions[0].ClientTransactionID)
+
+				avail, _, _, err := sc.DomainAvailable("PLANNED.EXAMPLE")
+				So(err, ShouldBeNil)
+				So(avail, ShouldBeFalse)
+			})
+
+			Convey("A planned command that times out should not leave its response for the next command", func() {
+				sc.SetDryRun(nil)
+
+				xml, err := recorded[0].ToString()
+				So(err, ShouldBeNil)
+
+				sc.Timeout = time.Nanosecond
+
+				for attempt := 0; attempt < 10 && err != ErrResponseTimeout; attempt++ {
+					_, _, err = sc.SendPlanned([]byte(xml), actions[0].Action, actions[0].Args)
+				}
+				So(err, ShouldEqual, ErrResponseTimeout)
+
+				sc.Timeout = superclientTimeout
+
+				avail, _, _, err := sc.DomainAvailable("NEXT.EXAMPLE")
+				So(err, ShouldBeNil)
+				So(avail, ShouldBeTrue)
+			})
+		})
+
+		Convey("Sending something that is not a command should fail", func() {
+			_, _, err := sc.SendPlanned([]byte("<epp><hello/></epp>"), lib.EPPLogActionHello, "")
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
@@ -3,7 +3,9 @@ package superclient
 import (
 	"errors"
 	"fmt"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/op/go-logging"
@@ -58,20 +60,72 @@ const (
 )
 
 // SuperClient is used to handle a easier interface to the EPP server.
+// Commands are run using a pool of logged in sessions so that multiple
+// commands may be run at the same time.
 type SuperClient struct {
-	client client.EPPClient
+	clients []*client.EPPClient
+
+	// sessions holds the clients that are not currently running a
+	// command.
+	sessions chan *client.EPPClient
+
+	// discarded holds the clients that timed out waiting for a response
+	// and have been replaced in the pool. clientsLock guards it along
+	// with clients.
+	discarded   map[*client.EPPClient]bool
+	clientsLock sync.Mutex
+
+	loginCallback  func(lib.EPPAction)
+	logoutCallback func(lib.EPPAction)
+
+	config client.Config
+	log    *logging.Logger
+
+	// dryRunRecorder is passed the commands that would change the
+	// registry when the client is in dry run mode.
+	dryRunRecorder func(msg epp.Epp, action lib.EPPAction)
 
 	Timeout time.Duration
 }
 
 // NewSuperClient takes the configuration information and a logger
-// interface and will start a new EPP client and wait for the client
-// to be ready for use before returning. If an error occurs while
-// setting up the client, it will be returned.
+// interface and will start the pool of EPP clients and wait for the
+// clients to be ready for use before returning. The number of clients
+// started is taken from the config. If an error occurs while setting up
+// the clients, it will be returned.
 func NewSuperClient(config client.Config, log *logging.Logger, loginCallback func(lib.EPPAction), logoutCallback func(lib.EPPAction)) (sc *SuperClient, err error) {
-	sc = &SuperClient{}
-	sc.client = client.NewEPPClient()
-	sc.client.Prepare(config, log, func(eppMsg epp.Epp) error {
+	config.ShareTransactionIDs()
+
+	sessionCount := config.GetSessionCount()
+
+	sc = &SuperClient{
+		config:         config,
+		log:            log,
+		sessions:       make(chan *client.EPPClient, sessionCount),
+		discarded:      make(map[*client.EPPClient]bool),
+		loginCallback:  loginCallback,
+		logoutCallback: logoutCallback,
+		Timeout:        superclientTimeout,
+	}
+
+	for idx := 0; idx < sessionCount; idx++ {
+		cli, err := sc.startSession()
+		if err != nil {
+			return sc, err
+		}
+
+		sc.clients = append(sc.clients, cli)
+		sc.sessions <- cli
+	}
+
+	return sc, nil
+}
+
+// startSession starts a new EPP client using the super client's config
+// and waits for it to be ready for use.
+func (sc *SuperClient) startSession() (*client.EPPClient, error) {
+	cli := client.NewEPPClient()
+	cli.Prepare(sc.config, sc.log, func(eppMsg epp.Epp) error {
 		txid := ""
 
 		if eppMsg.ResponseObject != nil {
@@ -85,7 +139,7 @@ func NewSuperClient(config client.Config, log *logging.Logger, loginCallback fun
 			return fmt.Errorf("error connecting to server: %w", err)
 		}
 
-		loginCallback(action)
+		sc.loginCallback(action)
 
 		return nil
 	}, func(eppMsg epp.Epp) error {
@@ -102,29 +156,27 @@ func NewSuperClient(config client.Config, log *logging.Logger, loginCallback fun
 			return fmt.Errorf("error disconnecting from the epp server: %w", err)
 		}
 
-		logoutCallback(action)
+		sc.logoutCallback(action)
 
 		return nil
 	})
 
-	err = sc.client.Start()
+	err := cli.Start()
 
 	if err != nil {
-		return sc, fmt.Errorf("error starting client: %w", err)
+		return nil, fmt.Errorf("error starting client: %w", err)
 	}
 
-	sc.Timeout = superclientTimeout
-
-	blockUntilWaitForWork(sc.client)
+	blockUntilWaitForWork(&cli)
 
-	go sc.StatusWatcher()
+	go sc.StatusWatcher(&cli)
 
-	return sc, nil
+	return &cli, nil
 }
 
 // blockUntilWaitForWork will block from doing anything until the client
 // is in the state where it is ready to use.
-func blockUntilWaitForWork(cli client.EPPClient) {
+func blockUntilWaitForWork(cli *client.EPPClient) {
 	for status := range cli.StatusChannel {
 		if status.Status == client.WaitForWork {
 			return
@@ -132,29 +184,88 @@ func blockUntilWaitForWork(cli client.EPPClient) {
 	}
 }
 
-// StatusWatcher will watch for status channel updates and log them to the debug
-// stream of the log function.
-func (sc *SuperClient) StatusWatcher() {
-	for msg := range sc.client.StatusChannel {
-		sc.client.Log.Debug(msg.Status)
+// StatusWatcher will watch for status channel updates of the client
+// passed and log them to the debug stream of the log function.
+func (sc *SuperClient) StatusWatcher(cli *client.EPPClient) {
+	for msg := range cli.StatusChannel {
+		sc.log.Debug(msg.Status)
 
 		if msg.Status == client.ShutdownRequested {
-			sc.client.StatusChannel <- msg
+			cli.StatusChannel <- msg
 
 			return
 		}
 	}
 }
 
-// getTransactionID is a helper funcation that will use the parent
-// client to generate a new transaction id for a request.
+// acquireSession blocks until one of the clients in the pool is free
+// and returns it. The client must be returned to the pool with
+// releaseSession once the command has completed.
+func (sc *SuperClient) acquireSession() *client.EPPClient {
+	return <-sc.sessions
+}
+
+// releaseSession returns a client to the pool unless it has been
+// discarded.
+func (sc *SuperClient) releaseSession(cli *client.EPPClient) {
+	sc.clientsLock.Lock()
+	discarded := sc.discarded[cli]
+	delete(sc.discarded, cli)
+	sc.clientsLock.Unlock()
+
+	if !discarded {
+		sc.sessions <- cli
+	}
+}
+
+// discardSession stops a client that timed out waiting for a response,
+// as a late response would otherwise be read as the response to the
+// next command run on it, and starts a new session to take its place
+// in the pool. If the new session cannot be started the pool is left
+// with one less session.
+func (sc *SuperClient) discardSession(cli *client.EPPClient) {
+	sc.log.Warning("Discarding an EPP session that timed out waiting for a response")
+
+	sc.clientsLock.Lock()
+	sc.discarded[cli] = true
+	sc.clientsLock.Unlock()
+
+	cli.Stop()
+
+	replacement, err := sc.startSession()
+
+	sc.clientsLock.Lock()
+	defer sc.clientsLock.Unlock()
+
+	idx := slices.Index(sc.clients, cli)
+
+	if err != nil {
+		sc.log.Errorf("Unable to replace the discarded EPP session: %s", err)
+
+		if idx >= 0 {
+			sc.clients = slices.Delete(sc.clients, idx, idx+1)
+		}
+
+		return
+	}
+
+	if idx >= 0 {
+		sc.clients[idx] = replacement
+	}
+
+	sc.sessions <- replacement
+}
+
+// getTransactionID is a helper funcation that will use the shared
+// config to generate a new transaction id for a request that is unique
+// across all of the clients in the pool.
 func (sc *SuperClient) getTransactionID() string {
-	return sc.client.ClientConfig.GetNewTransactionID()
+	return sc.config.GetNewTransactionID()
 }
 
 // getTimeout is a helper function to generate a go routine that will
 // send a message to a channel after a set period of time.
-func (sc SuperClient) getTimeout() chan bool {
+func (sc *SuperClient) getTimeout() chan bool {
 	return makeTimeout(sc.Timeout)
 }
 
@@ -194,14 +305,22 @@ func (sc *SuperClient) AckPoll(messageID string) (action lib.EPPAction, err erro
 	action.SetAction(lib.EPPLogActionPollAck, messageID)
 
 	msg := epp.GetEPPPollAcknowledge(messageID, action.ClientTransactionID)
-	sc.client.NewWork <- msg
+	if sc.recordDryRun(msg, &action) {
+		return action, nil
+	}
+
+	session := sc.acquireSession()
+	defer sc.releaseSession(session)
+
+	session.NewWork <- msg
 	timeout := sc.getTimeout()
 
 	select {
-	case respMsg := <-sc.client.WorkResponse:
+	case respMsg := <-session.WorkResponse:
 		err := action.HandleResponse(respMsg)
 		action.SetError(err)
 	case <-timeout:
+		sc.discardSession(session)
 		action.SetError(ErrResponseTimeout)
 
 		return action, ErrResponseTimeout
@@ -217,11 +336,14 @@ func (sc *SuperClient) PollRequest() (hasMessages bool, pr *PollResponse, action
 	action.SetAction(lib.EPPLogActionPoll, "")
 
 	msg := epp.GetEPPPollRequest(action.ClientTransactionID)
-	sc.client.NewWork <- msg
+	session := sc.acquireSession()
+	defer sc.releaseSession(session)
+
+	session.NewWork <- msg
 	timeout := sc.getTimeout()
 
 	select {
-	case respMsg := <-sc.client.WorkResponse:
+	case respMsg := <-session.WorkResponse:
 		err = action.HandleResponse(respMsg)
 
 		if err != nil {
@@ -259,7 +381,7 @@ func (sc *SuperClient) PollRequest() (hasMessages bool, pr *PollResponse, action
 						case "Transfer Auto Approved.":
 							pr.IsDomainTransferApproved = true
 						default:
-							sc.client.Log.Infof("Unhandled poll message for %s", respMsg.ResponseObject.MessageQueue.Message)
+							sc.log.Infof("Unhandled poll message for %s", respMsg.ResponseObject.MessageQueue.Message)
 
 							return hasMessages, pr, action, ErrUnhandledPollMessage
 						}
@@ -281,7 +403,7 @@ func (sc *SuperClient) PollRequest() (hasMessages bool, pr *PollResponse, action
 						return hasMessages, pr, action, fmt.Errorf("error parsing epp message: %w", err)
 					}
 
-					sc.client.Log.Infof("Poll Type %s", msg)
+					sc.log.Infof("Poll Type %s", msg)
 				}
 
 				return true, pr, action, nil
@@ -289,12 +411,13 @@ func (sc *SuperClient) PollRequest() (hasMessages bool, pr *PollResponse, action
 
 			err = ErrUnknownPollResponseCode
 
-			sc.client.Log.Errorf("Unexpected poll response code: %s", respMsg.ResponseObject.Result.Code)
+			sc.log.Errorf("Unexpected poll response code: %s", respMsg.ResponseObject.Result.Code)
 			action.SetError(err)
 
 			return false, nil, action, err
 		}
 	case <-timeout:
+		sc.discardSession(session)
 		action.SetError(ErrResponseTimeout)
 
 		return false, nil, action, ErrResponseTimeout
@@ -316,10 +439,13 @@ func (sc *SuperClient) DomainAvailable(domainName string) (bool, string, lib.EPP
 	action.SetAction(lib.EPPLogActionDomainAvailable, domainName)
 
 	msg := epp.GetEPPDomainCheck(domainName, action.ClientTransactionID)
-	sc.client.NewWork <- msg
+	session := sc.acquireSession()
+	defer sc.releaseSession(session)
+
+	session.NewWork <- msg
 	timeout := sc.getTimeout()
 	select {
-	case respMsg := <-sc.client.WorkResponse:
+	case respMsg := <-session.WorkResponse:
 		err := action.HandleResponse(respMsg)
 		if err != nil {
 			return false, "", action, fmt.Errorf("unexpected epp error: %w", err)
@@ -353,6 +479,7 @@ func (sc *SuperClient) DomainAvailable(domainName string) (bool, string, lib.EPP
 		}
 
 	case <-timeout:
+		sc.discardSession(session)
 		action.SetError(ErrResponseTimeout)
 
 		return false, "", action, ErrResponseTimeout
@@ -373,11 +500,14 @@ func (sc *SuperClient) HostAvailable(hostName string) (bool, lib.EPPAction, erro
 
 	msg := epp.GetEPPHostCheck(hostName, action.ClientTransactionID)
 
-	sc.client.NewWork <- msg
+	session := sc.acquireSession()
+	defer sc.releaseSession(session)
+
+	session.NewWork <- msg
 	timeout := sc.getTimeout()
 
 	select {
-	case respMsg := <-sc.client.WorkResponse:
+	case respMsg := <-session.WorkResponse:
 		err := action.HandleResponse(respMsg)
 		if err != nil {
 			return false, action, fmt.Errorf("unexpected epp error: %w", err)
@@ -411,6 +541,7 @@ func (sc *SuperClient) HostAvailable(hostName string) (bool, lib.EPPAction, erro
 		}
 
 	case <-timeout:
+		sc.discardSession(session)
 		action.SetError(ErrResponseTimeout)
 
 		return false, action, ErrResponseTimeout
@@ -439,11 +570,14 @@ func (sc *SuperClient) DomainInfo(domainName string, hosts epp.DomainInfoHosts,
 		msg = epp.GetEPPDomainInfo(domainName, action.ClientTransactionID, "", hosts)
 	}
 
-	sc.client.NewWork <- msg
+	session := sc.acquireSession()
+	defer sc.releaseSession(session)
+
+	session.NewWork <- msg
 	timeout := sc.getTimeout()
 
 	select {
-	case respMsg := <-sc.client.WorkResponse:
+	case respMsg := <-session.WorkResponse:
 		err := action.HandleResponse(respMsg)
 		if err != nil {
 			return nil, nil, action, fmt.Errorf("unexpected epp error: %w", err)
@@ -463,6 +597,7 @@ func (sc *SuperClient) DomainInfo(domainName string, hosts epp.DomainInfoHosts,
 		}
 
 	case <-timeout:
+		sc.discardSession(session)
 		action.SetError(ErrResponseTimeout)
 
 		return nil, nil, action, ErrResponseTimeout
@@ -481,11 +616,14 @@ func (sc *SuperClient) HostInfo(hostName string) (*epp.HostInfDataResp, *epp.Res
 	action.SetAction(lib.EPPLogActionHostInfo, hostName)
 
 	msg := epp.GetEPPHostInfo(hostName, action.ClientTransactionID)
-	sc.client.NewWork <- msg
+	session := sc.acquireSession()
+	defer sc.releaseSession(session)
+
+	session.NewWork <- msg
 	timeout := sc.getTimeout()
 
 	select {
-	case respMsg := <-sc.client.WorkResponse:
+	case respMsg := <-session.WorkResponse:
 		err := action.HandleResponse(respMsg)
 		if err != nil {
 			return nil, nil, action, fmt.Errorf("unexpected epp error: %w", err)
@@ -505,6 +643,7 @@ func (sc *SuperClient) HostInfo(hostName string) (*epp.HostInfDataResp, *epp.Res
 		}
 
 	case <-timeout:
+		sc.discardSession(session)
 		action.SetError(ErrResponseTimeout)
 
 		return nil, nil, action, ErrResponseTimeout
@@ -515,15 +654,32 @@ func (sc *SuperClient) HostInfo(hostName string) (*epp.HostInfDataResp, *epp.Res
 	return nil, nil, action, ErrInvalidHostReturned
 }
 
-// Logout will send a logout request to the underlying client in order
-// to shut the client down and disconnect from the server.
+// Logout will wait for any running commands to complete and then send
+// a logout request to each of the underlying clients in order to shut
+// the clients down and disconnect from the server.
 func (sc *SuperClient) Logout() (lib.EPPAction, error) {
 	action := lib.NewEPPAction("")
 	action.SetAction(lib.EPPLogActionLogout, "")
 
-	sc.client.LogoutChannel <- true
+	sc.clientsLock.Lock()
+	clients := slices.Clone(sc.clients)
+	sc.clientsLock.Unlock()
 
-	return action, sc.waitForShutdown()
+	for range clients {
+		cli := sc.acquireSession()
+		cli.LogoutChannel <- true
+	}
+
+	deadline := time.Now().Add(sc.Timeout * shutdownTimeout)
+
+	for _, cli := range clients {
+		err := waitForShutdown(cli, time.Until(deadline))
+		if err != nil {
+			return action, err
+		}
+	}
+
+	return action, nil
 }
 
 const (
@@ -533,13 +689,13 @@ const (
 )
 
 // waitForShutdown will block until the client has either ended or the
-// system timeout has elapsed.
-func (sc SuperClient) waitForShutdown() error {
-	timeout := makeTimeout(sc.Timeout * shutdownTimeout)
+// timeout passed has elapsed.
+func waitForShutdown(cli *client.EPPClient, length time.Duration) error {
+	timeout := makeTimeout(length)
 
 	for {
 		select {
-		case status := <-sc.client.StatusChannel:
+		case status := <-cli.StatusChannel:
 			if status.Status == client.ShutdownRequested {
 				return nil
 			}
@@ -563,11 +719,18 @@ func (sc *SuperClient) RequestDomainTransfer(domainName string, authInfo string)
 	per.Value = 1
 
 	msg := epp.GetEPPDomainTransferRequest(domainName, per, authInfo, action.ClientTransactionID)
-	sc.client.NewWork <- msg
+	if sc.recordDryRun(msg, &action) {
+		return nil, action.ResponseCode, action, nil
+	}
+
+	session := sc.acquireSession()
+	defer sc.releaseSession(session)
+
+	session.NewWork <- msg
 	timeout := sc.getTimeout()
 
 	select {
-	case respMsg := <-sc.client.WorkResponse:
+	case respMsg := <-session.WorkResponse:
 		err := action.HandleResponse(respMsg)
 		if err != nil {
 			return nil, 0, action, fmt.Errorf("unexpected epp error: %w", err)
@@ -586,6 +749,7 @@ func (sc *SuperClient) RequestDomainTransfer(domainName string, authInfo string)
 
 		return nil, 0, action, err
 	case <-timeout:
+		sc.discardSession(session)
 		action.SetError(ErrResponseTimeout)
 
 		return nil, 0, action, ErrResponseTimeout
@@ -624,11 +788,14 @@ func (sc *SuperClient) QueryDomainTransfer(domainName string) (trResp *epp.Domai
 	action.SetAction(lib.EPPLogActionDomainTransferQuery, domainName)
 
 	msg := epp.GetEPPDomainTransferQuery(domainName, action.ClientTransactionID)
-	sc.client.NewWork <- msg
+	session := sc.acquireSession()
+	defer sc.releaseSession(session)
+
+	session.NewWork <- msg
 	timeout := sc.getTimeout()
 
 	select {
-	case respMsg := <-sc.client.WorkResponse:
+	case respMsg := <-session.WorkResponse:
 		err := action.HandleResponse(respMsg)
 		if err != nil {
 			return nil, action, fmt.Errorf("unexpected epp error: %w", err)
@@ -644,6 +811,7 @@ func (sc *SuperClient) QueryDomainTransfer(domainName string) (trResp *epp.Domai
 
 		return nil, action, err
 	case <-timeout:
+		sc.discardSession(session)
 		action.SetError(ErrResponseTimeout)
 
 		return nil, action, ErrResponseTimeout
@@ -999,11 +1167,14 @@ func (sc *SuperClient) SendHello() (responseCode int, action lib.EPPAction, err
 	action.SetAction(lib.EPPLogActionHello, "")
 
 	msg := epp.GetEPPHello()
-	sc.client.NewWork <- msg
+	session := sc.acquireSession()
+	defer sc.releaseSession(session)
+
+	session.NewWork <- msg
 	timeout := sc.getTimeout()
 
 	select {
-	case respMsg := <-sc.client.WorkResponse:
+	case respMsg := <-session.WorkResponse:
 		err := action.HandleResponse(respMsg)
 		if err != nil {
 			return 0, action, fmt.Errorf("unexpected epp error: %w", err)
@@ -1017,6 +1188,7 @@ func (sc *SuperClient) SendHello() (responseCode int, action lib.EPPAction, err
 
 		return 0, action, nil
 	case <-timeout:
+		sc.discardSession(session)
 		action.SetError(ErrResponseTimeout)
 
 		return 0, action, ErrResponseTimeout
@@ -1027,11 +1199,18 @@ func (sc *SuperClient) SendHello() (responseCode int, action lib.EPPAction, err
 // and receive a response expecting that the response message will be
 // a 1000 response code with no body.
 func (sc *SuperClient) expect1000Response(msg epp.Epp, action *lib.EPPAction) (responseCode int, actionOut lib.EPPAction, err error) {
-	sc.client.NewWork <- msg
+	if sc.recordDryRun(msg, action) {
+		return action.ResponseCode, *action, nil
+	}
+
+	session := sc.acquireSession()
+	defer sc.releaseSession(session)
+
+	session.NewWork <- msg
 	timeout := sc.getTimeout()
 
 	select {
-	case respMsg := <-sc.client.WorkResponse:
+	case respMsg := <-session.WorkResponse:
 		err := action.HandleResponse(respMsg)
 		if err != nil {
 			return 0, *action, fmt.Errorf("unexpected epp error: %w", err)
@@ -1054,6 +1233,7 @@ func (sc *SuperClient) expect1000Response(msg epp.Epp, action *lib.EPPAction) (r
 			return respMsg.ResponseObject.Result.Code, *action, err
 		}
 	case <-timeout:
+		sc.discardSession(session)
 		action.SetError(ErrResponseTimeout)
 
 		return 0, *action, ErrResponseTimeout
@@ -415,21 +415,30 @@ func (u Update) TypedMessage() Update {
 			out.ContactUpdateObj.RemoveObject.Statuses = append(out.ContactUpdateObj.RemoveObject.Statuses, ContactStatus{StatusFlag: status.Value})
 		}
 
-		out.ContactUpdateObj.ChangeObject.Postal = &PostalInfo{}
-		out.ContactUpdateObj.ChangeObject.Postal.PostalInfoType = u.GenericUpdateObj.ChangeObject.Postal.Type
-		out.ContactUpdateObj.ChangeObject.Postal.Name = u.GenericUpdateObj.ChangeObject.Postal.Name
-		out.ContactUpdateObj.ChangeObject.Postal.Org = u.GenericUpdateObj.ChangeObject.Postal.Org
-		out.ContactUpdateObj.ChangeObject.Postal.Address.Street = append(out.ContactUpdateObj.ChangeObject.Postal.Address.Street, u.GenericUpdateObj.ChangeObject.Postal.Addr.Streets...)
-		out.ContactUpdateObj.ChangeObject.Postal.Address.City = u.GenericUpdateObj.ChangeObject.Postal.Addr.City
-		out.ContactUpdateObj.ChangeObject.Postal.Address.Sp = u.GenericUpdateObj.ChangeObject.Postal.Addr.StateProv
-		out.ContactUpdateObj.ChangeObject.Postal.Address.Pc = u.GenericUpdateObj.ChangeObject.Postal.Addr.PostalCode
-		out.ContactUpdateObj.ChangeObject.Postal.Address.Cc = u.GenericUpdateObj.ChangeObject.Postal.Addr.Country
-		out.ContactUpdateObj.ChangeObject.VoiceNumber = &PhoneNumber{}
-		out.ContactUpdateObj.ChangeObject.VoiceNumber.Number = u.GenericUpdateObj.ChangeObject.VoiceNumber.Number
-		out.ContactUpdateObj.ChangeObject.VoiceNumber.Extension = u.GenericUpdateObj.ChangeObject.VoiceNumber.Extension
-		out.ContactUpdateObj.ChangeObject.FaxNumber = &PhoneNumber{}
-		out.ContactUpdateObj.ChangeObject.FaxNumber.Number = u.GenericUpdateObj.ChangeObject.FaxNumber.Number
-		out.ContactUpdateObj.ChangeObject.FaxNumber.Extension = u.GenericUpdateObj.ChangeObject.FaxNumber.Extension
+		if u.GenericUpdateObj.ChangeObject.Postal != nil {
+			out.ContactUpdateObj.ChangeObject.Postal = &PostalInfo{}
+			out.ContactUpdateObj.ChangeObject.Postal.PostalInfoType = u.GenericUpdateObj.ChangeObject.Postal.Type
+			out.ContactUpdateObj.ChangeObject.Postal.Name = u.GenericUpdateObj.ChangeObject.Postal.Name
+			out.ContactUpdateObj.ChangeObject.Postal.Org = u.GenericUpdateObj.ChangeObject.Postal.Org
+			out.ContactUpdateObj.ChangeObject.Postal.Address.Street = append(out.ContactUpdateObj.ChangeObject.Postal.Address.Street, u.GenericUpdateObj.ChangeObject.Postal.Addr.Streets...)
+			out.ContactUpdateObj.ChangeObject.Postal.Address.City = u.GenericUpdateObj.ChangeObject.Postal.Addr.City
+			out.ContactUpdateObj.ChangeObject.Postal.Address.Sp = u.GenericUpdateObj.ChangeObject.Postal.Addr.StateProv
+			out.ContactUpdateObj.ChangeObject.Postal.Address.Pc = u.GenericUpdateObj.ChangeObject.Postal.Addr.PostalCode
+			out.ContactUpdateObj.ChangeObject.Postal.Address.Cc = u.GenericUpdateObj.ChangeObject.Postal.Addr.Country
+		}
+
+		if u.GenericUpdateObj.ChangeObject.VoiceNumber != nil {
+			out.ContactUpdateObj.ChangeObject.VoiceNumber = &PhoneNumber{}
+			out.ContactUpdateObj.ChangeObject.VoiceNumber.Number = u.GenericUpdateObj.ChangeObject.VoiceNumber.Number
+			out.ContactUpdateObj.ChangeObject.VoiceNumber.Extension = u.GenericUpdateObj.ChangeObject.VoiceNumber.Extension
+		}
+
+		if u.GenericUpdateObj.ChangeObject.FaxNumber != nil {
+			out.ContactUpdateObj.ChangeObject.FaxNumber = &PhoneNumber{}
+			out.ContactUpdateObj.ChangeObject.FaxNumber.Number = u.GenericUpdateObj.ChangeObject.FaxNumber.Number
+			out.ContactUpdateObj.ChangeObject.FaxNumber.Extension = u.GenericUpdateObj.ChangeObject.FaxNumber.Extension
+		}
+
 		out.ContactUpdateObj.ChangeObject.Email = u.GenericUpdateObj.ChangeObject.Email
 
 		if u.GenericUpdateObj.ChangeObject.AuthInfo != nil {
@@ -450,9 +459,12 @@ func (u Update) TypedMessage() Update {
 			out.DomainUpdateObj.AddObject.Statuses = append(out.DomainUpdateObj.AddObject.Statuses, DomainStatus{StatusFlag: status.Value})
 		}
 
-		for _, ns := range u.GenericUpdateObj.AddObject.Hosts {
+		if len(u.GenericUpdateObj.AddObject.Hosts) > 0 {
 			out.DomainUpdateObj.AddObject.Hosts = &DomainHostList{}
-			out.DomainUpdateObj.AddObject.Hosts.Hosts = append(out.DomainUpdateObj.AddObject.Hosts.Hosts, DomainHost{Value: ns})
+
+			for _, ns := range u.GenericUpdateObj.AddObject.Hosts {
+				out.DomainUpdateObj.AddObject.Hosts.Hosts = append(out.DomainUpdateObj.AddObject.Hosts.Hosts, DomainHost{Value: ns})
+			}
 		}
 
 		for _, cont := range u.GenericUpdateObj.AddObject.Contacts {
@@ -466,9 +478,12 @@ func (u Update) TypedMessage() Update {
 			out.DomainUpdateObj.RemoveObject.Statuses = append(out.DomainUpdateObj.RemoveObject.Statuses, DomainStatus{StatusFlag: status.Value})
 		}
 
-		for _, ns := range u.GenericUpdateObj.RemoveObject.Hosts {
+		if len(u.GenericUpdateObj.RemoveObject.Hosts) > 0 {
 			out.DomainUpdateObj.RemoveObject.Hosts = &DomainHostList{}
-			out.DomainUpdateObj.RemoveObject.Hosts.Hosts = append(out.DomainUpdateObj.RemoveObject.Hosts.Hosts, DomainHost{Value: ns})
+
+			for _, ns := range u.GenericUpdateObj.RemoveObject.Hosts {
+				out.DomainUpdateObj.RemoveObject.Hosts.Hosts = append(out.DomainUpdateObj.RemoveObject.Hosts.Hosts, DomainHost{Value: ns})
+			}
 		}
 
 		for _, cont := range u.GenericUpdateObj.RemoveObject.Contacts {
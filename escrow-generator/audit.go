@@ -0,0 +1,270 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// AuditFile holds the outcome of re-reading a single deposit file listed in
+// the hash file.
+type AuditFile struct {
+	Name         string `json:"name"`
+	Path         string `json:"path,omitempty"`
+	ExpectedHash string `json:"expected_hash"`
+	Hash         string `json:"hash,omitempty"`
+	OK           bool   `json:"ok"`
+	Error        string `json:"error,omitempty"`
+}
+
+// AuditReport is the machine readable result of auditing the deposit files
+// written to the output path.
+type AuditReport struct {
+	Time            time.Time   `json:"time"`
+	OutputPath      string      `json:"output_path"`
+	DepositType     string      `json:"deposit_type,omitempty"`
+	ActiveDomains   int         `json:"active_domains"`
+	EscrowedDomains int         `json:"escrowed_domains"`
+	DepositDomains  int         `json:"deposit_domains"`
+	DepositHandles  int         `json:"deposit_handles"`
+	MissingHandles  []string    `json:"missing_handles"`
+	Unverified      []string    `json:"unverified"`
+	Files           []AuditFile `json:"files"`
+	Discrepancies   []string    `json:"discrepancies"`
+	OK              bool        `json:"ok"`
+
+	handles    map[string]bool
+	references map[string]bool
+}
+
+// NewAuditReport creates an empty report for the output path passed.
+func NewAuditReport(outputPath string, now time.Time) *AuditReport {
+	return &AuditReport{
+		Time:           now,
+		OutputPath:     outputPath,
+		MissingHandles: []string{},
+		Unverified:     []string{},
+		Files:          []AuditFile{},
+		Discrepancies:  []string{},
+		handles:        make(map[string]bool),
+		references:     make(map[string]bool),
+	}
+}
+
+// addDiscrepancy records a problem found by the audit.
+func (a *AuditReport) addDiscrepancy(format string, args ...interface{}) {
+	a.Discrepancies = append(a.Discrepancies, fmt.Sprintf(format, args...))
+}
+
+// AddUnverified records objects that could not be verified while the
+// deposit was generated. Each of them is a discrepancy.
+func (a *AuditReport) AddUnverified(objects []string) {
+	for _, obj := range objects {
+		a.Unverified = append(a.Unverified, obj)
+		a.addDiscrepancy("%s could not be verified", obj)
+	}
+}
+
+// CheckDomainCount compares the number of domains the registrar lists as
+// active with the number of domains that are held in escrow. A negative
+// escrowed count uses the number of domains found in the deposit files,
+// which is only possible for full deposits.
+func (a *AuditReport) CheckDomainCount(active int, escrowed int) {
+	if escrowed < 0 {
+		escrowed = a.DepositDomains
+	}
+
+	a.ActiveDomains = active
+	a.EscrowedDomains = escrowed
+
+	if active != escrowed {
+		a.addDiscrepancy("registrar has %d active domains but %d are held in escrow", active, escrowed)
+	}
+}
+
+// ReadFiles re-reads each of the deposit files listed in the hash files in
+// the output path, recomputing their hashes and collecting the domains,
+// contact handles and handle references they contain. Encrypted deposits
+// are decrypted with the keys passed when the gzip file is no longer
+// present.
+func (a *AuditReport) ReadFiles(keys *RDEKeys) {
+	hashFiles, _ := filepath.Glob(filepath.Join(a.OutputPath, fmt.Sprintf("*_RDE_*_%s.txt", FileTypeHash)))
+	if len(hashFiles) == 0 {
+		a.addDiscrepancy("no hash file found in %s", a.OutputPath)
+		return
+	}
+
+	for _, hashFile := range hashFiles {
+		hashData, err := os.ReadFile(hashFile)
+		if err != nil {
+			a.addDiscrepancy("unable to read %s: %s", hashFile, err)
+			continue
+		}
+
+		for _, line := range strings.Split(string(hashData), "\n") {
+			fields := strings.Fields(line)
+			if len(fields) != 2 {
+				continue
+			}
+
+			a.readFile(fields[1], fields[0], keys)
+		}
+	}
+
+	if a.DepositType == DepositTypeIncremental {
+		return
+	}
+
+	for handle := range a.references {
+		if !a.handles[handle] {
+			a.MissingHandles = append(a.MissingHandles, handle)
+		}
+	}
+
+	sort.Strings(a.MissingHandles)
+
+	for _, handle := range a.MissingHandles {
+		a.addDiscrepancy("contact handle %s is referenced but not in the deposit", handle)
+	}
+}
+
+// readFile re-reads a single deposit file and compares its hash with the
+// expected hash.
+func (a *AuditReport) readFile(name string, expectedHash string, keys *RDEKeys) {
+	file := AuditFile{Name: name, ExpectedHash: expectedHash}
+
+	raw, path, err := readDepositData(filepath.Join(a.OutputPath, name), keys)
+	file.Path = path
+	if err != nil {
+		file.Error = err.Error()
+		a.Files = append(a.Files, file)
+		a.addDiscrepancy("unable to read %s: %s", name, err)
+		return
+	}
+
+	file.Hash = fmt.Sprintf("%x", sha256.Sum256(raw))
+	file.OK = file.Hash == expectedHash
+	a.Files = append(a.Files, file)
+
+	if !file.OK {
+		a.addDiscrepancy("hash of %s is %s but %s was recorded", name, file.Hash, expectedHash)
+	}
+
+	if err := a.collect(name, raw); err != nil {
+		a.addDiscrepancy("unable to parse %s: %s", name, err)
+	}
+}
+
+// readDepositData returns the uncompressed contents of the deposit file
+// with the raw name passed, reading the gzip file if it is present and
+// otherwise decrypting the encrypted deposit. The path of the file read is
+// also returned.
+func readDepositData(rawFilename string, keys *RDEKeys) ([]byte, string, error) {
+	gzFilename := rawFilename + ".gz"
+
+	gzFile, err := os.Open(gzFilename)
+	if err == nil {
+		defer gzFile.Close()
+
+		zipReader, zipErr := gzip.NewReader(gzFile)
+		if zipErr != nil {
+			return nil, gzFilename, zipErr
+		}
+		defer zipReader.Close()
+
+		raw, readErr := io.ReadAll(zipReader)
+
+		return raw, gzFilename, readErr
+	}
+
+	rydeFilename, _ := RydeFilenames(rawFilename)
+	if _, statErr := os.Stat(rydeFilename); statErr != nil {
+		return nil, gzFilename, errors.New("file not found")
+	}
+
+	if keys == nil {
+		return nil, rydeFilename, errors.New("file is encrypted and no keys are configured")
+	}
+
+	result, err := keys.VerifyFile(rydeFilename)
+
+	return result.raw, rydeFilename, err
+}
+
+// collect gathers the domains, contact handles and handle references from
+// the contents of a deposit file.
+func (a *AuditReport) collect(name string, raw []byte) error {
+	switch {
+	case strings.HasSuffix(name, "."+FormatXML):
+		dep, err := ParseDeposit(bytes.NewReader(raw))
+		if err != nil {
+			return err
+		}
+
+		a.DepositType = dep.Type
+		a.DepositDomains += len(dep.Domains)
+		a.DepositHandles += len(dep.Contacts)
+
+		for _, con := range dep.Contacts {
+			a.handles[con.ID] = true
+		}
+		for _, dom := range dep.Domains {
+			if dom.Registrant != "" {
+				a.references[dom.Registrant] = true
+			}
+			for _, con := range dom.Contacts {
+				a.references[con.ID] = true
+			}
+		}
+	case strings.HasSuffix(name, "."+FormatCSV):
+		rows, err := csv.NewReader(bytes.NewReader(raw)).ReadAll()
+		if err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return errors.New("file has no header")
+		}
+
+		rows = rows[1:]
+		if strings.Contains(name, "_"+FileTypeHandle+"_") {
+			a.DepositHandles += len(rows)
+			for _, row := range rows {
+				a.handles[row[0]] = true
+			}
+		} else {
+			a.DepositType = DepositTypeFull
+			a.DepositDomains += len(rows)
+			for _, row := range rows {
+				if len(row) < 7 {
+					return fmt.Errorf("domain %s has %d fields", row[0], len(row))
+				}
+				for _, handle := range row[3:7] {
+					a.references[handle] = true
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// Finish marks the report as passing if no discrepancies were found and
+// writes the report as JSON to the writer passed.
+func (a *AuditReport) Finish(w io.Writer) error {
+	a.OK = len(a.Discrepancies) == 0
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(a)
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// writeTestFile writes the contents passed to a new deposit file in the
+// directory passed and returns the hash file line for it.
+func writeTestFile(dir string, fileType string, extension string, contents string) string {
+	file, err := NewRDEFile(dir, 9999, fileType, extension)
+	So(err, ShouldBeNil)
+	_, err = file.Write([]byte(contents))
+	So(err, ShouldBeNil)
+	file.Close()
+
+	_, name := filepath.Split(file.RawFilename)
+	return fmt.Sprintf("%s %s", file.Sums[file.RawFilename], name)
+}
+
+// writeTestHashFile writes the hash file lines passed to the directory.
+func writeTestHashFile(dir string, lines ...string) {
+	hashFile, _ := FileNames(dir, 9999, FileTypeHash, 0, "txt")
+	So(os.WriteFile(hashFile, []byte(strings.Join(lines, "\n")+"\n"), 0600), ShouldBeNil)
+}
+
+const (
+	testDomainFile = "registeredName,nameservers,expirationDate,registrant,technical,admin,billing\n" +
+		"example.com,ns1.example.com,2030-01-02T03:04:05Z,REG-1,REG-1,REG-2,REG-2\n" +
+		"example.net,ns1.example.com,2030-01-02T03:04:05Z,REG-1,REG-1,REG-1,REG-3\n"
+	testHandleFile = "handleid,name,postalAddress,email,phone,fax\n" +
+		"REG-1,Jane Doe,123 Main St,jane@example.com,+1.5555551234,\n" +
+		"REG-2,John Doe,123 Main St,john@example.com,+1.5555551234,\n"
+)
+
+func TestAuditReport(t *testing.T) {
+	t.Parallel()
+	Convey("Given a CSV deposit", t, func() {
+		dir := t.TempDir()
+		domainLine := writeTestFile(dir, FileTypeFull, FormatCSV, testDomainFile)
+		handleLine := writeTestFile(dir, FileTypeHandle, FormatCSV, testHandleFile+"REG-3,Jo Doe,1 Main St,jo@example.com,,\n")
+		writeTestHashFile(dir, domainLine, handleLine)
+
+		report := NewAuditReport(dir, time.Now())
+
+		Convey("A complete deposit should pass the audit", func() {
+			report.ReadFiles(nil)
+			report.CheckDomainCount(2, -1)
+
+			var buf bytes.Buffer
+			So(report.Finish(&buf), ShouldBeNil)
+			So(report.Discrepancies, ShouldBeEmpty)
+			So(report.OK, ShouldBeTrue)
+			So(report.DepositDomains, ShouldEqual, 2)
+			So(report.DepositHandles, ShouldEqual, 3)
+			So(report.Files, ShouldHaveLength, 2)
+			So(report.Files[0].OK, ShouldBeTrue)
+
+			var parsed map[string]interface{}
+			So(json.Unmarshal(buf.Bytes(), &parsed), ShouldBeNil)
+			So(parsed["ok"], ShouldEqual, true)
+			So(parsed["deposit_domains"], ShouldEqual, 2)
+		})
+
+		Convey("Missing handles, unverified objects and domain counts should be reported", func() {
+			handleLine = writeTestFile(dir, FileTypeHandle, FormatCSV, testHandleFile)
+			writeTestHashFile(dir, domainLine, handleLine)
+
+			report.AddUnverified([]string{"contact 3"})
+			report.ReadFiles(nil)
+			report.CheckDomainCount(3, -1)
+			So(report.Finish(&bytes.Buffer{}), ShouldBeNil)
+
+			So(report.OK, ShouldBeFalse)
+			So(report.MissingHandles, ShouldResemble, []string{"REG-3"})
+			So(report.Unverified, ShouldResemble, []string{"contact 3"})
+			So(report.Discrepancies, ShouldHaveLength, 3)
+		})
+
+		Convey("Files that do not match their hashes should be reported", func() {
+			writeTestHashFile(dir, strings.Replace(domainLine, domainLine[:4], "0000", 1), handleLine)
+
+			report.ReadFiles(nil)
+			So(report.Finish(&bytes.Buffer{}), ShouldBeNil)
+			So(report.OK, ShouldBeFalse)
+			So(report.Files[0].OK, ShouldBeFalse)
+		})
+
+		Convey("Missing files should be reported", func() {
+			So(os.Remove(filepath.Join(dir, strings.Fields(domainLine)[1]+".gz")), ShouldBeNil)
+
+			report.ReadFiles(nil)
+			So(report.Finish(&bytes.Buffer{}), ShouldBeNil)
+			So(report.OK, ShouldBeFalse)
+			So(report.Files[0].Error, ShouldEqual, "file not found")
+		})
+	})
+
+	Convey("Given an encrypted XML deposit", t, func() {
+		dir := t.TempDir()
+		escrowKey, escrowFingerprint := testArmoredKey("escrow", false, nil)
+		signingKey, signingFingerprint := testArmoredKey("registrar", true, nil)
+		keys, err := LoadRDEKeys(bytes.NewBufferString(escrowKey), escrowFingerprint, bytes.NewBufferString(signingKey), signingFingerprint, nil)
+		So(err, ShouldBeNil)
+
+		file, err := NewRDEFile(dir, 9999, FileTypeFull, FormatXML)
+		So(err, ShouldBeNil)
+		_, err = NewDeposit(DepositTypeFull, time.Now(), 1, "", 9999, testDepositObjects()).WriteTo(file)
+		So(err, ShouldBeNil)
+		file.Close()
+
+		_, _, err = keys.EncryptFile(file.FileName, file.RawFilename)
+		So(err, ShouldBeNil)
+		So(os.Remove(file.FileName), ShouldBeNil)
+
+		_, name := filepath.Split(file.RawFilename)
+		writeTestHashFile(dir, fmt.Sprintf("%s %s", file.Sums[file.RawFilename], name))
+
+		Convey("The deposit should be decrypted and audited", func() {
+			report := NewAuditReport(dir, time.Now())
+			report.ReadFiles(keys)
+			report.CheckDomainCount(1, -1)
+			So(report.Finish(&bytes.Buffer{}), ShouldBeNil)
+			So(report.Discrepancies, ShouldBeEmpty)
+			So(report.DepositType, ShouldEqual, DepositTypeFull)
+			So(report.DepositHandles, ShouldEqual, 2)
+		})
+
+		Convey("The deposit should not be audited without keys", func() {
+			report := NewAuditReport(dir, time.Now())
+			report.ReadFiles(nil)
+			So(report.Finish(&bytes.Buffer{}), ShouldBeNil)
+			So(report.OK, ShouldBeFalse)
+		})
+	})
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+)
+
+const (
+	// ExtensionRyde is the extension of an encrypted deposit file.
+	ExtensionRyde = "ryde"
+
+	// ExtensionSig is the extension of the detached signature of an
+	// encrypted deposit file.
+	ExtensionSig = "sig"
+)
+
+// RDEKeys holds the escrow agent's public key that deposits are encrypted
+// to and the registrar's private key that deposits are signed with.
+type RDEKeys struct {
+	Escrow  *openpgp.Entity
+	Signing *openpgp.Entity
+}
+
+// normalizeKeyID removes the formatting that may be present in a key ID or
+// fingerprint so it can be compared.
+func normalizeKeyID(id string) string {
+	id = strings.ToUpper(strings.ReplaceAll(id, " ", ""))
+	return strings.TrimPrefix(id, "0X")
+}
+
+// findEntity returns the entity in the key ring whose fingerprint or key ID
+// ends with the ID passed.
+func findEntity(keyRing openpgp.EntityList, id string) (*openpgp.Entity, error) {
+	id = normalizeKeyID(id)
+	if id == "" {
+		return nil, errors.New("no key ID or fingerprint configured")
+	}
+
+	for _, entity := range keyRing {
+		fingerprint := strings.ToUpper(fmt.Sprintf("%x", entity.PrimaryKey.Fingerprint))
+		if strings.HasSuffix(fingerprint, id) {
+			return entity, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no key found matching %s", id)
+}
+
+// LoadRDEKeys reads the escrow agent's armored public key, checking that it
+// matches the fingerprint passed, and the registrar's armored private key
+// with the key ID passed. The private key is decrypted with the passphrase
+// passed if required.
+func LoadRDEKeys(escrowKey io.Reader, escrowFingerprint string, signingKey io.Reader, signingKeyID string, passphrase []byte) (*RDEKeys, error) {
+	escrowRing, err := openpgp.ReadArmoredKeyRing(escrowKey)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read escrow key: %w", err)
+	}
+
+	escrow, err := findEntity(escrowRing, escrowFingerprint)
+	if err != nil {
+		return nil, fmt.Errorf("escrow key: %w", err)
+	}
+
+	if normalizeKeyID(escrowFingerprint) != strings.ToUpper(fmt.Sprintf("%x", escrow.PrimaryKey.Fingerprint)) {
+		return nil, fmt.Errorf("escrow key fingerprint does not match %s", escrowFingerprint)
+	}
+
+	signingRing, err := openpgp.ReadArmoredKeyRing(signingKey)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read signing key: %w", err)
+	}
+
+	signing, err := findEntity(signingRing, signingKeyID)
+	if err != nil {
+		return nil, fmt.Errorf("signing key: %w", err)
+	}
+
+	if signing.PrivateKey == nil {
+		return nil, errors.New("signing key does not include the private key")
+	}
+
+	if err := signing.DecryptPrivateKeys(passphrase); err != nil {
+		return nil, fmt.Errorf("unable to decrypt signing key: %w", err)
+	}
+
+	return &RDEKeys{Escrow: escrow, Signing: signing}, nil
+}
+
+// RydeFilenames returns the names of the encrypted deposit file and its
+// signature for the raw file name passed.
+func RydeFilenames(rawFilename string) (rydeFilename string, sigFilename string) {
+	base := strings.TrimSuffix(rawFilename, filepath.Ext(rawFilename))
+
+	return base + "." + ExtensionRyde, base + "." + ExtensionSig
+}
+
+// EncryptFile encrypts the gzipped deposit file to the escrow agent's key
+// and the registrar's key, so the registrar is able to audit the deposit,
+// and writes a detached signature of the encrypted file made with the
+// registrar's key. The names of the files written are returned.
+func (k *RDEKeys) EncryptFile(gzFilename string, rawFilename string) (rydeFilename string, sigFilename string, err error) {
+	rydeFilename, sigFilename = RydeFilenames(rawFilename)
+
+	in, err := os.Open(gzFilename)
+	if err != nil {
+		return rydeFilename, sigFilename, err
+	}
+	defer in.Close()
+
+	ryde, err := os.Create(rydeFilename)
+	if err != nil {
+		return rydeFilename, sigFilename, err
+	}
+	defer ryde.Close()
+
+	_, gzName := filepath.Split(gzFilename)
+	hints := &openpgp.FileHints{IsBinary: true, FileName: gzName}
+
+	plaintext, err := openpgp.Encrypt(ryde, []*openpgp.Entity{k.Escrow, k.Signing}, nil, hints, nil)
+	if err != nil {
+		return rydeFilename, sigFilename, err
+	}
+
+	if _, err = io.Copy(plaintext, in); err != nil {
+		return rydeFilename, sigFilename, err
+	}
+
+	if err = plaintext.Close(); err != nil {
+		return rydeFilename, sigFilename, err
+	}
+
+	if _, err = ryde.Seek(0, io.SeekStart); err != nil {
+		return rydeFilename, sigFilename, err
+	}
+
+	sig, err := os.Create(sigFilename)
+	if err != nil {
+		return rydeFilename, sigFilename, err
+	}
+	defer sig.Close()
+
+	err = openpgp.DetachSign(sig, k.Signing, ryde, nil)
+
+	return rydeFilename, sigFilename, err
+}
+
+// VerifyResult holds the outcome of checking an encrypted deposit file.
+type VerifyResult struct {
+	Filename string
+	Signer   string
+	Size     int64
+	Hash     string
+	Deposit  *ParsedDeposit
+
+	raw []byte
+}
+
+// VerifyFile checks the detached signature of the encrypted deposit file
+// against the registrar's key, then decrypts and decompresses the deposit
+// using the registrar's key and returns the sha256 hash of its contents.
+// XML deposits are also parsed to check that they are well formed.
+func (k *RDEKeys) VerifyFile(rydeFilename string) (result VerifyResult, err error) {
+	base := strings.TrimSuffix(rydeFilename, "."+ExtensionRyde)
+	sigFilename := base + "." + ExtensionSig
+
+	ryde, err := os.ReadFile(rydeFilename)
+	if err != nil {
+		return result, err
+	}
+
+	sig, err := os.ReadFile(sigFilename)
+	if err != nil {
+		return result, err
+	}
+
+	keyRing := openpgp.EntityList{k.Signing}
+
+	signer, err := openpgp.CheckDetachedSignature(keyRing, bytes.NewReader(ryde), bytes.NewReader(sig), nil)
+	if err != nil {
+		return result, fmt.Errorf("signature check failed: %w", err)
+	}
+
+	md, err := openpgp.ReadMessage(bytes.NewReader(ryde), keyRing, nil, nil)
+	if err != nil {
+		return result, fmt.Errorf("unable to decrypt deposit: %w", err)
+	}
+
+	zipReader, err := gzip.NewReader(md.UnverifiedBody)
+	if err != nil {
+		return result, fmt.Errorf("unable to decompress deposit: %w", err)
+	}
+	defer zipReader.Close()
+
+	var raw bytes.Buffer
+
+	hash := sha256.New()
+
+	result.Size, err = io.Copy(io.MultiWriter(hash, &raw), zipReader)
+	if err != nil {
+		return result, fmt.Errorf("unable to decompress deposit: %w", err)
+	}
+
+	result.Filename = strings.TrimSuffix(md.LiteralData.FileName, ".gz")
+	result.Signer = fmt.Sprintf("%X", signer.PrimaryKey.Fingerprint)
+	result.Hash = fmt.Sprintf("%x", hash.Sum(nil))
+	result.raw = raw.Bytes()
+
+	if strings.HasSuffix(result.Filename, "."+FormatXML) {
+		result.Deposit, err = ParseDeposit(bytes.NewReader(result.raw))
+		if err != nil {
+			return result, fmt.Errorf("invalid deposit: %w", err)
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// testArmoredKey generates a new key and returns the armored public or
+// private key along with its fingerprint.
+func testArmoredKey(name string, private bool, passphrase []byte) (string, string) {
+	entity, err := openpgp.NewEntity(name, "", name+"@example.com", nil)
+	So(err, ShouldBeNil)
+
+	buf := &bytes.Buffer{}
+	if private {
+		if passphrase != nil {
+			So(entity.EncryptPrivateKeys(passphrase, nil), ShouldBeNil)
+		}
+		writer, err := armor.Encode(buf, openpgp.PrivateKeyType, nil)
+		So(err, ShouldBeNil)
+		So(entity.SerializePrivateWithoutSigning(writer, nil), ShouldBeNil)
+		So(writer.Close(), ShouldBeNil)
+	} else {
+		writer, err := armor.Encode(buf, openpgp.PublicKeyType, nil)
+		So(err, ShouldBeNil)
+		So(entity.Serialize(writer), ShouldBeNil)
+		So(writer.Close(), ShouldBeNil)
+	}
+
+	return buf.String(), fmt.Sprintf("%X", entity.PrimaryKey.Fingerprint)
+}
+
+func TestEncryptDeposit(t *testing.T) {
+	t.Parallel()
+	Convey("Given an escrow agent key and a registrar signing key", t, func() {
+		passphrase := []byte("correct horse")
+		escrowKey, escrowFingerprint := testArmoredKey("escrow", false, nil)
+		signingKey, signingFingerprint := testArmoredKey("registrar", true, passphrase)
+		signingKeyID := signingFingerprint[len(signingFingerprint)-16:]
+
+		keys, err := LoadRDEKeys(bytes.NewBufferString(escrowKey), escrowFingerprint, bytes.NewBufferString(signingKey), signingKeyID, passphrase)
+		So(err, ShouldBeNil)
+
+		Convey("Keys that do not match the configuration should not load", func() {
+			_, err := LoadRDEKeys(bytes.NewBufferString(escrowKey), "0123456789ABCDEF", bytes.NewBufferString(signingKey), signingKeyID, passphrase)
+			So(err, ShouldNotBeNil)
+
+			_, err = LoadRDEKeys(bytes.NewBufferString(escrowKey), escrowFingerprint, bytes.NewBufferString(signingKey), signingKeyID, []byte("wrong"))
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("The passphrase file should be used even if the keychain is enabled", func() {
+			dir := t.TempDir()
+			conf := Config{}
+			conf.Mac.MacKeychainEnabled = true
+			conf.Registrar.RDEFingerprint = escrowFingerprint
+			conf.Registrar.RDEKeyID = signingKeyID
+			conf.Registrar.RDEEscrowKey = filepath.Join(dir, "escrow.pub.asc")
+			conf.Registrar.RDESigningKey = filepath.Join(dir, "rde.priv.asc")
+			conf.Registrar.RDEPassphrase = filepath.Join(dir, "gpgpass")
+
+			So(os.WriteFile(conf.Registrar.RDEEscrowKey, []byte(escrowKey), 0600), ShouldBeNil)
+			So(os.WriteFile(conf.Registrar.RDESigningKey, []byte(signingKey), 0600), ShouldBeNil)
+			So(os.WriteFile(conf.Registrar.RDEPassphrase, append(passphrase, '\n'), 0600), ShouldBeNil)
+
+			_, err := conf.GetRDEKeys()
+			So(err, ShouldBeNil)
+		})
+
+		Convey("An encrypted deposit should verify and decrypt", func() {
+			dir := t.TempDir()
+			file, err := NewRDEFile(dir, 9999, FileTypeFull, FormatXML)
+			So(err, ShouldBeNil)
+			_, err = NewDeposit(DepositTypeFull, time.Now(), 1, "", 9999, testDepositObjects()).WriteTo(file)
+			So(err, ShouldBeNil)
+			file.Close()
+
+			rydeFilename, sigFilename, err := keys.EncryptFile(file.FileName, file.RawFilename)
+			So(err, ShouldBeNil)
+			So(rydeFilename, ShouldEndWith, "_full_0.ryde")
+			So(sigFilename, ShouldEndWith, "_full_0.sig")
+
+			result, err := keys.VerifyFile(rydeFilename)
+			So(err, ShouldBeNil)
+			So(result.Hash, ShouldEqual, file.Sums[file.RawFilename])
+			So(result.Signer, ShouldEqual, signingFingerprint)
+			So(result.Deposit, ShouldNotBeNil)
+			So(result.Deposit.Domains, ShouldHaveLength, 1)
+
+			Convey("A modified deposit should fail verification", func() {
+				ryde, err := os.ReadFile(rydeFilename)
+				So(err, ShouldBeNil)
+				ryde[len(ryde)-1] ^= 0xff
+				So(os.WriteFile(rydeFilename, ryde, 0600), ShouldBeNil)
+
+				_, err = keys.VerifyFile(rydeFilename)
+				So(err, ShouldNotBeNil)
+			})
+		})
+	})
+}
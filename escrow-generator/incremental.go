@@ -0,0 +1,351 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/timapril/go-registrar/client"
+	"github.com/timapril/go-registrar/lib"
+)
+
+const (
+	// ScheduleFull makes a full deposit every time the generator is run.
+	ScheduleFull = "full"
+
+	// ScheduleIncremental makes an incremental deposit every time the
+	// generator is run once a full deposit has been made.
+	ScheduleIncremental = "incremental"
+
+	// ScheduleWeekly makes a full deposit on the configured day of the
+	// week, or if the last full deposit is more than a week old, and an
+	// incremental deposit on the other days.
+	ScheduleWeekly = "weekly"
+)
+
+// DepositRefs holds the IDs of the hosts and contacts used by a domain at
+// the time of a deposit.
+type DepositRefs struct {
+	Hosts    []int64
+	Contacts []int64
+}
+
+// UnverifiedObjects holds the IDs of the objects that could not be
+// verified when a deposit was made.
+type UnverifiedObjects struct {
+	Domains  []int64
+	Hosts    []int64
+	Contacts []int64
+}
+
+// Names returns a description of each of the unverified objects.
+func (u UnverifiedObjects) Names() (names []string) {
+	for _, id := range u.Domains {
+		names = append(names, fmt.Sprintf("domain %d", id))
+	}
+
+	for _, id := range u.Hosts {
+		names = append(names, fmt.Sprintf("host %d", id))
+	}
+
+	for _, id := range u.Contacts {
+		names = append(names, fmt.Sprintf("contact %d", id))
+	}
+
+	return names
+}
+
+// DepositState records the last deposit that was made so the next
+// incremental deposit can be built from the objects that have changed
+// since. Objects that could not be verified are left out of the deposit
+// and retrieved again for the next one.
+type DepositState struct {
+	ID         string
+	Type       string
+	Watermark  time.Time
+	Sequence   int64
+	LastFull   time.Time
+	Domains    map[int64]DepositRefs
+	Unverified UnverifiedObjects
+}
+
+// LoadDepositState reads the deposit state from the path passed. An empty
+// state is returned if the file does not exist yet.
+func LoadDepositState(path string) (*DepositState, error) {
+	state := &DepositState{Domains: make(map[int64]DepositRefs)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("unable to parse deposit state %s: %w", path, err)
+	}
+
+	if state.Domains == nil {
+		state.Domains = make(map[int64]DepositRefs)
+	}
+
+	return state, nil
+}
+
+// Save writes the deposit state to the path passed, replacing the previous
+// state only once the new state has been written in full.
+func (s *DepositState) Save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpPath, path)
+}
+
+// NextSequence returns the sequence number of the next deposit made at the
+// watermark passed. Sequence numbers start at 1 on each day.
+func (s *DepositState) NextSequence(watermark time.Time) int64 {
+	if s.ID != "" && s.Watermark.UTC().Format("20060102") == watermark.UTC().Format("20060102") {
+		return s.Sequence + 1
+	}
+
+	return 1
+}
+
+// Record updates the state to reflect the deposit passed, the domains and
+// objects they used at the time of the deposit and the objects that could
+// not be verified.
+func (s *DepositState) Record(deposit *Deposit, watermark time.Time, sequence int64, domains map[int64]DepositRefs, unverified UnverifiedObjects) {
+	s.ID = deposit.ID
+	s.Type = deposit.Type
+	s.Watermark = watermark
+	s.Sequence = sequence
+	s.Domains = domains
+	s.Unverified = unverified
+
+	if deposit.Type == DepositTypeFull {
+		s.LastFull = watermark
+	}
+}
+
+// NextDepositType returns the type of deposit that should be made at the
+// time passed using the schedule passed. A full deposit is always made if
+// no deposit has been made before.
+func NextDepositType(schedule string, fullDay time.Weekday, state *DepositState, now time.Time) string {
+	if state.ID == "" {
+		return DepositTypeFull
+	}
+
+	switch schedule {
+	case ScheduleIncremental:
+		return DepositTypeIncremental
+	case ScheduleWeekly:
+		if now.Weekday() == fullDay || now.Sub(state.LastFull) >= 7*24*time.Hour {
+			return DepositTypeFull
+		}
+		return DepositTypeIncremental
+	default:
+		return DepositTypeFull
+	}
+}
+
+// ParseWeekday returns the day of the week with the name passed.
+func ParseWeekday(name string) (time.Weekday, error) {
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if strings.EqualFold(day.String(), name) || strings.EqualFold(day.String()[:3], name) {
+			return day, nil
+		}
+	}
+
+	return time.Sunday, fmt.Errorf("unknown day of the week %q", name)
+}
+
+// depositSource is the part of the registrar client used to retrieve the
+// objects for a deposit.
+type depositSource interface {
+	GetVerifiedDomain(domainID int64, timestamp int64) (verified bool, errs []error, obj *lib.DomainExport)
+	GetVerifiedHost(hostID int64, timestamp int64) (verified bool, errs []error, obj *lib.HostExport)
+	GetVerifiedContact(contactID int64, timestamp int64) (verified bool, errs []error, obj *lib.ContactExport)
+	GetDomainAt(id int64, ts int64) (outobj *lib.DomainExport, errs []error)
+	GetHostAt(id int64, ts int64) (outobj *lib.HostExport, errs []error)
+}
+
+// isVerified logs the errors returned while retrieving an object and
+// returns true if the object was retrieved and verified.
+func isVerified(objectType string, id int64, verified bool, errs []error) bool {
+	for _, err := range errs {
+		log.Error(err.Error())
+	}
+
+	if !verified {
+		log.Errorf("Unable to verify %s %d", objectType, id)
+		return false
+	}
+
+	return true
+}
+
+// domainRefs returns the IDs of the hosts and contacts used by a domain.
+func domainRefs(dom *lib.DomainExport) (refs DepositRefs) {
+	rev := dom.CurrentRevision
+
+	for _, host := range rev.Hostnames {
+		refs.Hosts = append(refs.Hosts, host.ID)
+	}
+
+	for _, id := range []int64{rev.DomainRegistrant.ID, rev.DomainAdminContact.ID, rev.DomainTechContact.ID, rev.DomainBillingContact.ID} {
+		if id != 0 {
+			refs.Contacts = append(refs.Contacts, id)
+		}
+	}
+
+	return refs
+}
+
+// referencedObjects returns the sets of hosts and contacts used by the
+// domains passed.
+func referencedObjects(domains map[int64]DepositRefs) (hosts map[int64]bool, contacts map[int64]bool) {
+	hosts = make(map[int64]bool)
+	contacts = make(map[int64]bool)
+
+	for _, refs := range domains {
+		for _, id := range refs.Hosts {
+			hosts[id] = true
+		}
+		for _, id := range refs.Contacts {
+			contacts[id] = true
+		}
+	}
+
+	return hosts, contacts
+}
+
+// CollectDepositObjects retrieves the objects for a deposit of the type
+// passed, as they were at the watermark. A full deposit contains every
+// domain in the object directory along with the hosts and contacts they
+// use. An incremental deposit contains only the objects added since the
+// previous deposit or whose revision hint shows they have been updated
+// since, along with the objects removed since the previous deposit, whose
+// names are looked up as they were at the previous deposit. The hosts and
+// contacts used by each domain are returned so they can be recorded in the
+// deposit state. Objects that cannot be verified are left out of the
+// deposit and returned, along with those that could not be verified for the
+// previous deposit, so they are retrieved for the next deposit. A domain
+// that was in the previous deposit keeps the hosts and contacts it used then
+// so that it is not treated as removed.
+func CollectDepositObjects(src depositSource, dir client.ObjectDirectory, state *DepositState, depositType string, watermark time.Time) (objs DepositObjects, refs map[int64]DepositRefs, unverified UnverifiedObjects, err error) {
+	objs = DepositObjects{
+		Domains:  make(map[int64]*lib.DomainExport),
+		Hosts:    make(map[int64]*lib.HostExport),
+		Contacts: make(map[int64]*lib.ContactExport),
+	}
+	refs = make(map[int64]DepositRefs)
+	ts := watermark.Unix()
+	full := depositType == DepositTypeFull
+
+	changed := func(hints map[int64]lib.APIRevisionHint, retry []int64, id int64) bool {
+		hint, ok := hints[id]
+		return full || !ok || hint.LastUpdate.IsZero() || hint.LastUpdate.After(state.Watermark) || slices.Contains(retry, id)
+	}
+
+	for _, id := range dir.DomainIDs {
+		oldRefs, known := state.Domains[id]
+		if known && !changed(dir.DomainHints, state.Unverified.Domains, id) {
+			refs[id] = oldRefs
+			continue
+		}
+
+		verified, errs, dom := src.GetVerifiedDomain(id, ts)
+		if !isVerified("domain", id, verified && dom != nil, errs) {
+			unverified.Domains = append(unverified.Domains, id)
+			if known {
+				refs[id] = oldRefs
+			}
+			continue
+		}
+		objs.Domains[id] = dom
+		refs[id] = domainRefs(dom)
+	}
+
+	hosts, contacts := referencedObjects(refs)
+	oldHosts, oldContacts := referencedObjects(state.Domains)
+
+	for _, id := range sortedKeys(hosts) {
+		if oldHosts[id] && !changed(dir.HostHints, state.Unverified.Hosts, id) {
+			continue
+		}
+
+		verified, errs, host := src.GetVerifiedHost(id, ts)
+		if !isVerified("host", id, verified && host != nil, errs) {
+			unverified.Hosts = append(unverified.Hosts, id)
+			continue
+		}
+		objs.Hosts[id] = host
+	}
+
+	for _, id := range sortedKeys(contacts) {
+		if oldContacts[id] && !changed(dir.ContactHints, state.Unverified.Contacts, id) {
+			continue
+		}
+
+		verified, errs, con := src.GetVerifiedContact(id, ts)
+		if !isVerified("contact", id, verified && con != nil, errs) {
+			unverified.Contacts = append(unverified.Contacts, id)
+			continue
+		}
+		objs.Contacts[id] = con
+	}
+
+	if full {
+		return objs, refs, unverified, nil
+	}
+
+	previousTS := state.Watermark.Unix()
+
+	for _, id := range sortedKeys(state.Domains) {
+		if _, ok := refs[id]; ok {
+			continue
+		}
+
+		dom, errs := src.GetDomainAt(id, previousTS)
+		if !isVerified("deleted domain", id, dom != nil, errs) {
+			return objs, refs, unverified, fmt.Errorf("unable to retrieve deleted domain %d", id)
+		}
+		objs.DeletedDomains = append(objs.DeletedDomains, strings.ToLower(dom.DomainName))
+	}
+
+	for _, id := range sortedKeys(oldHosts) {
+		if hosts[id] {
+			continue
+		}
+
+		host, errs := src.GetHostAt(id, previousTS)
+		if !isVerified("deleted host", id, host != nil, errs) {
+			return objs, refs, unverified, fmt.Errorf("unable to retrieve deleted host %d", id)
+		}
+		objs.DeletedHosts = append(objs.DeletedHosts, strings.ToLower(host.HostName))
+	}
+
+	for _, id := range sortedKeys(oldContacts) {
+		if !contacts[id] {
+			objs.DeletedContacts = append(objs.DeletedContacts, ContactHandle(id))
+		}
+	}
+
+	sort.Strings(objs.DeletedDomains)
+	sort.Strings(objs.DeletedHosts)
+
+	return objs, refs, unverified, nil
+}
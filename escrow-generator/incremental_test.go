@@ -0,0 +1,232 @@
+package main
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/timapril/go-registrar/client"
+	"github.com/timapril/go-registrar/lib"
+)
+
+// fakeDepositSource is a depositSource that serves objects from memory and
+// records which objects are fetched.
+type fakeDepositSource struct {
+	domains  map[int64]*lib.DomainExport
+	hosts    map[int64]*lib.HostExport
+	contacts map[int64]*lib.ContactExport
+
+	unverified map[int64]bool
+	fetched    []string
+}
+
+func (f *fakeDepositSource) GetVerifiedDomain(id int64, _ int64) (bool, []error, *lib.DomainExport) {
+	f.fetched = append(f.fetched, "domain "+f.domains[id].DomainName)
+	if f.unverified[id] {
+		return false, []error{errors.New("bad signature")}, nil
+	}
+	return true, nil, f.domains[id]
+}
+
+func (f *fakeDepositSource) GetVerifiedHost(id int64, _ int64) (bool, []error, *lib.HostExport) {
+	f.fetched = append(f.fetched, "host "+f.hosts[id].HostName)
+	return true, nil, f.hosts[id]
+}
+
+func (f *fakeDepositSource) GetVerifiedContact(id int64, _ int64) (bool, []error, *lib.ContactExport) {
+	f.fetched = append(f.fetched, "contact "+ContactHandle(id))
+	return true, nil, f.contacts[id]
+}
+
+func (f *fakeDepositSource) GetDomainAt(id int64, _ int64) (*lib.DomainExport, []error) {
+	return f.domains[id], nil
+}
+
+func (f *fakeDepositSource) GetHostAt(id int64, _ int64) (*lib.HostExport, []error) {
+	return f.hosts[id], nil
+}
+
+// directory returns an object directory listing the domains passed with
+// every object last updated at the time passed.
+func (f *fakeDepositSource) directory(updated time.Time, domainIDs ...int64) client.ObjectDirectory {
+	dir := client.NewObjectDirectory()
+	dir.DomainIDs = domainIDs
+	for id := range f.domains {
+		dir.DomainHints[id] = lib.APIRevisionHint{ObjectID: id, LastUpdate: updated}
+	}
+	for id := range f.hosts {
+		dir.HostHints[id] = lib.APIRevisionHint{ObjectID: id, LastUpdate: updated}
+	}
+	for id := range f.contacts {
+		dir.ContactHints[id] = lib.APIRevisionHint{ObjectID: id, LastUpdate: updated}
+	}
+	return dir
+}
+
+func newFakeDepositSource() *fakeDepositSource {
+	src := &fakeDepositSource{
+		domains:    make(map[int64]*lib.DomainExport),
+		hosts:      make(map[int64]*lib.HostExport),
+		contacts:   make(map[int64]*lib.ContactExport),
+		unverified: make(map[int64]bool),
+	}
+
+	for _, dom := range []struct {
+		id      int64
+		name    string
+		host    int64
+		contact int64
+	}{
+		{1, "ONE.EXAMPLE", 10, 20},
+		{2, "TWO.EXAMPLE", 11, 21},
+		{3, "THREE.EXAMPLE", 10, 20},
+	} {
+		d := &lib.DomainExport{ID: dom.id, DomainName: dom.name}
+		d.CurrentRevision.Hostnames = []lib.HostExportShort{{ID: dom.host}}
+		d.CurrentRevision.DomainRegistrant.ID = dom.contact
+		src.domains[dom.id] = d
+	}
+
+	src.hosts[10] = &lib.HostExport{ID: 10, HostName: "NS1.EXAMPLE.NET"}
+	src.hosts[11] = &lib.HostExport{ID: 11, HostName: "NS2.EXAMPLE.NET"}
+	src.contacts[20] = &lib.ContactExport{ID: 20}
+	src.contacts[21] = &lib.ContactExport{ID: 21}
+
+	return src
+}
+
+func TestCollectDepositObjects(t *testing.T) {
+	t.Parallel()
+	Convey("Given a full deposit of two domains", t, func() {
+		src := newFakeDepositSource()
+		first := time.Date(2024, 5, 5, 0, 0, 0, 0, time.UTC)
+		state := &DepositState{Domains: make(map[int64]DepositRefs)}
+
+		objs, refs, unverified, err := CollectDepositObjects(src, src.directory(first.Add(-time.Hour), 1, 2), state, DepositTypeFull, first)
+		So(err, ShouldBeNil)
+		So(unverified.Names(), ShouldBeEmpty)
+		So(objs.Domains, ShouldHaveLength, 2)
+		So(objs.Hosts, ShouldHaveLength, 2)
+		So(objs.Contacts, ShouldHaveLength, 2)
+
+		deposit := NewDeposit(DepositTypeFull, first, state.NextSequence(first), state.ID, 9999, objs)
+		state.Record(deposit, first, 1, refs, unverified)
+		So(state.ID, ShouldEqual, "20240505001")
+		So(state.LastFull, ShouldEqual, first)
+
+		Convey("An incremental deposit should only contain changed, added and removed objects", func() {
+			second := first.Add(24 * time.Hour)
+			dir := src.directory(first.Add(-time.Hour), 1, 3)
+			dir.ContactHints[20] = lib.APIRevisionHint{ObjectID: 20, LastUpdate: first.Add(time.Hour)}
+			src.fetched = nil
+
+			objs, refs, _, err := CollectDepositObjects(src, dir, state, DepositTypeIncremental, second)
+			So(err, ShouldBeNil)
+			So(src.fetched, ShouldResemble, []string{"domain THREE.EXAMPLE", "contact REG-20"})
+			So(objs.Domains, ShouldContainKey, int64(3))
+			So(objs.Domains, ShouldHaveLength, 1)
+			So(objs.Hosts, ShouldBeEmpty)
+			So(objs.Contacts, ShouldContainKey, int64(20))
+			So(objs.DeletedDomains, ShouldResemble, []string{"two.example"})
+			So(objs.DeletedHosts, ShouldResemble, []string{"ns2.example.net"})
+			So(objs.DeletedContacts, ShouldResemble, []string{"REG-21"})
+			So(refs, ShouldContainKey, int64(1))
+			So(refs, ShouldContainKey, int64(3))
+			So(refs, ShouldNotContainKey, int64(2))
+
+			deposit := NewDeposit(DepositTypeIncremental, second, state.NextSequence(second), state.ID, 9999, objs)
+			So(deposit.PreviousID, ShouldEqual, "20240505001")
+			So(deposit.ID, ShouldEqual, "20240506001")
+		})
+
+		Convey("Objects that fail verification should be left out of the deposit and retried", func() {
+			second := first.Add(24 * time.Hour)
+			dir := src.directory(first, 1, 2)
+			dir.DomainHints[1] = lib.APIRevisionHint{ObjectID: 1, LastUpdate: first.Add(time.Hour)}
+			dir.DomainHints[2] = lib.APIRevisionHint{ObjectID: 2, LastUpdate: first.Add(time.Hour)}
+			src.unverified[1] = true
+
+			objs, refs, unverified, err := CollectDepositObjects(src, dir, state, DepositTypeIncremental, second)
+			So(err, ShouldBeNil)
+			So(unverified.Names(), ShouldResemble, []string{"domain 1"})
+			So(objs.Domains, ShouldNotContainKey, int64(1))
+			So(objs.Domains, ShouldContainKey, int64(2))
+			So(objs.DeletedDomains, ShouldBeEmpty)
+			So(objs.DeletedHosts, ShouldBeEmpty)
+			So(refs[1], ShouldResemble, state.Domains[1])
+
+			deposit := NewDeposit(DepositTypeIncremental, second, state.NextSequence(second), state.ID, 9999, objs)
+			state.Record(deposit, second, state.NextSequence(second), refs, unverified)
+
+			Convey("The next incremental deposit should retrieve them again", func() {
+				third := second.Add(24 * time.Hour)
+				delete(src.unverified, 1)
+				src.fetched = nil
+
+				objs, _, unverified, err := CollectDepositObjects(src, src.directory(first, 1, 2), state, DepositTypeIncremental, third)
+				So(err, ShouldBeNil)
+				So(unverified.Names(), ShouldBeEmpty)
+				So(src.fetched, ShouldResemble, []string{"domain ONE.EXAMPLE"})
+				So(objs.Domains, ShouldContainKey, int64(1))
+			})
+		})
+	})
+}
+
+func TestDepositState(t *testing.T) {
+	t.Parallel()
+	Convey("Given a deposit state", t, func() {
+		watermark := time.Date(2024, 5, 6, 12, 0, 0, 0, time.UTC) // a Monday
+		state := &DepositState{
+			ID:        "20240506001",
+			Watermark: watermark,
+			Sequence:  1,
+			LastFull:  watermark.Add(-24 * time.Hour),
+			Domains:   map[int64]DepositRefs{1: {Hosts: []int64{10}, Contacts: []int64{20}}},
+		}
+
+		Convey("The state should be saved and loaded", func() {
+			path := filepath.Join(t.TempDir(), "state.json")
+			So(state.Save(path), ShouldBeNil)
+
+			loaded, err := LoadDepositState(path)
+			So(err, ShouldBeNil)
+			So(loaded.ID, ShouldEqual, state.ID)
+			So(loaded.Watermark.Equal(watermark), ShouldBeTrue)
+			So(loaded.Domains, ShouldResemble, state.Domains)
+
+			empty, err := LoadDepositState(filepath.Join(t.TempDir(), "missing.json"))
+			So(err, ShouldBeNil)
+			So(empty.ID, ShouldEqual, "")
+		})
+
+		Convey("Sequence numbers should restart each day", func() {
+			So(state.NextSequence(watermark.Add(time.Hour)), ShouldEqual, 2)
+			So(state.NextSequence(watermark.Add(24*time.Hour)), ShouldEqual, 1)
+		})
+
+		Convey("The schedule should pick the deposit type", func() {
+			tuesday := watermark.Add(24 * time.Hour)
+			So(NextDepositType(ScheduleFull, time.Sunday, state, tuesday), ShouldEqual, DepositTypeFull)
+			So(NextDepositType(ScheduleIncremental, time.Sunday, state, tuesday), ShouldEqual, DepositTypeIncremental)
+			So(NextDepositType(ScheduleWeekly, time.Sunday, state, tuesday), ShouldEqual, DepositTypeIncremental)
+			So(NextDepositType(ScheduleWeekly, time.Tuesday, state, tuesday), ShouldEqual, DepositTypeFull)
+			So(NextDepositType(ScheduleWeekly, time.Sunday, state, tuesday.Add(7*24*time.Hour)), ShouldEqual, DepositTypeFull)
+			So(NextDepositType(ScheduleIncremental, time.Sunday, &DepositState{}, tuesday), ShouldEqual, DepositTypeFull)
+		})
+
+		Convey("Days of the week should parse", func() {
+			day, err := ParseWeekday("sun")
+			So(err, ShouldBeNil)
+			So(day, ShouldEqual, time.Sunday)
+			day, err = ParseWeekday("Wednesday")
+			So(err, ShouldBeNil)
+			So(day, ShouldEqual, time.Wednesday)
+			_, err = ParseWeekday("someday")
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bytes"
 	"compress/gzip"
 	"crypto/sha256"
 	"encoding/csv"
@@ -36,6 +37,11 @@ var (
 	// defaultContact = flag.Int64("default_contact", 1, "The ID of the default contact that should be set")
 
 	verbose = flag.Bool("v", false, "Verbose logging")
+
+	outputFormat = flag.String("format", FormatCSV, "The output format of the escrow deposit (csv or xml)")
+	sequence     = flag.Int64("sequence", 0, "The sequence number of the XML deposit for the day when no deposit state file is configured")
+	verify       = flag.String("verify", "", "Decrypt and check the signature of an encrypted deposit file rather than generating a deposit")
+	reportPath   = flag.String("report", "", "The file to write the JSON audit report to, the report is written to stdout if not set")
 )
 
 // Config is an object that holds the configuration for the client
@@ -50,6 +56,9 @@ type Config struct {
 		RegistrarID    int64
 		RDEFingerprint string
 		RDEKeyID       string
+		RDEEscrowKey   string
+		RDESigningKey  string
+		RDEPassphrase  string
 	}
 
 	Certs struct {
@@ -71,6 +80,11 @@ type Config struct {
 	Output struct {
 		Path string
 	}
+	Deposit struct {
+		StateFile string
+		Schedule  string
+		FullDay   string
+	}
 
 	CacheConfig client.DiskCacheConfig
 }
@@ -128,6 +142,62 @@ func (c Config) GetRegistrarClient() (cli client.Client, err error) {
 	return cli, nil
 }
 
+// GetSchedule will return the configured deposit schedule, defaulting to
+// making a full deposit every time
+func (c Config) GetSchedule() string {
+	if c.Deposit.Schedule == "" {
+		return ScheduleFull
+	}
+
+	return c.Deposit.Schedule
+}
+
+// GetFullDay will return the day of the week that full deposits are made on
+// when using the weekly schedule, defaulting to Sunday
+func (c Config) GetFullDay() (time.Weekday, error) {
+	if c.Deposit.FullDay == "" {
+		return time.Sunday, nil
+	}
+
+	return ParseWeekday(c.Deposit.FullDay)
+}
+
+// GetRDEKeys will load the escrow agent's public key and the registrar's
+// signing key used to encrypt and sign deposits. The signing key passphrase
+// is read from the passphrase file if one is configured, otherwise it is
+// taken from the keychain if it is enabled.
+func (c Config) GetRDEKeys() (*RDEKeys, error) {
+	var passphrase []byte
+
+	if c.Registrar.RDEPassphrase != "" {
+		pass, err := os.ReadFile(c.Registrar.RDEPassphrase)
+		if err != nil {
+			return nil, err
+		}
+		passphrase = bytes.TrimRight(pass, "\r\n")
+	} else if c.Mac.MacKeychainEnabled {
+		var err error
+		passphrase, err = keychain.GetKeyChainPassphrase(c.Mac)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	escrowKey, err := os.Open(c.Registrar.RDEEscrowKey)
+	if err != nil {
+		return nil, err
+	}
+	defer escrowKey.Close()
+
+	signingKey, err := os.Open(c.Registrar.RDESigningKey)
+	if err != nil {
+		return nil, err
+	}
+	defer signingKey.Close()
+
+	return LoadRDEKeys(escrowKey, c.Registrar.RDEFingerprint, signingKey, c.Registrar.RDEKeyID, passphrase)
+}
+
 var log = logging.MustGetLogger("registrar")
 var format = logging.MustStringFormatter(
 	//"%{color}%{level:.4s}  ▶ %{time:15:04:05.000000} %{shortfile} § %{longfunc} %{id:03x}%{color:reset} %{message}",
@@ -159,6 +229,13 @@ func main() {
 		log.Fatal(confErr.Error())
 	}
 
+	if *verify != "" {
+		if !verifyDeposit(*verify) {
+			os.Exit(1)
+		}
+		return
+	}
+
 	log.Error(conf.Output.Path)
 	createErr := os.Mkdir(conf.Output.Path, 0700)
 	if createErr != nil {
@@ -179,44 +256,86 @@ func main() {
 		return
 	}
 
-	domains := make(map[int64]*lib.DomainExport)
-	contacts := make(map[int64]*lib.ContactExport)
+	watermark := time.Now()
 
-	domGetErr := GetDomainInfo(&cli, &domains, &contacts)
-	if domGetErr != nil {
-		log.Error(domGetErr)
-		return
-	}
+	var files []*RDEFile
+	var state *DepositState
+	var unverified []string
 
-	conGetErr := GetContacts(&cli, &contacts)
-	if conGetErr != nil {
-		log.Error(conGetErr)
-		return
-	}
+	switch *outputFormat {
+	case FormatXML:
+		depositFile, depositState, depositErr := CreateDepositFile(&cli, cli.ObjectDir, watermark)
+		if depositErr != nil {
+			log.Error(depositErr)
+			return
+		}
+		files = append(files, depositFile)
+		state = depositState
+		unverified = state.Unverified.Names()
+	case FormatCSV:
+		domains := make(map[int64]*lib.DomainExport)
+		contacts := make(map[int64]*lib.ContactExport)
+
+		domGetErr := GetDomainInfo(&cli, &domains, &contacts, &unverified)
+		if domGetErr != nil {
+			log.Error(domGetErr)
+			return
+		}
+
+		conGetErr := GetContacts(&cli, &contacts, &unverified)
+		if conGetErr != nil {
+			log.Error(conGetErr)
+			return
+		}
+
+		handleFile, handleErr := CreateHandlesFile(contacts)
+		if handleErr != nil {
+			log.Error(handleErr)
+			return
+		}
 
-	handleFile, handleErr := CreateHandlesFile(contacts)
-	if handleErr != nil {
-		log.Error(handleErr)
+		domainFile, domainErr := CreateDomainFile(domains)
+		if domainErr != nil {
+			log.Error(domainErr)
+			return
+		}
+		files = append(files, domainFile, handleFile)
+	default:
+		log.Errorf("Unknown output format %s", *outputFormat)
 		return
 	}
 
-	domainFile, domainErr := CreateDomainFile(domains)
-	if domainErr != nil {
-		log.Error(domainErr)
-		return
+	var keys *RDEKeys
+
+	if conf.Registrar.RDEFingerprint != "" {
+		var keyErr error
+		keys, keyErr = conf.GetRDEKeys()
+		if keyErr != nil {
+			log.Error(keyErr)
+			return
+		}
+
+		for _, file := range files {
+			_, _, encErr := keys.EncryptFile(file.FileName, file.RawFilename)
+			if encErr != nil {
+				log.Error(encErr)
+				return
+			}
+			if rmErr := os.Remove(file.FileName); rmErr != nil {
+				log.Error(rmErr)
+				return
+			}
+		}
 	}
 
 	var hashes []string
 
-	for filename, hash := range domainFile.Sums {
-		_, fn := filepath.Split(filename)
-		line := fmt.Sprintf("%s %s", hash, fn)
-		hashes = append(hashes, line)
-	}
-	for filename, hash := range handleFile.Sums {
-		_, fn := filepath.Split(filename)
-		line := fmt.Sprintf("%s %s", hash, fn)
-		hashes = append(hashes, line)
+	for _, file := range files {
+		for filename, hash := range file.Sums {
+			_, fn := filepath.Split(filename)
+			line := fmt.Sprintf("%s %s", hash, fn)
+			hashes = append(hashes, line)
+		}
 	}
 
 	fileName, _ := FileNames(conf.Output.Path, conf.Registrar.RegistrarID, FileTypeHash, 0, "txt")
@@ -226,21 +345,133 @@ func main() {
 		return
 	}
 
+	if state != nil && conf.Deposit.StateFile != "" {
+		if saveErr := state.Save(conf.Deposit.StateFile); saveErr != nil {
+			log.Error(saveErr)
+			return
+		}
+	}
+
+	if !auditDeposit(&cli, state, unverified, keys) {
+		os.Exit(1)
+	}
+}
+
+// auditDeposit will re-read the deposit files that were written and check
+// them for completeness and consistency, writing a JSON report to the report
+// file or stdout. The number of domains held in escrow, taken from the files
+// for a full deposit or from the deposit state for an incremental deposit, is
+// compared with the number of active domains listed by the registrar. True is
+// returned if no discrepancies were found.
+func auditDeposit(cli *client.Client, state *DepositState, unverified []string, keys *RDEKeys) bool {
+	report := NewAuditReport(conf.Output.Path, time.Now())
+	report.AddUnverified(unverified)
+	report.ReadFiles(keys)
+
+	activeIDs, _, errs := cli.GetAll(lib.DomainType)
+	if len(errs) != 0 {
+		for _, err := range errs {
+			report.addDiscrepancy("unable to list active domains: %s", err)
+		}
+	} else {
+		escrowed := -1
+		if state != nil && state.Type == DepositTypeIncremental {
+			escrowed = len(state.Domains)
+		}
+		report.CheckDomainCount(len(activeIDs), escrowed)
+	}
+
+	out := os.Stdout
+	if *reportPath != "" {
+		reportFile, createErr := os.Create(*reportPath)
+		if createErr != nil {
+			log.Error(createErr)
+			return false
+		}
+		defer reportFile.Close()
+		out = reportFile
+	}
+
+	if err := report.Finish(out); err != nil {
+		log.Error(err)
+		return false
+	}
+
+	for _, discrepancy := range report.Discrepancies {
+		log.Errorf("Audit: %s", discrepancy)
+	}
+
+	return report.OK
+}
+
+// verifyDeposit will decrypt and check the signature of the encrypted
+// deposit file passed, comparing the hash of the deposit with the hash file
+// in the same directory if one lists it. The result is printed and true is
+// returned if the deposit passed all of the checks.
+func verifyDeposit(rydeFilename string) bool {
+	keys, keyErr := conf.GetRDEKeys()
+	if keyErr != nil {
+		log.Error(keyErr)
+		return false
+	}
+
+	result, verifyErr := keys.VerifyFile(rydeFilename)
+	if verifyErr != nil {
+		fmt.Printf("FAIL %s: %s\n", rydeFilename, verifyErr)
+		return false
+	}
+
+	fmt.Printf("Deposit file: %s\n", result.Filename)
+	fmt.Printf("Signed by: %s\n", result.Signer)
+	fmt.Printf("Size: %d\n", result.Size)
+	fmt.Printf("SHA256: %s\n", result.Hash)
+
+	if result.Deposit != nil {
+		fmt.Printf("Deposit: %s %s (previous %q) watermark %s\n", result.Deposit.Type, result.Deposit.ID, result.Deposit.PreviousID, result.Deposit.Watermark)
+		for _, count := range result.Deposit.Counts {
+			fmt.Printf("Count: %s %d\n", count.URI, count.Count)
+		}
+	}
+
+	hashFiles, _ := filepath.Glob(filepath.Join(filepath.Dir(rydeFilename), fmt.Sprintf("*_RDE_*_%s.txt", FileTypeHash)))
+	for _, hashFile := range hashFiles {
+		hashData, readErr := os.ReadFile(hashFile)
+		if readErr != nil {
+			log.Error(readErr)
+			return false
+		}
+		for _, line := range strings.Split(string(hashData), "\n") {
+			fields := strings.Fields(line)
+			if len(fields) != 2 || fields[1] != result.Filename {
+				continue
+			}
+			if fields[0] != result.Hash {
+				fmt.Printf("FAIL %s: hash does not match %s\n", rydeFilename, hashFile)
+				return false
+			}
+			fmt.Printf("Hash matches %s\n", hashFile)
+		}
+	}
+
+	fmt.Printf("OK %s\n", rydeFilename)
+
+	return true
 }
 
 // GetContacts will get all current valid contacts and save the contacts into
-// the map that is passed indexed by id
-func GetContacts(cli *client.Client, cons *map[int64]*lib.ContactExport) error {
+// the map that is passed indexed by id. Contacts that cannot be verified are
+// removed from the map and added to the list of unverified objects
+func GetContacts(cli *client.Client, cons *map[int64]*lib.ContactExport, unverified *[]string) error {
 	for id := range *cons {
 		verified, conErrs, con := cli.GetVerifiedContact(id, time.Now().Unix())
-		if !verified {
-			log.Errorf("Unable to verifiy contact %d", id)
+		for _, err := range conErrs {
+			log.Error(err.Error())
 		}
-		if len(conErrs) != 0 {
-			for _, err := range conErrs {
-				log.Error(err.Error())
-			}
-			return fmt.Errorf("error getting contact %d", id)
+		if !verified || con == nil {
+			log.Errorf("Unable to verifiy contact %d", id)
+			*unverified = append(*unverified, fmt.Sprintf("contact %d", id))
+			delete(*cons, id)
+			continue
 		}
 
 		(*cons)[id] = con
@@ -250,8 +481,9 @@ func GetContacts(cli *client.Client, cons *map[int64]*lib.ContactExport) error {
 }
 
 // GetDomainInfo will get all current valid domains and save the domains into
-// the map that is passed indexed by id
-func GetDomainInfo(cli *client.Client, doms *map[int64]*lib.DomainExport, cons *map[int64]*lib.ContactExport) error {
+// the map that is passed indexed by id. Domains that cannot be verified are
+// added to the list of unverified objects
+func GetDomainInfo(cli *client.Client, doms *map[int64]*lib.DomainExport, cons *map[int64]*lib.ContactExport, unverified *[]string) error {
 	// domIds, _, domGetAllErrs := cli.GetAll(lib.DomainType)
 	// if len(domGetAllErrs) != 0 {
 	// 	for _, err := range domGetAllErrs {
@@ -262,14 +494,13 @@ func GetDomainInfo(cli *client.Client, doms *map[int64]*lib.DomainExport, cons *
 
 	for _, domain := range cli.ObjectDir.DomainIDs {
 		verified, domErrs, dom := cli.GetVerifiedDomain(domain, time.Now().Unix())
-		if !verified {
-			log.Errorf("Unable to verifiy domain %d", domain)
+		for _, err := range domErrs {
+			log.Error(err.Error())
 		}
-		if len(domErrs) != 0 {
-			for _, err := range domErrs {
-				log.Error(err.Error())
-			}
-			return fmt.Errorf("error getting domain %d", domain)
+		if !verified || dom == nil {
+			log.Errorf("Unable to verifiy domain %d", domain)
+			*unverified = append(*unverified, fmt.Sprintf("domain %d", domain))
+			continue
 		}
 		(*doms)[domain] = dom
 		CheckOrAdd(dom.CurrentRevision.DomainAdminContact.ID, cons)
@@ -383,6 +614,61 @@ const (
 	FileTypeHash = "hash"
 )
 
+// CreateDepositFile will generate the RFC 8909 XML deposit of the objects
+// in the object directory as they were at the watermark. If a deposit state
+// file is configured, the deposit schedule is used to decide if a full or
+// incremental deposit is made and the state to be saved once the deposit has
+// been written is returned. Without a state file a full deposit is always
+// made. Objects that cannot be verified are left out of the deposit and
+// listed in the returned state. The RDE file is returned following the
+// processing along with any errors that may occur during the process.
+func CreateDepositFile(src depositSource, dir client.ObjectDirectory, watermark time.Time) (file *RDEFile, state *DepositState, err error) {
+	state = &DepositState{Domains: make(map[int64]DepositRefs)}
+	depositType := DepositTypeFull
+	seq := *sequence
+
+	if conf.Deposit.StateFile != "" {
+		state, err = LoadDepositState(conf.Deposit.StateFile)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		fullDay, dayErr := conf.GetFullDay()
+		if dayErr != nil {
+			return nil, nil, dayErr
+		}
+
+		depositType = NextDepositType(conf.GetSchedule(), fullDay, state, watermark)
+		seq = state.NextSequence(watermark)
+	}
+
+	objs, refs, unverified, err := CollectDepositObjects(src, dir, state, depositType, watermark)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	deposit := NewDeposit(depositType, watermark, seq, state.ID, conf.Registrar.RegistrarID, objs)
+
+	fileType := FileTypeFull
+	if depositType == DepositTypeIncremental {
+		fileType = FileTypeIncremental
+	}
+
+	f, rdefileErr := NewRDEFile(conf.Output.Path, conf.Registrar.RegistrarID, fileType, FormatXML)
+	if rdefileErr != nil {
+		return f, nil, rdefileErr
+	}
+	defer f.Close()
+
+	if _, err = deposit.WriteTo(f); err != nil {
+		return f, nil, err
+	}
+
+	state.Record(deposit, watermark, seq, refs, unverified)
+
+	return f, state, nil
+}
+
 // FileName takes the required fields for the RDEFile file name formation for
 // ICANN provided data escrow provider and will generate the output file name
 // needed
@@ -0,0 +1,645 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/timapril/go-registrar/lib"
+)
+
+const (
+	// DepositTypeFull is used for a deposit that contains every object.
+	DepositTypeFull = "FULL"
+
+	// DepositTypeIncremental is used for a deposit that contains the
+	// objects that have changed or been removed since the previous
+	// deposit.
+	DepositTypeIncremental = "INCR"
+
+	// FormatCSV is the legacy CSV handle and domain file output format.
+	FormatCSV = "csv"
+
+	// FormatXML is the RFC 8909/9022 XML deposit output format.
+	FormatXML = "xml"
+)
+
+// The namespaces used in an XML deposit.
+const (
+	NSRDE        = "urn:ietf:params:xml:ns:rde-1.0"
+	NSRDEHeader  = "urn:ietf:params:xml:ns:rdeHeader-1.0"
+	NSRDEDomain  = "urn:ietf:params:xml:ns:rdeDomain-1.0"
+	NSRDEHost    = "urn:ietf:params:xml:ns:rdeHost-1.0"
+	NSRDEContact = "urn:ietf:params:xml:ns:rdeContact-1.0"
+	NSDomain     = "urn:ietf:params:xml:ns:domain-1.0"
+	NSHost       = "urn:ietf:params:xml:ns:host-1.0"
+	NSContact    = "urn:ietf:params:xml:ns:contact-1.0"
+	NSSecDNS     = "urn:ietf:params:xml:ns:secDNS-1.1"
+)
+
+// rdeTimeFormat is the format used for dates in an XML deposit.
+const rdeTimeFormat = "2006-01-02T15:04:05Z"
+
+// Deposit is an RFC 8909 escrow deposit containing the RFC 9022 domain
+// name registration data objects.
+type Deposit struct {
+	XMLName      xml.Name `xml:"rde:deposit"`
+	XMLNSRDE     string   `xml:"xmlns:rde,attr"`
+	XMLNSHeader  string   `xml:"xmlns:rdeHeader,attr"`
+	XMLNSDomain  string   `xml:"xmlns:rdeDomain,attr"`
+	XMLNSHost    string   `xml:"xmlns:rdeHost,attr"`
+	XMLNSContact string   `xml:"xmlns:rdeContact,attr"`
+	XMLNSDom     string   `xml:"xmlns:domain,attr"`
+	XMLNSHos     string   `xml:"xmlns:host,attr"`
+	XMLNSCon     string   `xml:"xmlns:contact,attr"`
+	XMLNSSecDNS  string   `xml:"xmlns:secDNS,attr"`
+
+	Type       string `xml:"type,attr"`
+	ID         string `xml:"id,attr"`
+	PreviousID string `xml:"prevId,attr,omitempty"`
+	Resend     int64  `xml:"resend,attr"`
+
+	Watermark string  `xml:"rde:watermark"`
+	Menu      RDEMenu `xml:"rde:rdeMenu"`
+
+	Deletes  *RDEDeletes `xml:"rde:deletes,omitempty"`
+	Contents RDEContents `xml:"rde:contents"`
+}
+
+// RDEMenu lists the versions and object URIs used in a deposit.
+type RDEMenu struct {
+	Version string   `xml:"rde:version"`
+	ObjURIs []string `xml:"rde:objURI"`
+}
+
+// RDEContents holds the objects in a deposit.
+type RDEContents struct {
+	Header   RDEHeader    `xml:"rdeHeader:header"`
+	Domains  []RDEDomain  `xml:"rdeDomain:domain"`
+	Hosts    []RDEHost    `xml:"rdeHost:host"`
+	Contacts []RDEContact `xml:"rdeContact:contact"`
+}
+
+// RDEDeletes holds the objects that have been removed since the
+// previous deposit.
+type RDEDeletes struct {
+	Domains  []RDEDomainDelete  `xml:"rdeDomain:delete"`
+	Hosts    []RDEHostDelete    `xml:"rdeHost:delete"`
+	Contacts []RDEContactDelete `xml:"rdeContact:delete"`
+}
+
+// RDEDomainDelete identifies a domain removed since the previous deposit.
+type RDEDomainDelete struct {
+	Name string `xml:"rdeDomain:name"`
+}
+
+// RDEHostDelete identifies a host removed since the previous deposit.
+type RDEHostDelete struct {
+	Name string `xml:"rdeHost:name"`
+}
+
+// RDEContactDelete identifies a contact removed since the previous
+// deposit.
+type RDEContactDelete struct {
+	ID string `xml:"rdeContact:id"`
+}
+
+// RDEHeader holds the number of each type of object in a deposit.
+type RDEHeader struct {
+	TLD    string     `xml:"rdeHeader:tld,omitempty"`
+	Counts []RDECount `xml:"rdeHeader:count"`
+}
+
+// RDECount holds the number of objects of a single type in a deposit.
+type RDECount struct {
+	URI   string `xml:"uri,attr"`
+	Count int    `xml:",chardata"`
+}
+
+// RDEStatus is the status of an object in a deposit.
+type RDEStatus struct {
+	S string `xml:"s,attr"`
+}
+
+// RDEDomainContact is a contact linked to a domain in a deposit.
+type RDEDomainContact struct {
+	Type string `xml:"type,attr"`
+	ID   string `xml:",chardata"`
+}
+
+// RDEDomainNS holds the nameservers of a domain in a deposit.
+type RDEDomainNS struct {
+	HostObjs []string `xml:"domain:hostObj"`
+}
+
+// RDESecDNS holds the DS records of a domain in a deposit.
+type RDESecDNS struct {
+	DSData []RDEDSData `xml:"secDNS:dsData"`
+}
+
+// RDEDSData is a single DS record of a domain in a deposit.
+type RDEDSData struct {
+	KeyTag     int64  `xml:"secDNS:keyTag"`
+	Algorithm  int64  `xml:"secDNS:alg"`
+	DigestType int64  `xml:"secDNS:digestType"`
+	Digest     string `xml:"secDNS:digest"`
+}
+
+// RDEDomain is a domain object in a deposit.
+type RDEDomain struct {
+	Name       string             `xml:"rdeDomain:name"`
+	ROID       string             `xml:"rdeDomain:roid"`
+	Statuses   []RDEStatus        `xml:"rdeDomain:status"`
+	Registrant string             `xml:"rdeDomain:registrant,omitempty"`
+	Contacts   []RDEDomainContact `xml:"rdeDomain:contact"`
+	NS         *RDEDomainNS       `xml:"rdeDomain:ns,omitempty"`
+	ClientID   string             `xml:"rdeDomain:clID"`
+	CreateDate string             `xml:"rdeDomain:crDate,omitempty"`
+	ExpireDate string             `xml:"rdeDomain:exDate,omitempty"`
+	UpdateDate string             `xml:"rdeDomain:upDate,omitempty"`
+	SecDNS     *RDESecDNS         `xml:"rdeDomain:secDNS,omitempty"`
+}
+
+// RDEHostAddr is an address of a host in a deposit.
+type RDEHostAddr struct {
+	IP      string `xml:"ip,attr"`
+	Address string `xml:",chardata"`
+}
+
+// RDEHost is a host object in a deposit.
+type RDEHost struct {
+	Name       string        `xml:"rdeHost:name"`
+	ROID       string        `xml:"rdeHost:roid"`
+	Statuses   []RDEStatus   `xml:"rdeHost:status"`
+	Addresses  []RDEHostAddr `xml:"rdeHost:addr"`
+	ClientID   string        `xml:"rdeHost:clID"`
+	CreateDate string        `xml:"rdeHost:crDate,omitempty"`
+	UpdateDate string        `xml:"rdeHost:upDate,omitempty"`
+}
+
+// RDEContactAddr is the postal address of a contact in a deposit.
+type RDEContactAddr struct {
+	Streets    []string `xml:"contact:street"`
+	City       string   `xml:"contact:city"`
+	State      string   `xml:"contact:sp,omitempty"`
+	PostalCode string   `xml:"contact:pc,omitempty"`
+	Country    string   `xml:"contact:cc"`
+}
+
+// RDEContactPostalInfo is the postal information of a contact in a
+// deposit.
+type RDEContactPostalInfo struct {
+	Type    string         `xml:"type,attr"`
+	Name    string         `xml:"contact:name"`
+	Org     string         `xml:"contact:org,omitempty"`
+	Address RDEContactAddr `xml:"contact:addr"`
+}
+
+// RDEPhone is a phone number of a contact in a deposit.
+type RDEPhone struct {
+	Extension string `xml:"x,attr,omitempty"`
+	Number    string `xml:",chardata"`
+}
+
+// RDEContact is a contact object in a deposit.
+type RDEContact struct {
+	ID         string               `xml:"rdeContact:id"`
+	ROID       string               `xml:"rdeContact:roid"`
+	Statuses   []RDEStatus          `xml:"rdeContact:status"`
+	PostalInfo RDEContactPostalInfo `xml:"rdeContact:postalInfo"`
+	Voice      *RDEPhone            `xml:"rdeContact:voice,omitempty"`
+	Fax        *RDEPhone            `xml:"rdeContact:fax,omitempty"`
+	Email      string               `xml:"rdeContact:email"`
+	ClientID   string               `xml:"rdeContact:clID"`
+	CreateDate string               `xml:"rdeContact:crDate,omitempty"`
+	UpdateDate string               `xml:"rdeContact:upDate,omitempty"`
+}
+
+// DepositObjects holds the objects used to build a deposit. For an
+// incremental deposit the objects are the ones that have been added or
+// changed and the deleted lists hold the objects that have been removed
+// since the previous deposit.
+type DepositObjects struct {
+	Domains  map[int64]*lib.DomainExport
+	Hosts    map[int64]*lib.HostExport
+	Contacts map[int64]*lib.ContactExport
+
+	DeletedDomains  []string
+	DeletedHosts    []string
+	DeletedContacts []string
+}
+
+// DepositID returns the ID of the deposit with the sequence number passed
+// made at the watermark passed.
+func DepositID(watermark time.Time, sequence int64) string {
+	return fmt.Sprintf("%s%03d", watermark.UTC().Format("20060102"), sequence)
+}
+
+// ContactHandle returns the handle used for a contact in the escrow
+// deposits.
+func ContactHandle(contactID int64) string {
+	return fmt.Sprintf("REG-%d", contactID)
+}
+
+// NewDeposit builds a deposit of the type passed from the objects passed.
+// The previous ID must be set for an incremental deposit.
+func NewDeposit(depositType string, watermark time.Time, sequence int64, previousID string, registrarID int64, objs DepositObjects) *Deposit {
+	clID := strconv.FormatInt(registrarID, 10)
+
+	d := &Deposit{
+		XMLNSRDE:     NSRDE,
+		XMLNSHeader:  NSRDEHeader,
+		XMLNSDomain:  NSRDEDomain,
+		XMLNSHost:    NSRDEHost,
+		XMLNSContact: NSRDEContact,
+		XMLNSDom:     NSDomain,
+		XMLNSHos:     NSHost,
+		XMLNSCon:     NSContact,
+		XMLNSSecDNS:  NSSecDNS,
+		Type:         depositType,
+		ID:           DepositID(watermark, sequence),
+		Watermark:    watermark.UTC().Format(rdeTimeFormat),
+		Menu: RDEMenu{
+			Version: "1.0",
+			ObjURIs: []string{NSRDEHeader, NSRDEDomain, NSRDEHost, NSRDEContact},
+		},
+	}
+
+	if depositType == DepositTypeIncremental {
+		d.PreviousID = previousID
+	}
+
+	for _, id := range sortedKeys(objs.Domains) {
+		d.Contents.Domains = append(d.Contents.Domains, newRDEDomain(objs.Domains[id], clID))
+	}
+
+	for _, id := range sortedKeys(objs.Hosts) {
+		d.Contents.Hosts = append(d.Contents.Hosts, newRDEHost(objs.Hosts[id], clID))
+	}
+
+	for _, id := range sortedKeys(objs.Contacts) {
+		d.Contents.Contacts = append(d.Contents.Contacts, newRDEContact(objs.Contacts[id], clID))
+	}
+
+	if len(objs.DeletedDomains)+len(objs.DeletedHosts)+len(objs.DeletedContacts) != 0 {
+		d.Deletes = &RDEDeletes{}
+		for _, name := range objs.DeletedDomains {
+			d.Deletes.Domains = append(d.Deletes.Domains, RDEDomainDelete{Name: name})
+		}
+		for _, name := range objs.DeletedHosts {
+			d.Deletes.Hosts = append(d.Deletes.Hosts, RDEHostDelete{Name: name})
+		}
+		for _, id := range objs.DeletedContacts {
+			d.Deletes.Contacts = append(d.Deletes.Contacts, RDEContactDelete{ID: id})
+		}
+	}
+
+	d.Contents.Header.Counts = []RDECount{
+		{URI: NSRDEDomain, Count: len(d.Contents.Domains)},
+		{URI: NSRDEHost, Count: len(d.Contents.Hosts)},
+		{URI: NSRDEContact, Count: len(d.Contents.Contacts)},
+	}
+
+	return d
+}
+
+// WriteTo writes the deposit as an XML document to the writer passed.
+func (d *Deposit) WriteTo(w io.Writer) (int64, error) {
+	data, err := xml.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := w.Write(append([]byte(xml.Header), data...))
+
+	return int64(n), err
+}
+
+// sortedKeys returns the keys of the map in ascending order so deposits
+// are written in a stable order.
+func sortedKeys[T any](objs map[int64]T) (keys []int64) {
+	for id := range objs {
+		keys = append(keys, id)
+	}
+
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+
+	return keys
+}
+
+// formatRDETime formats a time for a deposit, returning an empty string
+// for times that are not set.
+func formatRDETime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.UTC().Format(rdeTimeFormat)
+}
+
+// newRDEStatuses converts a list of status names into deposit statuses,
+// using ok if no statuses are set.
+func newRDEStatuses(statuses []string) (out []RDEStatus) {
+	for _, status := range statuses {
+		out = append(out, RDEStatus{S: status})
+	}
+
+	if len(out) == 0 {
+		out = append(out, RDEStatus{S: "ok"})
+	}
+
+	return out
+}
+
+// newRDEDomain converts a domain into a deposit domain object.
+func newRDEDomain(dom *lib.DomainExport, clID string) RDEDomain {
+	var statuses []string
+
+	for _, flag := range []struct {
+		set  bool
+		name string
+	}{
+		{dom.ClientDeleteProhibitedStatus, "clientDeleteProhibited"},
+		{dom.ServerDeleteProhibitedStatus, "serverDeleteProhibited"},
+		{dom.ClientHoldStatus, "clientHold"},
+		{dom.ServerHoldStatus, "serverHold"},
+		{dom.ClientRenewProhibitedStatus, "clientRenewProhibited"},
+		{dom.ServerRenewProhibitedStatus, "serverRenewProhibited"},
+		{dom.ClientTransferProhibitedStatus, "clientTransferProhibited"},
+		{dom.ServerTransferProhibitedStatus, "serverTransferProhibited"},
+		{dom.ClientUpdateProhibitedStatus, "clientUpdateProhibited"},
+		{dom.ServerUpdateProhibitedStatus, "serverUpdateProhibited"},
+		{dom.PendingCreateStatus, "pendingCreate"},
+		{dom.PendingDeleteStatus, "pendingDelete"},
+		{dom.PendingRenewStatus, "pendingRenew"},
+		{dom.PendingTransferStatus, "pendingTransfer"},
+		{dom.PendingUpdateStatus, "pendingUpdate"},
+	} {
+		if flag.set {
+			statuses = append(statuses, flag.name)
+		}
+	}
+
+	rev := dom.CurrentRevision
+
+	d := RDEDomain{
+		Name:       strings.ToLower(dom.DomainName),
+		ROID:       dom.DomainROID,
+		Statuses:   newRDEStatuses(statuses),
+		ClientID:   clID,
+		CreateDate: formatRDETime(dom.CreateDate),
+		ExpireDate: formatRDETime(dom.ExpireDate),
+		UpdateDate: formatRDETime(dom.UpdateDate),
+	}
+
+	if rev.DomainRegistrant.ID != 0 {
+		d.Registrant = ContactHandle(rev.DomainRegistrant.ID)
+	}
+
+	for _, con := range []struct {
+		contactType string
+		id          int64
+	}{
+		{"admin", rev.DomainAdminContact.ID},
+		{"tech", rev.DomainTechContact.ID},
+		{"billing", rev.DomainBillingContact.ID},
+	} {
+		if con.id != 0 {
+			d.Contacts = append(d.Contacts, RDEDomainContact{Type: con.contactType, ID: ContactHandle(con.id)})
+		}
+	}
+
+	if len(rev.Hostnames) != 0 {
+		d.NS = &RDEDomainNS{}
+		for _, host := range rev.Hostnames {
+			d.NS.HostObjs = append(d.NS.HostObjs, strings.ToLower(host.HostName))
+		}
+	}
+
+	if len(rev.DSDataEntries) != 0 {
+		d.SecDNS = &RDESecDNS{}
+		for _, ds := range rev.DSDataEntries {
+			d.SecDNS.DSData = append(d.SecDNS.DSData, RDEDSData{
+				KeyTag:     ds.KeyTag,
+				Algorithm:  ds.Algorithm,
+				DigestType: ds.DigestType,
+				Digest:     ds.Digest,
+			})
+		}
+	}
+
+	return d
+}
+
+// newRDEHost converts a host into a deposit host object.
+func newRDEHost(hos *lib.HostExport, clID string) RDEHost {
+	rev := hos.CurrentRevision
+
+	var statuses []string
+
+	for _, flag := range []struct {
+		set  bool
+		name string
+	}{
+		{rev.ClientDeleteProhibitedStatus, "clientDeleteProhibited"},
+		{rev.ServerDeleteProhibitedStatus, "serverDeleteProhibited"},
+		{rev.ClientTransferProhibitedStatus, "clientTransferProhibited"},
+		{rev.ServerTransferProhibitedStatus, "serverTransferProhibited"},
+		{rev.ClientUpdateProhibitedStatus, "clientUpdateProhibited"},
+		{rev.ServerUpdateProhibitedStatus, "serverUpdateProhibited"},
+	} {
+		if flag.set {
+			statuses = append(statuses, flag.name)
+		}
+	}
+
+	h := RDEHost{
+		Name:       strings.ToLower(hos.HostName),
+		ROID:       hos.HostROID,
+		Statuses:   newRDEStatuses(statuses),
+		ClientID:   clID,
+		CreateDate: formatRDETime(hos.CreatedAt),
+		UpdateDate: formatRDETime(hos.UpdatedAt),
+	}
+
+	for _, addr := range rev.HostAddresses {
+		ipType := "v4"
+		if strings.Contains(addr.IPAddress, ":") {
+			ipType = "v6"
+		}
+		h.Addresses = append(h.Addresses, RDEHostAddr{IP: ipType, Address: addr.IPAddress})
+	}
+
+	return h
+}
+
+// newRDEContact converts a contact into a deposit contact object.
+func newRDEContact(con *lib.ContactExport, clID string) RDEContact {
+	rev := con.CurrentRevision
+
+	var statuses []string
+
+	for _, flag := range []struct {
+		set  bool
+		name string
+	}{
+		{rev.ClientDeleteProhibitedStatus, "clientDeleteProhibited"},
+		{rev.ServerDeleteProhibitedStatus, "serverDeleteProhibited"},
+		{rev.ClientTransferProhibitedStatus, "clientTransferProhibited"},
+		{rev.ServerTransferProhibitedStatus, "serverTransferProhibited"},
+		{rev.ClientUpdateProhibitedStatus, "clientUpdateProhibited"},
+		{rev.ServerUpdateProhibitedStatus, "serverUpdateProhibited"},
+	} {
+		if flag.set {
+			statuses = append(statuses, flag.name)
+		}
+	}
+
+	c := RDEContact{
+		ID:       ContactHandle(con.ID),
+		ROID:     con.ContactROID,
+		Statuses: newRDEStatuses(statuses),
+		PostalInfo: RDEContactPostalInfo{
+			Type: "int",
+			Name: rev.Name,
+			Org:  rev.Org,
+			Address: RDEContactAddr{
+				City:       rev.AddressCity,
+				State:      rev.AddressState,
+				PostalCode: rev.AddressPostalCode,
+				Country:    rev.AddressCountry,
+			},
+		},
+		Email:      rev.EmailAddress,
+		ClientID:   clID,
+		CreateDate: formatRDETime(con.CreatedAt),
+		UpdateDate: formatRDETime(con.UpdatedAt),
+	}
+
+	for _, street := range []string{rev.AddressStreet1, rev.AddressStreet2, rev.AddressStreet3} {
+		if street != "" {
+			c.PostalInfo.Address.Streets = append(c.PostalInfo.Address.Streets, street)
+		}
+	}
+
+	if rev.VoicePhoneNumber != "" {
+		c.Voice = &RDEPhone{Number: rev.VoicePhoneNumber, Extension: rev.VoicePhoneExtension}
+	}
+
+	if rev.FaxPhoneNumber != "" {
+		c.Fax = &RDEPhone{Number: rev.FaxPhoneNumber, Extension: rev.FaxPhoneExtension}
+	}
+
+	return c
+}
+
+// ParsedDeposit is a deposit read back from an XML document. It is used
+// to validate the deposits that are written, the XML decoder matches the
+// local names of the elements rather than the prefixed names used when
+// writing.
+type ParsedDeposit struct {
+	XMLName    xml.Name `xml:"urn:ietf:params:xml:ns:rde-1.0 deposit"`
+	Type       string   `xml:"type,attr"`
+	ID         string   `xml:"id,attr"`
+	PreviousID string   `xml:"prevId,attr"`
+	Resend     int64    `xml:"resend,attr"`
+	Watermark  string   `xml:"watermark"`
+	ObjURIs    []string `xml:"rdeMenu>objURI"`
+
+	Counts []RDECount `xml:"contents>header>count"`
+
+	Domains []struct {
+		Name       string      `xml:"name"`
+		ROID       string      `xml:"roid"`
+		Statuses   []RDEStatus `xml:"status"`
+		Registrant string      `xml:"registrant"`
+		Contacts   []struct {
+			Type string `xml:"type,attr"`
+			ID   string `xml:",chardata"`
+		} `xml:"contact"`
+		HostObjs   []string `xml:"ns>hostObj"`
+		ClientID   string   `xml:"clID"`
+		CreateDate string   `xml:"crDate"`
+		ExpireDate string   `xml:"exDate"`
+		DSData     []struct {
+			KeyTag     int64  `xml:"keyTag"`
+			Algorithm  int64  `xml:"alg"`
+			DigestType int64  `xml:"digestType"`
+			Digest     string `xml:"digest"`
+		} `xml:"secDNS>dsData"`
+	} `xml:"contents>domain"`
+
+	Hosts []struct {
+		Name      string      `xml:"name"`
+		ROID      string      `xml:"roid"`
+		Statuses  []RDEStatus `xml:"status"`
+		Addresses []struct {
+			IP      string `xml:"ip,attr"`
+			Address string `xml:",chardata"`
+		} `xml:"addr"`
+	} `xml:"contents>host"`
+
+	Contacts []struct {
+		ID       string      `xml:"id"`
+		ROID     string      `xml:"roid"`
+		Statuses []RDEStatus `xml:"status"`
+		Name     string      `xml:"postalInfo>name"`
+		Streets  []string    `xml:"postalInfo>addr>street"`
+		City     string      `xml:"postalInfo>addr>city"`
+		Country  string      `xml:"postalInfo>addr>cc"`
+		Voice    string      `xml:"voice"`
+		Email    string      `xml:"email"`
+	} `xml:"contents>contact"`
+
+	Deletes struct {
+		Domains []struct {
+			Name string `xml:"name"`
+		} `xml:"urn:ietf:params:xml:ns:rdeDomain-1.0 delete"`
+		Hosts []struct {
+			Name string `xml:"name"`
+		} `xml:"urn:ietf:params:xml:ns:rdeHost-1.0 delete"`
+		Contacts []struct {
+			ID string `xml:"id"`
+		} `xml:"urn:ietf:params:xml:ns:rdeContact-1.0 delete"`
+	} `xml:"deletes"`
+}
+
+// ParseDeposit reads a deposit from the XML document passed and checks
+// that the object counts in the header match the objects in the deposit.
+func ParseDeposit(r io.Reader) (*ParsedDeposit, error) {
+	var dep ParsedDeposit
+
+	if err := xml.NewDecoder(r).Decode(&dep); err != nil {
+		return nil, err
+	}
+
+	if dep.Type != DepositTypeFull && dep.Type != DepositTypeIncremental {
+		return nil, fmt.Errorf("unknown deposit type %q", dep.Type)
+	}
+
+	if dep.Type == DepositTypeIncremental && dep.PreviousID == "" {
+		return nil, fmt.Errorf("incremental deposit %s has no previous deposit ID", dep.ID)
+	}
+
+	if _, err := time.Parse(rdeTimeFormat, dep.Watermark); err != nil {
+		return nil, fmt.Errorf("invalid watermark: %w", err)
+	}
+
+	found := map[string]int{
+		NSRDEDomain:  len(dep.Domains),
+		NSRDEHost:    len(dep.Hosts),
+		NSRDEContact: len(dep.Contacts),
+	}
+
+	for _, count := range dep.Counts {
+		if found[count.URI] != count.Count {
+			return nil, fmt.Errorf("header count for %s is %d but the deposit has %d", count.URI, count.Count, found[count.URI])
+		}
+	}
+
+	return &dep, nil
+}
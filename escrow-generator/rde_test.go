@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/timapril/go-registrar/lib"
+)
+
+func testDepositObjects() DepositObjects {
+	objs := DepositObjects{
+		Domains:  make(map[int64]*lib.DomainExport),
+		Hosts:    make(map[int64]*lib.HostExport),
+		Contacts: make(map[int64]*lib.ContactExport),
+	}
+
+	dom := &lib.DomainExport{
+		ID:                           1,
+		DomainName:                   "EXAMPLE.COM",
+		DomainROID:                   "D1-EXAMPLE",
+		ClientDeleteProhibitedStatus: true,
+		ServerUpdateProhibitedStatus: true,
+		CreateDate:                   time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		ExpireDate:                   time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+	dom.CurrentRevision.DomainRegistrant.ID = 3
+	dom.CurrentRevision.DomainAdminContact.ID = 3
+	dom.CurrentRevision.DomainTechContact.ID = 4
+	dom.CurrentRevision.Hostnames = []lib.HostExportShort{{ID: 2, HostName: "NS1.EXAMPLE.COM"}}
+	dom.CurrentRevision.DSDataEntries = []lib.DSDataEntry{{KeyTag: 12345, Algorithm: 13, DigestType: 2, Digest: "ABCDEF"}}
+	objs.Domains[1] = dom
+
+	host := &lib.HostExport{ID: 2, HostName: "NS1.EXAMPLE.COM", HostROID: "H2-EXAMPLE"}
+	host.CurrentRevision.HostAddresses = []lib.HostAddress{{IPAddress: "192.0.2.1"}, {IPAddress: "2001:db8::1"}}
+	objs.Hosts[2] = host
+
+	for _, id := range []int64{3, 4} {
+		con := &lib.ContactExport{ID: id, ContactROID: "C-EXAMPLE"}
+		con.CurrentRevision.Name = "Jane Doe & Co"
+		con.CurrentRevision.AddressStreet1 = "123 Main St"
+		con.CurrentRevision.AddressCity = "Anytown"
+		con.CurrentRevision.AddressCountry = "US"
+		con.CurrentRevision.VoicePhoneNumber = "+1.5555551234"
+		con.CurrentRevision.EmailAddress = "jane@example.com"
+		objs.Contacts[id] = con
+	}
+
+	return objs
+}
+
+func TestDeposit(t *testing.T) {
+	t.Parallel()
+	Convey("Given a set of objects to escrow", t, func() {
+		objs := testDepositObjects()
+		watermark := time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC)
+
+		Convey("A full deposit should round trip through the parser", func() {
+			var buf bytes.Buffer
+			_, err := NewDeposit(DepositTypeFull, watermark, 1, "", 9999, objs).WriteTo(&buf)
+			So(err, ShouldBeNil)
+
+			dep, err := ParseDeposit(&buf)
+			So(err, ShouldBeNil)
+			So(dep.Type, ShouldEqual, DepositTypeFull)
+			So(dep.ID, ShouldEqual, "20240506001")
+			So(dep.PreviousID, ShouldEqual, "")
+			So(dep.Watermark, ShouldEqual, "2024-05-06T00:00:00Z")
+			So(dep.ObjURIs, ShouldContain, NSRDEDomain)
+			So(dep.Counts, ShouldResemble, []RDECount{
+				{URI: NSRDEDomain, Count: 1},
+				{URI: NSRDEHost, Count: 1},
+				{URI: NSRDEContact, Count: 2},
+			})
+
+			So(dep.Domains, ShouldHaveLength, 1)
+			dom := dep.Domains[0]
+			So(dom.Name, ShouldEqual, "example.com")
+			So(dom.ROID, ShouldEqual, "D1-EXAMPLE")
+			So(dom.Statuses, ShouldResemble, []RDEStatus{{S: "clientDeleteProhibited"}, {S: "serverUpdateProhibited"}})
+			So(dom.Registrant, ShouldEqual, "REG-3")
+			So(dom.Contacts, ShouldHaveLength, 2)
+			So(dom.Contacts[1].Type, ShouldEqual, "tech")
+			So(dom.Contacts[1].ID, ShouldEqual, "REG-4")
+			So(dom.HostObjs, ShouldResemble, []string{"ns1.example.com"})
+			So(dom.ClientID, ShouldEqual, "9999")
+			So(dom.CreateDate, ShouldEqual, "2020-01-02T03:04:05Z")
+			So(dom.ExpireDate, ShouldEqual, "2030-01-02T03:04:05Z")
+			So(dom.DSData, ShouldHaveLength, 1)
+			So(dom.DSData[0].KeyTag, ShouldEqual, 12345)
+			So(dom.DSData[0].Digest, ShouldEqual, "ABCDEF")
+
+			So(dep.Hosts, ShouldHaveLength, 1)
+			So(dep.Hosts[0].Statuses, ShouldResemble, []RDEStatus{{S: "ok"}})
+			So(dep.Hosts[0].Addresses, ShouldHaveLength, 2)
+			So(dep.Hosts[0].Addresses[1].IP, ShouldEqual, "v6")
+			So(dep.Hosts[0].Addresses[1].Address, ShouldEqual, "2001:db8::1")
+
+			So(dep.Contacts, ShouldHaveLength, 2)
+			So(dep.Contacts[0].ID, ShouldEqual, "REG-3")
+			So(dep.Contacts[0].Name, ShouldEqual, "Jane Doe & Co")
+			So(dep.Contacts[0].Streets, ShouldResemble, []string{"123 Main St"})
+			So(dep.Contacts[0].Voice, ShouldEqual, "+1.5555551234")
+			So(dep.Contacts[0].Email, ShouldEqual, "jane@example.com")
+		})
+
+		Convey("An incremental deposit should include the previous deposit and deletes", func() {
+			objs.DeletedDomains = []string{"old.example"}
+			objs.DeletedHosts = []string{"ns9.example.com"}
+			objs.DeletedContacts = []string{"REG-7"}
+
+			var buf bytes.Buffer
+			_, err := NewDeposit(DepositTypeIncremental, watermark, 2, "20240505001", 9999, objs).WriteTo(&buf)
+			So(err, ShouldBeNil)
+
+			dep, err := ParseDeposit(&buf)
+			So(err, ShouldBeNil)
+			So(dep.Type, ShouldEqual, DepositTypeIncremental)
+			So(dep.ID, ShouldEqual, "20240506002")
+			So(dep.PreviousID, ShouldEqual, "20240505001")
+			So(dep.Deletes.Domains, ShouldHaveLength, 1)
+			So(dep.Deletes.Domains[0].Name, ShouldEqual, "old.example")
+			So(dep.Deletes.Hosts, ShouldHaveLength, 1)
+			So(dep.Deletes.Hosts[0].Name, ShouldEqual, "ns9.example.com")
+			So(dep.Deletes.Contacts, ShouldHaveLength, 1)
+			So(dep.Deletes.Contacts[0].ID, ShouldEqual, "REG-7")
+		})
+
+		Convey("Deposits with incorrect header counts should not parse", func() {
+			deposit := NewDeposit(DepositTypeFull, watermark, 1, "", 9999, objs)
+			deposit.Contents.Header.Counts[0].Count = 5
+
+			var buf bytes.Buffer
+			_, err := deposit.WriteTo(&buf)
+			So(err, ShouldBeNil)
+
+			_, err = ParseDeposit(&buf)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Incremental deposits without a previous deposit should not parse", func() {
+			var buf bytes.Buffer
+			_, err := NewDeposit(DepositTypeIncremental, watermark, 1, "", 9999, objs).WriteTo(&buf)
+			So(err, ShouldBeNil)
+
+			_, err = ParseDeposit(&buf)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
@@ -0,0 +1,13 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/timapril/go-registrar/lib"
+)
+
+// ExpiryCheck will move approvals and change requests that were not completed
+// within their validity window to the expired state
+func ExpiryCheck(w ResponseWriter, request *http.Request, ctx noAuthWebContext) (err error) {
+	return lib.ExpireStaleChangeRequests(ctx.GetDB(), ctx.GetConf())
+}
@@ -474,8 +474,8 @@ func (a *APIUser) UpdateState(dbCache *DBCache, _ Config) (changesMade bool, err
 
 						return changesMade, errs
 					}
-				} else if changeRequest.State == StateDeclined {
-					logger.Infof("CR %d has been declined", changeRequest.GetID())
+				} else if changeRequest.State == StateDeclined || changeRequest.State == StateExpired {
+					logger.Infof("CR %d has been %s", changeRequest.GetID(), changeRequest.State)
 					if err := a.PendingRevision.Decline(dbCache); err != nil {
 						errs = append(errs, err)
 
@@ -3,6 +3,10 @@ package lib
 
 import (
 	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -29,12 +33,19 @@ type Approval struct {
 	IsSigned        bool   `json:"IsSigned"        sql:"DEFAULT:false"`
 	IsFinalApproval bool   `json:"IsFinalApproval"`
 
+	SignaturesCollected int64 `json:"SignaturesCollected"`
+	SignaturesRequired  int64 `json:"SignaturesRequired"`
+
 	ChangeRequestID int64 `json:"ChangeRequestID"`
 	ApproverSetID   int64 `json:"ApproverSetID"`
 
 	ApprovalApproverSet ApproverSet `json:"ApprovalAPproverSet" sql:"-"`
 	Signature           []byte      `json:"Signature"           sql:"signature,type:text"`
 
+	// OpenedAt is the time the Approval was first opened for signing,
+	// the validity window of the Approval starts at this time.
+	OpenedAt *time.Time `json:"OpenedAt"`
+
 	CreatedAt time.Time `json:"CreatedAt"`
 	CreatedBy string    `json:"CreatedBy"`
 	UpdatedAt time.Time `json:"UpdatedAt"`
@@ -50,6 +61,9 @@ type ApprovalExport struct {
 	IsSigned        bool `json:"IsSigned"`
 	IsFinalApproval bool `json:"IsFinalApproval"`
 
+	SignaturesCollected int64 `json:"SignaturesCollected"`
+	SignaturesRequired  int64 `json:"SignaturesRequired"`
+
 	ChangeRequestID int64 `json:"ChangeRequestID"`
 	ApproverSetID   int64 `json:"ApproverSetID"`
 
@@ -59,6 +73,12 @@ type ApprovalExport struct {
 	CreatedBy string    `json:"CreatedBy"`
 }
 
+// Progress returns the number of approving signatures collected out of
+// the number required, for example "1/2".
+func (a ApprovalExport) Progress() string {
+	return fmt.Sprintf("%d/%d", a.SignaturesCollected, a.SignaturesRequired)
+}
+
 // ToJSON will return a string containing a JSON representation
 // of the object. An empty string and an error are returned if a JSON
 // representation cannot be returned.
@@ -78,6 +98,71 @@ func (a ApprovalExport) GetDiff() (string, error) {
 	return "", errors.New("unable to diff a single revision")
 }
 
+// ErrDuplicateSigner is returned when an approver uploads a signature
+// for an Approval they have already signed.
+var ErrDuplicateSigner = errors.New("the approval has already been signed by this approver")
+
+// ErrApprovalExpired is returned when a signature is uploaded for an
+// Approval that is past its validity window.
+var ErrApprovalExpired = errors.New("the approval has expired")
+
+// SignatureTally holds the distinct signers that have approved or
+// declined an Approval and the number of approving signatures that the
+// Approver Set requires.
+type SignatureTally struct {
+	Approvers []string
+	Decliners []string
+	Required  int64
+}
+
+// Add records the action taken by the signer passed. An error is
+// returned if the signer has already been recorded or the action is
+// not known.
+func (t *SignatureTally) Add(signer string, action string) error {
+	for _, existing := range append(append([]string{}, t.Approvers...), t.Decliners...) {
+		if existing == signer {
+			return ErrDuplicateSigner
+		}
+	}
+
+	switch action {
+	case ActionApproved:
+		t.Approvers = append(t.Approvers, signer)
+	case ActionDeclined:
+		t.Decliners = append(t.Decliners, signer)
+	default:
+		return fmt.Errorf("unknown approval action %s", action)
+	}
+
+	return nil
+}
+
+// Collected returns the number of distinct signers that have approved.
+func (t SignatureTally) Collected() int64 {
+	return int64(len(t.Approvers))
+}
+
+// Action returns ActionDeclined if any signer has declined,
+// ActionApproved if enough signers have approved to meet the required
+// number of signatures and "none" otherwise.
+func (t SignatureTally) Action() string {
+	if len(t.Decliners) != 0 {
+		return ActionDeclined
+	}
+
+	if t.Collected() >= t.Required {
+		return ActionApproved
+	}
+
+	return "none"
+}
+
+// Progress returns the number of approving signatures collected out of
+// the number required, for example "1/2".
+func (t SignatureTally) Progress() string {
+	return fmt.Sprintf("%d/%d", t.Collected(), t.Required)
+}
+
 // ApprovalPage is used to hold all the information required to render
 // the Approval HTML Template.
 type ApprovalPage struct {
@@ -87,6 +172,8 @@ type ApprovalPage struct {
 	IsSigned        bool
 	IsFinalApproval bool
 	SigLen          int
+	Progress        string
+	ExpiresAt       time.Time
 
 	HasSigner bool
 	Signers   []Approver
@@ -137,6 +224,9 @@ func (a *Approval) GetExportVersion() RegistrarObjectExport {
 		Signature:       a.Signature,
 		CreatedAt:       a.CreatedAt,
 		CreatedBy:       a.CreatedBy,
+
+		SignaturesCollected: a.SignaturesCollected,
+		SignaturesRequired:  a.SignaturesRequired,
 	}
 
 	return export
@@ -159,43 +249,49 @@ func (a *Approval) ParseFromForm(_ *http.Request, _ *DBCache) error {
 
 // GetApprovalAttestation will extract the approval attestation from the
 // signed message stored in the object, if there is one and the
-// signature is valid.
+// signature is valid. If more than one signature has been collected
+// the attestation from the first one is returned.
 func (a *Approval) GetApprovalAttestation(dbCache *DBCache) (appatt ApprovalAttestationUnmarshal, validSig bool, err error) {
-	validSig = false
-	block, _ := clearsign.Decode(a.Signature)
-
-	if block == nil {
-		return appatt, validSig, errors.New("no signature found")
-	}
+	appatt, _, err = a.checkSignature(a.Signature, dbCache)
 
-	blocksErr := a.ApprovalApproverSet.PrepareGPGKeys(dbCache)
+	return appatt, err == nil, err
+}
 
-	if blocksErr == nil {
-		// entity, err)
-		_, sigErr := openpgp.CheckDetachedSignature(a.ApprovalApproverSet, bytes.NewBuffer(block.Bytes), block.ArmoredSignature.Body, nil)
-		if sigErr == nil {
-			jsonerr := json.Unmarshal(block.Bytes, &appatt)
+// checkSignature verifies that the signed message passed was signed by
+// one of the valid approvers in the linked Approver Set and returns the
+// approval attestation it holds along with the fingerprint of the key
+// that signed it.
+func (a *Approval) checkSignature(sig []byte, dbCache *DBCache) (appatt ApprovalAttestationUnmarshal, signer string, err error) {
+	block, _ := clearsign.Decode(sig)
 
-			if jsonerr == nil {
-				validSig = true
+	if block == nil {
+		return appatt, signer, errors.New("no signature found")
+	}
 
-				return appatt, validSig, err
-			}
+	if err = a.ApprovalApproverSet.PrepareGPGKeys(dbCache); err != nil {
+		return appatt, signer, err
+	}
 
-			err = jsonerr
-			validSig = false
+	entity, err := openpgp.CheckDetachedSignature(a.ApprovalApproverSet, bytes.NewBuffer(block.Bytes), block.ArmoredSignature.Body, nil)
+	if err != nil {
+		return appatt, signer, err
+	}
 
-			return appatt, validSig, err
-		}
+	if err = json.Unmarshal(block.Bytes, &appatt); err != nil {
+		return appatt, signer, err
+	}
 
-		err = sigErr
+	return appatt, fmt.Sprintf("%X", entity.PrimaryKey.Fingerprint), nil
+}
 
-		return appatt, validSig, err
+// attestationAction returns the action held in an approval attestation
+// or an error if the action is not known.
+func attestationAction(att ApprovalAttestationUnmarshal) (string, error) {
+	if att.Action == ActionApproved || att.Action == ActionDeclined {
+		return att.Action, nil
 	}
 
-	err = blocksErr
-
-	return appatt, validSig, err
+	return "none", fmt.Errorf("unknown approval action %s", att.Action)
 }
 
 // CheckSignature inspectes the signature of the approval object to
@@ -209,18 +305,77 @@ func (a *Approval) CheckSignature(dbCache *DBCache) (validSig bool, action strin
 	att, validSig, err = a.GetApprovalAttestation(dbCache)
 
 	if validSig && err == nil {
-		if att.Action == ActionApproved {
-			action = ActionApproved
-		} else if att.Action == ActionDeclined {
-			action = ActionDeclined
-		} else {
-			err = fmt.Errorf("unknown approval action %s", att.Action)
-		}
+		action, err = attestationAction(att)
 	}
 
 	return
 }
 
+// SplitSignatures splits the signatures collected for an approval,
+// which are stored one after another, into the individual clearsigned
+// messages. Any data that cannot be decoded is returned as a final
+// entry so that it fails verification.
+func SplitSignatures(sig []byte) (sigs [][]byte) {
+	rest := sig
+
+	for len(bytes.TrimSpace(rest)) != 0 {
+		block, next := clearsign.Decode(rest)
+		if block == nil {
+			return append(sigs, rest)
+		}
+
+		begin := bytes.Index(rest, []byte("-----BEGIN PGP SIGNED MESSAGE-----"))
+		sigs = append(sigs, rest[begin:len(rest)-len(next)])
+		rest = next
+	}
+
+	return sigs
+}
+
+// tallySignatures checks each of the signatures passed against the
+// linked Approver Set and counts the distinct signers that have
+// approved or declined the change request. Signatures that are not
+// valid are left out of the tally and their errors are returned.
+func (a *Approval) tallySignatures(sig []byte, dbCache *DBCache) (tally SignatureTally, errs []error) {
+	tally.Required = a.ApprovalApproverSet.CurrentRevision.GetRequiredSignatures()
+
+	for _, single := range SplitSignatures(sig) {
+		att, signer, err := a.checkSignature(single, dbCache)
+		if err != nil {
+			errs = append(errs, err)
+
+			continue
+		}
+
+		action, err := attestationAction(att)
+		if err != nil {
+			errs = append(errs, err)
+
+			continue
+		}
+
+		if err = tally.Add(signer, action); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return tally, errs
+}
+
+// TallySignatures checks each of the signatures collected for the
+// approval and counts the distinct signers that have approved or
+// declined the change request along with the number of approving
+// signatures that the Approver Set requires.
+func (a *Approval) TallySignatures(dbCache *DBCache) (tally SignatureTally, errs []error) {
+	if !a.prepared {
+		if err := a.Prepare(dbCache); err != nil {
+			return tally, []error{err}
+		}
+	}
+
+	return a.tallySignatures(a.Signature, dbCache)
+}
+
 // ParseFromFormUpdate takes a http Request and parses the field values
 // and populates the acceptable values into the new object. An error is
 // returned if there is difficulty parsing any of the fileds.
@@ -311,30 +466,54 @@ func (a *Approval) processUploadedSignature(sig []byte, username string, appSet
 		return false, err
 	}
 
+	if app.IsPastValidity() {
+		logger.Errorf("Approval %d expired at %s", app.ID, app.ExpiresAt().Format(time.RFC3339))
+
+		return false, ErrApprovalExpired
+	}
+
 	updateMade = false
 	tmp := new(Approval)
-	tmp.Signature = sig
 	// logger.Debugf("Sig: %s", string(sig))
 	tmp.ApprovalApproverSet = appSet
-	validSig, _, sigErr := tmp.CheckSignature(dbCache)
-
-	if validSig {
-		logger.Debug("Valid Signature")
+	att, signer, sigErr := tmp.checkSignature(sig, dbCache)
 
-		a.Signature = sig
-		a.IsSigned = true
-		updateMade = true
-	} else {
+	if sigErr != nil {
 		logger.Debug("Invalid Signature")
+		logger.Errorf("%s", sigErr)
+
+		return updateMade, errors.New("unable to accept signature")
+	}
+
+	if bindErr := att.CheckBinding(app.ID, changeRequest.ID, changeRequest.ProposedRevisionID, conf); bindErr != nil {
+		logger.Errorf("Attestation for Approval %d rejected: %s", app.ID, bindErr.Error())
 
-		if sigErr != nil {
-			err = errors.New("unable to accept signature")
-			logger.Errorf("%s", sigErr)
+		return updateMade, bindErr
+	}
+
+	logger.Debug("Valid Signature")
+
+	for _, existing := range SplitSignatures(app.Signature) {
+		if _, existingSigner, existingErr := tmp.checkSignature(existing, dbCache); existingErr == nil && existingSigner == signer {
+			return updateMade, ErrDuplicateSigner
 		}
+	}
 
-		return updateMade, err
+	combined := append([]byte{}, app.Signature...)
+	if len(combined) != 0 && !bytes.HasSuffix(combined, []byte("\n")) {
+		combined = append(combined, '\n')
 	}
 
+	combined = append(combined, sig...)
+
+	tally, _ := tmp.tallySignatures(combined, dbCache)
+
+	a.Signature = combined
+	a.IsSigned = true
+	a.SignaturesCollected = tally.Collected()
+	a.SignaturesRequired = tally.Required
+	updateMade = true
+
 	if updateMade {
 		a.UpdatedBy = username
 		a.UpdatedAt = TimeNow()
@@ -513,6 +692,10 @@ func (a *Approval) UpdateState(dbCache *DBCache, conf Config) (changesMade bool,
 			a.State = StateCancelled
 			changesMade = true
 			cascadeState = true
+		} else if changeRequest.IsExpired() {
+			a.State = StateExpired
+			changesMade = true
+			cascadeState = true
 		} else {
 			changeRequest := ChangeRequest{}
 			logger.Debugf("Change Request ID: %d", a.ChangeRequestID)
@@ -526,7 +709,7 @@ func (a *Approval) UpdateState(dbCache *DBCache, conf Config) (changesMade bool,
 			logger.Infof("Parent Change request state %s", changeRequest.State)
 
 			if changeRequest.State == StatePendingApproval {
-				newState, newStateErr := a.CheckValidityOfApproverSet(dbCache)
+				newState, newStateErr := a.CheckValidityOfApproverSet(dbCache, conf)
 
 				if newStateErr != nil {
 					logger.Errorf("Approval Error: %s", newState)
@@ -544,7 +727,7 @@ func (a *Approval) UpdateState(dbCache *DBCache, conf Config) (changesMade bool,
 				}
 
 				if a.State != newState {
-					a.State = newState
+					a.setState(newState)
 					changesMade = true
 					cascadeState = true
 				}
@@ -566,12 +749,33 @@ func (a *Approval) UpdateState(dbCache *DBCache, conf Config) (changesMade bool,
 				errs = append(errs, err)
 			}
 
+			changesMade = true
+			cascadeState = true
+		} else if changeRequest.IsExpired() || a.IsPastValidity() {
+			logger.Infof("Approval %d has expired", a.ID)
+			a.State = StateExpired
+
+			if err := a.ApprovalUpdateEmail("EXPIRED", conf, dbCache); err != nil {
+				errs = append(errs, err)
+			}
+
 			changesMade = true
 			cascadeState = true
 		} else if len(a.Signature) != 0 {
-			validSig, action, sigErr := a.CheckSignature(dbCache)
+			tally, tallyErrs := a.TallySignatures(dbCache)
+			for _, tallyErr := range tallyErrs {
+				logger.Debugf("Approval %d: %s", a.ID, tallyErr.Error())
+			}
+
+			logger.Debugf("Approval %d has %s signatures", a.ID, tally.Progress())
 
-			if sigErr == nil && validSig {
+			if a.SignaturesCollected != tally.Collected() || a.SignaturesRequired != tally.Required {
+				a.SignaturesCollected = tally.Collected()
+				a.SignaturesRequired = tally.Required
+				changesMade = true
+			}
+
+			if action := tally.Action(); action != "none" {
 				if changeRequest.Object != nil {
 					verifies, verifyErrs := changeRequest.Object.VerifyCR(dbCache)
 
@@ -597,13 +801,14 @@ func (a *Approval) UpdateState(dbCache *DBCache, conf Config) (changesMade bool,
 						if len(verifyErrs) != 0 {
 							errs = append(errs, verifyErrs...)
 							a.Signature = []byte("")
+							a.SignaturesCollected = 0
 						}
 					}
 				}
 				changesMade = true
 			}
 		} else {
-			newState, newStateErr := a.CheckValidityOfApproverSet(dbCache)
+			newState, newStateErr := a.CheckValidityOfApproverSet(dbCache, conf)
 			if newStateErr != nil {
 				errs = append(errs, newStateErr)
 
@@ -616,31 +821,44 @@ func (a *Approval) UpdateState(dbCache *DBCache, conf Config) (changesMade bool,
 				}
 			}
 			if newState != a.State {
-				a.State = newState
+				a.setState(newState)
 				changesMade = true
 				cascadeState = true
 			}
 		}
 	case StateNoValidApprovers, StateInactiveApproverSet:
-		newState, newStateErr := a.CheckValidityOfApproverSet(dbCache)
-		if newStateErr != nil {
-			errs = append(errs, newStateErr)
+		changeRequest := ChangeRequest{}
+
+		if err := dbCache.FindByID(&changeRequest, a.ChangeRequestID); err != nil {
+			errs = append(errs, err)
 
 			return changesMade, errs
 		}
 
-		if newState == StatePendingApproval && a.State != newState {
-			err := a.NewApprovalEmail(conf, dbCache)
-			if err != nil {
-				logger.Error(err.Error())
+		if changeRequest.IsExpired() {
+			a.State = StateExpired
+			changesMade = true
+		} else {
+			newState, newStateErr := a.CheckValidityOfApproverSet(dbCache, conf)
+			if newStateErr != nil {
+				errs = append(errs, newStateErr)
+
+				return changesMade, errs
 			}
-		}
 
-		if newState != a.State {
-			a.State = newState
-			changesMade = true
+			if newState == StatePendingApproval && a.State != newState {
+				err := a.NewApprovalEmail(conf, dbCache)
+				if err != nil {
+					logger.Error(err.Error())
+				}
+			}
+
+			if newState != a.State {
+				a.setState(newState)
+				changesMade = true
+			}
 		}
-	case StateCancelled, StateApproved, StateDeclined, StateSkippedNoValidApprovers, StateSkippedInactiveApproverSet:
+	case StateCancelled, StateApproved, StateDeclined, StateSkippedNoValidApprovers, StateSkippedInactiveApproverSet, StateExpired:
 		// TerminalState, Nothing to do
 	default:
 		errs = append(errs, fmt.Errorf("don't know how to process state %s", a.State))
@@ -674,8 +892,11 @@ func (a *Approval) UpdateState(dbCache *DBCache, conf Config) (changesMade bool,
 // CheckValidityOfApproverSet will return StateInactiveApproverSet if
 // the approver set is not valid, StateNoValidApprovers if there were
 // no valid approvers for the approverset and StatePendingApproval if
-// the approver set is valid and has valid approvers.
-func (a *Approval) CheckValidityOfApproverSet(dbCache *DBCache) (state string, err error) {
+// the approver set is valid and has valid approvers. StateNew is
+// returned if the approval is waiting on an earlier stage of the
+// approval workflow or is the final approval and other approvals are
+// still outstanding.
+func (a *Approval) CheckValidityOfApproverSet(dbCache *DBCache, conf Config) (state string, err error) {
 	approverSet := ApproverSet{}
 
 	if err = dbCache.FindByID(&approverSet, a.ApproverSetID); err != nil {
@@ -710,7 +931,20 @@ func (a *Approval) CheckValidityOfApproverSet(dbCache *DBCache) (state string, e
 		return StateNoValidApprovers, nil
 	}
 
-	if !a.IsFinalApproval || changeRequest.ReadyForFinalApproval() {
+	if a.IsFinalApproval {
+		if changeRequest.ReadyForFinalApproval() {
+			return StatePendingApproval, nil
+		}
+
+		return StateNew, nil
+	}
+
+	workflow, err := changeRequest.GetApprovalWorkflow(conf)
+	if err != nil {
+		return state, err
+	}
+
+	if changeRequest.StageOpen(workflow, a.ApproverSetID) {
 		return StatePendingApproval, nil
 	}
 
@@ -722,23 +956,33 @@ func (a *Approval) CheckValidityOfApproverSet(dbCache *DBCache) (state string, e
 // from the signed object information.
 func (a *Approval) GetSigner(dbCache *DBCache) (signers []Approver, err error) {
 	if len(a.Signature) > 0 {
-		block, _ := clearsign.Decode(a.Signature)
 		blocksErr := a.ApprovalApproverSet.PrepareGPGKeys(dbCache)
 
 		if blocksErr == nil {
-			entity, err1 := openpgp.CheckDetachedSignature(a.ApprovalApproverSet, bytes.NewBuffer(block.Bytes), block.ArmoredSignature.Body, nil)
+			for _, sig := range SplitSignatures(a.Signature) {
+				block, _ := clearsign.Decode(sig)
+				if block == nil {
+					err = errors.New("signature Not Found or Invalid")
+
+					continue
+				}
+
+				entity, err1 := openpgp.CheckDetachedSignature(a.ApprovalApproverSet, bytes.NewBuffer(block.Bytes), block.ArmoredSignature.Body, nil)
 
-			if entity != nil {
-				for idenStr := range entity.Identities {
-					app, errApp := a.ApprovalApproverSet.ApproverFromIdentityName(idenStr, dbCache)
+				if entity != nil {
+					for idenStr := range entity.Identities {
+						app, errApp := a.ApprovalApproverSet.ApproverFromIdentityName(idenStr, dbCache)
 
-					if errApp == nil {
-						signers = append(signers, app)
+						if errApp == nil {
+							signers = append(signers, app)
+						}
 					}
 				}
-			}
 
-			err = err1
+				if err1 != nil {
+					err = err1
+				}
+			}
 
 			return signers, err
 		}
@@ -778,7 +1022,45 @@ func (a *Approval) IsCancelled() bool {
 func (a *Approval) IsEditable() bool {
 	return a.State != StateApproved && a.State != StateDeclined &&
 		a.State != StateCancelled && a.State != StateSkippedNoValidApprovers &&
-		a.State != StateSkippedInactiveApproverSet
+		a.State != StateSkippedInactiveApproverSet && a.State != StateExpired
+}
+
+// setState moves the Approval to the state passed. The first time the
+// Approval moves to StatePendingApproval the time it was opened for
+// signing is recorded.
+func (a *Approval) setState(state string) {
+	if state == StatePendingApproval && a.OpenedAt == nil {
+		openedAt := TimeNow()
+		a.OpenedAt = &openedAt
+	}
+
+	a.State = state
+}
+
+// ExpiresAt returns the time at which the Approval expires if it has
+// not been completed. The validity window starts when the Approval was
+// opened for signing, or when it was created if it was opened before
+// the opened time was recorded. The zero time is returned if Approvals
+// for the linked Approver Set do not expire.
+func (a *Approval) ExpiresAt() (expiresAt time.Time) {
+	if validity := a.ApprovalApproverSet.CurrentRevision.GetApprovalValidity(); validity != 0 {
+		openedAt := a.CreatedAt
+		if a.OpenedAt != nil {
+			openedAt = *a.OpenedAt
+		}
+
+		expiresAt = openedAt.Add(validity)
+	}
+
+	return expiresAt
+}
+
+// IsPastValidity returns true iff the Approval has a validity window
+// and it has passed.
+func (a *Approval) IsPastValidity() bool {
+	expiresAt := a.ExpiresAt()
+
+	return !expiresAt.IsZero() && TimeNow().After(expiresAt)
 }
 
 // GetPage will return an object that can be used to render the HTML
@@ -806,6 +1088,10 @@ func (a *Approval) GetPage(dbCache *DBCache, _ string, email string) (rop Regist
 		ret.SigLen = -1
 	}
 
+	tally, _ := a.TallySignatures(dbCache)
+	ret.Progress = tally.Progress()
+	ret.ExpiresAt = a.ExpiresAt()
+
 	err = a.ApprovalApproverSet.Prepare(dbCache)
 
 	if err != nil {
@@ -858,12 +1144,24 @@ type ApprovalAttestationUnmarshal struct {
 	Action     string          `json:"Action"`
 	ObjectType string          `json:"ObjectType"`
 	Signatures [][]byte        `json:"Signature"`
+
+	ChangeRequestID    int64     `json:"ChangeRequestID"`
+	ProposedRevisionID int64     `json:"ProposedRevisionID"`
+	Nonce              string    `json:"Nonce"`
+	IssuedAt           time.Time `json:"IssuedAt"`
+	ExpiresAt          time.Time `json:"ExpiresAt"`
 }
 
 // ApprovalAttestation is used to create the object that is exported
 // and presented to an Approver so they can either approve or decline
 // an approval. The exported object is then signed using a GPG key
 // and then resubmitted to the registrar system.
+//
+// The attestation is bound to the Change Request and proposed revision
+// it was issued for and is only accepted until it expires. The Nonce
+// is issued by the server and carries a MAC over the other binding
+// fields so that they cannot be altered before the attestation is
+// signed.
 type ApprovalAttestation struct {
 	ApprovalID int64                 `json:"ApprovalID"`
 	ExportRev  RegistrarObjectExport `json:"ExportRev"`
@@ -871,6 +1169,12 @@ type ApprovalAttestation struct {
 	Action     string                `json:"Action"`
 	ObjectType string                `json:"ObjectType"`
 	Signatures [][]byte              `json:"Signature"`
+
+	ChangeRequestID    int64     `json:"ChangeRequestID"`
+	ProposedRevisionID int64     `json:"ProposedRevisionID"`
+	Nonce              string    `json:"Nonce"`
+	IssuedAt           time.Time `json:"IssuedAt"`
+	ExpiresAt          time.Time `json:"ExpiresAt"`
 }
 
 // ToJSON will return a string containing a JSON representation of the
@@ -889,12 +1193,103 @@ func (a ApprovalAttestation) ToJSON() (string, error) {
 	return string(byteArr), nil
 }
 
+// attestationNonceSeedLength is the number of random bytes included in
+// an attestation nonce.
+const attestationNonceSeedLength = 16
+
+// The errors returned when an uploaded approval attestation is not
+// bound to the approval it was uploaded for.
+var (
+	// ErrAttestationExpired is returned when an attestation is uploaded
+	// after it has expired.
+	ErrAttestationExpired = errors.New("the approval attestation has expired, please download a new one")
+
+	// ErrAttestationMismatch is returned when an attestation was issued
+	// for a different approval, change request or revision.
+	ErrAttestationMismatch = errors.New("the approval attestation was not issued for this approval")
+
+	// ErrAttestationNonce is returned when the nonce of an attestation
+	// was not issued by the server for the rest of the attestation.
+	ErrAttestationNonce = errors.New("the approval attestation nonce is not valid")
+)
+
+// attestationNonceMAC calculates the MAC of an attestation nonce over
+// the fields that bind the attestation to an approval.
+func attestationNonceMAC(seed string, approvalID int64, changeRequestID int64, proposedRevisionID int64, issuedAt time.Time, expiresAt time.Time, conf Config) string {
+	message := fmt.Sprintf("%s:%d:%d:%d:%d:%d", seed, approvalID, changeRequestID, proposedRevisionID, issuedAt.Unix(), expiresAt.Unix())
+	mac := hmac.New(sha256.New, conf.GetHMACKey())
+	mac.Write([]byte(message))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// newAttestationNonce generates a nonce for the attestation passed
+// using a random seed and a MAC over the binding fields.
+func newAttestationNonce(aa ApprovalAttestation, conf Config) (string, error) {
+	seedBytes := make([]byte, attestationNonceSeedLength)
+	if _, err := rand.Read(seedBytes); err != nil {
+		return "", fmt.Errorf("unable to get random seed: %w", err)
+	}
+
+	seed := hex.EncodeToString(seedBytes)
+
+	return seed + ":" + attestationNonceMAC(seed, aa.ApprovalID, aa.ChangeRequestID, aa.ProposedRevisionID, aa.IssuedAt, aa.ExpiresAt, conf), nil
+}
+
+// CheckBinding verifies that the attestation was issued by the server
+// for the approval, change request and proposed revision passed and
+// that it has not expired. An error is returned describing the first
+// check that fails.
+func (aa ApprovalAttestationUnmarshal) CheckBinding(approvalID int64, changeRequestID int64, proposedRevisionID int64, conf Config) error {
+	if aa.ApprovalID != approvalID || aa.ChangeRequestID != changeRequestID || aa.ProposedRevisionID != proposedRevisionID {
+		return ErrAttestationMismatch
+	}
+
+	seed, mac, found := strings.Cut(aa.Nonce, ":")
+	if !found || seed == "" {
+		return ErrAttestationNonce
+	}
+
+	expected := attestationNonceMAC(seed, aa.ApprovalID, aa.ChangeRequestID, aa.ProposedRevisionID, aa.IssuedAt, aa.ExpiresAt, conf)
+	if !hmac.Equal([]byte(mac), []byte(expected)) {
+		return ErrAttestationNonce
+	}
+
+	now := TimeNow()
+	if aa.IssuedAt.After(now) || !aa.ExpiresAt.After(now) {
+		return ErrAttestationExpired
+	}
+
+	return nil
+}
+
+// SameApproval returns true iff the attestation passed takes the same
+// action on the same approval, change request and revision as the
+// calling attestation. The fields issued to each signer, such as the
+// username and nonce, are not compared.
+func (aa ApprovalAttestationUnmarshal) SameApproval(other ApprovalAttestationUnmarshal) bool {
+	if aa.ApprovalID != other.ApprovalID || aa.ChangeRequestID != other.ChangeRequestID || aa.ProposedRevisionID != other.ProposedRevisionID {
+		return false
+	}
+
+	if aa.Action != other.Action || aa.ObjectType != other.ObjectType {
+		return false
+	}
+
+	exportRev, otherExportRev := new(bytes.Buffer), new(bytes.Buffer)
+	if json.Compact(exportRev, aa.ExportRev) != nil || json.Compact(otherExportRev, other.ExportRev) != nil {
+		return false
+	}
+
+	return bytes.Equal(exportRev.Bytes(), otherExportRev.Bytes())
+}
+
 // GetDownload is used to generate an approval assertion that can be
 // used to approve or decline the Approval.
 //
 // TODO: add an error to the return.
-func (a *Approval) GetDownload(dbCache *DBCache, username string, method string) string {
-	aa, err := a.GetDownloadAttestation(dbCache, username, method)
+func (a *Approval) GetDownload(dbCache *DBCache, username string, method string, conf Config) string {
+	aa, err := a.GetDownloadAttestation(dbCache, username, method, conf)
 	if err != nil {
 		return ""
 	}
@@ -905,9 +1300,9 @@ func (a *Approval) GetDownload(dbCache *DBCache, username string, method string)
 }
 
 // GetDownloadAttestation will create and return an ApprovalAttestation
-// object for the calling Approval. If the method is not valid an error
-// will be returned.
-func (a *Approval) GetDownloadAttestation(dbCache *DBCache, username string, method string) (aa ApprovalAttestation, err error) {
+// object for the calling Approval with a newly issued nonce. If the
+// method is not valid an error will be returned.
+func (a *Approval) GetDownloadAttestation(dbCache *DBCache, username string, method string, conf Config) (aa ApprovalAttestation, err error) {
 	if method != ActionApproved && method != ActionDeclined {
 		err = fmt.Errorf("invalid approval method %s", method)
 
@@ -920,12 +1315,23 @@ func (a *Approval) GetDownloadAttestation(dbCache *DBCache, username string, met
 		return aa, err
 	}
 
+	issuedAt := TimeNow()
+
 	aa = ApprovalAttestation{
 		ApprovalID: a.ID,
 		ExportRev:  changeRequest.Object.GetExportVersion(),
 		Username:   username,
 		Action:     method,
 		ObjectType: changeRequest.RegistrarObjectType,
+
+		ChangeRequestID:    changeRequest.ID,
+		ProposedRevisionID: changeRequest.ProposedRevisionID,
+		IssuedAt:           issuedAt,
+		ExpiresAt:          issuedAt.Add(conf.AttestationValidity()),
+	}
+
+	if aa.Nonce, err = newAttestationNonce(aa, conf); err != nil {
+		return aa, err
 	}
 
 	if a.IsFinalApproval {
@@ -933,7 +1339,7 @@ func (a *Approval) GetDownloadAttestation(dbCache *DBCache, username string, met
 
 		for _, app := range changeRequest.Approvals {
 			if app.ID != a.ID {
-				aa.Signatures = append(aa.Signatures, app.Signature)
+				aa.Signatures = append(aa.Signatures, SplitSignatures(app.Signature)...)
 			}
 		}
 	}
@@ -1047,7 +1453,7 @@ func (a *Approval) TakeAction(responseWriter http.ResponseWriter, request *http.
 
 		if authMethod == RemoteUserAuthType {
 			logger.Debugf("Downloading approval for %s", runame)
-			output := a.GetDownload(dbCache, runame, action)
+			output := a.GetDownload(dbCache, runame, action, conf)
 
 			responseWriter.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=approval%d-%s.txt", a.ID, runame))
 			responseWriter.Header().Set("Content-Type", request.Header.Get("Content-Type"))
@@ -1055,7 +1461,7 @@ func (a *Approval) TakeAction(responseWriter http.ResponseWriter, request *http.
 			fmt.Fprint(responseWriter, output)
 		} else if authMethod == CertAuthType {
 			logger.Debugf("Downloading approval for %s", runame)
-			output, err := a.GetDownloadAttestation(dbCache, runame, action)
+			output, err := a.GetDownloadAttestation(dbCache, runame, action, conf)
 			if err != nil {
 				errs = append(errs, err)
 
@@ -3,6 +3,7 @@ package lib
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
@@ -10,6 +11,7 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	. "github.com/smartystreets/goconvey/convey"
 )
@@ -110,7 +112,7 @@ func Test_Approval_ParseFromFormUpdate_NoRemoteUser(t *testing.T) {
 		t.Error(err)
 	}
 
-	message := appr.GetDownload(dbCache, "test", ActionApproved)
+	message := appr.GetDownload(dbCache, "test", ActionApproved, mustGetTestConf())
 
 	signed, err := ClearsignMessage(message, TestUser1Username)
 	if err != nil {
@@ -163,7 +165,7 @@ func Test_Approval_ParseFromFormUpdate_IncorrectSigner(t *testing.T) {
 		err = appr.Prepare(dbCache)
 		So(err, ShouldBeNil)
 
-		message := appr.GetDownload(dbCache, "test", ActionApproved)
+		message := appr.GetDownload(dbCache, "test", ActionApproved, mustGetTestConf())
 
 		path, err := getOrGenerateTestingGPGKey(TestUser2Username)
 		So(err, ShouldBeNil)
@@ -213,7 +215,7 @@ func Test_Approval_ParseFromFormUpdate_CorrectSigner(t *testing.T) {
 			t.Errorf("Expected approval %d to be in %s rather than %s", app.ID, StatePendingApproval, app.State)
 		}
 
-		message := app.GetDownload(dbCache, "test", ActionApproved)
+		message := app.GetDownload(dbCache, "test", ActionApproved, mustGetTestConf())
 
 		signed, err := ClearsignMessage(message, TestUser1Username)
 		if err != nil {
@@ -374,7 +376,7 @@ func Test_Approval_ParseFromFormUpdate_CorrectSigner_Declined(t *testing.T) {
 			t.Errorf("Expected approval %d to be in %s rather than %s", app.ID, StatePendingApproval, app.State)
 		}
 
-		message := app.GetDownload(dbCache, "test", ActionDeclined)
+		message := app.GetDownload(dbCache, "test", ActionDeclined, mustGetTestConf())
 
 		signed, err := ClearsignMessage(message, TestUser1Username)
 		if err != nil {
@@ -483,7 +485,7 @@ func Test_Approval_ParseFromFormUpdate_CorrectSigner_CorruptedSigAfterUpload(t *
 			t.Errorf("Expected approval %d to be in %s rather than %s", app.ID, StatePendingApproval, app.State)
 		}
 
-		message := app.GetDownload(dbCache, "test", ActionDeclined)
+		message := app.GetDownload(dbCache, "test", ActionDeclined, mustGetTestConf())
 
 		signed, err := ClearsignMessage(message, TestUser1Username)
 		if err != nil {
@@ -607,13 +609,15 @@ func Test_Approval_ParseFromFormUpdate_BogusAction(t *testing.T) {
 			t.Errorf("Expected approval %d to be in %s rather than %s", app.ID, StatePendingApproval, app.State)
 		}
 
-		message := app.GetDownload(dbCache, "test", bogusState)
+		conf := mustGetTestConf()
+
+		message := app.GetDownload(dbCache, "test", bogusState, conf)
 		if len(message) != 0 {
 			t.Error("Expected GetDownload to return an empty string with passed an unknown action")
 		}
 
 		// Now lets create an action that does not parse correctly
-		message = app.GetDownload(dbCache, "test", ActionApproved)
+		message = app.GetDownload(dbCache, "test", ActionApproved, conf)
 		message = strings.Replace(message, "\"Action\": \"approve\"", "\"Action\": \"bogus\"", 1)
 
 		signed, err := ClearsignMessage(message, TestUser1Username)
@@ -721,13 +725,15 @@ func Test_Approval_ParseFromFormUpdate_JSONError(t *testing.T) {
 			t.Errorf("Expected approval %d to be in %s rather than %s", app.ID, StatePendingApproval, app.State)
 		}
 
-		message := app.GetDownload(dbCache, "test", bogusState)
+		conf := mustGetTestConf()
+
+		message := app.GetDownload(dbCache, "test", bogusState, conf)
 		if len(message) != 0 {
 			t.Error("Expected GetDownload to return an empty string with passed an unknown action")
 		}
 
 		// Now lets create an action that does not parse correctly
-		message = app.GetDownload(dbCache, "test", ActionApproved)
+		message = app.GetDownload(dbCache, "test", ActionApproved, conf)
 		message = strings.Replace(message, "\"Action\": \"approve\"", "\"Action\": \"bogus\"}", 1)
 
 		signed, err := ClearsignMessage(message, TestUser1Username)
@@ -843,13 +849,15 @@ func Test_Approval_ParseFromFormUpdate_FormError(t *testing.T) {
 			t.Errorf("Expected approval %d to be in %s rather than %s", app.ID, StatePendingApproval, app.State)
 		}
 
-		message := app.GetDownload(dbCache, "test", bogusState)
+		conf := mustGetTestConf()
+
+		message := app.GetDownload(dbCache, "test", bogusState, conf)
 		if len(message) != 0 {
 			t.Error("Expected GetDownload to return an empty string with passed an unknown action")
 		}
 
 		// Now lets create an action that does not parse correctly
-		message = app.GetDownload(dbCache, "test", ActionApproved)
+		message = app.GetDownload(dbCache, "test", ActionApproved, conf)
 		message = strings.Replace(message, "\"Action\": \"approve\"", "\"Action\": \"bogus\"", 1)
 
 		signed, err := ClearsignMessage(message, TestUser1Username)
@@ -1171,7 +1179,7 @@ func Test_Approval_GetPage(t *testing.T) {
 			t.Error("Expected SigLen to be -1 since there is no signature")
 		}
 
-		message := app.GetDownload(dbCache, "test", ActionApproved)
+		message := app.GetDownload(dbCache, "test", ActionApproved, mustGetTestConf())
 
 		signed, err := ClearsignMessage(message, TestUser1Username)
 		if err != nil {
@@ -1487,6 +1495,194 @@ func TestApprovalIsCanclled(t *testing.T) {
 	})
 }
 
+func TestApprovalIsPastValidity(t *testing.T) {
+	t.Parallel()
+	Convey("Given an approval for an approver set without a validity window", t, func() {
+		app := Approval{CreatedAt: TimeNow().AddDate(0, -1, 0)}
+
+		Convey("The approval should not expire", func() {
+			So(app.ExpiresAt().IsZero(), ShouldBeTrue)
+			So(app.IsPastValidity(), ShouldBeFalse)
+		})
+	})
+
+	Convey("Given an approval for an approver set with a validity window", t, func() {
+		app := Approval{}
+		app.ApprovalApproverSet.CurrentRevision.ApprovalValidityDays = 3
+
+		Convey("A recent approval should not be past its validity", func() {
+			app.CreatedAt = TimeNow().AddDate(0, 0, -2)
+			So(app.ExpiresAt(), ShouldEqual, app.CreatedAt.AddDate(0, 0, 3))
+			So(app.IsPastValidity(), ShouldBeFalse)
+			So(app.IsEditable(), ShouldBeTrue)
+		})
+
+		Convey("An old approval should be past its validity", func() {
+			app.CreatedAt = TimeNow().AddDate(0, 0, -4)
+			So(app.IsPastValidity(), ShouldBeTrue)
+		})
+	})
+
+	Convey("Given an expired approval", t, func() {
+		app := Approval{State: StateExpired}
+		So(app.IsEditable(), ShouldBeFalse)
+	})
+
+	Convey("Given a change request with two stages and a short validity window", t, func() {
+		workflow := ApprovalWorkflow{FinalApproverSetID: 1, Stages: [][]int64{{2}, {3}}}
+		createdAt := TimeNow().AddDate(0, 0, -5)
+		changeRequest := ChangeRequest{
+			Approvals: []Approval{
+				{ApproverSetID: 2, State: StateNew, CreatedAt: createdAt},
+				{ApproverSetID: 3, State: StateNew, CreatedAt: createdAt},
+			},
+		}
+
+		for idx := range changeRequest.Approvals {
+			changeRequest.Approvals[idx].ApprovalApproverSet.CurrentRevision.ApprovalValidityDays = 3
+		}
+
+		first := &changeRequest.Approvals[0]
+		second := &changeRequest.Approvals[1]
+
+		Convey("The second stage should be valid from when it opens", func() {
+			So(changeRequest.StageOpen(workflow, 2), ShouldBeTrue)
+			first.setState(StatePendingApproval)
+			So(first.OpenedAt, ShouldNotBeNil)
+
+			// The first stage took longer than the validity window of the
+			// approver set to be approved
+			openedAt := createdAt
+			first.OpenedAt = &openedAt
+			first.setState(StateApproved)
+			So(*first.OpenedAt, ShouldEqual, openedAt)
+
+			So(changeRequest.StageOpen(workflow, 3), ShouldBeTrue)
+			So(second.OpenedAt, ShouldBeNil)
+			second.setState(StatePendingApproval)
+			So(second.OpenedAt, ShouldNotBeNil)
+			So(second.ExpiresAt(), ShouldEqual, second.OpenedAt.AddDate(0, 0, 3))
+			So(second.IsPastValidity(), ShouldBeFalse)
+
+			Convey("And should expire once its own window has passed", func() {
+				reopenedAt := TimeNow().AddDate(0, 0, -4)
+				second.OpenedAt = &reopenedAt
+				second.setState(StatePendingApproval)
+				So(*second.OpenedAt, ShouldEqual, reopenedAt)
+				So(second.IsPastValidity(), ShouldBeTrue)
+			})
+		})
+	})
+}
+
+func TestApprovalAttestationCheckBinding(t *testing.T) {
+	t.Parallel()
+	Convey("Given an attestation issued for an approval", t, func() {
+		conf := Config{}
+		conf.CSRF.MACKey = "attestationkey"
+
+		issuedAt := TimeNow()
+		issued := ApprovalAttestation{
+			ApprovalID:         3,
+			Action:             ActionApproved,
+			ChangeRequestID:    2,
+			ProposedRevisionID: 5,
+			IssuedAt:           issuedAt,
+			ExpiresAt:          issuedAt.Add(conf.AttestationValidity()),
+		}
+
+		nonce, err := newAttestationNonce(issued, conf)
+		So(err, ShouldBeNil)
+
+		issued.Nonce = nonce
+
+		unmarshal := func(aa ApprovalAttestation) (att ApprovalAttestationUnmarshal) {
+			data, marshalErr := json.Marshal(aa)
+			So(marshalErr, ShouldBeNil)
+			So(json.Unmarshal(data, &att), ShouldBeNil)
+
+			return att
+		}
+
+		Convey("It should be accepted for the same approval", func() {
+			So(unmarshal(issued).CheckBinding(3, 2, 5, conf), ShouldBeNil)
+		})
+
+		Convey("It should be rejected for another approval, change request or revision", func() {
+			att := unmarshal(issued)
+			So(att.CheckBinding(4, 2, 5, conf), ShouldEqual, ErrAttestationMismatch)
+			So(att.CheckBinding(3, 1, 5, conf), ShouldEqual, ErrAttestationMismatch)
+			So(att.CheckBinding(3, 2, 6, conf), ShouldEqual, ErrAttestationMismatch)
+		})
+
+		Convey("It should be rejected if the binding fields were altered", func() {
+			altered := issued
+			altered.ApprovalID = 4
+			altered.ExpiresAt = altered.ExpiresAt.Add(time.Hour)
+			So(unmarshal(altered).CheckBinding(4, 2, 5, conf), ShouldEqual, ErrAttestationNonce)
+		})
+
+		Convey("It should be rejected if the nonce was not issued by the server", func() {
+			other := conf
+			other.CSRF.MACKey = "otherkey"
+			So(unmarshal(issued).CheckBinding(3, 2, 5, other), ShouldEqual, ErrAttestationNonce)
+
+			missing := issued
+			missing.Nonce = ""
+			So(unmarshal(missing).CheckBinding(3, 2, 5, conf), ShouldEqual, ErrAttestationNonce)
+		})
+
+		Convey("It should be rejected once it has expired", func() {
+			expired := issued
+			expired.IssuedAt = issuedAt.Add(-2 * conf.AttestationValidity())
+			expired.ExpiresAt = issuedAt.Add(-conf.AttestationValidity())
+			expired.Nonce, err = newAttestationNonce(expired, conf)
+			So(err, ShouldBeNil)
+			So(unmarshal(expired).CheckBinding(3, 2, 5, conf), ShouldEqual, ErrAttestationExpired)
+		})
+	})
+}
+
+func TestSignatureTally(t *testing.T) {
+	t.Parallel()
+	Convey("Given a tally requiring two signatures", t, func() {
+		tally := SignatureTally{Required: 2}
+
+		Convey("No action should be taken before any signatures are added", func() {
+			So(tally.Action(), ShouldEqual, "none")
+			So(tally.Progress(), ShouldEqual, "0/2")
+		})
+
+		Convey("A single approval should only be partial progress", func() {
+			So(tally.Add("AAAA", ActionApproved), ShouldBeNil)
+			So(tally.Action(), ShouldEqual, "none")
+			So(tally.Progress(), ShouldEqual, "1/2")
+
+			Convey("A second signature from the same signer should be rejected", func() {
+				So(tally.Add("AAAA", ActionApproved), ShouldEqual, ErrDuplicateSigner)
+				So(tally.Add("AAAA", ActionDeclined), ShouldEqual, ErrDuplicateSigner)
+				So(tally.Progress(), ShouldEqual, "1/2")
+			})
+
+			Convey("A second distinct approval should approve", func() {
+				So(tally.Add("BBBB", ActionApproved), ShouldBeNil)
+				So(tally.Action(), ShouldEqual, ActionApproved)
+				So(tally.Progress(), ShouldEqual, "2/2")
+			})
+
+			Convey("A decline should decline", func() {
+				So(tally.Add("BBBB", ActionDeclined), ShouldBeNil)
+				So(tally.Action(), ShouldEqual, ActionDeclined)
+			})
+		})
+
+		Convey("Unknown actions should be rejected", func() {
+			So(tally.Add("AAAA", bogusState), ShouldNotBeNil)
+			So(tally.Collected(), ShouldEqual, 0)
+		})
+	})
+}
+
 func TestApprovalUpdateState(t *testing.T) {
 	t.Parallel()
 	Convey("Given an approval in an unknown state", t, func() {
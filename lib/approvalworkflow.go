@@ -0,0 +1,109 @@
+// Package lib provides the objects required to operate registrar
+package lib
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DefaultFinalApproverSetID is the ID of the Approver Set that gives
+// the final approval for a Change Request if no other set is
+// configured. It is the Approver Set created when the registrar is
+// bootstrapped.
+const DefaultFinalApproverSetID int64 = 1
+
+// WorkflowConfig holds the configuration of the approval workflow for
+// Change Requests. FinalApproverSet is the ID of the Approver Set whose
+// approval is only opened after all other approvals have passed. Each
+// Stage holds a comma separated list of Approver Set IDs, the stages
+// are opened in the order they are listed.
+type WorkflowConfig struct {
+	FinalApproverSet int64
+	Stage            []string
+}
+
+// ApprovalWorkflow is the parsed approval workflow used for a Change
+// Request.
+type ApprovalWorkflow struct {
+	FinalApproverSetID int64
+	Stages             [][]int64
+}
+
+// ParseWorkflow parses the workflow configuration passed. An error is
+// returned if a stage holds a value that is not an Approver Set ID.
+func ParseWorkflow(wc WorkflowConfig) (workflow ApprovalWorkflow, err error) {
+	workflow.FinalApproverSetID = wc.FinalApproverSet
+	if workflow.FinalApproverSetID < 1 {
+		workflow.FinalApproverSetID = DefaultFinalApproverSetID
+	}
+
+	for _, stage := range wc.Stage {
+		var ids []int64
+
+		for _, raw := range strings.Split(stage, ",") {
+			if raw = strings.TrimSpace(raw); raw == "" {
+				continue
+			}
+
+			id, parseErr := strconv.ParseInt(raw, 10, 64)
+			if parseErr != nil || id < 1 {
+				return workflow, fmt.Errorf("invalid approver set id %q in workflow stage %q", raw, stage)
+			}
+
+			ids = append(ids, id)
+		}
+
+		if len(ids) != 0 {
+			workflow.Stages = append(workflow.Stages, ids)
+		}
+	}
+
+	return workflow, nil
+}
+
+// WorkflowKey returns the name of the objectWorkflow section that
+// configures the approval workflow for the object type and domain
+// class passed.
+func WorkflowKey(objectType string, class string) string {
+	if class == "" {
+		return objectType
+	}
+
+	return objectType + "/" + class
+}
+
+// GetApprovalWorkflow returns the approval workflow for Change Requests
+// on objects of the type passed. For domains the workflow configured for
+// the domain class is used if there is one, otherwise the workflow for
+// the object type is used, falling back to the default workflow.
+func (con Config) GetApprovalWorkflow(objectType string, class string) (ApprovalWorkflow, error) {
+	for _, key := range []string{WorkflowKey(objectType, class), objectType} {
+		if wc, ok := con.ObjectWorkflow[key]; ok && wc != nil {
+			return ParseWorkflow(*wc)
+		}
+	}
+
+	return ParseWorkflow(con.Workflow)
+}
+
+// StageOf returns the index of the stage that approvals for the
+// Approver Set passed are part of. Approver Sets that are not listed
+// in any stage are part of the first stage.
+func (w ApprovalWorkflow) StageOf(approverSetID int64) int {
+	for idx, stage := range w.Stages {
+		for _, id := range stage {
+			if id == approverSetID {
+				return idx
+			}
+		}
+	}
+
+	return 0
+}
+
+// IsFinal returns true iff the Approver Set passed is the final
+// Approver Set of the workflow.
+func (w ApprovalWorkflow) IsFinal(approverSetID int64) bool {
+	return approverSetID == w.FinalApproverSetID
+}
@@ -0,0 +1,125 @@
+package lib
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestParseWorkflow(t *testing.T) {
+	t.Parallel()
+	Convey("Given an empty workflow configuration", t, func() {
+		workflow, err := ParseWorkflow(WorkflowConfig{})
+		So(err, ShouldBeNil)
+
+		Convey("The bootstrap approver set should give the final approval", func() {
+			So(workflow.FinalApproverSetID, ShouldEqual, DefaultFinalApproverSetID)
+			So(workflow.IsFinal(1), ShouldBeTrue)
+			So(workflow.Stages, ShouldBeEmpty)
+		})
+	})
+
+	Convey("Given a workflow configuration with stages", t, func() {
+		workflow, err := ParseWorkflow(WorkflowConfig{FinalApproverSet: 4, Stage: []string{"2, 3", "5"}})
+		So(err, ShouldBeNil)
+
+		Convey("The stages should be parsed in order", func() {
+			So(workflow.FinalApproverSetID, ShouldEqual, 4)
+			So(workflow.Stages, ShouldResemble, [][]int64{{2, 3}, {5}})
+			So(workflow.StageOf(3), ShouldEqual, 0)
+			So(workflow.StageOf(5), ShouldEqual, 1)
+		})
+
+		Convey("Approver sets not in a stage should be in the first stage", func() {
+			So(workflow.StageOf(9), ShouldEqual, 0)
+		})
+	})
+
+	Convey("Given a workflow configuration with an invalid stage", t, func() {
+		_, err := ParseWorkflow(WorkflowConfig{Stage: []string{"2, tech"}})
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestConfigGetApprovalWorkflow(t *testing.T) {
+	t.Parallel()
+	Convey("Given a configuration with object and domain class workflows", t, func() {
+		conf := Config{}
+		conf.Workflow.FinalApproverSet = 2
+		conf.ObjectWorkflow = map[string]*WorkflowConfig{
+			DomainType: {FinalApproverSet: 3},
+			WorkflowKey(DomainType, DomainClassHighValue): {FinalApproverSet: 4, Stage: []string{"5", "6"}},
+		}
+
+		Convey("The domain class workflow should be used when it is set", func() {
+			workflow, err := conf.GetApprovalWorkflow(DomainType, DomainClassHighValue)
+			So(err, ShouldBeNil)
+			So(workflow.FinalApproverSetID, ShouldEqual, 4)
+			So(workflow.Stages, ShouldResemble, [][]int64{{5}, {6}})
+		})
+
+		Convey("The object type workflow should be used for other classes", func() {
+			workflow, err := conf.GetApprovalWorkflow(DomainType, DomainClassParked)
+			So(err, ShouldBeNil)
+			So(workflow.FinalApproverSetID, ShouldEqual, 3)
+		})
+
+		Convey("The default workflow should be used for other object types", func() {
+			workflow, err := conf.GetApprovalWorkflow(HostType, "")
+			So(err, ShouldBeNil)
+			So(workflow.FinalApproverSetID, ShouldEqual, 2)
+		})
+	})
+}
+
+func TestChangeRequestStageOpen(t *testing.T) {
+	t.Parallel()
+	Convey("Given a change request with a staged workflow", t, func() {
+		workflow := ApprovalWorkflow{FinalApproverSetID: 1, Stages: [][]int64{{2}, {3, 4}, {5}}}
+		changeRequest := ChangeRequest{
+			Approvals: []Approval{
+				{ApproverSetID: 2, State: StatePendingApproval},
+				{ApproverSetID: 3, State: StateNew},
+				{ApproverSetID: 4, State: StateNew},
+				{ApproverSetID: 5, State: StateNew},
+				{ApproverSetID: 1, State: StateNew, IsFinalApproval: true},
+			},
+		}
+
+		Convey("Only the first stage should be open", func() {
+			So(changeRequest.StageOpen(workflow, 2), ShouldBeTrue)
+			So(changeRequest.StageOpen(workflow, 3), ShouldBeFalse)
+			So(changeRequest.StageOpen(workflow, 5), ShouldBeFalse)
+		})
+
+		Convey("Later stages should open once earlier stages pass", func() {
+			changeRequest.Approvals[0].State = StateApproved
+			So(changeRequest.StageOpen(workflow, 3), ShouldBeTrue)
+			So(changeRequest.StageOpen(workflow, 5), ShouldBeFalse)
+
+			changeRequest.Approvals[1].State = StateApproved
+			changeRequest.Approvals[2].State = StateNoValidApprovers
+			So(changeRequest.StageOpen(workflow, 5), ShouldBeTrue)
+		})
+	})
+
+	Convey("Given a change request for a high value domain", t, func() {
+		conf := Config{}
+		conf.ObjectWorkflow = map[string]*WorkflowConfig{
+			WorkflowKey(DomainType, DomainClassHighValue): {FinalApproverSet: 4},
+		}
+
+		dom := &Domain{}
+		dom.CurrentRevisionID.Valid = true
+		dom.CurrentRevision.Class = DomainClassHighValue
+		dom.PendingRevision.Class = DomainClassParked
+
+		changeRequest := ChangeRequest{RegistrarObjectType: DomainType, Object: dom}
+
+		Convey("The workflow for the current class of the domain should be used", func() {
+			workflow, err := changeRequest.GetApprovalWorkflow(conf)
+			So(err, ShouldBeNil)
+			So(workflow.FinalApproverSetID, ShouldEqual, 4)
+		})
+	})
+}
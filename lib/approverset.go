@@ -251,6 +251,10 @@ func (a ApproverSet) SuggestedRevisionValue(field string) string {
 			return a.CurrentRevision.Title
 		case ApproverSetFieldDescription:
 			return a.CurrentRevision.Description
+		case ApproverSetFieldRequiredSignatures:
+			return strconv.FormatInt(a.CurrentRevision.GetRequiredSignatures(), 10)
+		case ApproverSetFieldApprovalValidityDays:
+			return strconv.FormatInt(a.CurrentRevision.ApprovalValidityDays, 10)
 		case SavedObjectNote:
 			return a.CurrentRevision.SavedNotes
 		}
@@ -435,8 +439,8 @@ func (a *ApproverSet) UpdateState(dbCache *DBCache, conf Config) (changesMade bo
 
 						return changesMade, errs
 					}
-				} else if changeRequest.State == StateDeclined {
-					logger.Infof("CR %d has been declined", changeRequest.GetID())
+				} else if changeRequest.State == StateDeclined || changeRequest.State == StateExpired {
+					logger.Infof("CR %d has been %s", changeRequest.GetID(), changeRequest.State)
 
 					if err := a.PendingRevision.Decline(dbCache); err != nil {
 						errs = append(errs, err)
@@ -36,6 +36,10 @@ type ApproverSetRevision struct {
 
 	Approvers []Approver `gorm:"many2many:approver_to_revision_set;"`
 
+	RequiredSignatures int64
+
+	ApprovalValidityDays int64
+
 	SavedNotes string `sql:"size:16384"`
 
 	RequiredApproverSets []ApproverSet `gorm:"many2many:required_approverset_to_approversetrevision"`
@@ -73,6 +77,10 @@ type ApproverSetRevisionExport struct {
 
 	Approvers []ApproverExportShort `json:"Approvers"`
 
+	RequiredSignatures int64 `json:"RequiredSignatures"`
+
+	ApprovalValidityDays int64 `json:"ApprovalValidityDays"`
+
 	SavedNotes string `json:"SavedNotes"`
 
 	ChangeRequestID int64 `json:"ChangeRequestID"`
@@ -206,22 +214,58 @@ func (asre ApproverSetRevisionExport) DecryptionKeys() (keys []openpgp.Key) {
 	return
 }
 
-// IsSignedBy will return true if the object is signed by one of the
-// members of the TrustAnchors list.
+// GetRequiredSignatures returns the number of signatures from distinct
+// approvers in the set that are required to approve a change request.
+// Revisions that do not set a threshold require a single signature.
+func (asre ApproverSetRevisionExport) GetRequiredSignatures() int64 {
+	if asre.RequiredSignatures < 1 {
+		return 1
+	}
+
+	return asre.RequiredSignatures
+}
+
+// IsSignedBy will return true if the signatures passed, which may hold
+// several clearsigned approval attestations, were made by enough
+// distinct verified approvers in the set to meet the number of
+// required signatures. Every signature must be valid and attest to the
+// same approval. The body of the first signature is returned.
 func (asre ApproverSetRevisionExport) IsSignedBy(sig []byte) (valid bool, signedBody []byte) {
-	block, _ := clearsign.Decode(sig)
+	var first ApprovalAttestationUnmarshal
 
-	if block == nil {
-		return false, signedBody
-	}
+	signers := make(map[string]bool)
 
-	_, sigErr := openpgp.CheckDetachedSignature(asre, bytes.NewBuffer(block.Bytes), block.ArmoredSignature.Body, nil)
+	for idx, single := range SplitSignatures(sig) {
+		block, _ := clearsign.Decode(single)
+		if block == nil {
+			return false, nil
+		}
+
+		entity, sigErr := openpgp.CheckDetachedSignature(asre, bytes.NewBuffer(block.Bytes), block.ArmoredSignature.Body, nil)
+		if sigErr != nil {
+			return false, nil
+		}
+
+		var att ApprovalAttestationUnmarshal
+		if err := json.Unmarshal(block.Bytes, &att); err != nil {
+			return false, nil
+		}
+
+		if idx == 0 {
+			first = att
+			signedBody = block.Bytes
+		} else if !first.SameApproval(att) {
+			return false, nil
+		}
+
+		signers[fmt.Sprintf("%X", entity.PrimaryKey.Fingerprint)] = true
+	}
 
-	if sigErr == nil {
-		return true, block.Bytes
+	if int64(len(signers)) < asre.GetRequiredSignatures() {
+		return false, nil
 	}
 
-	return false, signedBody
+	return true, signedBody
 }
 
 // Compare is used to compare an export version of an object to the
@@ -254,6 +298,16 @@ func (asre ApproverSetRevisionExport) Compare(asr ApproverSetRevision) (pass boo
 		pass = false
 	}
 
+	if asre.RequiredSignatures != asr.RequiredSignatures {
+		errs = append(errs, fmt.Errorf("the RequiredSignatures fields did not match"))
+		pass = false
+	}
+
+	if asre.ApprovalValidityDays != asr.ApprovalValidityDays {
+		errs = append(errs, fmt.Errorf("the ApprovalValidityDays fields did not match"))
+		pass = false
+	}
+
 	if asre.SavedNotes != asr.SavedNotes {
 		errs = append(errs, fmt.Errorf("the Saved Notes field did not match"))
 		pass = false
@@ -321,6 +375,16 @@ func (asre ApproverSetRevisionExport) CompareExport(asr ApproverSetRevisionExpor
 		pass = false
 	}
 
+	if asre.RequiredSignatures != asr.RequiredSignatures {
+		errs = append(errs, fmt.Errorf("the RequiredSignatures fields did not match"))
+		pass = false
+	}
+
+	if asre.ApprovalValidityDays != asr.ApprovalValidityDays {
+		errs = append(errs, fmt.Errorf("the ApprovalValidityDays fields did not match"))
+		pass = false
+	}
+
 	if asre.SavedNotes != asr.SavedNotes {
 		errs = append(errs, fmt.Errorf("the Saved Notes field did not match"))
 		pass = false
@@ -439,6 +503,9 @@ func (a *ApproverSetRevision) GetExportVersion() RegistrarObjectExport {
 		Notes:         a.Notes,
 		CreatedAt:     a.CreatedAt,
 		CreatedBy:     a.CreatedBy,
+
+		RequiredSignatures:   a.RequiredSignatures,
+		ApprovalValidityDays: a.ApprovalValidityDays,
 	}
 	for idx := range a.Approvers {
 		export.Approvers = append(export.Approvers, a.Approvers[idx].GetExportShortVersion())
@@ -476,11 +543,80 @@ func (a *ApproverSetRevision) GetState(cleartextState string) string {
 	return StateActive
 }
 
+// GetRequiredSignatures returns the number of signatures from distinct
+// approvers in the set that are required to approve a change request.
+// Revisions that do not set a threshold require a single signature.
+func (a ApproverSetRevision) GetRequiredSignatures() int64 {
+	if a.RequiredSignatures < 1 {
+		return 1
+	}
+
+	return a.RequiredSignatures
+}
+
+// GetApprovalValidity returns the length of time that Approvals for
+// the set remain valid after they are created. A zero duration is
+// returned if Approvals for the set do not expire.
+func (a ApproverSetRevision) GetApprovalValidity() time.Duration {
+	if a.ApprovalValidityDays < 1 {
+		return 0
+	}
+
+	return time.Duration(a.ApprovalValidityDays) * 24 * time.Hour
+}
+
+// ParseApprovalValidityDays parses the number of days that Approvals
+// for the set remain valid from the form field named. Approvals do not
+// expire if the field is not set or is zero.
+func ParseApprovalValidityDays(request *http.Request, fieldName string) (int64, error) {
+	value := request.FormValue(fieldName)
+	if value == "" {
+		return 0, nil
+	}
+
+	days, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("error parsing approval validity days: %w", err)
+	}
+
+	if days < 0 {
+		return 0, errors.New("approval validity days cannot be negative")
+	}
+
+	return days, nil
+}
+
+// ParseRequiredSignatures parses the number of signatures required to
+// approve a change request from the form field named and checks that
+// it can be met by the number of approvers passed. A single signature
+// is required if the field is not set.
+func ParseRequiredSignatures(request *http.Request, fieldName string, approverCount int) (int64, error) {
+	value := request.FormValue(fieldName)
+	if value == "" {
+		return 1, nil
+	}
+
+	required, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("error parsing required signatures: %w", err)
+	}
+
+	if required < 1 {
+		return 0, errors.New("at least one signature must be required")
+	}
+
+	if required > int64(approverCount) {
+		return 0, fmt.Errorf("%d signatures cannot be required from %d approvers", required, approverCount)
+	}
+
+	return required, nil
+}
+
 // ParseFromForm takes a http Request and parses the field values and
 // populates the acceptable values into the new object. An error is
 // returned if there is difficulty parsing any of the fileds.
 func (a *ApproverSetRevision) ParseFromForm(request *http.Request, dbCache *DBCache) error {
-	var err2, err3, err4 error
+	var err2, err3, err4, err5, err6 error
 
 	runame, err := GetRemoteUser(request)
 	if err != nil {
@@ -496,6 +632,8 @@ func (a *ApproverSetRevision) ParseFromForm(request *http.Request, dbCache *DBCa
 	a.UpdatedBy = runame
 
 	a.Approvers, err2 = ParseApprovers(request, dbCache, "approver_id")
+	a.RequiredSignatures, err5 = ParseRequiredSignatures(request, "revision_required_signatures", len(a.Approvers))
+	a.ApprovalValidityDays, err6 = ParseApprovalValidityDays(request, "revision_approval_validity_days")
 
 	a.ApproverSetID = setID
 	a.SavedNotes = request.FormValue("revision_saved_notes")
@@ -525,6 +663,14 @@ func (a *ApproverSetRevision) ParseFromForm(request *http.Request, dbCache *DBCa
 		return err4
 	}
 
+	if err5 != nil {
+		return err5
+	}
+
+	if err6 != nil {
+		return err6
+	}
+
 	return nil
 }
 
@@ -572,6 +718,20 @@ func (a *ApproverSetRevision) ParseFromFormUpdate(request *http.Request, dbCache
 				return err4
 			}
 
+			requiredSignatures, err5 := ParseRequiredSignatures(request, "revision_required_signatures", len(approvers))
+			if err5 != nil {
+				return err5
+			}
+
+			a.RequiredSignatures = requiredSignatures
+
+			approvalValidityDays, err6 := ParseApprovalValidityDays(request, "revision_approval_validity_days")
+			if err6 != nil {
+				return err6
+			}
+
+			a.ApprovalValidityDays = approvalValidityDays
+
 			if err = dbCache.DB.Model(a).Association("Approvers").Clear().Error; err != nil {
 				return err
 			}
@@ -1,11 +1,18 @@
 package lib
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"testing"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/ProtonMail/go-crypto/openpgp/clearsign"
 
 	. "github.com/smartystreets/goconvey/convey"
 )
@@ -47,6 +54,203 @@ func TestApproverSetRevisionGetState(t *testing.T) {
 // 	})
 // }
 
+func TestApproverSetRevisionRequiredSignatures(t *testing.T) {
+	t.Parallel()
+	Convey("Given an ApproverSetRevision without a threshold", t, func() {
+		asr := ApproverSetRevision{}
+
+		Convey("A single signature should be required", func() {
+			So(asr.GetRequiredSignatures(), ShouldEqual, 1)
+		})
+	})
+
+	Convey("Given an ApproverSetRevision with a threshold", t, func() {
+		asr := ApproverSetRevision{RequiredSignatures: 2}
+
+		Convey("The threshold should be required", func() {
+			So(asr.GetRequiredSignatures(), ShouldEqual, 2)
+		})
+	})
+
+	Convey("Given a form with a required signature count", t, func() {
+		form := func(value string) *http.Request {
+			request, _ := http.NewRequestWithContext(context.Background(), http.MethodPost, "/", nil)
+			request.Form = url.Values{"revision_required_signatures": {value}}
+
+			return request
+		}
+
+		Convey("An empty value should require a single signature", func() {
+			required, err := ParseRequiredSignatures(form(""), "revision_required_signatures", 5)
+			So(err, ShouldBeNil)
+			So(required, ShouldEqual, 1)
+		})
+
+		Convey("A threshold that can be met should be accepted", func() {
+			required, err := ParseRequiredSignatures(form("2"), "revision_required_signatures", 5)
+			So(err, ShouldBeNil)
+			So(required, ShouldEqual, 2)
+		})
+
+		Convey("Invalid thresholds should be rejected", func() {
+			for _, value := range []string{"two", "0", "-1", "6"} {
+				_, err := ParseRequiredSignatures(form(value), "revision_required_signatures", 5)
+				So(err, ShouldNotBeNil)
+			}
+		})
+	})
+}
+
+func TestApproverSetRevisionApprovalValidity(t *testing.T) {
+	t.Parallel()
+	Convey("Given an ApproverSetRevision without a validity window", t, func() {
+		asr := ApproverSetRevision{}
+
+		Convey("Approvals should not expire", func() {
+			So(asr.GetApprovalValidity(), ShouldEqual, 0)
+		})
+	})
+
+	Convey("Given an ApproverSetRevision with a validity window", t, func() {
+		asr := ApproverSetRevision{ApprovalValidityDays: 7}
+
+		Convey("Approvals should expire after the window", func() {
+			So(asr.GetApprovalValidity(), ShouldEqual, 7*24*time.Hour)
+		})
+	})
+
+	Convey("Given a form with an approval validity", t, func() {
+		form := func(value string) *http.Request {
+			request, _ := http.NewRequestWithContext(context.Background(), http.MethodPost, "/", nil)
+			request.Form = url.Values{"revision_approval_validity_days": {value}}
+
+			return request
+		}
+
+		Convey("An empty value should not expire approvals", func() {
+			days, err := ParseApprovalValidityDays(form(""), "revision_approval_validity_days")
+			So(err, ShouldBeNil)
+			So(days, ShouldEqual, 0)
+		})
+
+		Convey("A number of days should be accepted", func() {
+			days, err := ParseApprovalValidityDays(form("14"), "revision_approval_validity_days")
+			So(err, ShouldBeNil)
+			So(days, ShouldEqual, 14)
+		})
+
+		Convey("Invalid numbers of days should be rejected", func() {
+			for _, value := range []string{"week", "-1"} {
+				_, err := ParseApprovalValidityDays(form(value), "revision_approval_validity_days")
+				So(err, ShouldNotBeNil)
+			}
+		})
+	})
+}
+
+// quorumTestingSigner generates a key for an approver and returns the
+// approver, with its armored public key, and a function that clearsigns
+// an attestation with the key.
+func quorumTestingSigner(name string) (ApproverExportFull, func(ApprovalAttestation) []byte, error) {
+	var approver ApproverExportFull
+
+	entity, err := openpgp.NewEntity(name, "", name+"@example.com", nil)
+	if err != nil {
+		return approver, nil, err
+	}
+
+	pubKeyBuf := new(bytes.Buffer)
+
+	pubKeyWriter, err := armor.Encode(pubKeyBuf, openpgp.PublicKeyType, nil)
+	if err != nil {
+		return approver, nil, err
+	}
+
+	if err = entity.Serialize(pubKeyWriter); err != nil {
+		return approver, nil, err
+	}
+
+	if err = pubKeyWriter.Close(); err != nil {
+		return approver, nil, err
+	}
+
+	approver.CurrentRevision.PublicKey = pubKeyBuf.String()
+
+	sign := func(att ApprovalAttestation) []byte {
+		att.Username = name
+		message, _ := att.ToJSON()
+
+		buffer := new(bytes.Buffer)
+		writer, _ := clearsign.Encode(buffer, entity.PrivateKey, nil)
+		_, _ = writer.Write([]byte(message))
+		writer.Close()
+
+		return buffer.Bytes()
+	}
+
+	return approver, sign, nil
+}
+
+func TestApproverSetRevisionExportIsSignedBy(t *testing.T) {
+	t.Parallel()
+	Convey("Given an approver set revision export requiring two signatures", t, func() {
+		asre := ApproverSetRevisionExport{RequiredSignatures: 2}
+
+		var signers []func(ApprovalAttestation) []byte
+
+		for _, name := range []string{"alice", "bob"} {
+			approver, sign, err := quorumTestingSigner(name)
+			So(err, ShouldBeNil)
+			So(asre.AddVerifiedApprover(approver), ShouldBeNil)
+
+			signers = append(signers, sign)
+		}
+
+		_, outsider, err := quorumTestingSigner("mallory")
+		So(err, ShouldBeNil)
+
+		att := ApprovalAttestation{
+			ApprovalID:         1,
+			ExportRev:          ApproverSetRevisionExport{ID: 3, Title: "Approvers"},
+			Action:             ActionApproved,
+			ObjectType:         ApproverSetType,
+			ChangeRequestID:    2,
+			ProposedRevisionID: 3,
+		}
+
+		declined := att
+		declined.Action = ActionDeclined
+
+		tests := []struct {
+			name  string
+			sig   []byte
+			valid bool
+		}{
+			{name: "no signatures", sig: nil},
+			{name: "single approver", sig: signers[0](att)},
+			{name: "repeated approver", sig: bytes.Join([][]byte{signers[0](att), signers[0](att)}, nil)},
+			{name: "quorum of approvers", sig: bytes.Join([][]byte{signers[0](att), signers[1](att)}, nil), valid: true},
+			{name: "quorum signed in the other order", sig: bytes.Join([][]byte{signers[1](att), signers[0](att)}, nil), valid: true},
+			{name: "quorum with a signer outside the set", sig: bytes.Join([][]byte{signers[0](att), signers[1](att), outsider(att)}, nil)},
+			{name: "quorum with an outside first signer", sig: bytes.Join([][]byte{outsider(att), signers[0](att), signers[1](att)}, nil)},
+			{name: "quorum attesting to different actions", sig: bytes.Join([][]byte{signers[0](att), signers[1](declined)}, nil)},
+		}
+
+		for _, test := range tests {
+			Convey("For a "+test.name, func() {
+				valid, body := asre.IsSignedBy(test.sig)
+				So(valid, ShouldEqual, test.valid)
+
+				if test.valid {
+					var signed ApprovalAttestationUnmarshal
+					So(json.Unmarshal(body, &signed), ShouldBeNil)
+					So(signed.ChangeRequestID, ShouldEqual, att.ChangeRequestID)
+				}
+			})
+		}
+	})
+}
+
 func TestApproverSetRevisionUpdateState(t *testing.T) {
 	t.Parallel()
 	Convey("Given a bootstrapped database", t, func() {
@@ -32,6 +32,8 @@ type ChangeRequest struct {
 	ChangeJSON string `json:"ChangeJSON" sql:"type:text;"`
 	ChangeDiff string `json:"ChangeDiff" sql:"type:text;"`
 
+	EffectiveAt *time.Time `json:"EffectiveAt"`
+
 	Approvals []Approval `json:"Approvals"`
 
 	CreatedAt time.Time `json:"CreatedAt"`
@@ -55,6 +57,8 @@ type ChangeRequestExport struct {
 	ChangeJSON string `json:"ChangeJSON"`
 	ChangeDiff string `json:"ChangeDiff"`
 
+	EffectiveAt *time.Time `json:"EffectiveAt"`
+
 	Approvals []ApprovalExport `json:"Approvals"`
 
 	CreatedAt time.Time `json:"CreatedAt"`
@@ -105,6 +109,13 @@ func (c ChangeRequestPage) SplitJSON(json string) []string {
 	return strings.Split(json, "\n")
 }
 
+// IsScheduled is a helper function used by the ChangeRequest template
+// to mark Change Requests that will not be provisioned until their
+// effective at time.
+func (c ChangeRequestPage) IsScheduled() bool {
+	return c.CR.IsScheduled(TimeNow())
+}
+
 // ChangeRequestsPage is used to render the html template which lists
 // all of the Change Requests currently in the registrar system
 //
@@ -136,6 +147,7 @@ func (c *ChangeRequest) GetExportVersion() RegistrarObjectExport {
 		ProposedRevisionID:  c.ProposedRevisionID,
 		ChangeJSON:          c.ChangeJSON,
 		ChangeDiff:          c.ChangeDiff,
+		EffectiveAt:         c.EffectiveAt,
 		CreatedAt:           c.CreatedAt,
 		CreatedBy:           c.CreatedBy,
 	}
@@ -228,10 +240,17 @@ func (c *ChangeRequest) UpdateState(dbCache *DBCache, conf Config) (changesMade
 				cascadeUpdate = true
 			}
 
-			logger.Info("Looking for final approver set")
+			workflow, workflowErr := c.GetApprovalWorkflow(conf)
+			if workflowErr != nil {
+				errs = append(errs, workflowErr)
+
+				return changesMade, errs
+			}
+
+			logger.Infof("Looking for final approver set %d", workflow.FinalApproverSetID)
 
 			for idx, app := range c.Approvals {
-				if app.ApproverSetID == 1 {
+				if workflow.IsFinal(app.ApproverSetID) {
 					logger.Infof("Found Approval %d to mark as final approver set", app.ID)
 					tmpApp := Approval{}
 
@@ -273,9 +292,17 @@ func (c *ChangeRequest) UpdateState(dbCache *DBCache, conf Config) (changesMade
 			var finalApproval Approval
 			finalApprovalFound := false
 
+			workflow, workflowErr := c.GetApprovalWorkflow(conf)
+			if workflowErr != nil {
+				errs = append(errs, workflowErr)
+
+				return changesMade, errs
+			}
+
 			numApp := 0
 			numApproved := 0
 			numDeclined := 0
+			numExpired := 0
 			numNoValidApprovers := 0
 			numInactiveApproverSet := 0
 
@@ -290,6 +317,10 @@ func (c *ChangeRequest) UpdateState(dbCache *DBCache, conf Config) (changesMade
 					numDeclined = numDeclined + 1
 				}
 
+				if app.State == StateExpired {
+					numExpired = numExpired + 1
+				}
+
 				if app.State == StateNoValidApprovers {
 					numNoValidApprovers = numNoValidApprovers + 1
 				}
@@ -301,12 +332,16 @@ func (c *ChangeRequest) UpdateState(dbCache *DBCache, conf Config) (changesMade
 				if app.IsFinalApproval {
 					finalApprovalFound = true
 					finalApproval = app
+				} else if app.State == StateNew && c.StageOpen(workflow, app.ApproverSetID) {
+					logger.Infof("Stage %d is open for Approval %d", workflow.StageOf(app.ApproverSetID), app.ID)
+					cascadeUpdate = true
 				}
 			}
 
 			logger.Infof("Number of Approvals: %d", numApp)
 			logger.Infof("Number of Approved Approvals: %d", numApproved)
 			logger.Infof("Number of Declined Approvals: %d", numDeclined)
+			logger.Infof("Number of Expired Approvals: %d", numExpired)
 			logger.Infof("Number of No Valid Approver Approvals: %d", numNoValidApprovers)
 			logger.Infof("Number of Inactive Approver Set Approvals: %d", numInactiveApproverSet)
 
@@ -337,6 +372,19 @@ func (c *ChangeRequest) UpdateState(dbCache *DBCache, conf Config) (changesMade
 				changesMade = true
 				cascadeUpdate = true
 			}
+
+			// If an approval has expired or the Change Request is past its
+			// validity window before it was completed, it has expired
+			if c.State == StatePendingApproval && (numExpired >= 1 || c.IsPastValidity(conf)) {
+				logger.Infof("Change Request %d has expired", c.ID)
+				c.State = StateExpired
+				changesMade = true
+				cascadeUpdate = true
+
+				if err := c.ExpiredEmail(conf); err != nil {
+					errs = append(errs, err)
+				}
+			}
 		}
 		// case StateCancelled:
 		// // Do Nothing, Cancelled is a terminal State
@@ -497,6 +545,55 @@ func (c *ChangeRequest) Prepare(dbCache *DBCache) (err error) {
 	})
 }
 
+// GetApprovalWorkflow returns the approval workflow configured for the
+// object of the Change Request. For domains the class of the current
+// revision is used, or the class of the proposed revision if the
+// domain does not have a current revision yet.
+func (c *ChangeRequest) GetApprovalWorkflow(conf Config) (ApprovalWorkflow, error) {
+	class := ""
+
+	if dom, ok := c.Object.(*Domain); ok {
+		if dom.CurrentRevisionID.Valid {
+			class = dom.CurrentRevision.Class
+		} else {
+			class = dom.PendingRevision.Class
+		}
+	}
+
+	return conf.GetApprovalWorkflow(c.RegistrarObjectType, class)
+}
+
+// approvalPassed returns true iff an Approval in the state passed no
+// longer blocks the approvals in later stages.
+func approvalPassed(state string) bool {
+	switch state {
+	case StateApproved, StateNoValidApprovers, StateInactiveApproverSet, StateSkippedNoValidApprovers, StateSkippedInactiveApproverSet:
+		return true
+	}
+
+	return false
+}
+
+// StageOpen determines if the approvals for the Approver Set passed may
+// be opened, which is once all of the approvals in earlier stages of
+// the workflow have passed. The final approval is not part of any
+// stage, see ReadyForFinalApproval.
+func (c *ChangeRequest) StageOpen(workflow ApprovalWorkflow, approverSetID int64) bool {
+	stage := workflow.StageOf(approverSetID)
+
+	for _, app := range c.Approvals {
+		if app.IsFinalApproval || workflow.StageOf(app.ApproverSetID) >= stage {
+			continue
+		}
+
+		if !approvalPassed(app.State) {
+			return false
+		}
+	}
+
+	return true
+}
+
 // ReadyForFinalApproval determines if the change request has all of
 // the required approvals other than the final approval.
 func (c *ChangeRequest) ReadyForFinalApproval() bool {
@@ -554,6 +651,115 @@ func (c *ChangeRequest) IsCancelled() bool {
 	return c.State == StateCancelled
 }
 
+// IsExpired returns true iff the object has expired.
+func (c *ChangeRequest) IsExpired() bool {
+	return c.State == StateExpired
+}
+
+// IsScheduled returns true iff the Change Request has an effective at
+// time that is after the time passed. The change will not be
+// provisioned until the effective at time.
+func (c *ChangeRequest) IsScheduled(now time.Time) bool {
+	return !IsEffective(c.EffectiveAt, now)
+}
+
+// ExpiresAt returns the time at which the Change Request expires if it
+// has not been completed. The zero time is returned if Change Requests
+// for the object type do not expire.
+func (c *ChangeRequest) ExpiresAt(conf Config) (expiresAt time.Time) {
+	if validity := conf.ChangeRequestValidity(c.RegistrarObjectType); validity != 0 {
+		expiresAt = c.CreatedAt.Add(validity)
+	}
+
+	return expiresAt
+}
+
+// IsPastValidity returns true iff the Change Request has a validity
+// window and it has passed.
+func (c *ChangeRequest) IsPastValidity(conf Config) bool {
+	expiresAt := c.ExpiresAt(conf)
+
+	return !expiresAt.IsZero() && TimeNow().After(expiresAt)
+}
+
+// ExpiredEmail will generate and send an email to the creator of the
+// Change Request once it has expired.
+func (c *ChangeRequest) ExpiredEmail(conf Config) error {
+	subject := fmt.Sprintf("Registrar: Change Request %d EXPIRED", c.ID)
+	message := fmt.Sprintf(`Hello,
+
+This message is to inform you that change request %d for %s %d
+was not approved before it expired in the registrar system. The
+pending revision has not been applied and a new change request must
+be submitted to make the change. You can view the Change Request
+information at the link below. If you have any questions about this
+change request, please send a message to %s or respond to this
+thread.
+
+%s/view/changerequest/%d
+
+Thank you,
+The registrar Admins
+`, c.ID, c.RegistrarObjectType, c.RegistrarObjectID, conf.Email.FromEmail, conf.Server.AppURL, c.ID)
+
+	recipients := []string{conf.Email.Announce}
+	if c.CreatedBy != "" {
+		recipients = append(recipients, c.CreatedBy+"@"+conf.Server.DefaultUserDomain)
+	}
+
+	return conf.SendAllEmail(subject, message, recipients)
+}
+
+// ExpireStaleChangeRequests will review all of the Approvals and Change
+// Requests that are pending approval and will move those that are past
+// their validity window to the expired state. If an error occurs
+// during the processing it will be returned.
+func ExpireStaleChangeRequests(dbCache *DBCache, conf Config) (err error) {
+	logger.Info("Running Expiry Check")
+
+	var approvals []Approval
+
+	if err = dbCache.DB.Where("state = ?", StatePendingApproval).Find(&approvals).Error; err != nil {
+		return err
+	}
+
+	for _, pending := range approvals {
+		app := Approval{}
+
+		if err = dbCache.FindByID(&app, pending.ID); err != nil {
+			return err
+		}
+
+		if app.State == StatePendingApproval && app.IsPastValidity() {
+			if _, errs := app.UpdateState(dbCache, conf); len(errs) != 0 {
+				return errs[0]
+			}
+		}
+	}
+
+	var changeRequests []ChangeRequest
+
+	if err = dbCache.DB.Where("state = ?", StatePendingApproval).Find(&changeRequests).Error; err != nil {
+		return err
+	}
+
+	for _, pending := range changeRequests {
+		changeRequest := ChangeRequest{}
+
+		if err = dbCache.FindByID(&changeRequest, pending.ID); err != nil {
+			return err
+		}
+
+		if changeRequest.State == StatePendingApproval && changeRequest.IsPastValidity(conf) {
+			if _, errs := changeRequest.UpdateState(dbCache, conf); len(errs) != 0 {
+				return errs[0]
+			}
+		}
+	}
+
+	return nil
+}
+
 // IsEditable returns true iff the object is editable.
 func (c *ChangeRequest) IsEditable() bool {
 	return false
@@ -6,6 +6,7 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"testing"
+	"time"
 
 	. "github.com/smartystreets/goconvey/convey"
 )
@@ -20,6 +21,31 @@ func TestChangeRequestPageSplitJSON(t *testing.T) {
 	})
 }
 
+func TestChangeRequestPageIsScheduled(t *testing.T) {
+	t.Parallel()
+	Convey("Given a Change Request Page", t, func() {
+		future := TimeNow().Add(time.Hour)
+		past := TimeNow().Add(-time.Hour)
+
+		cases := []struct {
+			name        string
+			effectiveAt *time.Time
+			scheduled   bool
+		}{
+			{"without an effective at time", nil, false},
+			{"with an effective at time in the past", &past, false},
+			{"with an effective at time in the future", &future, true},
+		}
+
+		for _, tc := range cases {
+			Convey("A Change Request "+tc.name+" should report if it is scheduled", func() {
+				crp := ChangeRequestPage{CR: ChangeRequest{EffectiveAt: tc.effectiveAt}}
+				So(crp.IsScheduled(), ShouldEqual, tc.scheduled)
+			})
+		}
+	})
+}
+
 func TestChangeRequestGetExportVersion(t *testing.T) {
 	t.Parallel()
 	Convey("Given a Change Request", t, func() {
@@ -126,6 +152,38 @@ func TestChangeRequestIsEditable(t *testing.T) {
 	})
 }
 
+func TestChangeRequestIsPastValidity(t *testing.T) {
+	t.Parallel()
+	Convey("Given a configuration with a domain change request expiry", t, func() {
+		conf := Config{}
+		conf.ObjectExpiry = map[string]*struct{ ChangeRequestDays int64 }{
+			DomainType: {ChangeRequestDays: 7},
+		}
+
+		Convey("A recent change request should not be past its validity", func() {
+			changeRequest := ChangeRequest{RegistrarObjectType: DomainType, CreatedAt: TimeNow().AddDate(0, 0, -1)}
+			So(changeRequest.ExpiresAt(conf), ShouldEqual, changeRequest.CreatedAt.AddDate(0, 0, 7))
+			So(changeRequest.IsPastValidity(conf), ShouldBeFalse)
+		})
+
+		Convey("An old change request should be past its validity", func() {
+			changeRequest := ChangeRequest{RegistrarObjectType: DomainType, CreatedAt: TimeNow().AddDate(0, 0, -8)}
+			So(changeRequest.IsPastValidity(conf), ShouldBeTrue)
+		})
+
+		Convey("A change request for another object type should not expire", func() {
+			changeRequest := ChangeRequest{RegistrarObjectType: HostType, CreatedAt: TimeNow().AddDate(0, 0, -8)}
+			So(changeRequest.ExpiresAt(conf).IsZero(), ShouldBeTrue)
+			So(changeRequest.IsPastValidity(conf), ShouldBeFalse)
+		})
+	})
+
+	Convey("Given an expired change request", t, func() {
+		changeRequest := ChangeRequest{State: StateExpired}
+		So(changeRequest.IsExpired(), ShouldBeTrue)
+	})
+}
+
 func TestChangeRequestGetPage(t *testing.T) {
 	t.Parallel()
 	Convey("Given a valid change request", t, func() {
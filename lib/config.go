@@ -94,6 +94,19 @@ type Config struct {
 	Registrar struct {
 		ID string
 	}
+
+	Expiry struct {
+		ChangeRequestDays int64
+		AttestationHours  int64
+	}
+
+	ObjectExpiry map[string]*struct {
+		ChangeRequestDays int64
+	}
+
+	Workflow WorkflowConfig
+
+	ObjectWorkflow map[string]*WorkflowConfig
 }
 
 // LoadConfig will attempt to load the configuration at the path
@@ -120,6 +133,16 @@ func (con *Config) LoadConfig(path string) error {
 		return fmt.Errorf("error configuring logging: %w", err)
 	}
 
+	if _, err = ParseWorkflow(con.Workflow); err != nil {
+		return fmt.Errorf("error parsing workflow: %w", err)
+	}
+
+	for key, wc := range con.ObjectWorkflow {
+		if _, err = ParseWorkflow(*wc); err != nil {
+			return fmt.Errorf("error parsing workflow for %s: %w", key, err)
+		}
+	}
+
 	con.Bootstrap.PubkeyContents, err = os.ReadFile(con.Bootstrap.Pubkeyfile)
 
 	if err != nil {
@@ -140,6 +163,39 @@ func (con Config) GetHMACKey() []byte {
 	return []byte(con.CSRF.MACKey)
 }
 
+// ChangeRequestValidity returns the length of time that Change Requests
+// for the object type passed remain valid after they are created. The
+// object type specific value is used if one is configured, otherwise
+// the default is used. A zero duration is returned if Change Requests
+// for the object type do not expire.
+func (con Config) ChangeRequestValidity(objectType string) time.Duration {
+	days := con.Expiry.ChangeRequestDays
+
+	if objExpiry, ok := con.ObjectExpiry[objectType]; ok && objExpiry != nil {
+		days = objExpiry.ChangeRequestDays
+	}
+
+	if days < 1 {
+		return 0
+	}
+
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// DefaultAttestationValidity is the length of time that downloaded
+// approval attestations remain valid for if no validity is configured.
+const DefaultAttestationValidity = 24 * time.Hour
+
+// AttestationValidity returns the length of time that a downloaded
+// approval attestation may be signed and uploaded within.
+func (con Config) AttestationValidity() time.Duration {
+	if con.Expiry.AttestationHours < 1 {
+		return DefaultAttestationValidity
+	}
+
+	return time.Duration(con.Expiry.AttestationHours) * time.Hour
+}
+
 // GetMailHosts will resolve the MX records for a domain name and return the
 // list of hosts sorted by preference if MX records are sent, otherwise the
 // domain name is returned as a fallback A or AAAA record.
@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/ProtonMail/go-crypto/openpgp"
 	"github.com/ProtonMail/go-crypto/openpgp/armor"
@@ -27,6 +28,35 @@ func Test_LoadConfig_BogusFile(t *testing.T) {
 	t.Log(err)
 }
 
+func TestConfigChangeRequestValidity(t *testing.T) {
+	t.Parallel()
+	Convey("Given a configuration without an expiry", t, func() {
+		conf := Config{}
+
+		Convey("Change Requests should not expire", func() {
+			So(conf.ChangeRequestValidity(DomainType), ShouldEqual, 0)
+		})
+	})
+
+	Convey("Given a configuration with a default and an object type expiry", t, func() {
+		conf := Config{}
+		conf.Expiry.ChangeRequestDays = 30
+		conf.ObjectExpiry = map[string]*struct{ ChangeRequestDays int64 }{
+			DomainType: {ChangeRequestDays: 7},
+			HostType:   {ChangeRequestDays: 0},
+		}
+
+		Convey("The object type expiry should be used when it is set", func() {
+			So(conf.ChangeRequestValidity(DomainType), ShouldEqual, 7*24*time.Hour)
+			So(conf.ChangeRequestValidity(HostType), ShouldEqual, 0)
+		})
+
+		Convey("The default expiry should be used otherwise", func() {
+			So(conf.ChangeRequestValidity(ContactType), ShouldEqual, 30*24*time.Hour)
+		})
+	})
+}
+
 func genTestingGPGKey(path string, keyFilename string, entityName string, entityComent string, _ string) (string, error) {
 	privPath := filepath.Join(path, fmt.Sprintf("%s.key", keyFilename))
 	pubPath := filepath.Join(path, fmt.Sprintf("%s.pub", keyFilename))
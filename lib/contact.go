@@ -1099,8 +1099,8 @@ func (c *Contact) UpdateState(dbCache *DBCache, _ Config) (changesMade bool, err
 					}
 
 					logger.Infof("Pending Revision State = %s", pendingRevision.RevisionState)
-				} else if changeRequest.State == StateDeclined {
-					logger.Infof("CR %d has been declined", changeRequest.GetID())
+				} else if changeRequest.State == StateDeclined || changeRequest.State == StateExpired {
+					logger.Infof("CR %d has been %s", changeRequest.GetID(), changeRequest.State)
 
 					if err := c.PendingRevision.Decline(dbCache); err != nil {
 						errs = append(errs, err)
@@ -789,6 +789,54 @@ func GetCheckboxState(cleartextForm string) bool {
 	return false
 }
 
+// EffectiveAtFormat is the layout used for the effective at time of a
+// revision in HTML forms. Times in this layout are in UTC.
+const EffectiveAtFormat = "2006-01-02T15:04"
+
+// ParseEffectiveAt parses the time that a revision should take effect
+// from the form field named. Nil is returned if the field is not set,
+// in which case the revision takes effect as soon as it is approved.
+func ParseEffectiveAt(request *http.Request, fieldName string) (*time.Time, error) {
+	value := strings.TrimSpace(request.FormValue(fieldName))
+	if value == "" {
+		return nil, nil
+	}
+
+	effectiveAt, err := time.Parse(EffectiveAtFormat, value)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing effective at time: %w", err)
+	}
+
+	return &effectiveAt, nil
+}
+
+// FormatEffectiveAt formats an effective at time so it can be used as
+// the value of a HTML form field. An empty string is returned if the
+// time is not set.
+func FormatEffectiveAt(effectiveAt *time.Time) string {
+	if effectiveAt == nil {
+		return ""
+	}
+
+	return effectiveAt.UTC().Format(EffectiveAtFormat)
+}
+
+// IsEffective checks if a change with the effective at time passed
+// may be provisioned at the time now. Changes without an effective at
+// time are always effective.
+func IsEffective(effectiveAt *time.Time, now time.Time) bool {
+	return effectiveAt == nil || !now.Before(*effectiveAt)
+}
+
+// CompareEffectiveAt checks if two effective at times are the same.
+func CompareEffectiveAt(a *time.Time, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return a.Equal(*b)
+}
+
 // ClientDeleteFlag is a name that can be used to reference the
 // Client Delete field of the current revision.
 const ClientDeleteFlag = "ClientDelete"
@@ -5,7 +5,9 @@ import (
 	"database/sql"
 	"fmt"
 	"net/http"
+	"net/url"
 	"testing"
+	"time"
 )
 
 var bogusState = "bogus"
@@ -101,3 +103,58 @@ func Test_NullInt64ConfirmEqual(t *testing.T) {
 		}
 	}
 }
+
+func Test_Core_ParseEffectiveAt(t *testing.T) {
+	t.Parallel()
+
+	request, _ := http.NewRequestWithContext(context.Background(), http.MethodPost, "/", nil)
+
+	effectiveAt, err := ParseEffectiveAt(request, "revision_effective_at")
+	if err != nil || effectiveAt != nil {
+		t.Errorf("Expected no effective at time for an empty field, got %v (%v)", effectiveAt, err)
+	}
+
+	request.Form = url.Values{"revision_effective_at": {"2026-10-17T22:30"}}
+
+	effectiveAt, err = ParseEffectiveAt(request, "revision_effective_at")
+	if err != nil {
+		t.Errorf("Unexpected error parsing a valid effective at time: %s", err.Error())
+	} else if expected := time.Date(2026, 10, 17, 22, 30, 0, 0, time.UTC); !effectiveAt.Equal(expected) {
+		t.Errorf("Expected effective at time to be %s, got %s", expected, effectiveAt)
+	} else if FormatEffectiveAt(effectiveAt) != "2026-10-17T22:30" {
+		t.Errorf("Expected the effective at time to format as it was submitted, got %s", FormatEffectiveAt(effectiveAt))
+	}
+
+	request.Form = url.Values{"revision_effective_at": {"tonight"}}
+
+	if _, err = ParseEffectiveAt(request, "revision_effective_at"); err == nil {
+		t.Error("Expected an error parsing an invalid effective at time")
+	}
+}
+
+func Test_Core_IsEffective(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	later := now.Add(time.Hour)
+
+	if !IsEffective(nil, now) {
+		t.Error("Expected a change without an effective at time to be effective")
+	}
+
+	if IsEffective(&later, now) {
+		t.Error("Expected a change with a future effective at time not to be effective")
+	}
+
+	if !IsEffective(&later, later) {
+		t.Error("Expected a change to be effective at its effective at time")
+	}
+
+	if !CompareEffectiveAt(nil, nil) || CompareEffectiveAt(nil, &now) || CompareEffectiveAt(&now, &later) {
+		t.Error("Unexpected result comparing effective at times")
+	}
+
+	if nowUTC := now.UTC(); !CompareEffectiveAt(&now, &nowUTC) {
+		t.Error("Expected the same time in different locations to match")
+	}
+}
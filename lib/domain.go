@@ -144,6 +144,27 @@ var (
 	EPPStatusPendingRenew = "Pending Renew"
 )
 
+const (
+	// DNSStatusMatches represents a domain whose delegation in the parent
+	// zone matches the registrar and the registry.
+	DNSStatusMatches = "Delegation Matches"
+
+	// DNSStatusDrift is the prefix of the status of a domain whose
+	// delegation in the parent zone, registry or registrar differ. The
+	// differences found follow the prefix.
+	DNSStatusDrift = "Delegation Drift"
+
+	// DNSStatusLookupFailed is the prefix of the status of a domain whose
+	// delegation could not be retrieved from the parent zone.
+	DNSStatusLookupFailed = "Lookup Failed"
+)
+
+// DNSStatusUpdate is the body of a request to update the DNS status of a
+// domain.
+type DNSStatusUpdate struct {
+	DNSStatus string `json:"DNSStatus"`
+}
+
 // DomainExport is an object that is used to export the current
 // state of a Domain object. The full version of the export object
 // also contains the current and pending revision (if either exist).
@@ -153,6 +174,13 @@ type DomainExport struct {
 
 	DomainName string `json:"DomainName"`
 	DomainROID string `json:"DomainROID"`
+	EPPStatus  string `json:"EPPStatus"`
+
+	EPPLastUpdate    time.Time        `json:"EPPLastUpdate"`
+	DomainNSList     string           `json:"DomainNSList"`
+	EPPDSDataEntries []DSDataEntryEpp `json:"EPPDSDataEntries"`
+	DNSStatus        string           `json:"DNSStatus"`
+	DNSLastUpdate    time.Time        `json:"DNSLastUpdate"`
 
 	CurrentRevision DomainRevisionExport `json:"CurrentRevision"`
 	PendingRevision DomainRevisionExport `json:"PendingRevision"`
@@ -279,6 +307,11 @@ func (d *Domain) GetExportVersion() RegistrarObjectExport {
 		State:           d.State,
 		DomainName:      d.DomainName,
 		DomainROID:      d.DomainROID,
+		EPPStatus:       d.EPPStatus,
+		EPPLastUpdate:   d.EPPLastUpdate,
+		DomainNSList:    d.DomainNSList,
+		DNSStatus:       d.DNSStatus,
+		DNSLastUpdate:   d.DNSLastUpdate,
 		PendingRevision: (d.PendingRevision.GetExportVersion()).(DomainRevisionExport),
 		CurrentRevision: (d.CurrentRevision.GetExportVersion()).(DomainRevisionExport),
 		UpdatedAt:       d.UpdatedAt,
@@ -313,6 +346,8 @@ func (d *Domain) GetExportVersion() RegistrarObjectExport {
 		HoldReason: d.HoldReason,
 	}
 
+	export.EPPDSDataEntries = append(export.EPPDSDataEntries, d.DSDataEntries...)
+
 	return export
 }
 
@@ -911,6 +946,21 @@ func (d *Domain) TakeAction(response http.ResponseWriter, request *http.Request,
 				errs = append(errs, err)
 			}
 
+			return errs
+		}
+	case ActionUpdateDNSStatus:
+		if authMethod == CertAuthType {
+			update := DNSStatusUpdate{}
+			if err := json.NewDecoder(request.Body).Decode(&update); err != nil {
+				errs = append(errs, err)
+
+				return errs
+			}
+
+			if err := d.SetDNSStatus(update.DNSStatus, dbCache); err != nil {
+				errs = append(errs, err)
+			}
+
 			return errs
 		}
 	case ActionUpdateEPPCheckRequired:
@@ -954,6 +1004,15 @@ func (d *Domain) TakeAction(response http.ResponseWriter, request *http.Request,
 	return errs
 }
 
+// SetDNSStatus records the result of comparing the delegation of the domain
+// in its parent zone with the registrar and registry.
+func (d *Domain) SetDNSStatus(status string, dbCache *DBCache) error {
+	d.DNSStatus = status
+	d.DNSLastUpdate = TimeNow()
+
+	return dbCache.Save(d)
+}
+
 // LoadEPPInfo accepts an EPP response and attempts to marshall the data
 // from the response into the object that was called.
 //
@@ -1108,6 +1167,11 @@ func (d *Domain) LoadEPPInfo(data []byte, dbCache *DBCache, conf Config) (err er
 		implemented = true
 	}
 
+	// A scheduled change remains pending until it takes effect
+	if !implemented && !d.CurrentRevision.IsEffective(TimeNow()) {
+		d.EPPStatus = EPPStatusPendingChange
+	}
+
 	if initialFlag != d.EPPStatus && eppStatusFlag == EPPStatusProvisioned {
 		msg := fmt.Sprintf("The domain changes you requested for %s have been fully provisioned", d.DomainName)
 
@@ -1472,8 +1536,8 @@ func (d *Domain) UpdateState(dbCache *DBCache, _ Config) (changesMade bool, errs
 					}
 
 					logger.Infof("Pending Revision State = %s", pendingRevision.RevisionState)
-				} else if changeRequest.State == StateDeclined {
-					logger.Infof("CR %d has been declined", changeRequest.GetID())
+				} else if changeRequest.State == StateDeclined || changeRequest.State == StateExpired {
+					logger.Infof("CR %d has been %s", changeRequest.GetID(), changeRequest.State)
 
 					if err := d.PendingRevision.Decline(dbCache); err != nil {
 						errs = append(errs, err)
@@ -1626,9 +1690,45 @@ func DomainExists(domainName string, dbCache *DBCache) (bool, error) {
 	return true, err
 }
 
+// scheduledDomainRevisions returns the IDs of the current revisions of the
+// domains passed that have an effective at time after the time passed, in
+// which case the domain must not be provisioned yet. The revisions are
+// loaded with a single query. If an error occurs, it will be returned.
+func scheduledDomainRevisions(dbCache *DBCache, domains []Domain, now time.Time) (map[int64]bool, error) {
+	scheduled := make(map[int64]bool)
+
+	var revisionIDs []int64
+
+	for _, domain := range domains {
+		if domain.CurrentRevisionID.Valid {
+			revisionIDs = append(revisionIDs, domain.CurrentRevisionID.Int64)
+		}
+	}
+
+	if len(revisionIDs) == 0 {
+		return scheduled, nil
+	}
+
+	var revisions []DomainRevision
+
+	err := dbCache.DB.Where("id in (?) and effective_at is not null", revisionIDs).Find(&revisions).Error
+	if err != nil {
+		return scheduled, err
+	}
+
+	for _, rev := range revisions {
+		if !rev.IsEffective(now) {
+			scheduled[rev.ID] = true
+		}
+	}
+
+	return scheduled, nil
+}
+
 // GetWorkDomains returns a list of IDs for domains that require
 // attention in the form of an update to the registry or other related
-// information. If an error occurs, it will be returned.
+// information. Domains with a current revision that is not yet
+// effective are excluded. If an error occurs, it will be returned.
 func GetWorkDomains(dbCache *DBCache) (retlist []int64, revisions []APIRevisionHint, err error) {
 	var domains []Domain
 
@@ -1637,7 +1737,18 @@ func GetWorkDomains(dbCache *DBCache) (retlist []int64, revisions []APIRevisionH
 		return
 	}
 
+	scheduled, err := scheduledDomainRevisions(dbCache, domains, TimeNow())
+	if err != nil {
+		return
+	}
+
 	for _, domain := range domains {
+		if domain.CurrentRevisionID.Valid && scheduled[domain.CurrentRevisionID.Int64] {
+			logger.Infof("Domain %d is scheduled, skipping until its effective at time", domain.ID)
+
+			continue
+		}
+
 		retlist = append(retlist, domain.ID)
 
 		if domain.CurrentRevisionID.Valid {
@@ -1699,7 +1810,15 @@ func GetServerLockChanges(dbCache *DBCache) (unlock map[string][]string, lock ma
 		return unlock, lock, err
 	}
 
+	now := TimeNow()
+
 	for _, dom := range domains {
+		// Scheduled changes must not unlock the domain before they take
+		// effect
+		if !dom.CurrentRevision.IsEffective(now) {
+			continue
+		}
+
 		var addFlags, removeFlags []string
 		// Step 1: see if anything needs to be removed
 
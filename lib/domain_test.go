@@ -2,11 +2,16 @@ package lib
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"net/http"
 	"net/url"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/jinzhu/gorm"
 
 	. "github.com/smartystreets/goconvey/convey"
 )
@@ -435,3 +440,65 @@ func DoDomainDBTests(t *testing.T, testBlock func(*DBCache, *DomainBaseObjs)) {
 
 	testBlock(dbCache, &objs)
 }
+
+// getWorkTestDB returns a DBCache for a new database in the test's
+// temporary directory with the schema created.
+func getWorkTestDB(t *testing.T) *DBCache {
+	t.Helper()
+
+	dbraw, err := gorm.Open("sqlite3", filepath.Join(t.TempDir(), "work.db"))
+	if err != nil {
+		t.Fatalf("Failed to open db: %s", err.Error())
+	}
+
+	t.Cleanup(func() { dbraw.Close() })
+
+	dbCache := NewDBCache(&dbraw)
+
+	if err := CreateDBSchema(&dbCache, mustGetTestConf()); err != nil {
+		t.Fatalf("Failed to create db schema: %s", err.Error())
+	}
+
+	return &dbCache
+}
+
+func TestGetWorkDomainsScheduled(t *testing.T) {
+	t.Parallel()
+	Convey("Given domains that require work with current revisions", t, func() {
+		dbCache := getWorkTestDB(t)
+		effectiveAt := TimeNow().Add(time.Hour)
+
+		current := DomainRevision{}
+		So(dbCache.DB.Create(&current).Error, ShouldBeNil)
+
+		scheduled := DomainRevision{EffectiveAt: &effectiveAt}
+		So(dbCache.DB.Create(&scheduled).Error, ShouldBeNil)
+
+		currentDomain := Domain{DomainName: "CURRENT.COM", CheckRequired: true, CurrentRevisionID: sql.NullInt64{Int64: current.ID, Valid: true}}
+		So(dbCache.DB.Create(&currentDomain).Error, ShouldBeNil)
+
+		scheduledDomain := Domain{DomainName: "SCHEDULED.COM", CheckRequired: true, CurrentRevisionID: sql.NullInt64{Int64: scheduled.ID, Valid: true}}
+		So(dbCache.DB.Create(&scheduledDomain).Error, ShouldBeNil)
+
+		newDomain := Domain{DomainName: "NEW.COM", CheckRequired: true}
+		So(dbCache.DB.Create(&newDomain).Error, ShouldBeNil)
+
+		Convey("A domain with a future effective at time should not be returned", func() {
+			domains, revisions, err := GetWorkDomains(dbCache)
+			So(err, ShouldBeNil)
+			So(domains, ShouldResemble, []int64{currentDomain.ID, newDomain.ID})
+			So(revisions, ShouldHaveLength, 1)
+			So(revisions[0].RevisionID, ShouldEqual, current.ID)
+		})
+
+		Convey("A domain should be returned once its effective at time has passed", func() {
+			passed := TimeNow().Add(-time.Minute)
+			So(dbCache.DB.Model(&scheduled).Update("effective_at", &passed).Error, ShouldBeNil)
+
+			domains, revisions, err := GetWorkDomains(dbCache)
+			So(err, ShouldBeNil)
+			So(domains, ShouldResemble, []int64{currentDomain.ID, scheduledDomain.ID, newDomain.ID})
+			So(revisions, ShouldHaveLength, 2)
+		})
+	})
+}
@@ -61,6 +61,8 @@ type DomainRevision struct {
 	IssueCR string `sql:"size:256"`
 	Notes   string `sql:"size:2048"`
 
+	EffectiveAt *time.Time
+
 	CreatedAt time.Time `json:"CreatedAt"`
 	CreatedBy string    `json:"CreatedBy"`
 	UpdatedAt time.Time `json:"UpdatedAt"`
@@ -111,6 +113,8 @@ type DomainRevisionExport struct {
 	IssueCR string `json:"IssueCR"`
 	Notes   string `json:"Notes"`
 
+	EffectiveAt *time.Time `json:"EffectiveAt"`
+
 	RequiredApproverSets []ApproverSetExportShort `json:"RequiredApproverSets"`
 	InformedApproverSets []ApproverSetExportShort `json:"InformedApproverSets"`
 
@@ -238,6 +242,11 @@ func (dre DomainRevisionExport) Compare(domainRevision DomainRevision) (pass boo
 		pass = false
 	}
 
+	if !CompareEffectiveAt(dre.EffectiveAt, domainRevision.EffectiveAt) {
+		errs = append(errs, fmt.Errorf("the EffectiveAt fields did not match"))
+		pass = false
+	}
+
 	dsDataEntriesCheck := CompareDSDataEntries(domainRevision.DSDataEntries, dre.DSDataEntries)
 	if !dsDataEntriesCheck {
 		errs = append(errs, fmt.Errorf("the DS Data Entries did not match"))
@@ -380,6 +389,11 @@ func (dre DomainRevisionExport) CompareExport(domainRevision DomainRevisionExpor
 		pass = false
 	}
 
+	if !CompareEffectiveAt(dre.EffectiveAt, domainRevision.EffectiveAt) {
+		errs = append(errs, fmt.Errorf("the EffectiveAt fields did not match"))
+		pass = false
+	}
+
 	dsDataEntriesCheck := CompareDSDataEntries(domainRevision.DSDataEntries, dre.DSDataEntries)
 	if !dsDataEntriesCheck {
 		errs = append(errs, fmt.Errorf("the DS Data Entries did not match"))
@@ -453,6 +467,12 @@ func (d *DomainRevisionsPage) SetCSRFToken(newToken string) {
 	d.CSRFToken = newToken
 }
 
+// IsEffective returns true iff the revision may be provisioned at the
+// time passed.
+func (dre DomainRevisionExport) IsEffective(now time.Time) bool {
+	return IsEffective(dre.EffectiveAt, now)
+}
+
 // ToJSON will return a string containing a JSON representation
 // of the object. An empty string and an error are returned if a JSON
 // representation cannot be returned.
@@ -496,6 +516,7 @@ func (d *DomainRevision) GetExportVersion() RegistrarObjectExport {
 		SavedNotes:                     d.SavedNotes,
 		IssueCR:                        d.IssueCR,
 		Notes:                          d.Notes,
+		EffectiveAt:                    d.EffectiveAt,
 		DomainRegistrant:               d.DomainRegistrant.GetExportVersionShort(),
 		DomainAdminContact:             d.DomainAdminContact.GetExportVersionShort(),
 		DomainTechContact:              d.DomainTechContact.GetExportVersionShort(),
@@ -534,6 +555,19 @@ func (d DomainRevision) IsDesiredState(state string) bool {
 	return d.DesiredState == state
 }
 
+// IsEffective returns true iff the revision may be provisioned at the
+// time passed.
+func (d DomainRevision) IsEffective(now time.Time) bool {
+	return IsEffective(d.EffectiveAt, now)
+}
+
+// EffectiveAtValue returns the effective at time of the revision
+// formatted for use in a HTML form. This function is intended to be
+// used with templates.
+func (d DomainRevision) EffectiveAtValue() string {
+	return FormatEffectiveAt(d.EffectiveAt)
+}
+
 // HasHappened is a helper function that will return true if the value
 // of the timestamp who's name is passes has happened after the revision
 // was created. This function is intended to be used with templates.
@@ -1017,6 +1051,7 @@ func (d *DomainRevision) StartApprovalProcess(request *http.Request, dbCache *DB
 		InitialRevisionID:   domain.CurrentRevisionID,
 		ChangeJSON:          changeRequestJSON,
 		ChangeDiff:          diff,
+		EffectiveAt:         d.EffectiveAt,
 		CreatedBy:           runame,
 		UpdatedBy:           runame,
 		UpdatedAt:           TimeNow(),
@@ -1278,6 +1313,13 @@ func (d *DomainRevision) ParseFromForm(request *http.Request, dbCache *DBCache)
 	d.IssueCR = request.FormValue("revision_issue_cr")
 	d.Notes = request.FormValue("revision_notes")
 
+	effectiveAt, effectiveAtErr := ParseEffectiveAt(request, "revision_effective_at")
+	if effectiveAtErr != nil {
+		return effectiveAtErr
+	}
+
+	d.EffectiveAt = effectiveAt
+
 	domainRegistrantID, err5 := strconv.ParseInt(request.FormValue("revision_registrant_contact"), 10, 64)
 	domainAdminContactID, err6 := strconv.ParseInt(request.FormValue("revision_admin_contact"), 10, 64)
 	domainTechContactID, err7 := strconv.ParseInt(request.FormValue("revision_tech_contact"), 10, 64)
@@ -1418,6 +1460,13 @@ func (d *DomainRevision) ParseFromFormUpdate(request *http.Request, dbCache *DBC
 			d.IssueCR = request.FormValue("revision_issue_cr")
 			d.Notes = request.FormValue("revision_notes")
 
+			effectiveAt, effectiveAtErr := ParseEffectiveAt(request, "revision_effective_at")
+			if effectiveAtErr != nil {
+				return effectiveAtErr
+			}
+
+			d.EffectiveAt = effectiveAt
+
 			d.DomainRegistrantID = domainRegistrantID
 			d.DomainAdminContactID = domainAdminContactID
 			d.DomainTechContactID = domainTechContactID
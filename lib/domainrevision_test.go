@@ -2,6 +2,7 @@ package lib
 
 import (
 	"testing"
+	"time"
 
 	. "github.com/smartystreets/goconvey/convey"
 )
@@ -23,3 +24,38 @@ func TestDomainRevisionExportToJSON(t *testing.T) {
 		})
 	})
 }
+
+func TestDomainRevisionEffectiveAt(t *testing.T) {
+	t.Parallel()
+	Convey("Given a Domain Revision with an effective at time", t, func() {
+		effectiveAt := time.Date(2026, 10, 17, 22, 30, 0, 0, time.UTC)
+		revision := DomainRevision{Model: Model{ID: 1}, EffectiveAt: &effectiveAt}
+
+		Convey("The revision should only be effective from that time", func() {
+			So(revision.IsEffective(effectiveAt.Add(-time.Minute)), ShouldBeFalse)
+			So(revision.IsEffective(effectiveAt), ShouldBeTrue)
+		})
+
+		Convey("The export should include the effective at time", func() {
+			export, ok := revision.GetExportVersion().(DomainRevisionExport)
+			So(ok, ShouldBeTrue)
+			So(export.EffectiveAt, ShouldNotBeNil)
+			So(export.EffectiveAt.Equal(effectiveAt), ShouldBeTrue)
+			So(export.IsEffective(effectiveAt.Add(-time.Minute)), ShouldBeFalse)
+
+			Convey("A change to the effective at time should not match", func() {
+				rescheduled := effectiveAt.Add(time.Hour)
+				other := export
+				other.EffectiveAt = &rescheduled
+
+				pass, errs := export.CompareExport(other)
+				So(pass, ShouldBeFalse)
+				So(errs, ShouldNotBeEmpty)
+
+				other.EffectiveAt = nil
+				pass, _ = export.CompareExport(other)
+				So(pass, ShouldBeFalse)
+			})
+		})
+	})
+}
@@ -165,6 +165,57 @@ const (
 	// name that is updated. The host IP addresses and statuses are included in
 	// the action notes field.
 	EPPLogActionHostUpdate = "HostUpdate"
+
+	// EPPLogActionContactAvailable represents the action where an EPP Available
+	// request has been made for a contact. The Argument provided is the contact
+	// ID that is queried.
+	EPPLogActionContactAvailable = "ContactAvailable"
+
+	// EPPLogActionContactInfo represents the action where an EPP Info request
+	// has been made for a contact. The Argument provided is the contact ID that
+	// is queried.
+	EPPLogActionContactInfo = "ContactInfo"
+
+	// EPPLogActionContactCreate represents the action where an EPP Create
+	// request has been made for a contact. The Argument provided is the contact
+	// ID that is created.
+	EPPLogActionContactCreate = "ContactCreate"
+
+	// EPPLogActionContactUpdate represents the action where an EPP Update
+	// request has been made for a contact. The Argument provided is the contact
+	// ID that is updated. The fields and statuses changed are included in the
+	// action notes field.
+	EPPLogActionContactUpdate = "ContactUpdate"
+
+	// EPPLogActionContactDelete represents the action where an EPP Delete
+	// request has been made for a contact. The Argument provided is the contact
+	// ID that is deleted.
+	EPPLogActionContactDelete = "ContactDelete"
+
+	// EPPLogActionContactTransferRequest represents the action where an EPP
+	// Transfer Request request has been made for a contact. The Argument
+	// provided is the contact ID that is requested.
+	EPPLogActionContactTransferRequest = "ContactTransferRequest"
+
+	// EPPLogActionContactTransferReject represents the action where an EPP
+	// Transfer Reject request has been made for a contact. The Argument
+	// provided is the contact ID that is rejected.
+	EPPLogActionContactTransferReject = "ContactTransferReject"
+
+	// EPPLogActionContactTransferApprove represents the action where an EPP
+	// Transfer Approve request has been made for a contact. The Argument
+	// provided is the contact ID that is approved.
+	EPPLogActionContactTransferApprove = "ContactTransferApprove"
+
+	// EPPLogActionContactTransferCancel represents the action where an EPP
+	// Transfer Cancel request has been made for a contact. The Argument
+	// provided is the contact ID that is cancelled.
+	EPPLogActionContactTransferCancel = "ContactTransferCancel"
+
+	// EPPLogActionContactTransferQuery represents the action where an EPP
+	// Transfer Query request has been made for a contact. The Argument
+	// provided is the contact ID that is queried.
+	EPPLogActionContactTransferQuery = "ContactTransferQuery"
 )
 
 // NewEPPAction will gerenate and return a new EPPAction object.
@@ -189,17 +240,22 @@ func (a *EPPAction) AddNote(note string) {
 // server transaction id and the error based on the response.
 func (a *EPPAction) HandleResponse(resp epp.Epp) (err error) {
 	a.ServerTransactionID, err = resp.GetServerTransactionID()
-	a.ResponseCode = resp.ResponseObject.Result.Code
-	a.ResponseMessage = resp.ResponseObject.Result.Msg
 	a.SetError(err)
 
-	if resp.ResponseObject != nil {
+	if resp.ResponseObject != nil && resp.ResponseObject.Result != nil {
+		a.ResponseCode = resp.ResponseObject.Result.Code
+		a.ResponseMessage = resp.ResponseObject.Result.Msg
+
 		if resp.ResponseObject.IsError() {
 			a.SetError(resp.ResponseObject.GetError())
 		}
 	}
 
-	return fmt.Errorf("unable to get server transaction id: %w", err)
+	if err != nil {
+		return fmt.Errorf("unable to get server transaction id: %w", err)
+	}
+
+	return nil
 }
 
 // SetError will set the error of the action to the error provided and if the
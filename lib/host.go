@@ -1084,8 +1084,8 @@ func (h *Host) UpdateState(dbCache *DBCache, _ Config) (changesMade bool, errs [
 					}
 
 					logger.Infof("Pending Revision State = %s", pendingRevision.RevisionState)
-				} else if changeRequest.State == StateDeclined {
-					logger.Infof("CR %d has been declined", changeRequest.GetID())
+				} else if changeRequest.State == StateDeclined || changeRequest.State == StateExpired {
+					logger.Infof("CR %d has been %s", changeRequest.GetID(), changeRequest.State)
 
 					if err := h.PendingRevision.Decline(dbCache); err != nil {
 						errs = append(errs, err)
@@ -1394,9 +1394,45 @@ func HostnameExists(hostname string, dbCache *DBCache) (ret bool, err error) {
 	return (hostnameCount != 0), nil
 }
 
+// scheduledHostRevisions returns the IDs of the current revisions of the
+// hosts passed that have an effective at time after the time passed, in
+// which case the host must not be provisioned yet. The revisions are
+// loaded with a single query. If an error occurs, it will be returned.
+func scheduledHostRevisions(dbCache *DBCache, hosts []Host, now time.Time) (map[int64]bool, error) {
+	scheduled := make(map[int64]bool)
+
+	var revisionIDs []int64
+
+	for _, host := range hosts {
+		if host.CurrentRevisionID.Valid {
+			revisionIDs = append(revisionIDs, host.CurrentRevisionID.Int64)
+		}
+	}
+
+	if len(revisionIDs) == 0 {
+		return scheduled, nil
+	}
+
+	var revisions []HostRevision
+
+	err := dbCache.DB.Where("id in (?) and effective_at is not null", revisionIDs).Find(&revisions).Error
+	if err != nil {
+		return scheduled, err
+	}
+
+	for _, rev := range revisions {
+		if !rev.IsEffective(now) {
+			scheduled[rev.ID] = true
+		}
+	}
+
+	return scheduled, nil
+}
+
 // GetWorkHosts returns a list of IDs for hosts that require
 // attention in the form of an update to the registry or other related
-// information. If an error occurs, it will be returned
+// information. Hosts with a current revision that is not yet effective
+// are excluded. If an error occurs, it will be returned
 //
 // TODO: Consider moving the query into dbcache.
 func GetWorkHosts(dbCache *DBCache) (retlist []int64, revisions []APIRevisionHint, err error) {
@@ -1407,7 +1443,18 @@ func GetWorkHosts(dbCache *DBCache) (retlist []int64, revisions []APIRevisionHin
 		return
 	}
 
+	scheduled, err := scheduledHostRevisions(dbCache, hosts, TimeNow())
+	if err != nil {
+		return
+	}
+
 	for _, host := range hosts {
+		if host.CurrentRevisionID.Valid && scheduled[host.CurrentRevisionID.Int64] {
+			logger.Infof("Host %d is scheduled, skipping until its effective at time", host.ID)
+
+			continue
+		}
+
 		retlist = append(retlist, host.ID)
 
 		if host.CurrentRevisionID.Valid {
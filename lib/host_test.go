@@ -2,11 +2,13 @@ package lib
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"net/http"
 	"net/url"
 	"strings"
 	"testing"
+	"time"
 
 	. "github.com/smartystreets/goconvey/convey"
 )
@@ -388,3 +390,41 @@ func DoHostDBTests(t *testing.T, testBlock func(*DBCache, *HostBaseObjs)) {
 
 	testBlock(dbCache, &objs)
 }
+
+func TestGetWorkHostsScheduled(t *testing.T) {
+	t.Parallel()
+	Convey("Given hosts that require work with current revisions", t, func() {
+		dbCache := getWorkTestDB(t)
+		effectiveAt := TimeNow().Add(time.Hour)
+
+		current := HostRevision{}
+		So(dbCache.DB.Create(&current).Error, ShouldBeNil)
+
+		scheduled := HostRevision{EffectiveAt: &effectiveAt}
+		So(dbCache.DB.Create(&scheduled).Error, ShouldBeNil)
+
+		currentHost := Host{HostName: "NS1.CURRENT.COM", CheckRequired: true, CurrentRevisionID: sql.NullInt64{Int64: current.ID, Valid: true}}
+		So(dbCache.DB.Create(&currentHost).Error, ShouldBeNil)
+
+		scheduledHost := Host{HostName: "NS1.SCHEDULED.COM", CheckRequired: true, CurrentRevisionID: sql.NullInt64{Int64: scheduled.ID, Valid: true}}
+		So(dbCache.DB.Create(&scheduledHost).Error, ShouldBeNil)
+
+		Convey("A host with a future effective at time should not be returned", func() {
+			hosts, revisions, err := GetWorkHosts(dbCache)
+			So(err, ShouldBeNil)
+			So(hosts, ShouldResemble, []int64{currentHost.ID})
+			So(revisions, ShouldHaveLength, 1)
+			So(revisions[0].RevisionID, ShouldEqual, current.ID)
+		})
+
+		Convey("A host should be returned once its effective at time has passed", func() {
+			passed := TimeNow().Add(-time.Minute)
+			So(dbCache.DB.Model(&scheduled).Update("effective_at", &passed).Error, ShouldBeNil)
+
+			hosts, revisions, err := GetWorkHosts(dbCache)
+			So(err, ShouldBeNil)
+			So(hosts, ShouldResemble, []int64{currentHost.ID, scheduledHost.ID})
+			So(revisions, ShouldHaveLength, 2)
+		})
+	})
+}
@@ -43,6 +43,8 @@ type HostRevision struct {
 	IssueCR string `sql:"size:256"`
 	Notes   string `sql:"size:2048"`
 
+	EffectiveAt *time.Time
+
 	CreatedAt time.Time `json:"CreatedAt"`
 	CreatedBy string    `json:"CreatedBy"`
 	UpdatedAt time.Time `json:"UpdatedAt"`
@@ -80,6 +82,8 @@ type HostRevisionExport struct {
 	IssueCR string `json:"IssueCR"`
 	Notes   string `json:"Notes"`
 
+	EffectiveAt *time.Time `json:"EffectiveAt"`
+
 	RequiredApproverSets []ApproverSetExportShort `json:"RequiredApproverSets"`
 	InformedApproverSets []ApproverSetExportShort `json:"InformedApproverSets"`
 
@@ -134,6 +138,12 @@ func (h *HostRevisionsPage) SetCSRFToken(newToken string) {
 	h.CSRFToken = newToken
 }
 
+// IsEffective returns true iff the revision may be provisioned at the
+// time passed.
+func (hre HostRevisionExport) IsEffective(now time.Time) bool {
+	return IsEffective(hre.EffectiveAt, now)
+}
+
 // ToJSON will return a string containing a JSON representation
 // of the object. An empty string and an error are returned if a JSON
 // representation cannot be returned.
@@ -171,6 +181,7 @@ func (h *HostRevision) GetExportVersion() RegistrarObjectExport {
 		SavedNotes:                     h.SavedNotes,
 		IssueCR:                        h.IssueCR,
 		Notes:                          h.Notes,
+		EffectiveAt:                    h.EffectiveAt,
 		CreatedAt:                      h.CreatedAt,
 		CreatedBy:                      h.CreatedBy,
 	}
@@ -200,6 +211,19 @@ func (h HostRevision) IsDesiredState(state string) bool {
 	return h.DesiredState == state
 }
 
+// IsEffective returns true iff the revision may be provisioned at the
+// time passed.
+func (h HostRevision) IsEffective(now time.Time) bool {
+	return IsEffective(h.EffectiveAt, now)
+}
+
+// EffectiveAtValue returns the effective at time of the revision
+// formatted for use in a HTML form. This function is intended to be
+// used with templates.
+func (h HostRevision) EffectiveAtValue() string {
+	return FormatEffectiveAt(h.EffectiveAt)
+}
+
 // HasHappened is a helper function that will return true if the value
 // of the timestamp who's name is passes has happened after the revision
 // was created. This function is intended to be used with templates.
@@ -629,6 +653,7 @@ func (h *HostRevision) StartApprovalProcess(request *http.Request, dbCache *DBCa
 		InitialRevisionID:   host.CurrentRevisionID,
 		ChangeJSON:          changeRequestJSON,
 		ChangeDiff:          diff,
+		EffectiveAt:         h.EffectiveAt,
 		CreatedBy:           runame,
 		UpdatedBy:           runame,
 		UpdatedAt:           TimeNow(),
@@ -785,6 +810,13 @@ func (h *HostRevision) ParseFromForm(request *http.Request, dbCache *DBCache) er
 	h.IssueCR = request.FormValue("revision_issue_cr")
 	h.Notes = request.FormValue("revision_notes")
 
+	effectiveAt, effectiveAtErr := ParseEffectiveAt(request, "revision_effective_at")
+	if effectiveAtErr != nil {
+		return effectiveAtErr
+	}
+
+	h.EffectiveAt = effectiveAt
+
 	hostAddresses, hostErrs := ParseHostAddresses(request, dbCache, "host_address")
 
 	h.RequiredApproverSets, err2 = ParseApproverSets(request, dbCache, "approver_set_required_id", true)
@@ -854,6 +886,13 @@ func (h *HostRevision) ParseFromFormUpdate(request *http.Request, dbCache *DBCac
 			h.IssueCR = request.FormValue("revision_issue_cr")
 			h.Notes = request.FormValue("revision_notes")
 
+			effectiveAt, effectiveAtErr := ParseEffectiveAt(request, "revision_effective_at")
+			if effectiveAtErr != nil {
+				return effectiveAtErr
+			}
+
+			h.EffectiveAt = effectiveAt
+
 			hostAddresses, hostErrs := ParseHostAddresses(request, dbCache, "host_address")
 
 			RequiredApproverSets, err1 := ParseApproverSets(request, dbCache, "approver_set_required_id", true)
@@ -1141,6 +1180,11 @@ func (hre HostRevisionExport) Compare(hostRevision HostRevision) (pass bool, err
 		pass = false
 	}
 
+	if !CompareEffectiveAt(hre.EffectiveAt, hostRevision.EffectiveAt) {
+		errs = append(errs, fmt.Errorf("the EffectiveAt fields did not match"))
+		pass = false
+	}
+
 	// HostAddresses []HostAddress
 	hostAddressCheck := CompareHostAddressLists(hostRevision.HostAddresses, hre.HostAddresses)
 	if !hostAddressCheck {
@@ -1229,6 +1273,11 @@ func (hre HostRevisionExport) CompareExport(hostRevision HostRevisionExport) (pa
 		pass = false
 	}
 
+	if !CompareEffectiveAt(hre.EffectiveAt, hostRevision.EffectiveAt) {
+		errs = append(errs, fmt.Errorf("the EffectiveAt fields did not match"))
+		pass = false
+	}
+
 	// HostAddresses []HostAddress
 	hostAddressCheck := CompareHostAddressLists(hostRevision.HostAddresses, hre.HostAddresses)
 	if !hostAddressCheck {
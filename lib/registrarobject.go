@@ -137,6 +137,10 @@ const (
 	// StateSuperseded is used to indicate that a new revision has been
 	// approved and is now the current revsion.
 	StateSuperseded string = "superseded"
+
+	// StateExpired is used to indicate that a Change Request or Approval
+	// was not completed within its validity window.
+	StateExpired string = "expired"
 )
 
 // All contants prefixed with Action are used to represent an action
@@ -170,6 +174,10 @@ const (
 	// client is trying to unset the check_reqired field for an object.
 	ActionUpdateEPPCheckRequired string = "updateEPPCheckRequired"
 
+	// ActionUpdateDNSStatus is used to represent the API action where the
+	// client is trying to update the DNS status of an object.
+	ActionUpdateDNSStatus string = "updateDNSStatus"
+
 	// ActionTriggerUpdate is used to represent that a parent update should have
 	// its update state process triggered.
 	ActionTriggerUpdate string = "triggerUpdate"
@@ -366,6 +374,16 @@ const ApproverSetFieldTitle string = "Title"
 // the Description field of the current approver set revision.
 const ApproverSetFieldDescription string = "Description"
 
+// ApproverSetFieldRequiredSignatures is the name that can be used to
+// reference the RequiredSignatures field of the current approver set
+// revision.
+const ApproverSetFieldRequiredSignatures string = "RequiredSignatures"
+
+// ApproverSetFieldApprovalValidityDays is the name that can be used to
+// reference the ApprovalValidityDays field of the current approver set
+// revision.
+const ApproverSetFieldApprovalValidityDays string = "ApprovalValidityDays"
+
 // DesiredStateActive is the name that can be used to reference the
 // desired state of active when checking for a suggested value.
 const DesiredStateActive string = "DesiredStateActive"
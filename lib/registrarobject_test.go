@@ -206,7 +206,7 @@ func AlmostApprove(t *testing.T, dbCache *DBCache, obj, rev RegistrarObject, cha
 			t.Fatalf("No Error Expected when calling prepare on an existing Approval")
 		}
 
-		message := app.GetDownload(dbCache, TestUser1Username, ActionApproved)
+		message := app.GetDownload(dbCache, TestUser1Username, ActionApproved, conf)
 
 		signed, err := ClearsignMessage(message, TestUser1Username)
 		if err != nil {
@@ -970,7 +970,7 @@ func ApproveApproval(dbCache *DBCache, username string, approvalID int64, _ Conf
 
 	// Getting download object
 
-	message := app.GetDownload(dbCache, username, ActionApproved)
+	message := app.GetDownload(dbCache, username, ActionApproved, conf)
 
 	signed, err := ClearsignMessage(message, username)
 	if err != nil {
@@ -1046,7 +1046,7 @@ func DeclineApproval(dbCache *DBCache, username string, approvalID int64, _ Conf
 		return errors.New("no Error Expected when calling prepare on an existing Approval")
 	}
 
-	message := app.GetDownload(dbCache, username, ActionDeclined)
+	message := app.GetDownload(dbCache, username, ActionDeclined, conf)
 
 	signed, err := ClearsignMessage(message, username)
 	if err != nil {
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/timapril/go-registrar/epp"
+	"github.com/timapril/go-registrar/lib"
+
+	superclient "github.com/timapril/go-registrar/epp/superClient"
+)
+
+func getTestingContactRevision(desiredState string, email string, clientUpdateProhibited bool) lib.ContactRevisionExport {
+	return lib.ContactRevisionExport{
+		DesiredState:                 desiredState,
+		ClientDeleteProhibitedStatus: true,
+		ClientUpdateProhibitedStatus: clientUpdateProhibited,
+		Name:                         "Jane Doe",
+		Org:                          "Example Org",
+		AddressStreet1:               "123 Main St",
+		AddressCity:                  "Springfield",
+		AddressState:                 "VA",
+		AddressPostalCode:            "22150",
+		AddressCountry:               "US",
+		VoicePhoneNumber:             "+1.5555551234",
+		EmailAddress:                 email,
+	}
+}
+
+func getTestingContactInfo(statuses ...string) *epp.ContactInfDataResp {
+	postalInfo, email, voice, fax := GetContactEPPValues(getTestingContactRevision(lib.StateActive, "jane@example.com", false))
+	info := &epp.ContactInfDataResp{
+		ID:          "C1",
+		PostalInfos: []epp.PostalInfo{postalInfo},
+		Voice:       voice,
+		Fax:         fax,
+		Email:       email,
+		AuthPW:      &epp.ContactAuth{Password: "Old-Pass1"},
+	}
+	for _, status := range statuses {
+		info.Status = append(info.Status, epp.ContactStatus{StatusFlag: status})
+	}
+	return info
+}
+
+func TestGetContactClientStatuses(t *testing.T) {
+	t.Parallel()
+	Convey("Given a contact revision, the client statuses should match the revision", t, func() {
+		So(GetContactClientStatuses(lib.ContactRevisionExport{}), ShouldBeEmpty)
+
+		rev := lib.ContactRevisionExport{
+			ClientDeleteProhibitedStatus:   true,
+			ClientTransferProhibitedStatus: true,
+			ClientUpdateProhibitedStatus:   true,
+			ServerUpdateProhibitedStatus:   true,
+		}
+		So(GetContactClientStatuses(rev), ShouldResemble, []string{epp.StatusClientDeleteProhibited, epp.StatusClientTransferProhibited, epp.StatusClientUpdateProhibited})
+	})
+}
+
+func TestGetContactUpdates(t *testing.T) {
+	t.Parallel()
+	Convey("Given a contact at the registry, the updates should match the revision", t, func() {
+		newEmail := "jane.doe@example.com"
+		newAuthInfo := "New-Pass2"
+
+		tests := []struct {
+			name         string
+			rev          lib.ContactRevisionExport
+			info         *epp.ContactInfDataResp
+			authInfo     string
+			changes      []superclient.ContactChanges
+			serverLocked bool
+		}{
+			{
+				name: "matching active contact",
+				rev:  getTestingContactRevision(lib.StateActive, "jane@example.com", false),
+				info: getTestingContactInfo(epp.StatusClientDeleteProhibited),
+			},
+			{
+				name:     "contact with a matching authInfo",
+				rev:      getTestingContactRevision(lib.StateActive, "jane@example.com", false),
+				info:     getTestingContactInfo(epp.StatusClientDeleteProhibited),
+				authInfo: "Old-Pass1",
+			},
+			{
+				name:    "unlocked contact with a new email",
+				rev:     getTestingContactRevision(lib.StateActive, newEmail, false),
+				info:    getTestingContactInfo(epp.StatusClientDeleteProhibited),
+				changes: []superclient.ContactChanges{{Email: &newEmail}},
+			},
+			{
+				name:     "contact with a new authInfo",
+				rev:      getTestingContactRevision(lib.StateActive, "jane@example.com", false),
+				info:     getTestingContactInfo(epp.StatusClientDeleteProhibited),
+				authInfo: newAuthInfo,
+				changes:  []superclient.ContactChanges{{AuthInfo: &newAuthInfo}},
+			},
+			{
+				name: "locked contact that stays locked",
+				rev:  getTestingContactRevision(lib.StateActive, newEmail, true),
+				info: getTestingContactInfo(epp.StatusClientDeleteProhibited, epp.StatusClientUpdateProhibited),
+				changes: []superclient.ContactChanges{
+					{RemoveStatuses: []string{epp.StatusClientUpdateProhibited}},
+					{Email: &newEmail},
+					{AddStatuses: []string{epp.StatusClientUpdateProhibited}},
+				},
+			},
+			{
+				name: "locked contact that is unlocked with other changes",
+				rev:  getTestingContactRevision(lib.StateActive, newEmail, false),
+				info: getTestingContactInfo(epp.StatusClientDeleteProhibited, epp.StatusClientUpdateProhibited),
+				changes: []superclient.ContactChanges{
+					{RemoveStatuses: []string{epp.StatusClientUpdateProhibited}},
+					{Email: &newEmail, RemoveStatuses: []string{}},
+				},
+			},
+			{
+				name:    "locked contact that is only unlocked",
+				rev:     getTestingContactRevision(lib.StateActive, "jane@example.com", false),
+				info:    getTestingContactInfo(epp.StatusClientDeleteProhibited, epp.StatusClientUpdateProhibited),
+				changes: []superclient.ContactChanges{{RemoveStatuses: []string{epp.StatusClientUpdateProhibited}}},
+			},
+			{
+				name:    "inactive contact with client statuses",
+				rev:     getTestingContactRevision(lib.StateInactive, "jane@example.com", false),
+				info:    getTestingContactInfo(epp.StatusClientDeleteProhibited, epp.StatusClientUpdateProhibited),
+				changes: []superclient.ContactChanges{{RemoveStatuses: []string{epp.StatusClientDeleteProhibited, epp.StatusClientUpdateProhibited}}},
+			},
+			{
+				name: "inactive contact without client statuses",
+				rev:  getTestingContactRevision(lib.StateInactive, "jane@example.com", false),
+				info: getTestingContactInfo(),
+			},
+			{
+				name:         "registry locked contact",
+				rev:          getTestingContactRevision(lib.StateActive, newEmail, false),
+				info:         getTestingContactInfo(epp.StatusClientDeleteProhibited, epp.StatusServerUpdateProhibited),
+				changes:      []superclient.ContactChanges{{Email: &newEmail}},
+				serverLocked: true,
+			},
+			{
+				name: "contact in another state",
+				rev:  getTestingContactRevision(lib.StateNew, newEmail, false),
+				info: getTestingContactInfo(epp.StatusClientDeleteProhibited),
+			},
+		}
+
+		for _, test := range tests {
+			Convey("For a "+test.name, func() {
+				changes, serverLocked := GetContactUpdates(test.rev, test.info, test.authInfo)
+				So(changes, ShouldResemble, test.changes)
+				So(serverLocked, ShouldEqual, test.serverLocked)
+			})
+		}
+	})
+}
@@ -11,6 +11,7 @@ import (
 	"os"
 	"os/exec"
 	"path"
+	"slices"
 	"strings"
 	"time"
 
@@ -44,6 +45,10 @@ var (
 	passphraseIn = flag.Bool("passin", false, "Set if the EPP passphrase will be provided via stdin")
 
 	confpath = flag.String("conf", "./conf", "The path to the configuration file")
+
+	planMode  = flag.Bool("plan", false, "Plan the EPP changes that would be made without sending them")
+	planPath  = flag.String("planout", "", "The path to save the plan to when run with -plan")
+	applyPath = flag.String("apply", "", "The path of a saved plan to apply")
 )
 
 // Config is used to load the configuration file information from a correctly
@@ -66,6 +71,11 @@ type Config struct {
 		AuthInfoOut string
 	}
 
+	Renewal struct {
+		PeriodYears  int64
+		HorizonYears int64
+	}
+
 	Mac keychain.Conf
 
 	Testing struct {
@@ -84,6 +94,20 @@ type Config struct {
 	CacheConfig client.DiskCacheConfig
 }
 
+const (
+	// defaultRenewalPeriodYears is the number of years a domain is renewed for
+	// in each period if no period is configured
+	defaultRenewalPeriodYears = 1
+
+	// defaultRenewalHorizonYears is the number of years into the future that
+	// a domain is renewed through if no horizon is configured
+	defaultRenewalHorizonYears = 1
+
+	// maxRegistrationYears is the maximum number of years into the future a
+	// domain may be registered through
+	maxRegistrationYears = 10
+)
+
 var runReportTemplate = `==========================================================================
 ===                          Begin Run Report                          ===
 ==========================================================================
@@ -94,6 +118,7 @@ Start Time:    {{.StartTime}}
 End Time:      {{.EndTime}}
 Duration:      {{.Duration}}
 Run Completed: {{.CompletedRun}}
+Plan Only:     {{.PlanOnly}}
 
 Local Transfer Actions:
 	Approved transfer out:{{ range $val := .TransfersLocalApproved}}
@@ -119,9 +144,21 @@ Verified Work:
 	Hosts:{{ range $val := .VerifiedHosts}}
 		{{$val}}{{end}}
 
+	Contacts:{{ range $val := .VerifiedContacts}}
+		{{$val}}{{end}}
+
 Registered Domains:{{ range $val := .DomainsRegistered}}
 	{{$val}}{{end}}
 
+Renewed Domains:{{ range $val := .DomainsRenewed}}
+	{{$val}}{{end}}
+
+Renewals Skipped:{{ range $val := .DomainsRenewalSkipped}}
+	{{$val}}{{end}}
+
+Created Contacts:{{ range $val := .ContactsCreated}}
+	{{$val}}{{end}}
+
 Work Done:{{range $val := .WorkLog}}
 	{{$val}}{{end}}
 
@@ -142,6 +179,7 @@ type RunReport struct {
 	EndTime             time.Time
 	Duration            time.Duration
 	CompletedRun        bool
+	PlanOnly            bool
 
 	TransfersLocalRejected []string
 	TransfersLocalApproved []string
@@ -150,12 +188,18 @@ type RunReport struct {
 	TransfersRemoteApproved  []string
 	TransfersRemoteCancelled []string
 
-	VerifiedDomains []string
-	VerifiedHosts   []string
+	VerifiedDomains  []string
+	VerifiedHosts    []string
+	VerifiedContacts []string
 
 	DomainsRegistered        []string
 	DomainsTransferRequested []string
 
+	DomainsRenewed        []string
+	DomainsRenewalSkipped []string
+
+	ContactsCreated []string
+
 	RegistryLockChanges []string
 
 	WorkLog []string
@@ -185,6 +229,25 @@ func (r RunReport) DisplayReport() (string, error) {
 	return s, nil
 }
 
+// GetRenewalPeriod returns the number of years that a domain will be renewed
+// for in each renewal period, defaulting to 1 year if not set
+func (c Config) GetRenewalPeriod() int64 {
+	if c.Renewal.PeriodYears <= 0 {
+		return defaultRenewalPeriodYears
+	}
+	return c.Renewal.PeriodYears
+}
+
+// GetRenewalHorizon returns the number of years into the future that a
+// renewed domain should be registered through, defaulting to 1 year if not
+// set
+func (c Config) GetRenewalHorizon() int64 {
+	if c.Renewal.HorizonYears <= 0 {
+		return defaultRenewalHorizonYears
+	}
+	return c.Renewal.HorizonYears
+}
+
 // GetConnectionURL will return the URL that can be used to connect to the
 // Registrar server as defined by the parameters in the configuartion
 func (c Config) GetConnectionURL() string {
@@ -338,6 +401,16 @@ func main() {
 		return
 	}
 
+	// plan holds the plan being built when provision is run in plan mode or
+	// while checking a plan before it is applied. It is nil during a normal
+	// run.
+	var plan *Plan
+	if *planMode || *applyPath != "" {
+		plan = NewPlan(rr.TransactionIDPrefix)
+		sc.SetDryRun(plan.Record)
+		rr.PlanOnly = *planMode
+	}
+
 	if crid != nil && *crid > 0 {
 		fmt.Printf("looking up cr id: %d\n", *crid)
 		cr, err := cli.GetChangeRequest(int64(*crid))
@@ -373,12 +446,16 @@ func main() {
 	hostAvailability := make(map[string]bool)
 	hostInfoResponses := make(map[string]*epp.Response)
 
+	verifiedContacts := make(map[int64]*lib.ContactExport)
+	contactAvailability := make(map[int64]bool)
+	contactInfoResponses := make(map[int64]*epp.Response)
+
 	var shouldKeepGoingHostInfo bool
 	var skipHostnamems []string
 
 	hostDomains := make(map[string]bool)
 
-	domainIds, hostIds, _, errs := GetAllWork(cli)
+	domainIds, hostIds, contactIds, errs := GetAllWork(cli)
 	if len(errs) != 0 {
 		for _, err := range errs {
 			log.Error(err)
@@ -422,6 +499,29 @@ func main() {
 	}
 	log.Info("End: Host verification")
 
+	// Verify all of the contacts that require work
+	log.Info("Begin: Contact verification")
+	for _, contact := range contactIds {
+		log.Infof("\tStart: Contact ID %d", contact)
+		verified, errs, object := cli.GetVerifiedContact(contact, time.Now().Unix())
+		if len(errs) != 0 {
+			for _, err := range errs {
+				log.Errorf("\tError: %s", err)
+			}
+		} else {
+			if verified {
+				verifiedContacts[object.ID] = object
+				rr.VerifiedContacts = append(rr.VerifiedContacts, fmt.Sprintf("%s (%d)", object.CurrentRevision.Name, object.ID))
+				contactInfoResponses[object.ID] = nil
+				log.Infof("\t\tContact %d verified", object.ID)
+			} else {
+				log.Errorf("\t\tError verifying contact %d", contact)
+			}
+		}
+		log.Infof("\tEnd: Contact ID %d", contact)
+	}
+	log.Info("End: Contact verification")
+
 	// TODO: Get domains for all hosts on record
 	// Lets get all of the verified domains first
 	log.Info("Begin: Domain verification")
@@ -472,7 +572,7 @@ func main() {
 	eppPhase++
 
 	log.Infof("Starting Phase %d: %s", eppPhase, phaseName)
-	if shouldKeepGoing := HandlePolls(&rr, cli, sc, &verifiedDomains); !shouldKeepGoing {
+	if shouldKeepGoing := HandlePolls(&rr, cli, sc, &verifiedDomains, plan); !shouldKeepGoing {
 		log.Errorf("Terminal Phase %d: %s", eppPhase, phaseName)
 		goto Cleanup
 	}
@@ -481,7 +581,7 @@ func main() {
 	eppPhase++
 	phaseName = "Domain Existance Check"
 	log.Infof("Starting Phase %d: %s", eppPhase, phaseName)
-	if shouldKeepGoing := GetDomainExistance(&rr, cli, sc, &verifiedDomains, &domainInfoResponses, &domainAvailability); !shouldKeepGoing {
+	if shouldKeepGoing := GetDomainExistance(&rr, cli, sc, &verifiedDomains, &domainInfoResponses, &domainAvailability, plan); !shouldKeepGoing {
 		log.Errorf("Terminal Phase %d: %s", eppPhase, phaseName)
 		goto Cleanup
 	}
@@ -518,6 +618,33 @@ func main() {
 	}
 	log.Infof("Ending Phase %d: %s", eppPhase, phaseName)
 
+	eppPhase++
+	phaseName = "Contact Existance Check"
+	log.Infof("Starting Phase %d: %s", eppPhase, phaseName)
+	if shouldKeepGoing := GetContactExistance(&rr, cli, sc, &verifiedContacts, &contactAvailability, plan); !shouldKeepGoing {
+		log.Errorf("Terminal Phase %d: %s", eppPhase, phaseName)
+		goto Cleanup
+	}
+	log.Infof("Ending Phase %d: %s", eppPhase, phaseName)
+
+	eppPhase++
+	phaseName = "Contact infomration gathering"
+	log.Infof("Starting Phase %d: %s", eppPhase, phaseName)
+	if shouldKeepGoing := GetContactInfo(&rr, cli, sc, &verifiedContacts, &contactAvailability, &contactInfoResponses); !shouldKeepGoing {
+		log.Errorf("Terminal Phase %d: %s", eppPhase, phaseName)
+		goto Cleanup
+	}
+	log.Infof("Ending Phase %d: %s", eppPhase, phaseName)
+
+	eppPhase++
+	phaseName = "Contact Updates"
+	log.Infof("Starting Phase %d: %s", eppPhase, phaseName)
+	if shouldKeepGoing := ContactUpdate(&rr, cli, sc, &verifiedContacts, &contactAvailability, &contactInfoResponses); !shouldKeepGoing {
+		log.Errorf("Terminal Phase %d: %s", eppPhase, phaseName)
+		goto Cleanup
+	}
+	log.Infof("Ending Phase %d: %s", eppPhase, phaseName)
+
 	eppPhase++
 	phaseName = "Domain Transfer In"
 	log.Infof("Starting Phase %d: %s", eppPhase, phaseName)
@@ -545,6 +672,59 @@ func main() {
 	}
 	log.Infof("Ending Phase %d: %s", eppPhase, phaseName)
 
+	eppPhase++
+	phaseName = "Domain Renewals"
+	log.Infof("Starting Phase %d: %s", eppPhase, phaseName)
+	if shouldKeepGoing := DomainRenewals(conf, &rr, cli, sc, &verifiedDomains, &domainAvailability, &domainInfoResponses); !shouldKeepGoing {
+		log.Errorf("Terminal Phase %d: %s", eppPhase, phaseName)
+		goto Cleanup
+	}
+	log.Infof("Ending Phase %d: %s", eppPhase, phaseName)
+
+	if plan != nil {
+		RecordRegistryState(plan, &domainInfoResponses, &hostInfoResponses, &contactInfoResponses)
+		if *applyPath == "" {
+			fmt.Println(plan.Render())
+			if *planPath != "" {
+				if saveErr := plan.Save(*planPath); saveErr != nil {
+					log.Error(saveErr)
+					goto Cleanup
+				}
+				log.Infof("Plan saved to %s", *planPath)
+			}
+			rr.CompletedRun = true
+			goto Cleanup
+		}
+
+		eppPhase++
+		phaseName = "Apply Plan"
+		log.Infof("Starting Phase %d: %s", eppPhase, phaseName)
+		applied, shouldKeepGoing := ApplyPlan(&rr, cli, sc, plan, *applyPath)
+		if !shouldKeepGoing {
+			log.Errorf("Terminal Phase %d: %s", eppPhase, phaseName)
+			goto Cleanup
+		}
+		log.Infof("Ending Phase %d: %s", eppPhase, phaseName)
+
+		eppPhase++
+		phaseName = "Refresh Registry Information"
+		log.Infof("Starting Phase %d: %s", eppPhase, phaseName)
+		RefreshAfterApply(applied, &verifiedContacts, &domainAvailability, &hostAvailability, &contactAvailability, &domainInfoResponses, &hostInfoResponses, &contactInfoResponses)
+		if shouldKeepGoing := GetDomainInfo(&rr, cli, sc, &verifiedDomains, &domainAvailability, &domainInfoResponses, false); !shouldKeepGoing {
+			log.Errorf("Terminal Phase %d: %s", eppPhase, phaseName)
+			goto Cleanup
+		}
+		if shouldKeepGoing, _ := GetHostInfo(&rr, cli, sc, &verifiedHosts, &hostAvailability, &verifiedDomains, &hostInfoResponses, false); !shouldKeepGoing {
+			log.Errorf("Terminal Phase %d: %s", eppPhase, phaseName)
+			goto Cleanup
+		}
+		if shouldKeepGoing := GetContactInfo(&rr, cli, sc, &verifiedContacts, &contactAvailability, &contactInfoResponses); !shouldKeepGoing {
+			log.Errorf("Terminal Phase %d: %s", eppPhase, phaseName)
+			goto Cleanup
+		}
+		log.Infof("Ending Phase %d: %s", eppPhase, phaseName)
+	}
+
 	eppPhase++
 	phaseName = "Update Registrar with Domains"
 	log.Infof("Starting Phase %d: %s", eppPhase, phaseName)
@@ -563,7 +743,14 @@ func main() {
 	}
 	log.Infof("Ending Phase %d: %s", eppPhase, phaseName)
 
-	// TODO: Check for renewals
+	eppPhase++
+	phaseName = "Update Registrar with Contacts"
+	log.Infof("Starting Phase %d: %s", eppPhase, phaseName)
+	if shouldKeepGoing := PushContacts(cli, &verifiedContacts, &contactInfoResponses); !shouldKeepGoing {
+		log.Errorf("Terminal Phase %d: %s", eppPhase, phaseName)
+		goto Cleanup
+	}
+	log.Infof("Ending Phase %d: %s", eppPhase, phaseName)
 
 	rr.CompletedRun = true
 
@@ -612,8 +799,9 @@ func GetParentDomain(hostname string) (parentDomain string, err error) {
 }
 
 // HandlePolls will issue poll requests to the EPP Server and process poll
-// requests that are pending for the server
-func HandlePolls(rr *RunReport, client client.Client, eppClient *superclient.SuperClient, verifiedDomains *map[string]*lib.DomainExport) (shouldContinue bool) {
+// requests that are pending for the server. If a plan is passed, only the
+// first message is processed and it is recorded in the plan.
+func HandlePolls(rr *RunReport, client client.Client, eppClient *superclient.SuperClient, verifiedDomains *map[string]*lib.DomainExport, plan *Plan) (shouldContinue bool) {
 	log.Info("Begin: Poll request processing")
 	for {
 		rr.AddWorkItem("EPP POLL QUERY")
@@ -701,7 +889,18 @@ func HandlePolls(rr *RunReport, client client.Client, eppClient *superclient.Sup
 				return false
 			}
 
+			if plan != nil {
+				// The message is not removed from the queue when planning so
+				// only the first message can be processed
+				plan.SetPollState(pollMessage.ID)
+				log.Info("\tPlanning only processes the first poll message")
+				break
+			}
+
 		} else {
+			if plan != nil {
+				plan.SetPollState("")
+			}
 			log.Info("\tNo more poll requests found")
 			break
 		}
@@ -713,8 +912,9 @@ func HandlePolls(rr *RunReport, client client.Client, eppClient *superclient.Sup
 
 // GetDomainExistance will iterate through the list of domains that have
 // been verifed to make sure that they all exist. If a domain does not exist
-// and its status is Active then it will be registered.
-func GetDomainExistance(rr *RunReport, client client.Client, eppClient *superclient.SuperClient, verifiedDomains *map[string]*lib.DomainExport, domainMap *map[string]*epp.Response, da *map[string]bool) (shouldContinue bool) {
+// and its status is Active then it will be registered. If a plan is passed,
+// the domain is only recorded in the plan and is left as available.
+func GetDomainExistance(rr *RunReport, client client.Client, eppClient *superclient.SuperClient, verifiedDomains *map[string]*lib.DomainExport, domainMap *map[string]*epp.Response, da *map[string]bool, plan *Plan) (shouldContinue bool) {
 	for domainName := range *domainMap {
 		rr.AddWorkItem(fmt.Sprintf("EPP DOMAIN CHECK %s", domainName))
 		domAvail, _, action, domAvailErr := eppClient.DomainAvailable(domainName)
@@ -738,7 +938,9 @@ func GetDomainExistance(rr *RunReport, client client.Client, eppClient *supercli
 						log.Errorf("\tError creating domain %s - (%d) %s", domainName, rc, err)
 						return false
 					}
-					(*da)[domainName] = false
+					// When planning the domain has not actually been created so it
+					// is still available at the registry
+					(*da)[domainName] = plan != nil
 					rr.DomainsRegistered = append(rr.DomainsRegistered, domainName)
 				} else {
 					(*da)[domainName] = true
@@ -757,15 +959,19 @@ func GetDomainExistance(rr *RunReport, client client.Client, eppClient *supercli
 // it will not be created, not enough information is availabe at this point
 // to register domains yet
 func GetHostExistance(rr *RunReport, client client.Client, eppClient *superclient.SuperClient, verifiedHosts *map[string]*lib.HostExport, hostMap *map[string]*epp.Response, ha *map[string]bool) (shouldContinue bool) {
+	var hostNames []string
 	for hostName := range *hostMap {
+		hostNames = append(hostNames, hostName)
+	}
+	for _, res := range eppClient.HostsAvailable(hostNames) {
+		hostName := res.HostName
 		rr.AddWorkItem(fmt.Sprintf("EPP HOST CHECK %s", hostName))
-		hosAvail, action, hosAvailErr := eppClient.HostAvailable(hostName)
-		client.PushEPPActionLog(action)
-		if hosAvailErr != nil {
-			log.Errorf("\tError checking if %s is available - %s", hostName, hosAvailErr)
+		client.PushEPPActionLog(res.Action)
+		if res.Err != nil {
+			log.Errorf("\tError checking if %s is available - %s", hostName, res.Err)
 			return false
 		}
-		if !hosAvail {
+		if !res.Available {
 			log.Infof("\tHost %s exits", hostName)
 			(*ha)[hostName] = false
 		} else {
@@ -779,9 +985,11 @@ func GetHostExistance(rr *RunReport, client client.Client, eppClient *superclien
 // GetDomainInfo will iterate over the list of domains in the doaminMap that
 // does not have a response object associated yet and if  the domain is
 // implemented, it will request the domain infomration otherwise it will skip
-// the domain
+// the domain. The domain information is requested using all of the sessions
+// available to the EPP client.
 func GetDomainInfo(rr *RunReport, client client.Client, eppClient *superclient.SuperClient, verifiedDomains *map[string]*lib.DomainExport, da *map[string]bool, domainMap *map[string]*epp.Response, refresh bool) (shouldContinue bool) {
 	log.Info("Iterating through all domains to get their infomration from the registry")
+	var domainNames []string
 	for domainName, val := range *domainMap {
 		// In the case where you want to fill out missing entries you want to skip
 		// existing entries
@@ -798,23 +1006,30 @@ func GetDomainInfo(rr *RunReport, client client.Client, eppClient *superclient.S
 		log.Infof("\tStart Domain %s", domainName)
 		if val, ok := (*domainMap)[domainName]; val == nil || !ok {
 			log.Infof("\tGetting domain info for %s", domainName)
-			domainInfoErr := UpdateDomainInfo(rr, client, eppClient, domainName, domainMap)
-			if domainInfoErr != nil {
-				log.Errorf("\tError getting domain info for %s - %s", domainName, domainInfoErr)
-				return false
-			}
-			log.Infof("\tDone getting domain info for %s", domainName)
+			domainNames = append(domainNames, domainName)
 		}
 	}
+	for _, res := range eppClient.DomainInfos(domainNames, epp.DomainInfoHostsDelegated) {
+		rr.AddWorkItem(fmt.Sprintf("EPP DOMAIN INFO %s", res.DomainName))
+		client.PushEPPActionLog(res.Action)
+		(*domainMap)[res.DomainName] = res.Response
+		if res.Err != nil {
+			log.Errorf("\tError getting domain info for %s - %s", res.DomainName, res.Err)
+			return false
+		}
+		log.Infof("\tDone getting domain info for %s", res.DomainName)
+	}
 	return true
 }
 
 // GetHostInfo will iterate over the list of domains in the hostMap that
 // does not have a response object associated yet and if the host is
 // implemented, it will request the host infomration otherwise it will skip
-// the host
+// the host. The host information is requested using all of the sessions
+// available to the EPP client.
 func GetHostInfo(rr *RunReport, client client.Client, eppClient *superclient.SuperClient, verifiedHosts *map[string]*lib.HostExport, ha *map[string]bool, verifiedDomains *map[string]*lib.DomainExport, hostMap *map[string]*epp.Response, refresh bool) (shouldContinue bool, skipHostnames []string) {
 	log.Info("Iterating through all hosts to get their information from the registry")
+	var hostnames []string
 	for hostname, val := range *hostMap {
 		// In the case where you want to fill missing entries, you want to skip
 		// existing entries
@@ -846,15 +1061,20 @@ func GetHostInfo(rr *RunReport, client client.Client, eppClient *superclient.Sup
 			log.Infof("\tStarting Host %s", hostname)
 			if val, ok := (*hostMap)[hostname]; val == nil || !ok {
 				log.Infof("\tGetting host info for %s", hostname)
-				hostInfoErr := UpdateHostInfo(rr, client, eppClient, hostname, hostMap)
-				if hostInfoErr != nil {
-					log.Errorf("\tError getting host info for %s - %s", hostname, hostInfoErr)
-					skipHostnames = append(skipHostnames, hostname)
-				}
-				log.Infof("\tDone getting host info for %s", hostname)
+				hostnames = append(hostnames, hostname)
 			}
 		}
 	}
+	for _, res := range eppClient.HostInfos(hostnames) {
+		rr.AddWorkItem(fmt.Sprintf("EPP HOST INFO %s", res.HostName))
+		client.PushEPPActionLog(res.Action)
+		(*hostMap)[res.HostName] = res.Response
+		if res.Err != nil {
+			log.Errorf("\tError getting host info for %s - %s", res.HostName, res.Err)
+			skipHostnames = append(skipHostnames, res.HostName)
+		}
+		log.Infof("\tDone getting host info for %s", res.HostName)
+	}
 	return true, skipHostnames
 }
 
@@ -864,6 +1084,11 @@ func DomainUpdate(conf Config, rr *RunReport, client client.Client, eppClient *s
 	for domainName, domainRegObject := range *verifiedDomains {
 		log.Infof("Domain %s: Starting to process domain", domainName)
 
+		if !domainRegObject.CurrentRevision.IsEffective(lib.TimeNow()) {
+			log.Infof("Domain %s: Current revision is not effective until %s, skipping", domainName, domainRegObject.CurrentRevision.EffectiveAt.UTC().Format(time.RFC3339))
+			continue
+		}
+
 		domainChangeMade := false
 
 		domainAvailable, domainAvailableFound := (*da)[domainName]
@@ -1133,6 +1358,11 @@ func HostUpdate(rr *RunReport, client client.Client, eppClient *superclient.Supe
 	for hostname, hostRegObject := range *verifiedHosts {
 		log.Infof("Host %s: Starting to process host", hostname)
 
+		if !hostRegObject.CurrentRevision.IsEffective(lib.TimeNow()) {
+			log.Infof("Host %s: Current revision is not effective until %s, skipping", hostname, hostRegObject.CurrentRevision.EffectiveAt.UTC().Format(time.RFC3339))
+			continue
+		}
+
 		hostChangeMade := false
 
 		parentDomain, parentDomainErr := GetParentDomain(hostname)
@@ -1280,6 +1510,107 @@ func HostUpdate(rr *RunReport, client client.Client, eppClient *superclient.Supe
 	return true
 }
 
+// DomainRenewals will iterate over the domains that have been flagged as
+// requiring renewal and renew each of them that is not prohibited from being
+// renewed. The domains are renewed by a multiple of the configured period so
+// that they are registered through at least the configured horizon. After a
+// domain is renewed its information is refreshed so it can be pushed back to
+// the registrar server.
+func DomainRenewals(conf Config, rr *RunReport, client client.Client, eppClient *superclient.SuperClient, verifiedDomains *map[string]*lib.DomainExport, da *map[string]bool, domainMap *map[string]*epp.Response) (shouldContinue bool) {
+	now := time.Now().UTC()
+	for domainName, domainRegObject := range *verifiedDomains {
+		if domainRegObject.EPPStatus != lib.EPPStatusPendingRenew {
+			continue
+		}
+		log.Infof("Domain %s: Flagged for renewal", domainName)
+		if domainAvailable, found := (*da)[domainName]; !found || domainAvailable {
+			log.Infof("Domain %s: Domain is not registered, skipping renewal", domainName)
+			continue
+		}
+		if domainRegObject.CurrentRevision.DesiredState != lib.StateActive {
+			log.Infof("Domain %s: Domain is not set to the Active state, skipping renewal", domainName)
+			continue
+		}
+		if domainRegObject.CurrentRevision.ClientRenewProhibitedStatus {
+			log.Infof("Domain %s: Domain is set to client renew prohibited, skipping renewal", domainName)
+			rr.DomainsRenewalSkipped = append(rr.DomainsRenewalSkipped, fmt.Sprintf("%s - client renew prohibited", domainName))
+			continue
+		}
+		resp := (*domainMap)[domainName]
+		if resp == nil || resp.ResultData == nil || resp.ResultData.DomainInfDataResp == nil {
+			log.Errorf("Domain %s: No registry information found, skipping renewal", domainName)
+			continue
+		}
+		info := resp.ResultData.DomainInfDataResp
+		renewLocked := false
+		for _, status := range info.Status {
+			if status.StatusFlag == epp.StatusClientRenewProhibited || status.StatusFlag == epp.StatusServerRenewProhibited {
+				renewLocked = true
+			}
+		}
+		if renewLocked {
+			log.Errorf("Domain %s: Registry has the domain set to renew prohibited, skipping renewal", domainName)
+			rr.DomainsRenewalSkipped = append(rr.DomainsRenewalSkipped, fmt.Sprintf("%s - renew prohibited at registry", domainName))
+			continue
+		}
+		expireDate, parseErr := time.Parse(time.RFC3339, info.ExpireDate)
+		if parseErr != nil {
+			log.Errorf("Domain %s: Unable to parse expire date %s - %s", domainName, info.ExpireDate, parseErr)
+			continue
+		}
+		years, yearsErr := GetRenewalYears(expireDate, now, conf.GetRenewalPeriod(), conf.GetRenewalHorizon())
+		if yearsErr != nil {
+			log.Errorf("Domain %s: Unable to calculate the renewal period - %s", domainName, yearsErr)
+			rr.DomainsRenewalSkipped = append(rr.DomainsRenewalSkipped, fmt.Sprintf("%s - %s", domainName, yearsErr))
+			continue
+		}
+		if years == 0 {
+			log.Infof("Domain %s: Domain is already registered through the renewal horizon", domainName)
+			continue
+		}
+		currentExpireDate := expireDate.Format(epp.EPPDateForamt)
+		rr.AddWorkItem(fmt.Sprintf("EPP DOMAIN RENEW %s - %d yrs", domainName, years))
+		_, action, renewErr := eppClient.DomainRenew(domainName, currentExpireDate, epp.DomainPeriod{Unit: epp.DomainPeriodYear, Value: int(years)})
+		client.PushEPPActionLog(action)
+		if renewErr != nil {
+			log.Errorf("Domain %s: Error renewing domain - %s", domainName, renewErr)
+			rr.DomainsRenewalSkipped = append(rr.DomainsRenewalSkipped, fmt.Sprintf("%s - renewal failed: %s", domainName, renewErr))
+			continue
+		}
+		rr.DomainsRenewed = append(rr.DomainsRenewed, fmt.Sprintf("%s - %d yrs from %s", domainName, years, currentExpireDate))
+		if infoErr := UpdateDomainInfo(rr, client, eppClient, domainName, domainMap); infoErr != nil {
+			log.Errorf("Domain %s: Error updating domain info - %s", domainName, infoErr)
+		}
+	}
+	return true
+}
+
+// GetRenewalYears calculates the number of years a domain that expires at the
+// expire date provided should be renewed for so that it is registered through
+// at least the horizon from now. The number of years is a multiple of the
+// period. If no renewal is required, 0 is returned. If the renewal would be
+// longer than the maximum number of years allowed by the registry, or would
+// leave the domain registered for more than that many years, an error is
+// returned.
+func GetRenewalYears(expireDate time.Time, now time.Time, period int64, horizon int64) (int64, error) {
+	if period < 1 {
+		return 0, fmt.Errorf("invalid renewal period of %d years", period)
+	}
+	target := now.AddDate(int(horizon), 0, 0)
+	var years int64
+	for expireDate.AddDate(int(years), 0, 0).Before(target) {
+		years += period
+	}
+	if years == 0 {
+		return 0, nil
+	}
+	limit := now.AddDate(maxRegistrationYears, 0, 0)
+	if years > maxRegistrationYears || expireDate.AddDate(int(years), 0, 0).After(limit) {
+		return 0, fmt.Errorf("a renewal of %d years would exceed the %d year maximum", years, maxRegistrationYears)
+	}
+	return years, nil
+}
+
 // UpdateHostInfo will attempt to query the registry for the host information.
 // If an error is encountered, it will be returned.
 func UpdateHostInfo(rr *RunReport, client client.Client, eppClient *superclient.SuperClient, hostname string, hostMap *map[string]*epp.Response) error {
@@ -1300,6 +1631,314 @@ func UpdateDomainInfo(rr *RunReport, client client.Client, eppClient *superclien
 	return domainInfoErr
 }
 
+// maxContactIDAttempts is the number of registry IDs that will be tried for a
+// new contact before giving up if the generated IDs are already in use at the
+// registry
+const maxContactIDAttempts = 10
+
+// GetContactExistance will iterate through the list of contacts that have been
+// verified and check if they exist at the registry. If a contact does not
+// exist and should be active it will be created. Contacts that do not have a
+// registry ID yet will be assigned one and the ID will be pushed back to the
+// registrar server once the contact has been created. If a plan is passed,
+// contacts are only recorded in the plan and the registry ID is pushed when
+// the plan is applied.
+func GetContactExistance(rr *RunReport, client client.Client, eppClient *superclient.SuperClient, verifiedContacts *map[int64]*lib.ContactExport, ca *map[int64]bool, plan *Plan) (shouldContinue bool) {
+	for contactID, contactObject := range *verifiedContacts {
+		if contactObject.ContactRegistryID != "" {
+			registryID := contactObject.ContactRegistryID
+			rr.AddWorkItem(fmt.Sprintf("EPP CONTACT CHECK %s", registryID))
+			conAvail, action, conAvailErr := eppClient.ContactAvailable(registryID)
+			client.PushEPPActionLog(action)
+			if conAvailErr != nil {
+				log.Errorf("\tError checking if contact %s is available - %s", registryID, conAvailErr)
+				return false
+			}
+			if !conAvail {
+				log.Infof("\tContact %s exists", registryID)
+				(*ca)[contactID] = false
+				continue
+			}
+			log.Infof("\tContact %s does not exist yet", registryID)
+			if contactObject.CurrentRevision.DesiredState != lib.StateActive {
+				(*ca)[contactID] = true
+				continue
+			}
+			if createErr := CreateContact(rr, client, eppClient, contactObject, registryID); createErr != nil {
+				log.Errorf("\tError creating contact %s - %s", registryID, createErr)
+				return false
+			}
+			(*ca)[contactID] = plan != nil
+			continue
+		}
+
+		if contactObject.CurrentRevision.DesiredState != lib.StateActive {
+			log.Infof("\tContact %d has no registry ID and is not set to the Active state, skipping creation", contactID)
+			(*ca)[contactID] = true
+			continue
+		}
+
+		created := false
+		for iteration := int64(0); iteration < maxContactIDAttempts; iteration++ {
+			registryID := contactObject.GetRegistryID(iteration)
+			rr.AddWorkItem(fmt.Sprintf("EPP CONTACT CHECK %s", registryID))
+			conAvail, action, conAvailErr := eppClient.ContactAvailable(registryID)
+			client.PushEPPActionLog(action)
+			if conAvailErr != nil {
+				log.Errorf("\tError checking if contact %s is available - %s", registryID, conAvailErr)
+				return false
+			}
+			if !conAvail {
+				log.Infof("\tContact ID %s is already in use, trying the next option", registryID)
+				continue
+			}
+			if createErr := CreateContact(rr, client, eppClient, contactObject, registryID); createErr != nil {
+				log.Errorf("\tError creating contact %s - %s", registryID, createErr)
+				return false
+			}
+			if plan != nil {
+				// The registry ID is pushed to the registrar when the plan is
+				// applied
+				plan.SetLastContactID(contactID)
+				created = true
+				break
+			}
+			token, tokenErrs := client.GetToken()
+			if len(tokenErrs) != 0 {
+				for _, err := range tokenErrs {
+					log.Errorf("\tError getting token to push contact ID %s - %s", registryID, err)
+				}
+				return false
+			}
+			pushErrs := client.PushContactRegistryID(contactID, token, registryID)
+			if len(pushErrs) != 0 {
+				for _, err := range pushErrs {
+					log.Errorf("\tError pushing contact ID %s to Registrar - %s", registryID, err)
+				}
+				return false
+			}
+			contactObject.ContactRegistryID = registryID
+			created = true
+			break
+		}
+		if !created {
+			log.Errorf("\tUnable to find an unused registry ID for contact %d", contactID)
+			return false
+		}
+		(*ca)[contactID] = plan != nil
+	}
+	return true
+}
+
+// CreateContact will create the contact passed at the registry using the
+// registry ID provided and the values from the current revision of the
+// contact.
+func CreateContact(rr *RunReport, client client.Client, eppClient *superclient.SuperClient, contactObject *lib.ContactExport, registryID string) error {
+	log.Infof("\tContact %s should be created", registryID)
+	postalInfo, email, voice, fax := GetContactEPPValues(contactObject.CurrentRevision)
+	rr.AddWorkItem(fmt.Sprintf("EPP CONTACT CREATE %s", registryID))
+	rc, action, err := eppClient.ContactCreate(registryID, postalInfo, email, voice, fax)
+	client.PushEPPActionLog(action)
+	if err != nil {
+		return fmt.Errorf("(%d) %w", rc, err)
+	}
+	rr.ContactsCreated = append(rr.ContactsCreated, registryID)
+	return nil
+}
+
+// GetContactEPPValues will convert the contact information held in the
+// contact revision passed into the values used by the registry.
+func GetContactEPPValues(rev lib.ContactRevisionExport) (postalInfo epp.PostalInfo, email string, voice, fax epp.PhoneNumber) {
+	postalInfo = epp.GetEPPPostalInfo("int", rev.Name, rev.Org, rev.AddressStreet1, rev.AddressStreet2, rev.AddressStreet3, rev.AddressCity, rev.AddressState, rev.AddressPostalCode, rev.AddressCountry)
+	voice = epp.GetEPPPhoneNumber(rev.VoicePhoneNumber, rev.VoicePhoneExtension)
+	fax = epp.GetEPPPhoneNumber(rev.FaxPhoneNumber, rev.FaxPhoneExtension)
+	return postalInfo, rev.EmailAddress, voice, fax
+}
+
+// GetContactClientStatuses will return the list of client statuses that the
+// contact revision passed requires.
+func GetContactClientStatuses(rev lib.ContactRevisionExport) (statuses []string) {
+	if rev.ClientDeleteProhibitedStatus {
+		statuses = append(statuses, epp.StatusClientDeleteProhibited)
+	}
+	if rev.ClientTransferProhibitedStatus {
+		statuses = append(statuses, epp.StatusClientTransferProhibited)
+	}
+	if rev.ClientUpdateProhibitedStatus {
+		statuses = append(statuses, epp.StatusClientUpdateProhibited)
+	}
+	return statuses
+}
+
+// GetContactInfo will iterate over the list of contacts that exist at the
+// registry and request the contact information for each of them.
+func GetContactInfo(rr *RunReport, client client.Client, eppClient *superclient.SuperClient, verifiedContacts *map[int64]*lib.ContactExport, ca *map[int64]bool, contactMap *map[int64]*epp.Response) (shouldContinue bool) {
+	log.Info("Iterating through all contacts to get their information from the registry")
+	for contactID, contactObject := range *verifiedContacts {
+		contactAvailable, contactAvailableFound := (*ca)[contactID]
+		if !contactAvailableFound || contactAvailable {
+			log.Infof("\tContact %d is not registred, skipping", contactID)
+			continue
+		}
+		if err := UpdateContactInfo(rr, client, eppClient, contactObject.ContactRegistryID, contactID, contactMap); err != nil {
+			log.Errorf("\tError getting contact info for %s - %s", contactObject.ContactRegistryID, err)
+			return false
+		}
+		log.Infof("\tDone getting contact info for %s", contactObject.ContactRegistryID)
+	}
+	return true
+}
+
+// UpdateContactInfo will attempt to query the registry for the contact
+// information. If an error is encountered, it will be returned.
+func UpdateContactInfo(rr *RunReport, client client.Client, eppClient *superclient.SuperClient, registryID string, contactID int64, contactMap *map[int64]*epp.Response) error {
+	rr.AddWorkItem(fmt.Sprintf("EPP CONTACT INFO %s", registryID))
+	_, fullResponse, action, contactInfoErr := eppClient.ContactInfo(registryID, nil)
+	client.PushEPPActionLog(action)
+	(*contactMap)[contactID] = fullResponse
+	return contactInfoErr
+}
+
+// ContactUpdate will iterate over the contacts that require work and either
+// update or delete the contacts as defined by the object status. If a contact
+// is locked for updates, it will be unlocked to make the changes and then
+// relocked if required.
+func ContactUpdate(rr *RunReport, client client.Client, eppClient *superclient.SuperClient, verifiedContacts *map[int64]*lib.ContactExport, ca *map[int64]bool, contactMap *map[int64]*epp.Response) (shouldContinue bool) {
+	for contactID, contactObject := range *verifiedContacts {
+		registryID := contactObject.ContactRegistryID
+		contactAvailable, contactAvailableFound := (*ca)[contactID]
+		if !contactAvailableFound || contactAvailable {
+			continue
+		}
+		log.Infof("Contact %s: Starting to process contact", registryID)
+		resp := (*contactMap)[contactID]
+		if resp == nil || resp.ResultData == nil || resp.ResultData.ContactInfDataResp == nil {
+			log.Errorf("Contact %s: No registry information found, skipping contact", registryID)
+			continue
+		}
+		info := resp.ResultData.ContactInfDataResp
+
+		desiredState := contactObject.CurrentRevision.DesiredState
+		if desiredState != lib.StateActive && desiredState != lib.StateInactive {
+			log.Infof("Contact %s: Contact is in the %s state, skipping", registryID, desiredState)
+			continue
+		}
+
+		authInfo := ""
+		if desiredState == lib.StateActive {
+			var aiErr error
+			authInfo, aiErr = superclient.GetContactAuthInfo(info)
+			if aiErr != nil {
+				log.Errorf("Contact %s: Unable to generate authInfo - %s", registryID, aiErr)
+				return false
+			}
+		}
+
+		changes, serverLocked := GetContactUpdates(contactObject.CurrentRevision, info, authInfo)
+		if desiredState == lib.StateActive && len(changes) == 0 {
+			log.Infof("Contact %s: No changes are required, skipping", registryID)
+			continue
+		}
+
+		if serverLocked {
+			log.Errorf("Contact %s: Registry lock is in place, cannot make changes", registryID)
+			rr.RegistryLockChanges = append(rr.RegistryLockChanges, fmt.Sprintf("Contact %s requires changes but is registry locked", registryID))
+			continue
+		}
+
+		for _, change := range changes {
+			log.Infof("Contact %s: Updating contact", registryID)
+			rr.AddWorkItem(fmt.Sprintf("EPP CONTACT UPDATE %s", registryID))
+			_, action, updateErr := eppClient.ContactUpdate(registryID, change)
+			client.PushEPPActionLog(action)
+			if updateErr != nil {
+				log.Errorf("\tError updating contact %s - %s", registryID, updateErr)
+				return false
+			}
+		}
+
+		if desiredState == lib.StateInactive {
+			log.Infof("Contact %s: Contact is marked as inactive, deleting the object", registryID)
+			rr.AddWorkItem(fmt.Sprintf("EPP CONTACT DELETE %s", registryID))
+			_, action, delErr := eppClient.ContactDelete(registryID)
+			client.PushEPPActionLog(action)
+			if delErr != nil {
+				log.Errorf("\tError deleting contact %s - %s", registryID, delErr)
+				return false
+			}
+			(*ca)[contactID] = true
+			(*contactMap)[contactID] = nil
+			continue
+		}
+
+		if infoErr := UpdateContactInfo(rr, client, eppClient, registryID, contactID, contactMap); infoErr != nil {
+			log.Errorf("Contact %s: Error updating contact info - %s", registryID, infoErr)
+		}
+		log.Infof("Contact %s: Done processing contact", registryID)
+	}
+	return true
+}
+
+// GetContactUpdates compares the contact information held by the registry
+// with the contact revision passed and returns the list of updates to send,
+// in order, to make the registry match. Active contacts are brought in line
+// with the revision, inactive contacts have their client statuses removed so
+// they can be deleted. A flag indicating if the contact is registry locked is
+// also returned.
+func GetContactUpdates(rev lib.ContactRevisionExport, info *epp.ContactInfDataResp, authInfo string) (changes []superclient.ContactChanges, serverLocked bool) {
+	var currentClientStatuses []string
+	for _, status := range info.Status {
+		if strings.HasPrefix(status.StatusFlag, "client") {
+			currentClientStatuses = append(currentClientStatuses, status.StatusFlag)
+		}
+		if status.StatusFlag == epp.StatusServerUpdateProhibited {
+			serverLocked = true
+		}
+	}
+
+	switch rev.DesiredState {
+	case lib.StateActive:
+		postalInfo, email, voice, fax := GetContactEPPValues(rev)
+		desiredStatuses := GetContactClientStatuses(rev)
+		diff := superclient.GetContactChanges(info, postalInfo, email, voice, fax, authInfo, desiredStatuses)
+		if !diff.IsEmpty() {
+			changes = UnlockContactChanges(diff, currentClientStatuses, desiredStatuses)
+		}
+	case lib.StateInactive:
+		if len(currentClientStatuses) != 0 {
+			changes = append(changes, superclient.ContactChanges{RemoveStatuses: currentClientStatuses})
+		}
+	}
+	return changes, serverLocked
+}
+
+// UnlockContactChanges takes the changes required for a contact and, if the
+// contact is currently locked for updates and other changes are needed,
+// splits the changes into an unlock, the changes and, if the contact should
+// remain locked, a relock. The list of updates to send is returned in order.
+func UnlockContactChanges(changes superclient.ContactChanges, currentStatuses, desiredStatuses []string) []superclient.ContactChanges {
+	if !slices.Contains(currentStatuses, epp.StatusClientUpdateProhibited) {
+		return []superclient.ContactChanges{changes}
+	}
+
+	rest := changes
+	rest.RemoveStatuses = slices.DeleteFunc(slices.Clone(changes.RemoveStatuses), func(status string) bool {
+		return status == epp.StatusClientUpdateProhibited
+	})
+	if rest.IsEmpty() {
+		return []superclient.ContactChanges{changes}
+	}
+
+	updates := []superclient.ContactChanges{
+		{RemoveStatuses: []string{epp.StatusClientUpdateProhibited}},
+		rest,
+	}
+	if slices.Contains(desiredStatuses, epp.StatusClientUpdateProhibited) {
+		updates = append(updates, superclient.ContactChanges{AddStatuses: []string{epp.StatusClientUpdateProhibited}})
+	}
+	return updates
+}
+
 // DomainUnlockForChange will attempt to unlock a domain to allow changes to be
 // made to the domain. If an error occurs unlocking the domain, the error will
 // be returned, otherwise a flag indicating that the if domain has been unlocked
@@ -2071,6 +2710,59 @@ func HostUnsetCheck(client client.Client, objectID int64) error {
 	return nil
 }
 
+// PushContacts will iterate through the list of contact responses that have
+// been gathered and push the contact objects to the Registrar server which
+// records the registry ROID for each contact
+func PushContacts(client client.Client, verifiedContacts *map[int64]*lib.ContactExport, contactMap *map[int64]*epp.Response) (shouldContinue bool) {
+	for contactID, contactEPP := range *contactMap {
+		if contactRegObject, ok := (*verifiedContacts)[contactID]; ok && contactRegObject != nil {
+			if contactEPP == nil {
+				pushErr := ContactUnsetCheck(client, contactRegObject.ID)
+				if pushErr != nil {
+					log.Errorf("Contact %d: Error unsetting check in Registrar - %s", contactID, pushErr)
+				}
+				log.Infof("Contact %d: Has been marked as no check required", contactID)
+				continue
+			}
+
+			log.Infof("Contact %d: Pushing contact to Registrar", contactID)
+			pushErr := PushContactInfo(client, contactRegObject.ID, contactEPP)
+			if pushErr != nil {
+				log.Errorf("Contact %d: Error pushing contact to Registrar - %s", contactID, pushErr)
+			}
+		} else {
+			log.Infof("Contact %d: Cannot find the Registrar object id", contactID)
+		}
+	}
+	return true
+}
+
+// PushContactInfo will take the given contact info response object and push
+// it to the registrar server
+func PushContactInfo(client client.Client, objectID int64, resp *epp.Response) error {
+	pushErrs := client.PushInfoEPP(lib.ContactType, objectID, resp)
+	if len(pushErrs) != 0 {
+		for _, err := range pushErrs {
+			log.Errorf("Error pushing contact %d - %s", objectID, err)
+		}
+		return pushErrs[0]
+	}
+	return nil
+}
+
+// ContactUnsetCheck will toggle the check required field for a contact when
+// no epp data is available
+func ContactUnsetCheck(client client.Client, objectID int64) error {
+	pushErrs := client.UnsetEPPCheck(lib.ContactType, objectID)
+	if len(pushErrs) != 0 {
+		for _, err := range pushErrs {
+			log.Errorf("Error pushing contact %d - %s", objectID, err)
+		}
+		return pushErrs[0]
+	}
+	return nil
+}
+
 // APIUserVerify will take a client, signed data, an approval and the change
 // request and attempt to verify the api user object. Iff the domain is verified
 // it will return true and if not false will be returned with a list of errors
@@ -0,0 +1,359 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/timapril/go-registrar/client"
+	"github.com/timapril/go-registrar/epp"
+	"github.com/timapril/go-registrar/lib"
+
+	superclient "github.com/timapril/go-registrar/epp/superClient"
+)
+
+// registryStateNotRegistered is the registry state recorded for an
+// object that does not exist at the registry when the plan is made.
+const registryStateNotRegistered = "not registered"
+
+// registryStatePollEmpty is the registry state recorded for the poll
+// queue when there are no messages waiting.
+const registryStatePollEmpty = "empty"
+
+// registryStatePollKey is the key used to record the first message in
+// the poll queue in the registry state of a plan.
+const registryStatePollKey = "poll queue"
+
+// Plan holds the ordered list of EPP commands that a provision run would
+// send to the registry along with a fingerprint of the registry state the
+// commands were planned against.
+type Plan struct {
+	CreatedAt           time.Time
+	TransactionIDPrefix string
+
+	RegistryState map[string]string
+	Commands      []PlannedCommand
+
+	lock sync.Mutex
+}
+
+// PlannedCommand is a single EPP command in a plan. The changes hold the
+// human readable description of what the command will change and the
+// XML holds the exact command that will be sent.
+type PlannedCommand struct {
+	Sequence int
+	Action   string
+	Object   string
+	Changes  []string
+	XML      string
+
+	// ContactID is set for contact creates where the registry ID has to
+	// be pushed back to the registrar once the contact has been created.
+	ContactID int64 `json:",omitempty"`
+}
+
+// NewPlan returns an empty plan.
+func NewPlan(transactionIDPrefix string) *Plan {
+	return &Plan{
+		CreatedAt:           time.Now(),
+		TransactionIDPrefix: transactionIDPrefix,
+		RegistryState:       make(map[string]string),
+	}
+}
+
+// Record adds the EPP message and its action to the end of the plan. It
+// is used as the dry run recorder for the EPP client.
+func (p *Plan) Record(msg epp.Epp, action lib.EPPAction) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	xml, err := msg.ToString()
+	if err != nil {
+		log.Errorf("Error rendering planned %s %s - %s", action.Action, action.Args, err)
+	}
+
+	var changes []string
+
+	for _, note := range strings.Split(action.Notes, "\n") {
+		if note != "" && note != superclient.DryRunNote {
+			changes = append(changes, note)
+		}
+	}
+
+	p.Commands = append(p.Commands, PlannedCommand{
+		Sequence: len(p.Commands) + 1,
+		Action:   action.Action,
+		Object:   action.Args,
+		Changes:  changes,
+		XML:      xml,
+	})
+}
+
+// SetLastContactID marks the last command in the plan as the creation of
+// the registrar contact with the ID passed.
+func (p *Plan) SetLastContactID(contactID int64) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if len(p.Commands) != 0 {
+		p.Commands[len(p.Commands)-1].ContactID = contactID
+	}
+}
+
+// SetRegistryState records the state of an object at the registry using
+// a fingerprint of the info response passed. A nil response records the
+// object as not registered.
+func (p *Plan) SetRegistryState(key string, resp *epp.Response) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.RegistryState[key] = RegistryStateFingerprint(resp)
+}
+
+// SetPollState records the ID of the first message in the poll queue. An
+// empty ID records the queue as empty.
+func (p *Plan) SetPollState(messageID string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if messageID == "" {
+		messageID = registryStatePollEmpty
+	}
+
+	p.RegistryState[registryStatePollKey] = messageID
+}
+
+// RegistryStateFingerprint returns a hash of the object information in
+// the registry response passed. Transaction IDs and other details that
+// change between requests are excluded.
+func RegistryStateFingerprint(resp *epp.Response) string {
+	if resp == nil || resp.ResultData == nil {
+		return registryStateNotRegistered
+	}
+
+	data, err := json.Marshal(struct {
+		ResultData *epp.ResultData
+		Extension  *epp.ResponseExtension
+	}{resp.ResultData, resp.Extension})
+	if err != nil {
+		return fmt.Sprintf("error: %s", err)
+	}
+
+	sum := sha256.Sum256(data)
+
+	return hex.EncodeToString(sum[:])
+}
+
+// Drift compares the registry state recorded in the plan with the state
+// recorded in the current plan passed and returns a description of each
+// object whose state has changed.
+func (p *Plan) Drift(current *Plan) (drift []string) {
+	keys := make(map[string]bool)
+	for key := range p.RegistryState {
+		keys[key] = true
+	}
+
+	for key := range current.RegistryState {
+		keys[key] = true
+	}
+
+	var sorted []string
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+
+	sort.Strings(sorted)
+
+	for _, key := range sorted {
+		planned, inPlan := p.RegistryState[key]
+		now, inCurrent := current.RegistryState[key]
+
+		switch {
+		case !inPlan:
+			drift = append(drift, fmt.Sprintf("%s was not part of the plan", key))
+		case !inCurrent:
+			drift = append(drift, fmt.Sprintf("%s is no longer part of the work", key))
+		case planned != now:
+			drift = append(drift, fmt.Sprintf("%s has changed at the registry", key))
+		}
+	}
+
+	return drift
+}
+
+// Render returns a human readable version of the plan listing each of
+// the commands in order with the changes they make and the XML that will
+// be sent.
+func (p *Plan) Render() string {
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "Plan created %s with %d command(s)\n", p.CreatedAt.Format(time.RFC3339), len(p.Commands))
+
+	for _, cmd := range p.Commands {
+		fmt.Fprintf(&buf, "\n%d. %s %s\n", cmd.Sequence, cmd.Action, cmd.Object)
+
+		for _, change := range cmd.Changes {
+			fmt.Fprintf(&buf, "\t%s\n", change)
+		}
+
+		for _, line := range strings.Split(cmd.XML, "\n") {
+			fmt.Fprintf(&buf, "\t| %s\n", line)
+		}
+	}
+
+	return buf.String()
+}
+
+// Save writes the plan to the path passed as JSON.
+func (p *Plan) Save(path string) error {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding plan: %w", err)
+	}
+
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("error writing plan: %w", err)
+	}
+
+	return nil
+}
+
+// LoadPlan reads a plan that was saved with Save from the path passed.
+func LoadPlan(path string) (*Plan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading plan: %w", err)
+	}
+
+	plan := &Plan{}
+	if err := json.Unmarshal(data, plan); err != nil {
+		return nil, fmt.Errorf("error decoding plan: %w", err)
+	}
+
+	if plan.RegistryState == nil {
+		plan.RegistryState = make(map[string]string)
+	}
+
+	return plan, nil
+}
+
+// RecordRegistryState records the state of each of the domains, hosts and
+// contacts that were looked up at the registry in the plan passed.
+func RecordRegistryState(plan *Plan, domainMap *map[string]*epp.Response, hostMap *map[string]*epp.Response, contactMap *map[int64]*epp.Response) {
+	for domainName, resp := range *domainMap {
+		plan.SetRegistryState(fmt.Sprintf("domain %s", domainName), resp)
+	}
+
+	for hostName, resp := range *hostMap {
+		plan.SetRegistryState(fmt.Sprintf("host %s", hostName), resp)
+	}
+
+	for contactID, resp := range *contactMap {
+		plan.SetRegistryState(fmt.Sprintf("contact %d", contactID), resp)
+	}
+}
+
+// ApplyPlan loads the plan saved at the path passed and, if the registry
+// state has not changed since the plan was made, sends each of the planned
+// commands to the registry in order. The current plan must hold the
+// registry state gathered during this run. If the registry state has
+// drifted, no commands are sent. The plan that was applied is returned.
+func ApplyPlan(rr *RunReport, client client.Client, eppClient *superclient.SuperClient, current *Plan, path string) (applied *Plan, shouldContinue bool) {
+	saved, loadErr := LoadPlan(path)
+	if loadErr != nil {
+		log.Errorf("Error loading plan - %s", loadErr)
+		return nil, false
+	}
+
+	if drift := saved.Drift(current); len(drift) != 0 {
+		for _, item := range drift {
+			log.Errorf("\tDrift: %s", item)
+			rr.AddWorkItem(fmt.Sprintf("PLAN DRIFT %s", item))
+		}
+		log.Error("The registry has changed since the plan was made, refusing to apply the plan")
+		return nil, false
+	}
+
+	eppClient.SetDryRun(nil)
+
+	for _, cmd := range saved.Commands {
+		log.Infof("\tStep %d: %s %s", cmd.Sequence, cmd.Action, cmd.Object)
+		rr.AddWorkItem(fmt.Sprintf("EPP PLAN STEP %d %s %s", cmd.Sequence, cmd.Action, cmd.Object))
+		rc, action, sendErr := eppClient.SendPlanned([]byte(cmd.XML), cmd.Action, cmd.Object)
+		client.PushEPPActionLog(action)
+		if sendErr != nil {
+			log.Errorf("\tError sending step %d - (%d) %s", cmd.Sequence, rc, sendErr)
+			return nil, false
+		}
+
+		if cmd.ContactID != 0 {
+			token, tokenErrs := client.GetToken()
+			if len(tokenErrs) != 0 {
+				for _, err := range tokenErrs {
+					log.Errorf("\tError getting token to push contact ID %s - %s", cmd.Object, err)
+				}
+				return nil, false
+			}
+			pushErrs := client.PushContactRegistryID(cmd.ContactID, token, cmd.Object)
+			if len(pushErrs) != 0 {
+				for _, err := range pushErrs {
+					log.Errorf("\tError pushing contact ID %s to Registrar - %s", cmd.Object, err)
+				}
+				return nil, false
+			}
+		}
+	}
+
+	return saved, true
+}
+
+// RefreshAfterApply marks the objects created and deleted by the plan that
+// was applied as registered or available and clears the registry
+// information that was gathered before the plan was applied so that it is
+// requested again before being pushed to the registrar.
+func RefreshAfterApply(applied *Plan, verifiedContacts *map[int64]*lib.ContactExport, da *map[string]bool, ha *map[string]bool, ca *map[int64]bool, domainMap *map[string]*epp.Response, hostMap *map[string]*epp.Response, contactMap *map[int64]*epp.Response) {
+	for _, cmd := range applied.Commands {
+		switch cmd.Action {
+		case lib.EPPLogActionDomainCreate:
+			(*da)[cmd.Object] = false
+		case lib.EPPLogActionDomainDelete:
+			(*da)[cmd.Object] = true
+		case lib.EPPLogActionHostCreate:
+			(*ha)[cmd.Object] = false
+		case lib.EPPLogActionHostDelete:
+			(*ha)[cmd.Object] = true
+		case lib.EPPLogActionContactCreate:
+			for contactID, contactObject := range *verifiedContacts {
+				if contactID == cmd.ContactID || contactObject.ContactRegistryID == cmd.Object {
+					contactObject.ContactRegistryID = cmd.Object
+					(*ca)[contactID] = false
+				}
+			}
+		case lib.EPPLogActionContactDelete:
+			for contactID, contactObject := range *verifiedContacts {
+				if contactObject.ContactRegistryID == cmd.Object {
+					(*ca)[contactID] = true
+				}
+			}
+		}
+	}
+
+	for domainName := range *domainMap {
+		(*domainMap)[domainName] = nil
+	}
+
+	for hostName := range *hostMap {
+		(*hostMap)[hostName] = nil
+	}
+
+	for contactID := range *contactMap {
+		(*contactMap)[contactID] = nil
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/timapril/go-registrar/epp"
+	"github.com/timapril/go-registrar/lib"
+
+	superclient "github.com/timapril/go-registrar/epp/superClient"
+)
+
+func TestPlan(t *testing.T) {
+	t.Parallel()
+	Convey("Given a plan with a recorded command", t, func() {
+		plan := NewPlan("TEST-")
+
+		action := lib.NewEPPAction("TEST-1")
+		action.SetAction(lib.EPPLogActionDomainRenew, "EXAMPLE.COM")
+		action.AddNote("Renew Duration: 1 year(s)")
+		action.AddNote(superclient.DryRunNote)
+
+		period := epp.DomainPeriod{Unit: epp.DomainPeriodYear, Value: 1}
+		plan.Record(epp.GetEPPDomainRenew("EXAMPLE.COM", "2020-01-01", period, "TEST-1"), action)
+
+		domainResp := &epp.Response{ResultData: &epp.ResultData{DomainInfDataResp: &epp.DomainInfDataResp{Name: "EXAMPLE.COM"}}}
+		plan.SetRegistryState("domain EXAMPLE.COM", domainResp)
+		plan.SetRegistryState("domain NEW.COM", nil)
+		plan.SetPollState("")
+
+		Convey("The command should be rendered with its changes and XML", func() {
+			So(plan.Commands, ShouldHaveLength, 1)
+			So(plan.Commands[0].Sequence, ShouldEqual, 1)
+			So(plan.Commands[0].Changes, ShouldResemble, []string{"Renew Duration: 1 year(s)"})
+
+			rendered := plan.Render()
+			So(rendered, ShouldContainSubstring, "1. DomainRenew EXAMPLE.COM")
+			So(rendered, ShouldContainSubstring, "\tRenew Duration: 1 year(s)")
+			So(rendered, ShouldContainSubstring, "2020-01-01</domain:curExpDate>")
+		})
+
+		Convey("A saved plan should load with the same commands and state", func() {
+			path := filepath.Join(t.TempDir(), "plan.json")
+			So(plan.Save(path), ShouldBeNil)
+
+			loaded, err := LoadPlan(path)
+			So(err, ShouldBeNil)
+			So(loaded.Commands, ShouldResemble, plan.Commands)
+			So(loaded.RegistryState, ShouldResemble, plan.RegistryState)
+			So(loaded.Drift(plan), ShouldBeEmpty)
+		})
+
+		Convey("Changes to the registry state should be reported as drift", func() {
+			current := NewPlan("TEST-")
+			current.SetRegistryState("domain EXAMPLE.COM", &epp.Response{ResultData: &epp.ResultData{DomainInfDataResp: &epp.DomainInfDataResp{Name: "EXAMPLE.COM", UpdateDate: "2020-01-01T00:00:00Z"}}})
+			current.SetRegistryState("domain NEW.COM", nil)
+			current.SetPollState("42")
+			current.SetRegistryState("host NS1.EXAMPLE.COM", nil)
+
+			So(plan.Drift(current), ShouldResemble, []string{
+				"domain EXAMPLE.COM has changed at the registry",
+				"host NS1.EXAMPLE.COM was not part of the plan",
+				"poll queue has changed at the registry",
+			})
+		})
+
+		Convey("Transaction IDs should not change the fingerprint", func() {
+			other := *domainResp
+			other.TransactionID.ServerTransactionID = "SERVER-1"
+			So(RegistryStateFingerprint(&other), ShouldEqual, RegistryStateFingerprint(domainResp))
+			So(RegistryStateFingerprint(nil), ShouldEqual, registryStateNotRegistered)
+		})
+	})
+}
+
+func TestRefreshAfterApply(t *testing.T) {
+	t.Parallel()
+	Convey("Given a plan that has been applied", t, func() {
+		applied := NewPlan("TEST-")
+		applied.Commands = []PlannedCommand{
+			{Sequence: 1, Action: lib.EPPLogActionContactCreate, Object: "C1-NEW", ContactID: 1},
+			{Sequence: 2, Action: lib.EPPLogActionContactCreate, Object: "C2"},
+			{Sequence: 3, Action: lib.EPPLogActionContactDelete, Object: "C3"},
+			{Sequence: 4, Action: lib.EPPLogActionDomainCreate, Object: "NEW.COM"},
+			{Sequence: 5, Action: lib.EPPLogActionDomainDelete, Object: "OLD.COM"},
+			{Sequence: 6, Action: lib.EPPLogActionHostCreate, Object: "NS1.NEW.COM"},
+			{Sequence: 7, Action: lib.EPPLogActionHostDelete, Object: "NS1.OLD.COM"},
+			{Sequence: 8, Action: lib.EPPLogActionDomainAddStatuses, Object: "EXAMPLE.COM"},
+		}
+
+		verifiedContacts := map[int64]*lib.ContactExport{
+			1: {ID: 1},
+			2: {ID: 2, ContactRegistryID: "C2"},
+			3: {ID: 3, ContactRegistryID: "C3"},
+		}
+		domainAvailability := map[string]bool{"NEW.COM": true, "OLD.COM": false, "EXAMPLE.COM": false}
+		hostAvailability := map[string]bool{"NS1.NEW.COM": true, "NS1.OLD.COM": false}
+		contactAvailability := map[int64]bool{1: true, 2: true, 3: false}
+
+		registered := &epp.Response{}
+		domainMap := map[string]*epp.Response{"NEW.COM": nil, "OLD.COM": registered, "EXAMPLE.COM": registered}
+		hostMap := map[string]*epp.Response{"NS1.NEW.COM": nil, "NS1.OLD.COM": registered}
+		contactMap := map[int64]*epp.Response{3: registered}
+
+		RefreshAfterApply(applied, &verifiedContacts, &domainAvailability, &hostAvailability, &contactAvailability, &domainMap, &hostMap, &contactMap)
+
+		Convey("Created objects should be registered and deleted objects available", func() {
+			So(domainAvailability, ShouldResemble, map[string]bool{"NEW.COM": false, "OLD.COM": true, "EXAMPLE.COM": false})
+			So(hostAvailability, ShouldResemble, map[string]bool{"NS1.NEW.COM": false, "NS1.OLD.COM": true})
+			So(contactAvailability, ShouldResemble, map[int64]bool{1: false, 2: false, 3: true})
+			So(verifiedContacts[1].ContactRegistryID, ShouldEqual, "C1-NEW")
+		})
+
+		Convey("The registry information should be cleared so it is requested again", func() {
+			So(domainMap, ShouldResemble, map[string]*epp.Response{"NEW.COM": nil, "OLD.COM": nil, "EXAMPLE.COM": nil})
+			So(hostMap, ShouldResemble, map[string]*epp.Response{"NS1.NEW.COM": nil, "NS1.OLD.COM": nil})
+			So(contactMap, ShouldResemble, map[int64]*epp.Response{3: nil})
+		})
+	})
+}
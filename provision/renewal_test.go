@@ -0,0 +1,48 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestGetRenewalYears(t *testing.T) {
+	t.Parallel()
+	Convey("Given the current time, the renewal years should match the horizon and period", t, func() {
+		now := time.Date(2024, time.June, 1, 12, 0, 0, 0, time.UTC)
+
+		tests := []struct {
+			name       string
+			expireDate time.Time
+			period     int64
+			horizon    int64
+			years      int64
+			isErr      bool
+		}{
+			{name: "domain past the horizon", expireDate: now.AddDate(2, 0, 0), period: 1, horizon: 1, years: 0},
+			{name: "domain expiring on the horizon", expireDate: now.AddDate(1, 0, 0), period: 1, horizon: 1, years: 0},
+			{name: "domain expiring just before the horizon", expireDate: now.AddDate(1, 0, 0).Add(-time.Second), period: 1, horizon: 1, years: 1},
+			{name: "domain expiring within the horizon", expireDate: now.AddDate(0, 6, 0), period: 1, horizon: 1, years: 1},
+			{name: "multi year period", expireDate: now.AddDate(0, 6, 0), period: 2, horizon: 3, years: 4},
+			{name: "domain that has already expired", expireDate: now.AddDate(-2, 0, 0), period: 1, horizon: 1, years: 3},
+			{name: "domain renewed up to the maximum", expireDate: now, period: 1, horizon: 10, years: 10},
+			{name: "renewal past the maximum registration", expireDate: now.AddDate(0, 6, 0), period: 1, horizon: 10, isErr: true},
+			{name: "renewal longer than the maximum period", expireDate: now.AddDate(-12, 0, 0), period: 1, horizon: 1, isErr: true},
+			{name: "zero year period", expireDate: now, period: 0, horizon: 1, isErr: true},
+		}
+
+		for _, test := range tests {
+			Convey("For a "+test.name, func() {
+				years, err := GetRenewalYears(test.expireDate, now, test.period, test.horizon)
+				if test.isErr {
+					So(err, ShouldNotBeNil)
+					So(years, ShouldEqual, 0)
+				} else {
+					So(err, ShouldBeNil)
+					So(years, ShouldEqual, test.years)
+				}
+			})
+		}
+	})
+}
@@ -172,6 +172,7 @@ func getRouter(conf lib.Config, factory *handler.Factory) *mux.Router {
 
 	r.Handle("/liveness", factory.ForNoAuthWeb(handler.LivenessCheck))
 	r.Handle("/renewalCheck", factory.ForNoAuthWeb(handler.RenewCheck))
+	r.Handle("/expiryCheck", factory.ForNoAuthWeb(handler.ExpiryCheck))
 	r.Handle("/whoisconfirmemail", factory.ForNoAuthWeb(handler.WHOISConfirmEmail))
 
 	// FIXME: Delete me when in production.
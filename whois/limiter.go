@@ -0,0 +1,219 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultQueriesPerMinute is the number of queries a single client
+	// may make each minute if no limit is configured.
+	defaultQueriesPerMinute = 60
+
+	// defaultQueryBurst is the number of queries a single client may make
+	// in quick succession if no burst is configured.
+	defaultQueryBurst = 20
+
+	// defaultMaxConnections is the number of connections that may be open
+	// at the same time if no limit is configured.
+	defaultMaxConnections = 1000
+
+	// defaultReadTimeout is the number of seconds a client has to send its
+	// query if no timeout is configured.
+	defaultReadTimeout = 30
+
+	// defaultWriteTimeout is the number of seconds a client has to read the
+	// response if no timeout is configured.
+	defaultWriteTimeout = 30
+
+	// bucketCleanupInterval is how often idle client buckets are removed.
+	bucketCleanupInterval = time.Minute
+)
+
+// tokenBucket tracks the number of queries a single client is able to
+// make.
+type tokenBucket struct {
+	tokens     float64
+	lastUpdate time.Time
+}
+
+// whoisLimiter enforces the per client rate limits, allow and deny lists
+// and the maximum number of concurrent connections for the WHOIS server.
+type whoisLimiter struct {
+	ratePerSecond float64
+	burst         float64
+
+	allow []*net.IPNet
+	deny  []*net.IPNet
+
+	connections chan struct{}
+
+	lock        sync.Mutex
+	buckets     map[string]*tokenBucket
+	lastCleanup time.Time
+}
+
+// newWHOISLimiter creates a limiter from the limits in the configuration
+// filling in the defaults for any limits that are not set. An error is
+// returned if any of the allow or deny entries can not be parsed.
+func newWHOISLimiter(conf Config) (*whoisLimiter, error) {
+	limiter := &whoisLimiter{
+		ratePerSecond: conf.GetQueriesPerMinute() / 60,
+		burst:         float64(conf.GetQueryBurst()),
+		connections:   make(chan struct{}, conf.GetMaxConnections()),
+		buckets:       make(map[string]*tokenBucket),
+		lastCleanup:   time.Now(),
+	}
+
+	var err error
+
+	limiter.allow, err = parseNetworks(conf.Limits.Allow)
+	if err != nil {
+		return nil, err
+	}
+
+	limiter.deny, err = parseNetworks(conf.Limits.Deny)
+	if err != nil {
+		return nil, err
+	}
+
+	return limiter, nil
+}
+
+// parseNetworks parses a list of CIDR blocks or single IP addresses into
+// networks.
+func parseNetworks(entries []string) (networks []*net.IPNet, err error) {
+	for _, entry := range entries {
+		_, network, parseErr := net.ParseCIDR(entry)
+		if parseErr != nil {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("unable to parse network %q", entry)
+			}
+
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip = ip.To4()
+				bits = 8 * net.IPv4len
+			}
+
+			network = &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}
+		}
+
+		networks = append(networks, network)
+	}
+
+	return networks, nil
+}
+
+// containsIP returns true if the IP address is in any of the networks.
+func containsIP(networks []*net.IPNet, ip net.IP) bool {
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsDenied returns true if the client is in the deny list and is not in
+// the allow list.
+func (l *whoisLimiter) IsDenied(ip net.IP) bool {
+	return containsIP(l.deny, ip) && !containsIP(l.allow, ip)
+}
+
+// AcquireConnection reserves one of the available connections and
+// returns true. If all of the connections are in use false is returned.
+func (l *whoisLimiter) AcquireConnection() bool {
+	select {
+	case l.connections <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// ReleaseConnection returns a connection reserved with
+// AcquireConnection.
+func (l *whoisLimiter) ReleaseConnection() {
+	<-l.connections
+}
+
+// AllowQuery takes a token from the bucket for the client and returns
+// true if the client is allowed to make a query at the time passed.
+// Clients in the allow list are not rate limited.
+func (l *whoisLimiter) AllowQuery(ip net.IP, now time.Time) bool {
+	if containsIP(l.allow, ip) {
+		return true
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if now.Sub(l.lastCleanup) > bucketCleanupInterval {
+		l.cleanup(now)
+	}
+
+	key := ip.String()
+
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: l.burst, lastUpdate: now}
+		l.buckets[key] = bucket
+	}
+
+	l.refill(bucket, now)
+
+	if bucket.tokens < 1 {
+		return false
+	}
+
+	bucket.tokens--
+
+	return true
+}
+
+// refill adds the tokens the client has earned since the bucket was
+// last updated.
+func (l *whoisLimiter) refill(bucket *tokenBucket, now time.Time) {
+	elapsed := now.Sub(bucket.lastUpdate).Seconds()
+	if elapsed > 0 {
+		bucket.tokens += elapsed * l.ratePerSecond
+		if bucket.tokens > l.burst {
+			bucket.tokens = l.burst
+		}
+
+		bucket.lastUpdate = now
+	}
+}
+
+// cleanup removes the buckets of clients that have earned back all of
+// their tokens as they are the same as a new bucket. The lock must be
+// held when cleanup is called.
+func (l *whoisLimiter) cleanup(now time.Time) {
+	for key, bucket := range l.buckets {
+		l.refill(bucket, now)
+		if bucket.tokens >= l.burst {
+			delete(l.buckets, key)
+		}
+	}
+
+	l.lastCleanup = now
+}
+
+// remoteIP returns the IP address of the client of a connection.
+func remoteIP(addr net.Addr) net.IP {
+	if tcpAddr, ok := addr.(*net.TCPAddr); ok {
+		return tcpAddr.IP
+	}
+
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return nil
+	}
+
+	return net.ParseIP(host)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestWHOISLimiter(t *testing.T) {
+	t.Parallel()
+	Convey("Given a limiter with allow and deny lists", t, func() {
+		conf := Config{}
+		conf.Limits.QueriesPerMinute = 60
+		conf.Limits.QueryBurst = 2
+		conf.Limits.MaxConnections = 1
+		conf.Limits.Allow = []string{"192.0.2.10", "2001:db8::/32"}
+		conf.Limits.Deny = []string{"192.0.2.0/24"}
+
+		limiter, err := newWHOISLimiter(conf)
+		So(err, ShouldBeNil)
+
+		now := time.Now()
+		client := net.ParseIP("198.51.100.1")
+
+		Convey("Clients should be limited to the burst and then the rate", func() {
+			So(limiter.AllowQuery(client, now), ShouldBeTrue)
+			So(limiter.AllowQuery(client, now), ShouldBeTrue)
+			So(limiter.AllowQuery(client, now), ShouldBeFalse)
+			So(limiter.AllowQuery(net.ParseIP("198.51.100.2"), now), ShouldBeTrue)
+			So(limiter.AllowQuery(client, now.Add(time.Second)), ShouldBeTrue)
+			So(limiter.AllowQuery(client, now.Add(time.Second)), ShouldBeFalse)
+		})
+
+		Convey("Idle clients should be removed once their bucket is full", func() {
+			So(limiter.AllowQuery(client, now), ShouldBeTrue)
+			So(limiter.buckets, ShouldHaveLength, 1)
+			limiter.cleanup(now.Add(time.Minute))
+			So(limiter.buckets, ShouldBeEmpty)
+		})
+
+		Convey("Allowed clients should not be limited or denied", func() {
+			allowed := net.ParseIP("192.0.2.10")
+			So(limiter.IsDenied(allowed), ShouldBeFalse)
+			for i := 0; i < 5; i++ {
+				So(limiter.AllowQuery(allowed, now), ShouldBeTrue)
+			}
+			So(limiter.AllowQuery(net.ParseIP("2001:db8::1"), now), ShouldBeTrue)
+		})
+
+		Convey("Denied clients should be refused", func() {
+			So(limiter.IsDenied(net.ParseIP("192.0.2.11")), ShouldBeTrue)
+			So(limiter.IsDenied(client), ShouldBeFalse)
+		})
+
+		Convey("Connections should be limited to the maximum", func() {
+			So(limiter.AcquireConnection(), ShouldBeTrue)
+			So(limiter.AcquireConnection(), ShouldBeFalse)
+			limiter.ReleaseConnection()
+			So(limiter.AcquireConnection(), ShouldBeTrue)
+		})
+	})
+
+	Convey("Invalid networks should be rejected", t, func() {
+		conf := Config{}
+		conf.Limits.Deny = []string{"not-a-network"}
+		_, err := newWHOISLimiter(conf)
+		So(err, ShouldNotBeNil)
+	})
+}
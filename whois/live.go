@@ -0,0 +1,420 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	logging "github.com/op/go-logging"
+
+	"github.com/timapril/go-registrar/client"
+	"github.com/timapril/go-registrar/lib"
+	"github.com/timapril/go-registrar/whois/objects"
+)
+
+// defaultLiveInterval is the number of seconds between refreshes of the
+// WHOIS data from the registrar if no interval is configured.
+const defaultLiveInterval = 300
+
+// errNotVerified is used when an object could not be verified and the
+// registrar client did not return a reason.
+var errNotVerified = errors.New("unable to verify object")
+
+// liveSource provides the objects used to build the WHOIS data when the
+// server is run in live mode.
+type liveSource interface {
+	Directory() (client.ObjectDirectory, []error)
+	GetVerifiedDomain(domainID int64, timestamp int64) (bool, []error, *lib.DomainExport)
+	GetVerifiedHost(hostID int64, timestamp int64) (bool, []error, *lib.HostExport)
+	GetVerifiedContact(contactID int64, timestamp int64) (bool, []error, *lib.ContactExport)
+}
+
+// registrarSource is a liveSource that gets objects from the registrar
+// using a registrar client.
+type registrarSource struct {
+	*client.Client
+}
+
+// Directory refreshes the list of objects and revision hints from the
+// registrar and returns it.
+func (r registrarSource) Directory() (client.ObjectDirectory, []error) {
+	errs := r.PrepareObjectDirectory()
+
+	return r.ObjectDir, errs
+}
+
+// GetConnectionURL will return the URL that can be used to connect to the
+// Registrar server as defined by the parameters in the configuartion
+func (c Config) GetConnectionURL() string {
+	if c.Registrar.UseHTTPS {
+		return fmt.Sprintf("https://%s:%d", c.Registrar.Server, c.Registrar.Port)
+	}
+	return fmt.Sprintf("http://%s:%d", c.Registrar.Server, c.Registrar.Port)
+}
+
+// GetTrustAnchor will use the information in the config object to create the
+// trust anchor set for the application and return the trustanchors or an error
+// if an error occurs.
+func (c Config) GetTrustAnchor() (client.TrustAnchors, error) {
+	ta := client.TrustAnchors{}
+	for _, anchor := range c.Registrar.TrustAnchor {
+		pubkey, readErr := os.ReadFile(anchor)
+		if readErr != nil {
+			return ta, readErr
+		}
+		err := ta.AddKey(string(pubkey))
+		if err != nil {
+			return ta, err
+		}
+	}
+	return ta, nil
+}
+
+// GetRegistrarClient will use the configuration object and generate and
+// return an Registrar client object. If an error occurs when generating
+// the client, the error is returned
+func (c Config) GetRegistrarClient() (cli client.Client, err error) {
+	logger := logging.MustGetLogger("whois")
+
+	cli.TrustAnchor, err = c.GetTrustAnchor()
+	if err != nil {
+		return
+	}
+
+	if c.Testing.SpoofCert != "" {
+		cli.Prepare(c.GetConnectionURL(), logger, c.CacheConfig)
+		spoofCert, readErr := os.ReadFile(c.Testing.SpoofCert)
+		if readErr != nil {
+			err = readErr
+			return
+		}
+		cli.SpoofCertificateForTesting(string(spoofCert), string(c.Testing.CertHeader))
+	} else {
+		cli.PrepareSSL(c.GetConnectionURL(), c.Certs.CertPath, c.Certs.KeyPath, c.Certs.CACertPath, c.Mac, logger, c.CacheConfig)
+	}
+
+	return cli, nil
+}
+
+// GetLiveInterval returns the time between refreshes of the WHOIS data
+// in live mode.
+func (c Config) GetLiveInterval() time.Duration {
+	if c.Live.Interval <= 0 {
+		return defaultLiveInterval * time.Second
+	}
+	return time.Duration(c.Live.Interval) * time.Second
+}
+
+// liveStatus holds the outcome of the refreshes of the WHOIS data in
+// live mode.
+type liveStatus struct {
+	LastUpdate  time.Time
+	LastAttempt time.Time
+
+	// Fetched is the number of objects that were fetched from the
+	// registrar in the last refresh rather than reused.
+	Fetched int
+
+	// Failures holds the reason each object that could not be verified
+	// in the last refresh was not updated.
+	Failures map[string]string
+
+	// Error is set if the last refresh could not be completed.
+	Error string
+}
+
+// String returns a human readable version of the status.
+func (s liveStatus) String() string {
+	var lines []string
+
+	lines = append(lines, fmt.Sprintf("last update %s", s.LastUpdate.UTC().Format(time.RFC3339)))
+	lines = append(lines, fmt.Sprintf("last attempt %s", s.LastAttempt.UTC().Format(time.RFC3339)))
+	lines = append(lines, fmt.Sprintf("fetched %d object(s)", s.Fetched))
+
+	if s.Error != "" {
+		lines = append(lines, fmt.Sprintf("refresh failed: %s", s.Error))
+	}
+
+	var keys []string
+	for key := range s.Failures {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		lines = append(lines, fmt.Sprintf("verification failed for %s: %s", key, s.Failures[key]))
+	}
+
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// liveUpdater builds the WHOIS data from the verified objects at the
+// registrar. Objects whose current revision has not changed since the
+// last refresh are reused rather than being fetched and verified again.
+type liveUpdater struct {
+	source           liveSource
+	defaultContactID int64
+
+	data      objects.WHOIS
+	revisions map[string]int64
+	status    liveStatus
+}
+
+// newLiveUpdater creates a live updater for the source passed.
+func newLiveUpdater(source liveSource, defaultContactID int64) *liveUpdater {
+	return &liveUpdater{
+		source:           source,
+		defaultContactID: defaultContactID,
+		data:             objects.NewWHOIS(),
+		revisions:        make(map[string]int64),
+	}
+}
+
+// Refresh rebuilds the WHOIS data using the objects at the registrar at
+// the time passed. If the list of objects could not be retrieved, false
+// is returned and the previous data should continue to be used. Objects
+// that fail verification keep their last verified version, if there is
+// one, and are listed in the failures of the status.
+func (u *liveUpdater) Refresh(now time.Time) (objects.WHOIS, bool) {
+	u.status.LastAttempt = now
+
+	od, errs := u.source.Directory()
+	if len(errs) != 0 {
+		u.status.Error = joinErrors(errs)
+		return u.data, false
+	}
+
+	next := objects.NewWHOIS()
+	revisions := make(map[string]int64)
+	failures := make(map[string]string)
+	fetched := 0
+	ts := now.Unix()
+
+	refresh := func(objectType string, id int64, hints map[int64]lib.APIRevisionHint, reuse func() bool, fetch func() error) {
+		key := fmt.Sprintf("%s %d", objectType, id)
+		hint, hasHint := hints[id]
+
+		if hasHint && u.revisions[key] == hint.RevisionID && reuse() {
+			revisions[key] = hint.RevisionID
+			return
+		}
+
+		fetched++
+
+		if err := fetch(); err != nil {
+			failures[key] = err.Error()
+			log.Printf("VERIFYERR %s %s", key, err)
+			if reuse() {
+				revisions[key] = u.revisions[key]
+			}
+			return
+		}
+
+		if hasHint {
+			revisions[key] = hint.RevisionID
+		}
+	}
+
+	for _, id := range od.DomainIDs {
+		idKey := strconv.FormatInt(id, 10)
+		refresh(lib.DomainType, id, od.DomainHints, func() bool {
+			old, ok := u.data.Domains[idKey]
+			if ok {
+				next.Domains[idKey] = old
+			}
+			return ok
+		}, func() error {
+			verified, verErrs, dom := u.source.GetVerifiedDomain(id, ts)
+			if !verified || dom == nil {
+				return verificationError(verErrs)
+			}
+			who, err := objects.WHOISDomainFromExport(dom)
+			if err != nil {
+				return err
+			}
+			next.Domains[idKey] = who
+			return nil
+		})
+	}
+
+	hostIDs, contactIDs := u.requiredObjects(next, od)
+
+	for _, id := range hostIDs {
+		idKey := strconv.FormatInt(id, 10)
+		refresh(lib.HostType, id, od.HostHints, func() bool {
+			old, ok := u.data.Hosts[idKey]
+			if ok {
+				next.Hosts[idKey] = old
+			}
+			return ok
+		}, func() error {
+			verified, verErrs, hos := u.source.GetVerifiedHost(id, ts)
+			if !verified || hos == nil {
+				return verificationError(verErrs)
+			}
+			who, err := objects.WHOISHostFromExport(hos)
+			if err != nil {
+				return err
+			}
+			next.Hosts[idKey] = who
+			return nil
+		})
+	}
+
+	for _, id := range contactIDs {
+		idKey := strconv.FormatInt(id, 10)
+		refresh(lib.ContactType, id, od.ContactHints, func() bool {
+			old, ok := u.data.Contacts[idKey]
+			if ok {
+				next.Contacts[idKey] = old
+			}
+			return ok
+		}, func() error {
+			verified, verErrs, con := u.source.GetVerifiedContact(id, ts)
+			if !verified || con == nil {
+				return verificationError(verErrs)
+			}
+			who, err := objects.WHOISContactFromExport(con)
+			if err != nil {
+				return err
+			}
+			next.Contacts[idKey] = who
+			return nil
+		})
+	}
+
+	if con, ok := next.Contacts[strconv.FormatInt(u.defaultContactID, 10)]; ok {
+		next.DefaultContact = con
+	} else {
+		next.DefaultContact = u.data.DefaultContact
+	}
+
+	next.LastUpdate = now
+	next.Index()
+
+	u.data = next
+	u.revisions = revisions
+	u.status = liveStatus{
+		LastUpdate:  now,
+		LastAttempt: now,
+		Fetched:     fetched,
+		Failures:    failures,
+	}
+
+	return next, true
+}
+
+// Status returns the status of the last refresh.
+func (u *liveUpdater) Status() liveStatus {
+	return u.status
+}
+
+// requiredObjects returns the IDs of the hosts and contacts that are
+// active at the registrar or are used by one of the domains, along with
+// the default contact.
+func (u *liveUpdater) requiredObjects(data objects.WHOIS, od client.ObjectDirectory) (hostIDs []int64, contactIDs []int64) {
+	hosts := make(map[int64]bool)
+	contacts := map[int64]bool{u.defaultContactID: true}
+
+	for _, id := range od.HostIDs {
+		hosts[id] = true
+	}
+
+	for _, id := range od.ContactIDs {
+		contacts[id] = true
+	}
+
+	for _, dom := range data.Domains {
+		for _, id := range dom.HostIDs {
+			hosts[id] = true
+		}
+
+		contacts[dom.RegistrantContactID] = true
+		contacts[dom.AdminContactID] = true
+		contacts[dom.TechContactID] = true
+	}
+
+	delete(contacts, 0)
+
+	return sortedIDs(hosts), sortedIDs(contacts)
+}
+
+// sortedIDs returns the keys of the map in ascending order.
+func sortedIDs(ids map[int64]bool) (sorted []int64) {
+	for id := range ids {
+		sorted = append(sorted, id)
+	}
+
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	return sorted
+}
+
+// verificationError combines the errors returned when verifying an
+// object into a single error.
+func verificationError(errs []error) error {
+	if len(errs) == 0 {
+		return errNotVerified
+	}
+
+	return errors.New(joinErrors(errs))
+}
+
+// joinErrors returns the messages of the errors passed as a single
+// string.
+func joinErrors(errs []error) string {
+	var msgs []string
+	for _, err := range errs {
+		msgs = append(msgs, err.Error())
+	}
+
+	return strings.Join(msgs, "; ")
+}
+
+// runLiveUpdates refreshes the WHOIS data from the registrar on the
+// configured interval and whenever a connection is made to the reload
+// port. Each new version of the data is sent to the main loop and the
+// status of the refresh is written to the reload connection.
+func runLiveUpdates(conf Config, updater *liveUpdater, liveChan chan objects.WHOIS, reloadChan chan net.Conn) {
+	data, ok := updater.Refresh(time.Now())
+	if !ok {
+		log.Fatal("unable to load WHOIS data from the registrar: ", updater.Status().Error)
+	}
+	liveChan <- data
+	log.Print("NOTE ", "registrar data loaded")
+
+	ticker := time.NewTicker(conf.GetLiveInterval())
+	defer ticker.Stop()
+
+	for {
+		var reloadConn net.Conn
+
+		select {
+		case <-ticker.C:
+		case reloadConn = <-reloadChan:
+		}
+
+		data, ok := updater.Refresh(time.Now())
+		if ok {
+			liveChan <- data
+			log.Printf("NOTE registrar data loaded, fetched %d object(s), %d verification failure(s)", updater.Status().Fetched, len(updater.Status().Failures))
+		} else {
+			log.Print("ERR ", "unable to refresh registrar data: ", updater.Status().Error)
+		}
+
+		if reloadConn != nil {
+			_, err := reloadConn.Write([]byte(updater.Status().String()))
+			if err != nil {
+				log.Print("ERR ", err)
+			}
+			reloadConn.Close()
+		}
+	}
+}
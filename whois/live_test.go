@@ -0,0 +1,150 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/timapril/go-registrar/client"
+	"github.com/timapril/go-registrar/lib"
+)
+
+// fakeLiveSource is a liveSource that serves objects from memory and
+// counts how many times each object is fetched.
+type fakeLiveSource struct {
+	directory client.ObjectDirectory
+	dirErrs   []error
+
+	domains  map[int64]*lib.DomainExport
+	hosts    map[int64]*lib.HostExport
+	contacts map[int64]*lib.ContactExport
+
+	unverified map[int64]bool
+	fetches    int
+}
+
+func (f *fakeLiveSource) Directory() (client.ObjectDirectory, []error) {
+	return f.directory, f.dirErrs
+}
+
+func (f *fakeLiveSource) GetVerifiedDomain(id int64, _ int64) (bool, []error, *lib.DomainExport) {
+	f.fetches++
+	if f.unverified[id] {
+		return false, []error{errors.New("bad signature")}, nil
+	}
+	return true, nil, f.domains[id]
+}
+
+func (f *fakeLiveSource) GetVerifiedHost(id int64, _ int64) (bool, []error, *lib.HostExport) {
+	f.fetches++
+	return true, nil, f.hosts[id]
+}
+
+func (f *fakeLiveSource) GetVerifiedContact(id int64, _ int64) (bool, []error, *lib.ContactExport) {
+	f.fetches++
+	return true, nil, f.contacts[id]
+}
+
+func newFakeLiveSource() *fakeLiveSource {
+	source := &fakeLiveSource{
+		directory:  client.NewObjectDirectory(),
+		domains:    make(map[int64]*lib.DomainExport),
+		hosts:      make(map[int64]*lib.HostExport),
+		contacts:   make(map[int64]*lib.ContactExport),
+		unverified: make(map[int64]bool),
+	}
+
+	dom := &lib.DomainExport{ID: 1, DomainName: "EXAMPLE.COM"}
+	dom.CurrentRevision.ID = 10
+	dom.CurrentRevision.DomainRegistrant.ID = 3
+	dom.CurrentRevision.Hostnames = []lib.HostExportShort{{ID: 2}}
+	source.domains[1] = dom
+
+	hos := &lib.HostExport{ID: 2, HostName: "NS1.EXAMPLE.COM"}
+	hos.CurrentRevision.ID = 20
+	source.hosts[2] = hos
+
+	con := &lib.ContactExport{ID: 3}
+	con.CurrentRevision.ID = 30
+	con.CurrentRevision.Name = "Jane Doe"
+	source.contacts[3] = con
+
+	source.directory.DomainIDs = []int64{1}
+	source.directory.DomainHints[1] = lib.APIRevisionHint{ObjectID: 1, RevisionID: 10}
+	source.directory.HostHints[2] = lib.APIRevisionHint{ObjectID: 2, RevisionID: 20}
+	source.directory.ContactHints[3] = lib.APIRevisionHint{ObjectID: 3, RevisionID: 30}
+
+	return source
+}
+
+func TestLiveUpdater(t *testing.T) {
+	t.Parallel()
+	Convey("Given a live updater for a registrar with one domain", t, func() {
+		source := newFakeLiveSource()
+		updater := newLiveUpdater(source, 3)
+		now := time.Now()
+
+		data, ok := updater.Refresh(now)
+		So(ok, ShouldBeTrue)
+
+		Convey("The domain and the objects it uses should be loaded", func() {
+			dom, err := data.Find("example.com")
+			So(err, ShouldBeNil)
+			So(dom.ID, ShouldEqual, 1)
+			_, err = data.FindHost("ns1.example.com")
+			So(err, ShouldBeNil)
+			So(data.DefaultContact.Name, ShouldEqual, "Jane Doe")
+			So(data.LastUpdate, ShouldEqual, now)
+			So(source.fetches, ShouldEqual, 3)
+			So(updater.Status().Fetched, ShouldEqual, 3)
+		})
+
+		Convey("Unchanged revisions should not be fetched again", func() {
+			_, ok := updater.Refresh(now.Add(time.Minute))
+			So(ok, ShouldBeTrue)
+			So(source.fetches, ShouldEqual, 3)
+			So(updater.Status().Fetched, ShouldEqual, 0)
+		})
+
+		Convey("Changed revisions should be fetched again", func() {
+			source.contacts[3].CurrentRevision.Name = "John Doe"
+			source.directory.ContactHints[3] = lib.APIRevisionHint{ObjectID: 3, RevisionID: 31}
+
+			data, ok := updater.Refresh(now.Add(time.Minute))
+			So(ok, ShouldBeTrue)
+			So(source.fetches, ShouldEqual, 4)
+			So(data.GetContact(3).Name, ShouldEqual, "John Doe")
+		})
+
+		Convey("Objects that fail verification should keep the last verified version", func() {
+			source.domains[1].DomainName = "CHANGED.COM"
+			source.directory.DomainHints[1] = lib.APIRevisionHint{ObjectID: 1, RevisionID: 11}
+			source.unverified[1] = true
+
+			data, ok := updater.Refresh(now.Add(time.Minute))
+			So(ok, ShouldBeTrue)
+			_, err := data.Find("example.com")
+			So(err, ShouldBeNil)
+			So(updater.Status().Failures, ShouldContainKey, "domain 1")
+			So(updater.Status().String(), ShouldContainSubstring, "verification failed for domain 1: bad signature")
+
+			source.unverified[1] = false
+			data, _ = updater.Refresh(now.Add(2 * time.Minute))
+			_, err = data.Find("changed.com")
+			So(err, ShouldBeNil)
+			So(updater.Status().Failures, ShouldBeEmpty)
+		})
+
+		Convey("A failure to list the objects should keep the current data", func() {
+			source.dirErrs = []error{errors.New("connection refused")}
+
+			data, ok := updater.Refresh(now.Add(time.Minute))
+			So(ok, ShouldBeFalse)
+			So(data.LastUpdate, ShouldEqual, now)
+			So(updater.Status().Error, ShouldEqual, "connection refused")
+			So(updater.Status().LastUpdate, ShouldEqual, now)
+		})
+	})
+}
@@ -8,6 +8,7 @@ import (
 	"io"
 	"log"
 	"net"
+	"net/http"
 	"os"
 	"strings"
 	"text/template"
@@ -15,7 +16,10 @@ import (
 
 	"gopkg.in/gcfg.v1"
 
+	"github.com/timapril/go-registrar/client"
+	"github.com/timapril/go-registrar/keychain"
 	"github.com/timapril/go-registrar/whois/objects"
+	"github.com/timapril/go-registrar/whois/rdap"
 )
 
 // Config is used to load the configuration for the WHOIS server.
@@ -28,6 +32,148 @@ type Config struct {
 		ListenReload string
 		DataFile     string
 	}
+	Live struct {
+		Enabled          bool
+		Interval         int
+		DefaultContactID int64
+	}
+	Registrar struct {
+		Server      string
+		Port        int64
+		UseHTTPS    bool
+		TrustAnchor []string
+	}
+	Certs struct {
+		CACertPath string
+		CertPath   string
+		KeyPath    string
+	}
+	Mac     keychain.Conf
+	Testing struct {
+		SpoofCert  string
+		CertHeader string
+	}
+	CacheConfig client.DiskCacheConfig
+	Limits      struct {
+		QueriesPerMinute float64
+		QueryBurst       int
+		MaxConnections   int
+		ReadTimeout      int
+		WriteTimeout     int
+		Allow            []string
+		Deny             []string
+		QueryLog         string
+	}
+	Privacy struct {
+		ContactQueries string
+	}
+	RDAP struct {
+		Listen            string
+		BaseURL           string
+		TermsOfServiceURL string
+	}
+	RegistrarInfo struct {
+		WHOISServer       string
+		URL               string
+		Name              string
+		IANAID            int64
+		AbuseContactEmail string
+		AbuseContactPhone string
+		NoticeTextPath    string
+	}
+}
+
+// GetQueriesPerMinute returns the number of queries each client may make
+// per minute or the default if no limit is set.
+func (c Config) GetQueriesPerMinute() float64 {
+	if c.Limits.QueriesPerMinute <= 0 {
+		return defaultQueriesPerMinute
+	}
+	return c.Limits.QueriesPerMinute
+}
+
+// GetQueryBurst returns the number of queries each client may make in
+// quick succession or the default if no burst is set.
+func (c Config) GetQueryBurst() int {
+	if c.Limits.QueryBurst <= 0 {
+		return defaultQueryBurst
+	}
+	return c.Limits.QueryBurst
+}
+
+// GetMaxConnections returns the number of connections that may be open
+// at the same time or the default if no limit is set.
+func (c Config) GetMaxConnections() int {
+	if c.Limits.MaxConnections <= 0 {
+		return defaultMaxConnections
+	}
+	return c.Limits.MaxConnections
+}
+
+// GetReadTimeout returns the time a client has to send its query.
+func (c Config) GetReadTimeout() time.Duration {
+	if c.Limits.ReadTimeout <= 0 {
+		return defaultReadTimeout * time.Second
+	}
+	return time.Duration(c.Limits.ReadTimeout) * time.Second
+}
+
+// GetWriteTimeout returns the time a client has to read the response.
+func (c Config) GetWriteTimeout() time.Duration {
+	if c.Limits.WriteTimeout <= 0 {
+		return defaultWriteTimeout * time.Second
+	}
+	return time.Duration(c.Limits.WriteTimeout) * time.Second
+}
+
+// GetContactQueries returns how contact queries should be answered,
+// defaulting to not answering them if the setting is missing or not
+// recognized.
+func (c Config) GetContactQueries() string {
+	switch strings.ToLower(c.Privacy.ContactQueries) {
+	case ContactQueriesRedacted:
+		return ContactQueriesRedacted
+	case ContactQueriesFull:
+		return ContactQueriesFull
+	default:
+		return ContactQueriesDisabled
+	}
+}
+
+// registrarInformation holds the details of the registrar that are
+// included in each WHOIS response.
+type registrarInformation struct {
+	WHOISServer       string
+	URL               string
+	Name              string
+	IANAID            int64
+	AbuseContactEmail string
+	AbuseContactPhone string
+	NoticeText        string
+}
+
+// getRegistrarInformation builds the registrar information from the
+// configuration, reading the notice text from the configured file if
+// one is set.
+func getRegistrarInformation(conf Config) (registrarInformation, error) {
+	regInfo := registrarInformation{
+		WHOISServer:       conf.RegistrarInfo.WHOISServer,
+		URL:               conf.RegistrarInfo.URL,
+		Name:              conf.RegistrarInfo.Name,
+		IANAID:            conf.RegistrarInfo.IANAID,
+		AbuseContactEmail: conf.RegistrarInfo.AbuseContactEmail,
+		AbuseContactPhone: conf.RegistrarInfo.AbuseContactPhone,
+	}
+
+	if conf.RegistrarInfo.NoticeTextPath != "" {
+		noticeText, err := os.ReadFile(conf.RegistrarInfo.NoticeTextPath)
+		if err != nil {
+			return regInfo, err
+		}
+		regInfo.NoticeText = strings.TrimSpace(string(noticeText))
+	}
+
+	return regInfo, nil
 }
 
 func main() {
@@ -38,15 +184,46 @@ func main() {
 		log.Fatal(err)
 	}
 
+	regInfo, err := getRegistrarInformation(conf)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	limiter, err := newWHOISLimiter(conf)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	queryLog, err := newQueryLogger(conf.Limits.QueryLog)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	templates := template.Must(template.New("").Funcs(template.FuncMap{
 		"dict": dictify,
 	}).ParseGlob(conf.Templates.Path))
 
-	connChan := make(chan net.Conn, 1000)
+	connChan := make(chan net.Conn, conf.GetMaxConnections())
 	updateChan := make(chan net.Conn)
+	dataChan := make(chan chan objects.WHOIS)
+	liveChan := make(chan objects.WHOIS)
 
-	go listenReload(conf, updateChan)
-	go handleServer(conf, templates, connChan, updateChan)
+	if conf.Live.Enabled {
+		cli, err := conf.GetRegistrarClient()
+		if err != nil {
+			log.Fatal(err)
+		}
+		reloadChan := make(chan net.Conn)
+		go listenReload(conf, reloadChan)
+		go runLiveUpdates(conf, newLiveUpdater(registrarSource{&cli}, conf.Live.DefaultContactID), liveChan, reloadChan)
+	} else {
+		go listenReload(conf, updateChan)
+	}
+	go handleServer(conf, templates, regInfo, limiter, queryLog, connChan, updateChan, dataChan, liveChan)
+
+	if conf.RDAP.Listen != "" {
+		go listenRDAP(conf, dataChan)
+	}
 
 	whoisPort, err := net.Listen("tcp", conf.Server.Listen)
 	if err != nil {
@@ -57,15 +234,27 @@ func main() {
 		conn, err := whoisPort.Accept()
 		if err != nil {
 			log.Print("SRVERR ", err)
-		} else {
-			connChan <- conn
+			continue
 		}
+		clientIP := remoteIP(conn.RemoteAddr())
+		if limiter.IsDenied(clientIP) {
+			queryLog.Log(clientIP.String(), "", QueryResultDenied, time.Now())
+			conn.Close()
+			continue
+		}
+		if !limiter.AcquireConnection() {
+			queryLog.Log(clientIP.String(), "", QueryResultBusy, time.Now())
+			conn.Close()
+			continue
+		}
+		connChan <- conn
 	}
 }
 
 // listenReload listens on a socket to accpet connections from
 // the defined ip/port to reload the data configuration on the
-// server.
+// server. In live mode a connection triggers a refresh from the
+// registrar.
 func listenReload(conf Config, updateChan chan net.Conn) {
 	reloadPort, err := net.Listen("tcp", conf.Server.ListenReload)
 	if err != nil {
@@ -82,15 +271,49 @@ func listenReload(conf Config, updateChan chan net.Conn) {
 	}
 }
 
+// listenRDAP starts the RDAP service on the defined ip/port. Each
+// request gets the current version of the whois data from the main
+// loop so reloaded data is served as soon as it has been loaded.
+func listenRDAP(conf Config, dataChan chan chan objects.WHOIS) {
+	registrar := rdap.Registrar{
+		WHOISServer:       conf.RegistrarInfo.WHOISServer,
+		Name:              conf.RegistrarInfo.Name,
+		IANAID:            conf.RegistrarInfo.IANAID,
+		URL:               conf.RegistrarInfo.URL,
+		AbuseContactEmail: conf.RegistrarInfo.AbuseContactEmail,
+		AbuseContactPhone: conf.RegistrarInfo.AbuseContactPhone,
+	}
+
+	server := rdap.NewServer(registrar, conf.RDAP.BaseURL, conf.RDAP.TermsOfServiceURL, func() objects.WHOIS {
+		respChan := make(chan objects.WHOIS)
+		dataChan <- respChan
+		return <-respChan
+	})
+
+	httpServer := &http.Server{
+		Addr:              conf.RDAP.Listen,
+		Handler:           server.Handler(),
+		ReadHeaderTimeout: 30 * time.Second,
+	}
+
+	log.Fatal(httpServer.ListenAndServe())
+}
+
 // handleServer runs the main loop waiting for update requests and
 // user queries and sends requests to be processed with a current
 // version of the whois data.
-func handleServer(conf Config, templates *template.Template, connChan chan net.Conn, updateChan chan net.Conn) {
-	whoisData, loadErr := loadData(conf)
-	if loadErr != nil {
-		log.Fatal(loadErr)
+func handleServer(conf Config, templates *template.Template, regInfo registrarInformation, limiter *whoisLimiter, queryLog *queryLogger, connChan chan net.Conn, updateChan chan net.Conn, dataChan chan chan objects.WHOIS, liveChan chan objects.WHOIS) {
+	var whoisData objects.WHOIS
+	if conf.Live.Enabled {
+		whoisData = <-liveChan
+	} else {
+		var loadErr error
+		whoisData, loadErr = loadData(conf)
+		if loadErr != nil {
+			log.Fatal(loadErr)
+		}
+		log.Print("NOTE ", "config loaded")
 	}
-	log.Print("NOTE ", "config loaded")
 
 	for {
 		select {
@@ -114,7 +337,11 @@ func handleServer(conf Config, templates *template.Template, connChan chan net.C
 			}
 			updateConn.Close()
 		case conn := <-connChan:
-			go handleConn(conn, templates, whoisData)
+			go handleConn(conf, conn, templates, regInfo, limiter, queryLog, whoisData)
+		case newData := <-liveChan:
+			whoisData = newData
+		case respChan := <-dataChan:
+			respChan <- whoisData
 		}
 	}
 }
@@ -141,22 +368,44 @@ func loadData(conf Config) (whoisData objects.WHOIS, err error) {
 type errorResponse struct {
 	SearchString string
 	LastUpdate   string
+	Registrar    registrarInformation
 }
 
 // writeErrorResponse is used to compile an error response for a unknown
 // query.
-func writeErrorResponse(writer io.Writer, templates *template.Template, search string, updatetime string) {
+func writeErrorResponse(writer io.Writer, templates *template.Template, regInfo registrarInformation, search string, updatetime string) {
 	er := errorResponse{
 		SearchString: search,
 		LastUpdate:   updatetime,
+		Registrar:    regInfo,
 	}
 	executeTemplate(writer, templates, "error", er)
 }
 
+// rateExceededResponse contains the required elements to form a response
+// to a client that has exceeded its query rate.
+type rateExceededResponse struct {
+	SearchString string
+	ClientIP     string
+	Registrar    registrarInformation
+}
+
+// writeRateExceededResponse is used to compile the response sent to a
+// client that has exceeded its query rate.
+func writeRateExceededResponse(writer io.Writer, templates *template.Template, regInfo registrarInformation, search string, clientIP string) {
+	rr := rateExceededResponse{
+		SearchString: search,
+		ClientIP:     clientIP,
+		Registrar:    regInfo,
+	}
+	executeTemplate(writer, templates, "ratelimit", rr)
+}
+
 // okResponse contains the required elementes to form a valid response.
 type okResponse struct {
 	SearchString      string
 	LastUpdate        string
+	Registrar         registrarInformation
 	DomainName        string
 	DomainObject      objects.Domain
 	RegistrantContact objects.Contact
@@ -167,10 +416,11 @@ type okResponse struct {
 
 // writeAcceptedPage is used to compile a valid response for a WHOIS
 // query.
-func writeAcceptedPage(writer io.Writer, templates *template.Template, search string, updatetime string, dom objects.Domain, whoisData objects.WHOIS) {
+func writeAcceptedPage(writer io.Writer, templates *template.Template, regInfo registrarInformation, search string, updatetime string, dom objects.Domain, whoisData objects.WHOIS) {
 	or := okResponse{
 		SearchString:      search,
 		LastUpdate:        updatetime,
+		Registrar:         regInfo,
 		DomainName:        strings.ToUpper(dom.DomainName),
 		DomainObject:      dom,
 		RegistrantContact: whoisData.GetContact(int(dom.RegistrantContactID)),
@@ -198,28 +448,41 @@ func executeTemplate(writer io.Writer, templates *template.Template, templateNam
 }
 
 // handleConn processes a WHOIS request for a single connection.
-func handleConn(conn net.Conn, templates *template.Template, whoisData objects.WHOIS) {
+func handleConn(conf Config, conn net.Conn, templates *template.Template, regInfo registrarInformation, limiter *whoisLimiter, queryLog *queryLogger, whoisData objects.WHOIS) {
+	defer limiter.ReleaseConnection()
 	defer conn.Close()
 
-	err := conn.SetReadDeadline(time.Now().Add(120 * time.Second))
+	clientIP := remoteIP(conn.RemoteAddr())
+
+	err := conn.SetReadDeadline(time.Now().Add(conf.GetReadTimeout()))
 	if err != nil {
-		log.Fatal(err)
+		log.Print("ERR ", err)
+		return
 	}
 	reader := bufio.NewReader(conn)
 	line, _, err := reader.ReadLine()
+	received := time.Now()
 	if err != nil {
-		log.Fatal(err)
+		queryLog.Log(clientIP.String(), "", QueryResultError, received)
+		return
 	}
 
 	searchString := strings.ToUpper(strings.TrimSpace(string(line)))
 
-	dom, err := whoisData.Find(searchString)
+	err = conn.SetWriteDeadline(received.Add(conf.GetWriteTimeout()))
 	if err != nil {
-		writeErrorResponse(conn, templates, searchString, whoisData.LastUpdate.Format("Mon, 2 Jan 2006 15:04:05 GMT"))
-		log.Printf("ERR %s %s", conn.RemoteAddr().String(), searchString)
+		log.Print("ERR ", err)
+		return
+	}
+
+	if !limiter.AllowQuery(clientIP, received) {
+		writeRateExceededResponse(conn, templates, regInfo, searchString, clientIP.String())
+		queryLog.Log(clientIP.String(), searchString, QueryResultRateExceeded, received)
+		return
 	}
-	writeAcceptedPage(conn, templates, searchString, whoisData.LastUpdate.Format("Mon, 2 Jan 2006 15:04:05 GMT"), dom, whoisData)
-	log.Printf("OK %s %s", conn.RemoteAddr().String(), searchString)
+
+	result := answerQuery(conn, conf, templates, regInfo, whoisData, searchString)
+	queryLog.Log(clientIP.String(), searchString, result, received)
 }
 
 // dictify is a helper function for the template engine to allow more
@@ -10,6 +10,9 @@ import (
 type Contact struct {
 	ID int64
 
+	ContactRegistryID string
+	ContactROID       string
+
 	Name string
 	Org  string
 
@@ -36,6 +39,8 @@ func WHOISContactFromExport(libcontact *lib.ContactExport) (Contact, error) {
 	c := Contact{}
 
 	c.ID = libcontact.ID
+	c.ContactRegistryID = libcontact.ContactRegistryID
+	c.ContactROID = libcontact.ContactROID
 
 	currentRevision := libcontact.CurrentRevision
 	if currentRevision.ID == 0 {
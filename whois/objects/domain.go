@@ -2,7 +2,9 @@ package objects
 
 import (
 	"errors"
+	"time"
 
+	"github.com/timapril/go-registrar/epp"
 	"github.com/timapril/go-registrar/lib"
 )
 
@@ -16,6 +18,7 @@ type Domain struct {
 
 	DomainCreationDate string
 	DomainUpdateDate   string
+	DomainExpireDate   string
 
 	DomainStatuses []string
 
@@ -24,6 +27,32 @@ type Domain struct {
 	TechContactID       int64
 
 	HostIDs []int64
+
+	DSData []DSData
+}
+
+// DSData holds a single DS record published for a domain.
+type DSData struct {
+	KeyTag     int64
+	Algorithm  int64
+	DigestType int64
+	Digest     string
+}
+
+// IsSigned returns true if the domain has DS records published and
+// should be reported as a signed delegation.
+func (d Domain) IsSigned() bool {
+	return len(d.DSData) != 0
+}
+
+// formatDomainDate formats a domain date for display and returns an
+// empty string if the date is not set.
+func formatDomainDate(date time.Time) string {
+	if date.IsZero() {
+		return ""
+	}
+
+	return date.UTC().Format(epp.EPPTimeFormat)
 }
 
 // WHOISDomainFromExport takes a lib.DomainExport object and extracts
@@ -36,6 +65,11 @@ func WHOISDomainFromExport(libdomain *lib.DomainExport) (Domain, error) {
 	d.DomainName = libdomain.DomainName
 	d.DomainROID = libdomain.DomainROID
 
+	// Dates
+	d.DomainCreationDate = formatDomainDate(libdomain.CreateDate)
+	d.DomainUpdateDate = formatDomainDate(libdomain.UpdateDate)
+	d.DomainExpireDate = formatDomainDate(libdomain.ExpireDate)
+
 	currentRevision := libdomain.CurrentRevision
 
 	// if the current revision is 0 there is something wrong. No data
@@ -53,6 +87,16 @@ func WHOISDomainFromExport(libdomain *lib.DomainExport) (Domain, error) {
 		d.HostIDs = append(d.HostIDs, host.ID)
 	}
 
+	// DNSSEC
+	for _, ds := range currentRevision.DSDataEntries {
+		d.DSData = append(d.DSData, DSData{
+			KeyTag:     ds.KeyTag,
+			Algorithm:  ds.Algorithm,
+			DigestType: ds.DigestType,
+			Digest:     ds.Digest,
+		})
+	}
+
 	// Status Flags
 	if currentRevision.ClientDeleteProhibitedStatus {
 		d.DomainStatuses = append(d.DomainStatuses, "clientDeleteProhibited")
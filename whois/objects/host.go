@@ -29,6 +29,7 @@ func WHOISHostFromExport(libhost *lib.HostExport) (Host, error) {
 
 	// Hostname
 	h.HostName = libhost.HostName
+	h.HostROID = libhost.HostROID
 
 	// Host Addresses
 	for _, host := range currentRevision.HostAddresses {
@@ -3,6 +3,8 @@ package objects
 import (
 	"encoding/json"
 	"errors"
+	"net"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -17,7 +19,10 @@ type WHOIS struct {
 	Hosts    map[string]Host
 	Contacts map[string]Contact
 
-	domainsLookup map[string]Domain
+	domainsLookup  map[string]Domain
+	hostsLookup    map[string]Host
+	hostsByAddress map[string][]Host
+	contactsLookup map[string]Contact
 
 	DefaultContact Contact
 
@@ -39,22 +44,22 @@ func NewWHOIS() WHOIS {
 // object's list of hosts.
 func (w *WHOIS) AddHost(host *lib.HostExport) error {
 	hos, err := WHOISHostFromExport(host)
-	if err != nil {
+	if err == nil {
 		w.Hosts[strconv.Itoa(int(host.ID))] = hos
 	}
 
-	return nil
+	return err
 }
 
 // AddContact takes a lib.ContactExport object and adds it to the WHOIS
 // object's list of contacts.
 func (w *WHOIS) AddContact(contact *lib.ContactExport) error {
 	con, err := WHOISContactFromExport(contact)
-	if err != nil {
+	if err == nil {
 		w.Contacts[strconv.Itoa(int(contact.ID))] = con
 	}
 
-	return nil
+	return err
 }
 
 // SetDefaultContact takes a lib.ContactExport and sets it as the
@@ -62,11 +67,11 @@ func (w *WHOIS) AddContact(contact *lib.ContactExport) error {
 // not available.
 func (w *WHOIS) SetDefaultContact(contact *lib.ContactExport) error {
 	con, err := WHOISContactFromExport(contact)
-	if err != nil {
+	if err == nil {
 		w.DefaultContact = con
 	}
 
-	return nil
+	return err
 }
 
 // AddDomain takes a lib.DomainExport object and adds it to the WHOIS
@@ -95,6 +100,39 @@ func (w *WHOIS) Index() {
 	for _, dom := range w.Domains {
 		w.domainsLookup[strings.ToUpper(dom.DomainName)] = dom
 	}
+
+	w.hostsLookup = make(map[string]Host)
+	w.hostsByAddress = make(map[string][]Host)
+	for _, hos := range w.Hosts {
+		w.hostsLookup[strings.ToUpper(hos.HostName)] = hos
+		for _, addr := range hos.HostAddresses {
+			key := normalizeAddress(addr)
+			w.hostsByAddress[key] = append(w.hostsByAddress[key], hos)
+		}
+	}
+
+	for key := range w.hostsByAddress {
+		sort.Slice(w.hostsByAddress[key], func(i, j int) bool {
+			return w.hostsByAddress[key][i].HostName < w.hostsByAddress[key][j].HostName
+		})
+	}
+
+	w.contactsLookup = make(map[string]Contact)
+	for _, con := range w.Contacts {
+		if con.ContactROID != "" {
+			w.contactsLookup[strings.ToUpper(con.ContactROID)] = con
+		}
+	}
+}
+
+// normalizeAddress returns the canonical form of an IP address so that
+// addresses written in different forms can be matched.
+func normalizeAddress(addr string) string {
+	if ip := net.ParseIP(strings.TrimSpace(addr)); ip != nil {
+		return ip.String()
+	}
+
+	return strings.ToUpper(strings.TrimSpace(addr))
 }
 
 // GetContact will lookup the contact requested by its ID and if the
@@ -125,3 +163,33 @@ func (w *WHOIS) Find(domain string) (Domain, error) {
 	}
 	return Domain{}, errors.New("Unable to find domain")
 }
+
+// FindHost takes a hostname and tries to find the matching host. If no
+// host is found an error is returned.
+func (w *WHOIS) FindHost(hostname string) (Host, error) {
+	if hos, ok := w.hostsLookup[strings.ToUpper(hostname)]; ok {
+		return hos, nil
+	}
+	return Host{}, errors.New("Unable to find host")
+}
+
+// FindHostsByAddress takes an IP address and returns all of the hosts
+// that use the address. If no hosts are found an error is returned.
+func (w *WHOIS) FindHostsByAddress(address string) ([]Host, error) {
+	if hosts, ok := w.hostsByAddress[normalizeAddress(address)]; ok {
+		return hosts, nil
+	}
+	return nil, errors.New("Unable to find host")
+}
+
+// FindContact takes a contact ROID and tries to find the matching
+// contact. Only the ROID is matched as the contact ID and registry ID
+// are sequential and would allow every contact to be enumerated. Unlike
+// GetContact, the default contact is not returned if the contact cannot
+// be found, instead an error is returned.
+func (w *WHOIS) FindContact(roid string) (Contact, error) {
+	if con, ok := w.contactsLookup[strings.ToUpper(roid)]; ok {
+		return con, nil
+	}
+	return Contact{}, errors.New("Unable to find contact")
+}
@@ -0,0 +1,51 @@
+package objects
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/timapril/go-registrar/lib"
+)
+
+func TestWHOISAddObjects(t *testing.T) {
+	t.Parallel()
+	Convey("Given an empty WHOIS dataset", t, func() {
+		data := NewWHOIS()
+
+		Convey("A host with a current revision should be added", func() {
+			host := &lib.HostExport{ID: 20, HostName: "NS1.EXAMPLE.COM"}
+			host.CurrentRevision.ID = 1
+
+			So(data.AddHost(host), ShouldBeNil)
+			So(data.Hosts, ShouldContainKey, "20")
+			So(data.Hosts["20"].HostName, ShouldEqual, "NS1.EXAMPLE.COM")
+		})
+
+		Convey("A host without a current revision should be rejected", func() {
+			So(data.AddHost(&lib.HostExport{ID: 20}), ShouldNotBeNil)
+			So(data.Hosts, ShouldBeEmpty)
+		})
+
+		Convey("A contact with a current revision should be added", func() {
+			contact := &lib.ContactExport{ID: 10}
+			contact.CurrentRevision.ID = 1
+			contact.CurrentRevision.Name = "Jane Registrant"
+
+			So(data.AddContact(contact), ShouldBeNil)
+			So(data.Contacts, ShouldContainKey, "10")
+			So(data.Contacts["10"].Name, ShouldEqual, "Jane Registrant")
+
+			So(data.SetDefaultContact(contact), ShouldBeNil)
+			So(data.DefaultContact.Name, ShouldEqual, "Jane Registrant")
+		})
+
+		Convey("A contact without a current revision should be rejected", func() {
+			So(data.AddContact(&lib.ContactExport{ID: 10}), ShouldNotBeNil)
+			So(data.Contacts, ShouldBeEmpty)
+
+			So(data.SetDefaultContact(&lib.ContactExport{ID: 10}), ShouldNotBeNil)
+			So(data.DefaultContact.ID, ShouldEqual, 0)
+		})
+	})
+}
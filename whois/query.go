@@ -0,0 +1,197 @@
+package main
+
+import (
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/timapril/go-registrar/whois/objects"
+)
+
+const (
+	// QueryTypeDomain is used for queries for a domain name.
+	QueryTypeDomain = "domain"
+
+	// QueryTypeNameserver is used for queries for a nameserver by name.
+	QueryTypeNameserver = "nameserver"
+
+	// QueryTypeAddress is used for queries for the nameservers using an
+	// IP address.
+	QueryTypeAddress = "address"
+
+	// QueryTypeContact is used for queries for a contact by ROID.
+	QueryTypeContact = "contact"
+
+	// QueryTypeRegistrar is used for queries for the registrar.
+	QueryTypeRegistrar = "registrar"
+)
+
+const (
+	// ContactQueriesDisabled does not answer contact queries.
+	ContactQueriesDisabled = "disabled"
+
+	// ContactQueriesRedacted answers contact queries with the personal
+	// data of the contact redacted.
+	ContactQueriesRedacted = "redacted"
+
+	// ContactQueriesFull answers contact queries with all of the contact
+	// data.
+	ContactQueriesFull = "full"
+)
+
+// redactedValue is displayed in place of any redacted contact data.
+const redactedValue = "REDACTED FOR PRIVACY"
+
+// queryKeywords maps each of the keywords that may prefix a query to
+// the type of the query.
+var queryKeywords = map[string]string{
+	"DOMAIN":     QueryTypeDomain,
+	"NAMESERVER": QueryTypeNameserver,
+	"CONTACT":    QueryTypeContact,
+	"REGISTRAR":  QueryTypeRegistrar,
+}
+
+// parseQuery splits a query into its type and the search term. A query
+// without a keyword is treated as a domain query unless it is an IP
+// address. Nameserver queries for an IP address are treated as address
+// queries.
+func parseQuery(query string) (queryType string, term string) {
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return QueryTypeDomain, ""
+	}
+
+	queryType = QueryTypeDomain
+	term = strings.Join(fields, " ")
+
+	if keywordType, ok := queryKeywords[strings.ToUpper(fields[0])]; ok && len(fields) > 1 {
+		queryType = keywordType
+		term = strings.Join(fields[1:], " ")
+	}
+
+	if (queryType == QueryTypeDomain || queryType == QueryTypeNameserver) && net.ParseIP(term) != nil {
+		queryType = QueryTypeAddress
+	}
+
+	return queryType, term
+}
+
+// nameserverResponse contains the required elements to form a response
+// to a nameserver or address query.
+type nameserverResponse struct {
+	SearchString string
+	LastUpdate   string
+	Registrar    registrarInformation
+	Hosts        []objects.Host
+}
+
+// contactResponse contains the required elements to form a response to
+// a contact query.
+type contactResponse struct {
+	SearchString string
+	LastUpdate   string
+	Registrar    registrarInformation
+	Contact      objects.Contact
+}
+
+// registrarResponse contains the required elements to form a response
+// to a registrar query.
+type registrarResponse struct {
+	SearchString string
+	LastUpdate   string
+	Registrar    registrarInformation
+}
+
+// answerQuery finds the objects matching the query and writes the
+// response to the writer. The query log result is returned.
+func answerQuery(writer io.Writer, conf Config, templates *template.Template, regInfo registrarInformation, whoisData objects.WHOIS, searchString string) (result string) {
+	lastUpdate := whoisData.LastUpdate.Format("Mon, 2 Jan 2006 15:04:05 GMT")
+	queryType, term := parseQuery(searchString)
+
+	switch queryType {
+	case QueryTypeNameserver:
+		hos, err := whoisData.FindHost(term)
+		if err == nil {
+			executeTemplate(writer, templates, "nameserver", nameserverResponse{
+				SearchString: searchString,
+				LastUpdate:   lastUpdate,
+				Registrar:    regInfo,
+				Hosts:        []objects.Host{hos},
+			})
+			return QueryResultMatch
+		}
+	case QueryTypeAddress:
+		hosts, err := whoisData.FindHostsByAddress(term)
+		if err == nil {
+			executeTemplate(writer, templates, "nameserver", nameserverResponse{
+				SearchString: searchString,
+				LastUpdate:   lastUpdate,
+				Registrar:    regInfo,
+				Hosts:        hosts,
+			})
+			return QueryResultMatch
+		}
+	case QueryTypeContact:
+		privacy := conf.GetContactQueries()
+		if privacy != ContactQueriesDisabled {
+			con, err := whoisData.FindContact(term)
+			if err == nil {
+				if privacy != ContactQueriesFull {
+					con = redactContact(con)
+				}
+				executeTemplate(writer, templates, "contactresponse", contactResponse{
+					SearchString: searchString,
+					LastUpdate:   lastUpdate,
+					Registrar:    regInfo,
+					Contact:      con,
+				})
+				return QueryResultMatch
+			}
+		}
+	case QueryTypeRegistrar:
+		if strings.EqualFold(term, regInfo.Name) || term == strconv.FormatInt(regInfo.IANAID, 10) {
+			executeTemplate(writer, templates, "registrar", registrarResponse{
+				SearchString: searchString,
+				LastUpdate:   lastUpdate,
+				Registrar:    regInfo,
+			})
+			return QueryResultMatch
+		}
+	default:
+		dom, err := whoisData.Find(term)
+		if err == nil {
+			writeAcceptedPage(writer, templates, regInfo, searchString, lastUpdate, dom, whoisData)
+			return QueryResultMatch
+		}
+	}
+
+	writeErrorResponse(writer, templates, regInfo, searchString, lastUpdate)
+
+	return QueryResultNoMatch
+}
+
+// redactContact returns a copy of the contact with the personal data
+// replaced.
+func redactContact(con objects.Contact) objects.Contact {
+	for _, field := range []*string{
+		&con.Name,
+		&con.AddressStreet1,
+		&con.AddressStreet2,
+		&con.AddressStreet3,
+		&con.AddressCity,
+		&con.AddressPostalCode,
+		&con.VoicePhoneNumber,
+		&con.VoicePhoneExtension,
+		&con.FaxPhoneNumber,
+		&con.FaxPhoneExtension,
+		&con.EmailAddress,
+	} {
+		if *field != "" {
+			*field = redactedValue
+		}
+	}
+
+	return con
+}
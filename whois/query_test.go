@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"text/template"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/timapril/go-registrar/whois/objects"
+)
+
+func TestParseQuery(t *testing.T) {
+	t.Parallel()
+	Convey("Queries should be split into a type and a term", t, func() {
+		tests := []struct {
+			query     string
+			queryType string
+			term      string
+		}{
+			{"EXAMPLE.COM", QueryTypeDomain, "EXAMPLE.COM"},
+			{"domain EXAMPLE.COM", QueryTypeDomain, "EXAMPLE.COM"},
+			{"NAMESERVER NS1.EXAMPLE.COM", QueryTypeNameserver, "NS1.EXAMPLE.COM"},
+			{"nameserver 192.0.2.1", QueryTypeAddress, "192.0.2.1"},
+			{"2001:DB8::1", QueryTypeAddress, "2001:DB8::1"},
+			{"CONTACT C1234-EXAMPLE", QueryTypeContact, "C1234-EXAMPLE"},
+			{"REGISTRAR Example, LLC.", QueryTypeRegistrar, "Example, LLC."},
+			{"CONTACT", QueryTypeDomain, "CONTACT"},
+		}
+
+		for _, test := range tests {
+			queryType, term := parseQuery(test.query)
+			So(queryType, ShouldEqual, test.queryType)
+			So(term, ShouldEqual, test.term)
+		}
+	})
+}
+
+func TestAnswerQuery(t *testing.T) {
+	t.Parallel()
+	Convey("Given WHOIS data with a domain, hosts and a contact", t, func() {
+		templates := template.Must(template.New("").Funcs(template.FuncMap{
+			"dict": dictify,
+		}).ParseGlob("templates/*.tmpl"))
+
+		data := objects.NewWHOIS()
+		data.Domains["1"] = objects.Domain{ID: 1, DomainName: "EXAMPLE.COM", DomainStatuses: []string{"ok"}, HostIDs: []int64{2, 3}}
+		data.Hosts["2"] = objects.Host{ID: 2, HostName: "NS1.EXAMPLE.COM", HostAddresses: []string{"192.0.2.1"}}
+		data.Hosts["3"] = objects.Host{ID: 3, HostName: "NS2.EXAMPLE.COM", HostAddresses: []string{"192.0.2.1", "2001:db8::1"}}
+		data.Contacts["4"] = objects.Contact{ID: 4, ContactROID: "C4-EXAMPLE", ContactRegistryID: "GOREG-4", Name: "Jane Doe", Org: "Example Org", EmailAddress: "jane@example.com"}
+		data.LastUpdate = time.Now()
+		data.Index()
+
+		regInfo := registrarInformation{Name: "Example, LLC.", IANAID: 65000}
+		conf := Config{}
+
+		query := func(search string) (string, string) {
+			var buf bytes.Buffer
+			result := answerQuery(&buf, conf, templates, regInfo, data, search)
+			return buf.String(), result
+		}
+
+		Convey("Domain queries should return the domain", func() {
+			out, result := query("DOMAIN EXAMPLE.COM")
+			So(result, ShouldEqual, QueryResultMatch)
+			So(out, ShouldContainSubstring, "Domain Name: EXAMPLE.COM")
+			So(out, ShouldContainSubstring, "Name Server: NS2.EXAMPLE.COM")
+		})
+
+		Convey("Nameserver queries should return the host", func() {
+			out, result := query("NAMESERVER NS2.EXAMPLE.COM")
+			So(result, ShouldEqual, QueryResultMatch)
+			So(out, ShouldContainSubstring, "Server Name: NS2.EXAMPLE.COM")
+			So(out, ShouldContainSubstring, "IP Address: 2001:db8::1")
+		})
+
+		Convey("Address queries should return every host using the address", func() {
+			out, result := query("192.0.2.1")
+			So(result, ShouldEqual, QueryResultMatch)
+			So(out, ShouldContainSubstring, "Server Name: NS1.EXAMPLE.COM")
+			So(out, ShouldContainSubstring, "Server Name: NS2.EXAMPLE.COM")
+
+			out, result = query("NAMESERVER 2001:DB8:0::1")
+			So(result, ShouldEqual, QueryResultMatch)
+			So(out, ShouldNotContainSubstring, "NS1.EXAMPLE.COM")
+		})
+
+		Convey("Contact queries should follow the privacy setting", func() {
+			_, result := query("CONTACT C4-EXAMPLE")
+			So(result, ShouldEqual, QueryResultNoMatch)
+
+			conf.Privacy.ContactQueries = ContactQueriesRedacted
+			out, result := query("CONTACT c4-example")
+			So(result, ShouldEqual, QueryResultMatch)
+			So(out, ShouldContainSubstring, "Registry Contact ID: C4-EXAMPLE")
+			So(out, ShouldContainSubstring, "Contact Organization: Example Org")
+			So(out, ShouldContainSubstring, "Contact Name: "+redactedValue)
+			So(out, ShouldNotContainSubstring, "jane@example.com")
+
+			conf.Privacy.ContactQueries = ContactQueriesFull
+			out, _ = query("CONTACT C4-EXAMPLE")
+			So(out, ShouldContainSubstring, "Contact Email: jane@example.com")
+
+			_, result = query("CONTACT GOREG-4")
+			So(result, ShouldEqual, QueryResultNoMatch)
+		})
+
+		Convey("Registrar queries should match the name or IANA ID", func() {
+			out, result := query("REGISTRAR 65000")
+			So(result, ShouldEqual, QueryResultMatch)
+			So(out, ShouldContainSubstring, "Registrar: Example, LLC.")
+
+			_, result = query("REGISTRAR OTHER")
+			So(result, ShouldEqual, QueryResultNoMatch)
+		})
+
+		Convey("Unknown objects should return the error response", func() {
+			out, result := query("NAMESERVER NS9.EXAMPLE.COM")
+			So(result, ShouldEqual, QueryResultNoMatch)
+			So(out, ShouldContainSubstring, `No match for "NAMESERVER NS9.EXAMPLE.COM"`)
+		})
+	})
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	// QueryResultMatch is logged when a query matched an object.
+	QueryResultMatch = "match"
+
+	// QueryResultNoMatch is logged when a query did not match an object.
+	QueryResultNoMatch = "no match"
+
+	// QueryResultRateExceeded is logged when a query was refused because
+	// the client exceeded its query rate.
+	QueryResultRateExceeded = "rate exceeded"
+
+	// QueryResultDenied is logged when a connection was refused because
+	// the client is in the deny list.
+	QueryResultDenied = "denied"
+
+	// QueryResultBusy is logged when a connection was refused because the
+	// maximum number of connections were already open.
+	QueryResultBusy = "busy"
+
+	// QueryResultError is logged when the query could not be read or the
+	// response could not be written.
+	QueryResultError = "error"
+)
+
+// queryLogEntry is a single entry in the query log.
+type queryLogEntry struct {
+	Time      time.Time `json:"time"`
+	ClientIP  string    `json:"client_ip"`
+	Query     string    `json:"query"`
+	Result    string    `json:"result"`
+	LatencyMS float64   `json:"latency_ms"`
+}
+
+// queryLogger writes one JSON object per line for each query received
+// by the server.
+type queryLogger struct {
+	lock   sync.Mutex
+	writer io.Writer
+}
+
+// newQueryLogger creates a query logger that appends to the file at the
+// path passed. If no path is passed, entries are written to the server
+// log.
+func newQueryLogger(path string) (*queryLogger, error) {
+	if path == "" {
+		return &queryLogger{}, nil
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o640)
+	if err != nil {
+		return nil, err
+	}
+
+	return &queryLogger{writer: file}, nil
+}
+
+// Log records a query from the client along with the result and the
+// time taken since the query was received.
+func (q *queryLogger) Log(clientIP string, query string, result string, received time.Time) {
+	entry := queryLogEntry{
+		Time:      received.UTC(),
+		ClientIP:  clientIP,
+		Query:     query,
+		Result:    result,
+		LatencyMS: float64(time.Since(received).Microseconds()) / 1000,
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		log.Print("ERR ", err)
+		return
+	}
+
+	if q.writer == nil {
+		log.Print("QUERY ", string(data))
+		return
+	}
+
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if _, err := q.writer.Write(append(data, '\n')); err != nil {
+		log.Print("ERR ", err)
+	}
+}
@@ -0,0 +1,138 @@
+package rdap
+
+// The types in this file represent the RDAP JSON responses defined in
+// RFC 9083 along with the redaction extension defined in RFC 9537.
+
+// Link represents an RDAP link object.
+type Link struct {
+	Value string `json:"value,omitempty"`
+	Rel   string `json:"rel"`
+	Href  string `json:"href"`
+	Type  string `json:"type,omitempty"`
+}
+
+// Notice represents an RDAP notice or remark.
+type Notice struct {
+	Title       string   `json:"title,omitempty"`
+	Type        string   `json:"type,omitempty"`
+	Description []string `json:"description"`
+	Links       []Link   `json:"links,omitempty"`
+}
+
+// Event represents an RDAP event.
+type Event struct {
+	EventAction string `json:"eventAction"`
+	EventDate   string `json:"eventDate"`
+}
+
+// PublicID represents an RDAP public identifier.
+type PublicID struct {
+	Type       string `json:"type"`
+	Identifier string `json:"identifier"`
+}
+
+// RedactionName holds the name of a redacted field.
+type RedactionName struct {
+	Type string `json:"type"`
+}
+
+// RedactionReason holds the reason a field was redacted.
+type RedactionReason struct {
+	Type string `json:"type"`
+}
+
+// Redaction describes a field that has been redacted from the response
+// as defined in RFC 9537.
+type Redaction struct {
+	Name     RedactionName   `json:"name"`
+	PrePath  string          `json:"prePath,omitempty"`
+	PathLang string          `json:"pathLang,omitempty"`
+	Method   string          `json:"method"`
+	Reason   RedactionReason `json:"reason"`
+}
+
+// Entity represents an RDAP entity object.
+type Entity struct {
+	ObjectClassName string        `json:"objectClassName"`
+	Handle          string        `json:"handle,omitempty"`
+	VCardArray      []interface{} `json:"vcardArray,omitempty"`
+	Roles           []string      `json:"roles,omitempty"`
+	PublicIDs       []PublicID    `json:"publicIds,omitempty"`
+	Entities        []Entity      `json:"entities,omitempty"`
+	Remarks         []Notice      `json:"remarks,omitempty"`
+	Links           []Link        `json:"links,omitempty"`
+	Events          []Event       `json:"events,omitempty"`
+	Status          []string      `json:"status,omitempty"`
+
+	RDAPConformance []string    `json:"rdapConformance,omitempty"`
+	Notices         []Notice    `json:"notices,omitempty"`
+	Redacted        []Redaction `json:"redacted,omitempty"`
+}
+
+// IPAddresses holds the addresses of a nameserver.
+type IPAddresses struct {
+	V4 []string `json:"v4,omitempty"`
+	V6 []string `json:"v6,omitempty"`
+}
+
+// Nameserver represents an RDAP nameserver object.
+type Nameserver struct {
+	ObjectClassName string       `json:"objectClassName"`
+	Handle          string       `json:"handle,omitempty"`
+	LDHName         string       `json:"ldhName"`
+	IPAddresses     *IPAddresses `json:"ipAddresses,omitempty"`
+	Status          []string     `json:"status,omitempty"`
+	Links           []Link       `json:"links,omitempty"`
+	Events          []Event      `json:"events,omitempty"`
+
+	RDAPConformance []string `json:"rdapConformance,omitempty"`
+	Notices         []Notice `json:"notices,omitempty"`
+}
+
+// DSData represents a single DS record of a domain.
+type DSData struct {
+	KeyTag     int64  `json:"keyTag"`
+	Algorithm  int64  `json:"algorithm"`
+	DigestType int64  `json:"digestType"`
+	Digest     string `json:"digest"`
+}
+
+// SecureDNS holds the DNSSEC information for a domain.
+type SecureDNS struct {
+	DelegationSigned bool     `json:"delegationSigned"`
+	DSData           []DSData `json:"dsData,omitempty"`
+}
+
+// Domain represents an RDAP domain object.
+type Domain struct {
+	ObjectClassName string       `json:"objectClassName"`
+	Handle          string       `json:"handle,omitempty"`
+	LDHName         string       `json:"ldhName"`
+	Status          []string     `json:"status,omitempty"`
+	Entities        []Entity     `json:"entities,omitempty"`
+	Nameservers     []Nameserver `json:"nameservers,omitempty"`
+	SecureDNS       *SecureDNS   `json:"secureDNS,omitempty"`
+	Links           []Link       `json:"links,omitempty"`
+	Events          []Event      `json:"events,omitempty"`
+	Port43          string       `json:"port43,omitempty"`
+
+	RDAPConformance []string    `json:"rdapConformance,omitempty"`
+	Notices         []Notice    `json:"notices,omitempty"`
+	Redacted        []Redaction `json:"redacted,omitempty"`
+}
+
+// ErrorResponse represents an RDAP error response.
+type ErrorResponse struct {
+	ErrorCode   int      `json:"errorCode"`
+	Title       string   `json:"title"`
+	Description []string `json:"description,omitempty"`
+
+	RDAPConformance []string `json:"rdapConformance,omitempty"`
+	Notices         []Notice `json:"notices,omitempty"`
+}
+
+// Help represents an RDAP help response.
+type Help struct {
+	RDAPConformance []string `json:"rdapConformance"`
+	Notices         []Notice `json:"notices"`
+}
@@ -0,0 +1,499 @@
+// Package rdap serves registration data using the Registration Data
+// Access Protocol (RFC 9082 and RFC 9083) from the same objects.WHOIS
+// data set that is used by the port 43 WHOIS server.
+package rdap
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/timapril/go-registrar/whois/objects"
+)
+
+// ContentType is the media type used for all RDAP responses.
+const ContentType = "application/rdap+json"
+
+// Conformance lists the specifications that the RDAP responses conform
+// to.
+var Conformance = []string{
+	"rdap_level_0",
+	"redacted",
+	"icann_rdap_response_profile_1",
+	"icann_rdap_technical_implementation_guide_1",
+}
+
+// Registrar holds the information about the registrar that is returned
+// as the registrar entity of each domain.
+type Registrar struct {
+	WHOISServer       string
+	Name              string
+	IANAID            int64
+	URL               string
+	AbuseContactEmail string
+	AbuseContactPhone string
+}
+
+// Server answers RDAP queries using the current WHOIS data set.
+type Server struct {
+	Registrar Registrar
+
+	// BaseURL is the URL the RDAP service is reachable at and is used to
+	// build the links in each response.
+	BaseURL string
+
+	// TermsOfServiceURL is linked to from the terms of service notice if
+	// it is set.
+	TermsOfServiceURL string
+
+	// Data is called for each request to get the current WHOIS data so
+	// that a reloaded data set is used as soon as it is available.
+	Data func() objects.WHOIS
+}
+
+// NewServer creates a new RDAP server for the registrar passed that will
+// answer queries from the data returned by the data function.
+func NewServer(registrar Registrar, baseURL string, termsOfServiceURL string, data func() objects.WHOIS) *Server {
+	if baseURL != "" && !strings.HasSuffix(baseURL, "/") {
+		baseURL = baseURL + "/"
+	}
+
+	return &Server{
+		Registrar:         registrar,
+		BaseURL:           baseURL,
+		TermsOfServiceURL: termsOfServiceURL,
+		Data:              data,
+	}
+}
+
+// Handler returns the http.Handler that will answer RDAP queries.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /domain/{name}", s.handleDomain)
+	mux.HandleFunc("GET /nameserver/{name}", s.handleNameserver)
+	mux.HandleFunc("GET /entity/{handle}", s.handleEntity)
+	mux.HandleFunc("GET /help", s.handleHelp)
+	mux.HandleFunc("/", s.handleUnsupported)
+
+	return mux
+}
+
+// handleDomain answers a domain query.
+func (s *Server) handleDomain(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimSuffix(r.PathValue("name"), ".")
+	data := s.Data()
+
+	dom, err := data.Find(name)
+	if err != nil {
+		s.writeNotFound(w, r, fmt.Sprintf("No match for domain %q", name))
+		return
+	}
+
+	s.writeResponse(w, r, http.StatusOK, s.Domain(dom, data))
+}
+
+// handleNameserver answers a nameserver query.
+func (s *Server) handleNameserver(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimSuffix(r.PathValue("name"), ".")
+	data := s.Data()
+
+	hos, err := data.FindHost(name)
+	if err != nil {
+		s.writeNotFound(w, r, fmt.Sprintf("No match for nameserver %q", name))
+		return
+	}
+
+	ns := s.Nameserver(hos)
+	ns.Events = append(ns.Events, s.databaseUpdateEvent(data))
+	ns.RDAPConformance = Conformance
+	ns.Notices = s.notices()
+
+	s.writeResponse(w, r, http.StatusOK, ns)
+}
+
+// handleEntity answers an entity query. The handle may either be the
+// IANA ID of the registrar or the ROID of a contact.
+func (s *Server) handleEntity(w http.ResponseWriter, r *http.Request) {
+	handle := r.PathValue("handle")
+	data := s.Data()
+
+	var ent Entity
+
+	if handle == s.registrarHandle() {
+		ent = s.RegistrarEntity()
+	} else {
+		con, err := data.FindContact(handle)
+		if err != nil {
+			s.writeNotFound(w, r, fmt.Sprintf("No match for entity %q", handle))
+			return
+		}
+
+		ent, ent.Redacted = s.ContactEntity(con, nil, "$", "")
+	}
+
+	ent.Events = append(ent.Events, s.databaseUpdateEvent(data))
+	ent.RDAPConformance = Conformance
+	ent.Notices = s.notices()
+
+	s.writeResponse(w, r, http.StatusOK, ent)
+}
+
+// handleHelp answers a help query.
+func (s *Server) handleHelp(w http.ResponseWriter, r *http.Request) {
+	s.writeResponse(w, r, http.StatusOK, Help{
+		RDAPConformance: Conformance,
+		Notices:         s.notices(),
+	})
+}
+
+// handleUnsupported answers any query that is not supported by the
+// server.
+func (s *Server) handleUnsupported(w http.ResponseWriter, r *http.Request) {
+	s.writeResponse(w, r, http.StatusBadRequest, ErrorResponse{
+		ErrorCode:       http.StatusBadRequest,
+		Title:           "Bad Request",
+		Description:     []string{"The query is not supported by this server"},
+		RDAPConformance: Conformance,
+		Notices:         s.notices(),
+	})
+}
+
+// writeNotFound writes an RDAP error response for an object that could
+// not be found.
+func (s *Server) writeNotFound(w http.ResponseWriter, r *http.Request, description string) {
+	s.writeResponse(w, r, http.StatusNotFound, ErrorResponse{
+		ErrorCode:       http.StatusNotFound,
+		Title:           "Not Found",
+		Description:     []string{description},
+		RDAPConformance: Conformance,
+		Notices:         s.notices(),
+	})
+}
+
+// writeResponse encodes the response passed as JSON and writes it with
+// the status code passed.
+func (s *Server) writeResponse(w http.ResponseWriter, r *http.Request, status int, response interface{}) {
+	w.Header().Set("Content-Type", ContentType)
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Print("ERR ", err)
+	}
+
+	if status == http.StatusOK {
+		log.Printf("OK %s %s", r.RemoteAddr, r.URL.Path)
+	} else {
+		log.Printf("ERR %s %s", r.RemoteAddr, r.URL.Path)
+	}
+}
+
+// Domain builds the RDAP domain object for the domain passed using the
+// WHOIS data passed to find the linked contacts and hosts.
+func (s *Server) Domain(dom objects.Domain, data objects.WHOIS) Domain {
+	d := Domain{
+		ObjectClassName: "domain",
+		Handle:          dom.DomainROID,
+		LDHName:         dom.DomainName,
+		Status:          Statuses(dom.DomainStatuses),
+		SecureDNS:       &SecureDNS{DelegationSigned: dom.IsSigned()},
+		Links:           s.selfLinks("domain", dom.DomainName),
+		Port43:          s.Registrar.WHOISServer,
+		RDAPConformance: Conformance,
+		Notices:         s.notices(),
+	}
+
+	for _, ds := range dom.DSData {
+		d.SecureDNS.DSData = append(d.SecureDNS.DSData, DSData{
+			KeyTag:     ds.KeyTag,
+			Algorithm:  ds.Algorithm,
+			DigestType: ds.DigestType,
+			Digest:     ds.Digest,
+		})
+	}
+
+	d.Entities = append(d.Entities, s.RegistrarEntity())
+
+	roles := []struct {
+		Role      string
+		Label     string
+		ContactID int64
+	}{
+		{"registrant", "Registrant", dom.RegistrantContactID},
+		{"administrative", "Admin", dom.AdminContactID},
+		{"technical", "Tech", dom.TechContactID},
+	}
+
+	for _, role := range roles {
+		con := data.GetContact(int(role.ContactID))
+		prePath := fmt.Sprintf("$.entities[?(@.roles[0]=='%s')]", role.Role)
+		ent, redacted := s.ContactEntity(con, []string{role.Role}, prePath, role.Label)
+		d.Entities = append(d.Entities, ent)
+		d.Redacted = append(d.Redacted, redacted...)
+	}
+
+	for _, hid := range dom.HostIDs {
+		hos := data.GetHost(int(hid))
+		if hos.HostName != "" {
+			ns := s.Nameserver(hos)
+			ns.Status = nil
+			d.Nameservers = append(d.Nameservers, ns)
+		}
+	}
+
+	if dom.DomainCreationDate != "" {
+		d.Events = append(d.Events, Event{EventAction: "registration", EventDate: dom.DomainCreationDate})
+	}
+
+	if dom.DomainUpdateDate != "" {
+		d.Events = append(d.Events, Event{EventAction: "last changed", EventDate: dom.DomainUpdateDate})
+	}
+
+	if dom.DomainExpireDate != "" {
+		d.Events = append(d.Events, Event{EventAction: "expiration", EventDate: dom.DomainExpireDate})
+	}
+
+	d.Events = append(d.Events, s.databaseUpdateEvent(data))
+
+	return d
+}
+
+// Nameserver builds the RDAP nameserver object for the host passed.
+func (s *Server) Nameserver(hos objects.Host) Nameserver {
+	ns := Nameserver{
+		ObjectClassName: "nameserver",
+		Handle:          hos.HostROID,
+		LDHName:         hos.HostName,
+		Status:          []string{"active"},
+		Links:           s.selfLinks("nameserver", hos.HostName),
+	}
+
+	addrs := &IPAddresses{}
+	for _, addr := range hos.HostAddresses {
+		if strings.Contains(addr, ":") {
+			addrs.V6 = append(addrs.V6, addr)
+		} else {
+			addrs.V4 = append(addrs.V4, addr)
+		}
+	}
+
+	if len(addrs.V4) != 0 || len(addrs.V6) != 0 {
+		ns.IPAddresses = addrs
+	}
+
+	return ns
+}
+
+// RegistrarEntity builds the RDAP entity for the registrar including the
+// abuse contact.
+func (s *Server) RegistrarEntity() Entity {
+	ent := Entity{
+		ObjectClassName: "entity",
+		Handle:          s.registrarHandle(),
+		Roles:           []string{"registrar"},
+		PublicIDs: []PublicID{
+			{Type: "IANA Registrar ID", Identifier: s.registrarHandle()},
+		},
+		VCardArray: newVCard().
+			add("fn", "text", s.Registrar.Name).
+			array(),
+		Links: s.selfLinks("entity", s.registrarHandle()),
+	}
+
+	if s.Registrar.URL != "" {
+		ent.Links = append(ent.Links, Link{
+			Value: s.Registrar.URL,
+			Rel:   "about",
+			Href:  s.Registrar.URL,
+			Type:  "text/html",
+		})
+	}
+
+	abuse := newVCard().add("fn", "text", "Abuse Contact")
+	if s.Registrar.AbuseContactPhone != "" {
+		abuse.addWithParams("tel", map[string]string{"type": "voice"}, "uri", "tel:"+s.Registrar.AbuseContactPhone)
+	}
+
+	if s.Registrar.AbuseContactEmail != "" {
+		abuse.add("email", "text", s.Registrar.AbuseContactEmail)
+	}
+
+	ent.Entities = append(ent.Entities, Entity{
+		ObjectClassName: "entity",
+		Roles:           []string{"abuse"},
+		VCardArray:      abuse.array(),
+	})
+
+	return ent
+}
+
+// ContactEntity builds the RDAP entity for a contact with the roles
+// passed. Personal data in the contact is redacted and the list of
+// redactions made is returned. The prePath is the JSONPath to the entity
+// in the response and the label is used as the prefix for the name of
+// each redacted field.
+func (s *Server) ContactEntity(con objects.Contact, roles []string, prePath string, label string) (Entity, []Redaction) {
+	ent := Entity{
+		ObjectClassName: "entity",
+		Handle:          con.ContactROID,
+		Roles:           roles,
+	}
+
+	if con.ContactROID != "" {
+		ent.Links = s.selfLinks("entity", con.ContactROID)
+	}
+
+	if label == "" {
+		label = "Contact"
+	}
+
+	var redacted []Redaction
+
+	redact := func(value string, field string, path string, method string) {
+		if value != "" {
+			redacted = append(redacted, Redaction{
+				Name:     RedactionName{Type: fmt.Sprintf("%s %s", label, field)},
+				PrePath:  prePath + path,
+				PathLang: "jsonpath",
+				Method:   method,
+				Reason:   RedactionReason{Type: "Server policy"},
+			})
+		}
+	}
+
+	vcard := newVCard().add("fn", "text", "")
+	redact(con.Name, "Name", ".vcardArray[1][?(@[0]=='fn')][3]", "emptyValue")
+
+	if con.Org != "" {
+		vcard.add("org", "text", con.Org)
+	}
+
+	adrParams := map[string]string{}
+	if con.AddressCountry != "" {
+		adrParams["cc"] = con.AddressCountry
+	}
+
+	vcard.addWithParams("adr", adrParams, "text", []interface{}{
+		"", "", []string{"", "", ""}, "", con.AddressState, "", "",
+	})
+	redact(con.AddressStreet1+con.AddressStreet2+con.AddressStreet3, "Street", ".vcardArray[1][?(@[0]=='adr')][3][2][:]", "emptyValue")
+	redact(con.AddressCity, "City", ".vcardArray[1][?(@[0]=='adr')][3][3]", "emptyValue")
+	redact(con.AddressPostalCode, "Postal Code", ".vcardArray[1][?(@[0]=='adr')][3][5]", "emptyValue")
+
+	redact(con.VoicePhoneNumber, "Phone", ".vcardArray[1][?(@[1].type=='voice')]", "removal")
+	redact(con.VoicePhoneExtension, "Phone Ext", ".vcardArray[1][?(@[1].type=='voice')]", "removal")
+	redact(con.FaxPhoneNumber, "Fax", ".vcardArray[1][?(@[1].type=='fax')]", "removal")
+	redact(con.FaxPhoneExtension, "Fax Ext", ".vcardArray[1][?(@[1].type=='fax')]", "removal")
+	redact(con.EmailAddress, "Email", ".vcardArray[1][?(@[0]=='email')]", "removal")
+
+	ent.VCardArray = vcard.array()
+
+	if con.EmailAddress != "" {
+		ent.Remarks = append(ent.Remarks, Notice{
+			Title:       "EMAIL REDACTED FOR PRIVACY",
+			Type:        "object redacted due to authorization",
+			Description: []string{"Please query the registrar of record for information on how to contact this entity."},
+		})
+	}
+
+	return ent, redacted
+}
+
+// Statuses maps the EPP statuses passed to the RDAP status values
+// defined in RFC 8056. Statuses that do not have a mapping are returned
+// unchanged.
+func Statuses(eppStatuses []string) (statuses []string) {
+	for _, status := range eppStatuses {
+		if mapped, ok := statusMap[status]; ok {
+			statuses = append(statuses, mapped)
+		} else {
+			statuses = append(statuses, status)
+		}
+	}
+
+	return statuses
+}
+
+// statusMap holds the RDAP status for each EPP status as defined in
+// RFC 8056.
+var statusMap = map[string]string{
+	"ok":                       "active",
+	"inactive":                 "inactive",
+	"linked":                   "associated",
+	"pendingCreate":            "pending create",
+	"pendingDelete":            "pending delete",
+	"pendingRenew":             "pending renew",
+	"pendingTransfer":          "pending transfer",
+	"pendingUpdate":            "pending update",
+	"addPeriod":                "add period",
+	"autoRenewPeriod":          "auto renew period",
+	"renewPeriod":              "renew period",
+	"transferPeriod":           "transfer period",
+	"redemptionPeriod":         "redemption period",
+	"pendingRestore":           "pending restore",
+	"clientDeleteProhibited":   "client delete prohibited",
+	"serverDeleteProhibited":   "server delete prohibited",
+	"clientUpdateProhibited":   "client update prohibited",
+	"serverUpdateProhibited":   "server update prohibited",
+	"clientTransferProhibited": "client transfer prohibited",
+	"serverTransferProhibited": "server transfer prohibited",
+	"clientRenewProhibited":    "client renew prohibited",
+	"serverRenewProhibited":    "server renew prohibited",
+	"clientHold":               "client hold",
+	"serverHold":               "server hold",
+}
+
+// registrarHandle returns the handle used for the registrar entity.
+func (s *Server) registrarHandle() string {
+	return fmt.Sprintf("%d", s.Registrar.IANAID)
+}
+
+// selfLinks returns the self link for the object of the type and name
+// passed. If no base URL is configured no links are returned.
+func (s *Server) selfLinks(objectType string, name string) []Link {
+	if s.BaseURL == "" {
+		return nil
+	}
+
+	href := fmt.Sprintf("%s%s/%s", s.BaseURL, objectType, name)
+
+	return []Link{{Value: href, Rel: "self", Href: href, Type: ContentType}}
+}
+
+// databaseUpdateEvent returns the event showing when the WHOIS data was
+// last updated.
+func (s *Server) databaseUpdateEvent(data objects.WHOIS) Event {
+	return Event{
+		EventAction: "last update of RDAP database",
+		EventDate:   data.LastUpdate.UTC().Format(time.RFC3339),
+	}
+}
+
+// notices returns the notices that are included in each response.
+func (s *Server) notices() []Notice {
+	terms := Notice{
+		Title:       "Terms of Use",
+		Description: []string{fmt.Sprintf("Access to the registration data of %s is subject to its terms of use.", s.Registrar.Name)},
+	}
+
+	if s.TermsOfServiceURL != "" {
+		terms.Links = []Link{{Value: s.TermsOfServiceURL, Rel: "terms-of-service", Href: s.TermsOfServiceURL, Type: "text/html"}}
+	}
+
+	return []Notice{
+		terms,
+		{
+			Title:       "Status Codes",
+			Description: []string{"For more information on domain status codes, please visit https://icann.org/epp"},
+			Links:       []Link{{Value: "https://icann.org/epp", Rel: "glossary", Href: "https://icann.org/epp", Type: "text/html"}},
+		},
+		{
+			Title:       "RDDS Inaccuracy Complaint Form",
+			Description: []string{"URL of the ICANN RDDS Inaccuracy Complaint Form: https://icann.org/wicf"},
+			Links:       []Link{{Value: "https://icann.org/wicf", Rel: "help", Href: "https://icann.org/wicf", Type: "text/html"}},
+		},
+	}
+}
@@ -0,0 +1,194 @@
+package rdap
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/timapril/go-registrar/whois/objects"
+)
+
+func testWHOIS() objects.WHOIS {
+	data := objects.NewWHOIS()
+	data.Domains["1"] = objects.Domain{
+		ID:                  1,
+		DomainName:          "EXAMPLE.COM",
+		DomainROID:          "1234_DOMAIN_COM-VRSN",
+		DomainCreationDate:  "2001-02-03T04:05:06Z",
+		DomainExpireDate:    "2030-02-03T04:05:06Z",
+		DomainStatuses:      []string{"clientDeleteProhibited", "clientTransferProhibited"},
+		RegistrantContactID: 10,
+		AdminContactID:      10,
+		TechContactID:       10,
+		HostIDs:             []int64{20},
+		DSData:              []objects.DSData{{KeyTag: 12345, Algorithm: 13, DigestType: 2, Digest: "ABCDEF"}},
+	}
+	data.Hosts["20"] = objects.Host{
+		ID:            20,
+		HostName:      "NS1.EXAMPLE.COM",
+		HostAddresses: []string{"192.0.2.1", "2001:db8::1"},
+	}
+	data.Contacts["10"] = objects.Contact{
+		ID:                10,
+		ContactRegistryID: "GOREG-10",
+		ContactROID:       "C10-VRSN",
+		Name:              "Jane Registrant",
+		Org:               "Example Org",
+		AddressStreet1:    "123 Main St",
+		AddressCity:       "Anytown",
+		AddressState:      "VA",
+		AddressPostalCode: "20000",
+		AddressCountry:    "US",
+		VoicePhoneNumber:  "+1.5555551212",
+		EmailAddress:      "jane@example.com",
+	}
+	data.LastUpdate = time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	data.Index()
+
+	return data
+}
+
+func testServer() *httptest.Server {
+	data := testWHOIS()
+	server := NewServer(Registrar{
+		WHOISServer:       "whois.example.com",
+		Name:              "Example, LLC.",
+		IANAID:            65000,
+		AbuseContactEmail: "abuse@example.com",
+		AbuseContactPhone: "+1.8885551212",
+	}, "https://rdap.example.com", "https://example.com/tos", func() objects.WHOIS {
+		return data
+	})
+
+	return httptest.NewServer(server.Handler())
+}
+
+func getRDAP(server *httptest.Server, path string, response interface{}) *http.Response {
+	resp, err := http.Get(server.URL + path)
+	So(err, ShouldBeNil)
+	defer resp.Body.Close()
+
+	So(json.NewDecoder(resp.Body).Decode(response), ShouldBeNil)
+
+	return resp
+}
+
+func TestRDAPServer(t *testing.T) {
+	t.Parallel()
+	Convey("Given an RDAP server with a domain, host and contact", t, func() {
+		server := testServer()
+		defer server.Close()
+
+		Convey("A domain query should return the domain with its linked objects", func() {
+			dom := Domain{}
+			resp := getRDAP(server, "/domain/example.com", &dom)
+			So(resp.StatusCode, ShouldEqual, http.StatusOK)
+			So(resp.Header.Get("Content-Type"), ShouldEqual, ContentType)
+
+			So(dom.ObjectClassName, ShouldEqual, "domain")
+			So(dom.LDHName, ShouldEqual, "EXAMPLE.COM")
+			So(dom.Handle, ShouldEqual, "1234_DOMAIN_COM-VRSN")
+			So(dom.Status, ShouldResemble, []string{"client delete prohibited", "client transfer prohibited"})
+			So(dom.RDAPConformance, ShouldResemble, Conformance)
+			So(dom.Links[0].Href, ShouldEqual, "https://rdap.example.com/domain/EXAMPLE.COM")
+			So(dom.Events, ShouldResemble, []Event{
+				{EventAction: "registration", EventDate: "2001-02-03T04:05:06Z"},
+				{EventAction: "expiration", EventDate: "2030-02-03T04:05:06Z"},
+				{EventAction: "last update of RDAP database", EventDate: "2020-01-02T03:04:05Z"},
+			})
+
+			So(dom.Port43, ShouldEqual, "whois.example.com")
+			So(dom.SecureDNS.DelegationSigned, ShouldBeTrue)
+			So(dom.SecureDNS.DSData, ShouldResemble, []DSData{{KeyTag: 12345, Algorithm: 13, DigestType: 2, Digest: "ABCDEF"}})
+
+			So(dom.Nameservers, ShouldHaveLength, 1)
+			So(dom.Nameservers[0].LDHName, ShouldEqual, "NS1.EXAMPLE.COM")
+
+			So(dom.Entities, ShouldHaveLength, 4)
+			So(dom.Entities[0].Roles, ShouldResemble, []string{"registrar"})
+			So(dom.Entities[0].PublicIDs[0].Identifier, ShouldEqual, "65000")
+			So(dom.Entities[0].Entities[0].Roles, ShouldResemble, []string{"abuse"})
+			So(dom.Entities[1].Roles, ShouldResemble, []string{"registrant"})
+
+			So(len(dom.Notices), ShouldEqual, 3)
+			So(dom.Notices[0].Links[0].Href, ShouldEqual, "https://example.com/tos")
+		})
+
+		Convey("Personal data in a domain response should be redacted", func() {
+			body, err := http.Get(server.URL + "/domain/EXAMPLE.COM.")
+			So(err, ShouldBeNil)
+			defer body.Body.Close()
+
+			raw := make(map[string]interface{})
+			So(json.NewDecoder(body.Body).Decode(&raw), ShouldBeNil)
+
+			encoded, err := json.Marshal(raw)
+			So(err, ShouldBeNil)
+			So(string(encoded), ShouldNotContainSubstring, "Jane Registrant")
+			So(string(encoded), ShouldNotContainSubstring, "123 Main St")
+			So(string(encoded), ShouldNotContainSubstring, "jane@example.com")
+			So(string(encoded), ShouldNotContainSubstring, "+1.5555551212")
+			So(string(encoded), ShouldContainSubstring, "Example Org")
+
+			dom := Domain{}
+			So(json.Unmarshal(encoded, &dom), ShouldBeNil)
+
+			var names []string
+			for _, redaction := range dom.Redacted {
+				names = append(names, redaction.Name.Type)
+			}
+			So(names, ShouldContain, "Registrant Name")
+			So(names, ShouldContain, "Admin Email")
+			So(names, ShouldContain, "Tech Phone")
+			So(names, ShouldNotContain, "Registrant Fax")
+		})
+
+		Convey("A nameserver query should return the host addresses", func() {
+			ns := Nameserver{}
+			resp := getRDAP(server, "/nameserver/ns1.example.com", &ns)
+			So(resp.StatusCode, ShouldEqual, http.StatusOK)
+			So(ns.ObjectClassName, ShouldEqual, "nameserver")
+			So(ns.IPAddresses.V4, ShouldResemble, []string{"192.0.2.1"})
+			So(ns.IPAddresses.V6, ShouldResemble, []string{"2001:db8::1"})
+		})
+
+		Convey("An entity query should return the contact or the registrar", func() {
+			ent := Entity{}
+			resp := getRDAP(server, "/entity/C10-VRSN", &ent)
+			So(resp.StatusCode, ShouldEqual, http.StatusOK)
+			So(ent.Handle, ShouldEqual, "C10-VRSN")
+			So(ent.Links[0].Href, ShouldEqual, "https://rdap.example.com/entity/C10-VRSN")
+			So(ent.Redacted, ShouldNotBeEmpty)
+			So(ent.Redacted[0].PrePath, ShouldStartWith, "$.vcardArray")
+
+			reg := Entity{}
+			resp = getRDAP(server, "/entity/65000", &reg)
+			So(resp.StatusCode, ShouldEqual, http.StatusOK)
+			So(reg.Roles, ShouldResemble, []string{"registrar"})
+		})
+
+		Convey("Unknown objects should return an RDAP error", func() {
+			for _, path := range []string{"/domain/missing.com", "/nameserver/ns9.example.com", "/entity/99", "/entity/10", "/entity/GOREG-10"} {
+				errResp := ErrorResponse{}
+				resp := getRDAP(server, path, &errResp)
+				So(resp.StatusCode, ShouldEqual, http.StatusNotFound)
+				So(errResp.ErrorCode, ShouldEqual, http.StatusNotFound)
+			}
+
+			errResp := ErrorResponse{}
+			resp := getRDAP(server, "/autnum/65000", &errResp)
+			So(resp.StatusCode, ShouldEqual, http.StatusBadRequest)
+		})
+	})
+}
+
+func TestStatuses(t *testing.T) {
+	t.Parallel()
+	Convey("EPP statuses should be mapped to RDAP statuses", t, func() {
+		So(Statuses([]string{"ok", "serverHold", "somethingNew"}), ShouldResemble, []string{"active", "server hold", "somethingNew"})
+	})
+}
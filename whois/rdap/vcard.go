@@ -0,0 +1,32 @@
+package rdap
+
+// vCard is used to build the jCard (RFC 7095) representation of a
+// contact that is used as the vcardArray of an entity.
+type vCard struct {
+	properties []interface{}
+}
+
+// newVCard creates a new vCard with the version property set.
+func newVCard() *vCard {
+	v := &vCard{}
+
+	return v.add("version", "text", "4.0")
+}
+
+// add appends a property without parameters to the vCard.
+func (v *vCard) add(name string, valueType string, value interface{}) *vCard {
+	return v.addWithParams(name, map[string]string{}, valueType, value)
+}
+
+// addWithParams appends a property with the parameters passed to the
+// vCard.
+func (v *vCard) addWithParams(name string, params map[string]string, valueType string, value interface{}) *vCard {
+	v.properties = append(v.properties, []interface{}{name, params, valueType, value})
+
+	return v
+}
+
+// array returns the vCard as a jCard array.
+func (v *vCard) array() []interface{} {
+	return []interface{}{"vcard", v.properties}
+}